@@ -0,0 +1,148 @@
+package html_util
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+)
+
+// maxReportedPaths
+// Maximum number of node paths reported per AssertionFailure.
+const maxReportedPaths = 5
+
+// StructureAssertion
+// An expectation about the structure of a document, see AssertStructure and the Expect* helpers.
+type StructureAssertion struct {
+	Label             string                     // describes the assertion in failures
+	Condition         func(node *html.Node) bool // selects the nodes the assertion is about
+	MinCount          int                        // minimum number of nodes matching Condition
+	MaxCount          int                        // maximum number of nodes matching Condition, < 0 for no upper bound
+	RequiredAttribute string                     // if not "", every node matching Condition must have this attribute
+	NearMiss          func(node *html.Node) bool // optional relaxed Condition used to report near misses if too few nodes match
+}
+
+// AssertionFailure
+// A violated StructureAssertion.
+type AssertionFailure struct {
+	Label         string   // the Label of the assertion
+	Message       string   // describes the violation
+	ExpectedMin   int      // the MinCount of the assertion
+	ExpectedMax   int      // the MaxCount of the assertion, < 0 for no upper bound
+	ActualCount   int      // the number of nodes matching the Condition
+	NodePaths     []string // paths of offending nodes, e.g., those exceeding MaxCount or missing the RequiredAttribute
+	NearMissPaths []string // paths of nodes matching NearMiss but not Condition if too few nodes matched
+}
+
+func (f AssertionFailure) Error() string {
+	return fmt.Sprintf("assertion '%v' failed: %v", f.Label, f.Message)
+}
+
+// ExpectExactlyOne
+// Asserts that exactly one node matches cond.
+func ExpectExactlyOne(label string, cond func(node *html.Node) bool) StructureAssertion {
+	return ExpectCount(label, 1, 1, cond)
+}
+
+// ExpectAtLeast
+// Asserts that at least n nodes match cond.
+func ExpectAtLeast(label string, n int, cond func(node *html.Node) bool) StructureAssertion {
+	return ExpectCount(label, n, -1, cond)
+}
+
+// ExpectAtMost
+// Asserts that at most n nodes match cond.
+func ExpectAtMost(label string, n int, cond func(node *html.Node) bool) StructureAssertion {
+	return ExpectCount(label, 0, n, cond)
+}
+
+// ExpectCount
+// Asserts that at least minCount and at most maxCount nodes match cond. maxCount < 0 means no upper bound.
+func ExpectCount(label string, minCount, maxCount int, cond func(node *html.Node) bool) StructureAssertion {
+	return StructureAssertion{
+		Label:     label,
+		Condition: cond,
+		MinCount:  minCount,
+		MaxCount:  maxCount,
+	}
+}
+
+// ExpectAttribute
+// Asserts that at least one node matches cond and that all of them have the attribute key.
+func ExpectAttribute(label string, cond func(node *html.Node) bool, key string) StructureAssertion {
+	assertion := ExpectAtLeast(label, 1, cond)
+	assertion.RequiredAttribute = key
+	return assertion
+}
+
+// WithNearMiss
+// Returns a copy of the assertion which reports the paths of nodes matching the relaxed condition nearMiss if too few
+// nodes match, e.g., MakeByTagNameCondition("table") for an assertion about a table with a specific class.
+func (sa StructureAssertion) WithNearMiss(nearMiss func(node *html.Node) bool) StructureAssertion {
+	sa.NearMiss = nearMiss
+	return sa
+}
+
+// getNodePaths
+// Returns the paths of at most maxReportedPaths nodes.
+func getNodePaths(nodes []*html.Node) []string {
+	var paths []string
+	for i, node := range nodes {
+		if i == maxReportedPaths {
+			break
+		}
+		paths = append(paths, GetNodePath(node))
+	}
+	return paths
+}
+
+// AssertStructure
+// Checks all assertions against the tree of root (including root) and returns a failure for each violated one in the
+// order of assertions. Returns nil if all assertions hold.
+func AssertStructure(root *html.Node, assertions []StructureAssertion) []AssertionFailure {
+	var failures []AssertionFailure
+
+	for _, assertion := range assertions {
+		if assertion.Condition == nil {
+			failures = append(failures, AssertionFailure{Label: assertion.Label, Message: "assertion has no condition"})
+			continue
+		}
+
+		matches := GetNodesByCondition(root, assertion.Condition)
+		failure := AssertionFailure{
+			Label:       assertion.Label,
+			ExpectedMin: assertion.MinCount,
+			ExpectedMax: assertion.MaxCount,
+			ActualCount: len(matches),
+		}
+
+		switch {
+		case len(matches) < assertion.MinCount:
+			failure.Message = fmt.Sprintf("expected at least %v matching nodes, found %v", assertion.MinCount, len(matches))
+			if assertion.NearMiss != nil {
+				failure.NearMissPaths = getNodePaths(GetNodesByCondition(root, func(node *html.Node) bool {
+					return assertion.NearMiss(node) && !assertion.Condition(node)
+				}))
+			}
+		case assertion.MaxCount >= 0 && len(matches) > assertion.MaxCount:
+			failure.Message = fmt.Sprintf("expected at most %v matching nodes, found %v", assertion.MaxCount, len(matches))
+			failure.NodePaths = getNodePaths(matches[assertion.MaxCount:])
+		case assertion.RequiredAttribute != "":
+			var missing []*html.Node
+			for _, node := range matches {
+				if _, err := GetAttributeByKey(node, assertion.RequiredAttribute); err != nil {
+					missing = append(missing, node)
+				}
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			failure.Message = fmt.Sprintf("%v of %v matching nodes lack attribute '%v'", len(missing), len(matches), assertion.RequiredAttribute)
+			failure.NodePaths = getNodePaths(missing)
+		default:
+			continue
+		}
+
+		failures = append(failures, failure)
+	}
+
+	return failures
+}
@@ -0,0 +1,52 @@
+package html_util
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestAssertStructure(t *testing.T) {
+	doc := parseDocument(t, `<div id="main"><table class="data"></table><table></table>`+
+		`<a href="/a">a</a><a>b</a><a>c</a><p>x</p><p>y</p></div>`)
+
+	failures := AssertStructure(doc, []StructureAssertion{
+		ExpectExactlyOne("main", func(n *html.Node) bool { return nodeID(n) == "main" }),
+		ExpectExactlyOne("results table", MakeByClassNameCondition("results")).WithNearMiss(MakeByTagNameCondition("table")),
+		ExpectAtLeast("paragraphs", 3, MakeByTagNameCondition("p")),
+		ExpectAtMost("one paragraph", 1, MakeByTagNameCondition("p")),
+		ExpectAttribute("links", MakeByTagNameCondition("a"), "href"),
+		ExpectCount("tables", 1, 2, MakeByTagNameCondition("table")),
+		{Label: "broken"},
+	})
+
+	var got []string
+	for _, f := range failures {
+		got = append(got, fmt.Sprintf("%v|%v|%v..%v|%v|%v|%v", f.Label, f.Message, f.ExpectedMin, f.ExpectedMax, f.ActualCount,
+			strings.Join(f.NodePaths, ","), strings.Join(f.NearMissPaths, ",")))
+	}
+	want := []string{
+		"results table|expected at least 1 matching nodes, found 0|1..1|0||/html/body/div/table[1],/html/body/div/table[2]",
+		"paragraphs|expected at least 3 matching nodes, found 2|3..-1|2||",
+		"one paragraph|expected at most 1 matching nodes, found 2|0..1|2|/html/body/div/p[2]|",
+		"links|2 of 3 matching nodes lack attribute 'href'|1..-1|3|/html/body/div/a[2],/html/body/div/a[3]|",
+		"broken|assertion has no condition|0..0|0||",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got failures\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(failures) > 0 && !strings.HasPrefix(failures[0].Error(), "assertion 'results table' failed: ") {
+		t.Errorf("Error() = %q", failures[0].Error())
+	}
+
+	many := parseDocument(t, strings.Repeat("<i></i>", 2*maxReportedPaths))
+	failures = AssertStructure(many, []StructureAssertion{ExpectAtMost("no italics", 0, MakeByTagNameCondition("i"))})
+	if len(failures) != 1 || failures[0].ActualCount != 2*maxReportedPaths || len(failures[0].NodePaths) != maxReportedPaths {
+		t.Errorf("got %+v, want the number of reported paths capped", failures)
+	}
+
+	if failures := AssertStructure(doc, []StructureAssertion{ExpectExactlyOne("main", MakeByTagNameCondition("div"))}); failures != nil {
+		t.Errorf("got %v, want nil for holding assertions", failures)
+	}
+}
@@ -0,0 +1,185 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"io"
+	"sort"
+	"strings"
+)
+
+// attributeLess
+// Orders attributes by namespace first and key second, so attributes without namespace come first.
+func attributeLess(a, b html.Attribute) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Key < b.Key
+}
+
+// SortAttributes
+// Sorts the attributes of every element below and including root by namespace and key, which makes the rendered
+// output independent of the order in which attributes were added. Duplicate keys keep their original relative order.
+// Returns the number of elements whose attribute order changed. Frozen trees are not modified and yield 0.
+func SortAttributes(root *html.Node) int {
+	if root == nil || IsFrozen(root) {
+		return 0
+	}
+	changed := 0
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || len(n.Attr) < 2 {
+			return true
+		}
+		isSorted := sort.SliceIsSorted(n.Attr, func(i, j int) bool {
+			return attributeLess(n.Attr[i], n.Attr[j])
+		})
+		if !isSorted {
+			sort.SliceStable(n.Attr, func(i, j int) bool {
+				return attributeLess(n.Attr[i], n.Attr[j])
+			})
+			changed++
+		}
+		return true
+	})
+	return changed
+}
+
+// attributeKeepList
+// A set of attribute keys, entries ending with '*' keep all keys with the preceding prefix, e.g., "data-*".
+type attributeKeepList struct {
+	keys     map[string]bool
+	prefixes []string
+}
+
+// makeAttributeKeepList
+// Returns the keep list of the lower case entries.
+func makeAttributeKeepList(entries []string) attributeKeepList {
+	list := attributeKeepList{keys: make(map[string]bool, len(entries))}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if strings.HasSuffix(entry, "*") {
+			list.prefixes = append(list.prefixes, strings.TrimSuffix(entry, "*"))
+		} else {
+			list.keys[entry] = true
+		}
+	}
+	return list
+}
+
+// contains
+// Returns whether key, or namespace:key for namespaced attributes, is kept by the list.
+func (list attributeKeepList) contains(attr html.Attribute) bool {
+	key := strings.ToLower(attr.Key)
+	if attr.Namespace != "" {
+		key = strings.ToLower(attr.Namespace) + ":" + key
+	}
+	if list.keys[key] {
+		return true
+	}
+	for _, prefix := range list.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// StripAttributes
+// Removes every attribute of the elements below and including root which is neither listed for the element's tag in
+// keep nor in keepGlobal, e.g., to shrink archived documents by dropping styles and tracking attributes.
+// Keys are matched case-insensitively, namespaced attributes as "namespace:key", e.g., "xlink:href". An entry ending
+// with '*' keeps all attributes with the preceding prefix, e.g., "data-*" or "aria-*".
+// Returns the number of removed attributes. Frozen trees are not modified and yield 0.
+func StripAttributes(root *html.Node, keep map[string][]string, keepGlobal []string) int {
+	if root == nil || IsFrozen(root) {
+		return 0
+	}
+	global := makeAttributeKeepList(keepGlobal)
+	perTag := make(map[string]attributeKeepList, len(keep))
+	for tag, entries := range keep {
+		perTag[strings.ToLower(tag)] = makeAttributeKeepList(entries)
+	}
+
+	removed := 0
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || len(n.Attr) == 0 {
+			return true
+		}
+		tagList, hasTagList := perTag[n.Data]
+		attrs := n.Attr[:0]
+		for _, attr := range n.Attr {
+			if global.contains(attr) || hasTagList && tagList.contains(attr) {
+				attrs = append(attrs, attr)
+			} else {
+				removed++
+			}
+		}
+		n.Attr = attrs
+		return true
+	})
+	return removed
+}
+
+// DedupeAttributes
+// Removes all but the first attribute with the same namespace and key of every element below and including root, e.g.,
+// duplicates html.Parse keeps from malformed input like <a href="x" href="y">, which strict consumers reject. The first
+// one is the one browsers and GetAttributeByKey use.
+// Returns the number of removed attributes. Frozen trees are not modified and yield 0.
+func DedupeAttributes(root *html.Node) int {
+	if root == nil || IsFrozen(root) {
+		return 0
+	}
+	removed := 0
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || len(n.Attr) < 2 {
+			return true
+		}
+		seen := make(map[[2]string]bool, len(n.Attr))
+		attrs := n.Attr[:0]
+		for _, attr := range n.Attr {
+			key := [2]string{attr.Namespace, attr.Key}
+			if seen[key] {
+				removed++
+				continue
+			}
+			seen[key] = true
+			attrs = append(attrs, attr)
+		}
+		n.Attr = attrs
+		return true
+	})
+	return removed
+}
+
+// RenderOptions
+// Configures RenderWithOptions.
+type RenderOptions struct {
+	SortAttributes   bool // sort the attributes of all elements, see SortAttributes
+	DedupeAttributes bool // keep only the first attribute with the same key of each element, see DedupeAttributes
+}
+
+// RenderWithOptions
+// Renders node to w like html.Render, but with the attributes of all elements normalized as configured by opts.
+// The tree of node is not modified.
+func RenderWithOptions(w io.Writer, node *html.Node, opts RenderOptions) error {
+	if !opts.SortAttributes && !opts.DedupeAttributes {
+		return html.Render(w, node)
+	}
+	clone := CloneTree(node)
+	if clone == nil {
+		return html.Render(w, node)
+	}
+	if opts.DedupeAttributes {
+		DedupeAttributes(clone)
+	}
+	if opts.SortAttributes {
+		SortAttributes(clone)
+	}
+	return html.Render(w, clone)
+}
+
+// RenderWithSortedAttributes
+// Renders node to w like html.Render, but with the attributes of all elements sorted, see SortAttributes.
+// The tree of node is not modified.
+func RenderWithSortedAttributes(w io.Writer, node *html.Node) error {
+	return RenderWithOptions(w, node, RenderOptions{SortAttributes: true})
+}
@@ -0,0 +1,212 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+// attributeList
+// Formats the attributes of node as "namespace:key=val" separated by spaces.
+func attributeList(node *html.Node) string {
+	var parts []string
+	for _, attr := range node.Attr {
+		key := attr.Key
+		if attr.Namespace != "" {
+			key = attr.Namespace + ":" + key
+		}
+		parts = append(parts, key+"="+attr.Val)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestSortAttributes(t *testing.T) {
+	doc := parseDocument(t, `<div id="d" class="c" data-b="1" data-a="2"><p b="1" a="2" b="3" a="4"></p><i a="1"></i>`+
+		`<svg><a xlink:href="x" id="y" href="z"></a></svg></div>`)
+	if changed := SortAttributes(doc); changed != 3 {
+		t.Errorf("SortAttributes changed %v elements, want 3", changed)
+	}
+	tests := map[string]string{
+		"div": "class=c data-a=2 data-b=1 id=d",
+		"p":   "a=2 a=4 b=1 b=3",          // html.Parse keeps duplicate keys, their relative order is kept
+		"a":   "href=z id=y xlink:href=x", // attributes without namespace first
+	}
+	for tag, want := range tests {
+		if got := attributeList(GetElementNodesByTagName(tag, doc)[0]); got != want {
+			t.Errorf("attributes of <%v> = %q, want %q", tag, got, want)
+		}
+	}
+	if changed := SortAttributes(doc); changed != 0 {
+		t.Errorf("sorting again changed %v elements", changed)
+	}
+
+	frozen := FreezeTree(parseDocument(t, `<p b="1" a="2"></p>`))
+	defer frozen.Release()
+	if changed := SortAttributes(frozen.Root()); changed != 0 || attributeList(frozen.FirstByCondition(MakeByTagNameCondition("p"))) != "b=1 a=2" {
+		t.Error("SortAttributes modified a frozen tree")
+	}
+}
+
+func TestRenderWithSortedAttributes(t *testing.T) {
+	div := GetElementNodesByTagName("div", parseDocument(t, `<div z="1" a="2"><span y="&amp;" b=""></span></div>`))[0]
+	var sb strings.Builder
+	if err := RenderWithSortedAttributes(&sb, div); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.String(), `<div a="2" z="1"><span b="" y="&amp;"></span></div>`; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := attributeList(div); got != "z=1 a=2" {
+		t.Errorf("rendering modified the tree, attributes are %q", got)
+	}
+}
+
+func TestStripAttributes(t *testing.T) {
+	doc := parseDocument(t, `<div id="d" class="c" style="color: red" data-id="1" aria-label="l" DATA-X="2">`+
+		`<a href="/x" target="_blank" rel="nofollow" onclick="track()" style="s">x</a><img src="i.png" alt="i" width="1">`+
+		`<svg><a xlink:href="#y" href="z"></a></svg></div>`)
+	keep := map[string][]string{"A": {"href", "REL"}, "img": {"src", "alt"}}
+	if removed := StripAttributes(doc, keep, []string{"id", " data-* ", "aria-*", "xlink:href"}); removed != 6 {
+		t.Errorf("StripAttributes removed %v attributes, want 6", removed)
+	}
+	want := `<div id="d" data-id="1" aria-label="l" data-x="2">` +
+		`<a href="/x" rel="nofollow">x</a><img src="i.png" alt="i"/>` +
+		`<svg><a xlink:href="#y" href="z"></a></svg></div>`
+	if got := renderNode(t, GetElementNodesByTagName("div", doc)[0]); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	if removed := StripAttributes(doc, keep, []string{"id", "data-*", "aria-*", "xlink:href"}); removed != 0 {
+		t.Errorf("stripping again removed %v attributes", removed)
+	}
+
+	// without keep lists, all attributes are removed
+	p := GetElementNodesByTagName("p", parseDocument(t, `<p a="1" b="2"><i c="3"></i></p>`))[0]
+	if removed := StripAttributes(p, nil, nil); removed != 3 || renderNode(t, p) != "<p><i></i></p>" {
+		t.Errorf("removed %v attributes, got %v", removed, renderNode(t, p))
+	}
+
+	frozen := FreezeTree(parseDocument(t, `<p style="s"></p>`))
+	defer frozen.Release()
+	if removed := StripAttributes(frozen.Root(), nil, nil); removed != 0 {
+		t.Errorf("StripAttributes removed %v attributes of a frozen tree", removed)
+	}
+	if removed := StripAttributes(nil, nil, nil); removed != 0 {
+		t.Errorf("StripAttributes removed %v attributes of nil", removed)
+	}
+}
+
+func TestStripAttributesArchiveSize(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(`<html><head><link rel="stylesheet" href="/s.css" data-reactid=".0.0"></head>` +
+		`<body class="page page-article" style="margin: 0" data-reactroot="">`)
+	for i := 0; i < 50; i++ {
+		sb.WriteString(`<article class="teaser teaser--large js-track" style="padding: 4px 8px; border: 1px solid #eee" ` +
+			`data-reactid=".0.1.` + strings.Repeat("1", i%7) + `" aria-labelledby="h" itemprop="blogPost">` +
+			`<h2 id="h" class="teaser__title" style="font-weight: bold">Headline</h2>` +
+			`<a href="/article?id=42&utm_source=feed&utm_medium=rss" class="teaser__link" ` +
+			`onclick="ga('send', 'event', 'teaser', 'click')" data-track="teaser-click" target="_self">Read more</a>` +
+			`<img src="/img/42.jpg" alt="Photo" class="teaser__img lazyload" width="640" height="360" loading="lazy" ` +
+			`style="object-fit: cover" data-srcset="/img/42@2x.jpg 2x"></article>`)
+	}
+	sb.WriteString(`</body></html>`)
+	doc := parseDocument(t, sb.String())
+	before := len(renderNode(t, doc))
+
+	keep := map[string][]string{"a": {"href"}, "img": {"src", "alt", "width", "height"}, "link": {"rel", "href"}}
+	if removed := StripAttributes(doc, keep, []string{"id", "aria-*"}); removed == 0 {
+		t.Fatal("StripAttributes removed no attributes")
+	}
+	after := len(renderNode(t, doc))
+	if after*10 >= before*7 {
+		t.Errorf("render shrank from %v to %v bytes, want more than 30%%", before, after)
+	}
+
+	// kept attributes survive
+	tests := map[string]string{
+		"link":    "rel=stylesheet href=/s.css",
+		"body":    "",
+		"article": "aria-labelledby=h",
+		"h2":      "id=h",
+		"a":       "href=/article?id=42&utm_source=feed&utm_medium=rss",
+		"img":     "src=/img/42.jpg alt=Photo width=640 height=360",
+	}
+	for tag, want := range tests {
+		for _, node := range GetElementNodesByTagName(tag, doc) {
+			if got := attributeList(node); got != want {
+				t.Fatalf("attributes of <%v> = %q, want %q", tag, got, want)
+			}
+		}
+	}
+}
+
+func TestDedupeAttributes(t *testing.T) {
+	doc := parseDocument(t, `<div><a href="first" id="a" HREF="second" href="third">x</a><img src=a.png alt="" src=b.png>`+
+		`<svg><a xlink:href="#x" href="y" xlink:href="#z"></a></svg></div>`)
+	a := elementByID(t, doc, "a")
+	// html.Parse keeps the duplicates, lookups and browsers use the first one
+	if href, err := GetAttributeByKey(a, "href"); err != nil || href.Val != "first" {
+		t.Errorf("GetAttributeByKey() = %v, %v, want first", href.Val, err)
+	}
+	if got := renderNode(t, a); got != `<a href="first" id="a" href="second" href="third">x</a>` {
+		t.Errorf("parsed duplicates are not rendered: %v", got)
+	}
+
+	if removed := DedupeAttributes(doc); removed != 4 {
+		t.Errorf("DedupeAttributes removed %v attributes, want 4", removed)
+	}
+	want := `<div><a href="first" id="a">x</a><img src="a.png" alt=""/>` +
+		`<svg><a xlink:href="#x" href="y"></a></svg></div>`
+	if got := renderNode(t, GetElementNodesByTagName("div", doc)[0]); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	if href, _ := GetAttributeByKey(a, "href"); href.Val != "first" {
+		t.Errorf("the first href was not kept, got %v", href.Val)
+	}
+	if removed := DedupeAttributes(doc); removed != 0 {
+		t.Errorf("deduplicating again removed %v attributes", removed)
+	}
+
+	frozen := FreezeTree(parseDocument(t, `<p a="1" a="2"></p>`))
+	defer frozen.Release()
+	if removed := DedupeAttributes(frozen.Root()); removed != 0 {
+		t.Errorf("DedupeAttributes removed %v attributes of a frozen tree", removed)
+	}
+	if removed := DedupeAttributes(nil); removed != 0 {
+		t.Errorf("DedupeAttributes removed %v attributes of nil", removed)
+	}
+}
+
+func TestRenderWithOptions(t *testing.T) {
+	const source = `<p z="1" b="2" z="3"><i a="x" a="y"></i></p>`
+	tests := []struct {
+		opts RenderOptions
+		want string
+	}{
+		{RenderOptions{}, `<p z="1" b="2" z="3"><i a="x" a="y"></i></p>`},
+		{RenderOptions{DedupeAttributes: true}, `<p z="1" b="2"><i a="x"></i></p>`},
+		{RenderOptions{SortAttributes: true}, `<p b="2" z="1" z="3"><i a="x" a="y"></i></p>`},
+		{RenderOptions{SortAttributes: true, DedupeAttributes: true}, `<p b="2" z="1"><i a="x"></i></p>`},
+	}
+	for _, tt := range tests {
+		p := GetElementNodesByTagName("p", parseDocument(t, source))[0]
+		var sb strings.Builder
+		if err := RenderWithOptions(&sb, p, tt.opts); err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != tt.want {
+			t.Errorf("RenderWithOptions(%+v) got\n%v\nwant\n%v", tt.opts, got, tt.want)
+		}
+		if got := renderNode(t, p); got != source {
+			t.Errorf("RenderWithOptions(%+v) modified the tree: %v", tt.opts, got)
+		}
+	}
+
+	// frozen trees are rendered via their copy
+	frozen := FreezeTree(parseDocument(t, source))
+	defer frozen.Release()
+	var sb strings.Builder
+	if err := RenderWithOptions(&sb, frozen.FirstByCondition(MakeByTagNameCondition("p")),
+		RenderOptions{DedupeAttributes: true}); err != nil || sb.String() != `<p z="1" b="2"><i a="x"></i></p>` {
+		t.Errorf("got %v, %v for a frozen tree", sb.String(), err)
+	}
+}
@@ -0,0 +1,173 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// AuditCategory
+// The kind of an AuditFinding.
+type AuditCategory string
+
+const (
+	AuditEventHandler  AuditCategory = "event-handler"   // an on* attribute, e.g., onclick
+	AuditJavascriptURL AuditCategory = "javascript-url"  // an attribute value starting with 'javascript:'
+	AuditThirdParty    AuditCategory = "third-party-url" // an attribute value embedding a url of a host which is not allowed
+	AuditStyleURL      AuditCategory = "style-url"       // an inline style containing a url() reference
+)
+
+// defaultAuditSnippetLength
+// Maximum number of runes of AuditFinding.Snippet if AuditOptions.SnippetLength is 0.
+const defaultAuditSnippetLength = 80
+
+var (
+	auditURLRegex      = regexp.MustCompile(`(?i)(?:\bhttps?:)?//([a-z0-9](?:[a-z0-9-]*[a-z0-9])?(?:\.[a-z0-9](?:[a-z0-9-]*[a-z0-9])?)+)`)
+	auditStyleURLRegex = regexp.MustCompile(`(?i)url\(\s*['"]?([^'")]*)`)
+)
+
+// AuditOptions
+// Options for AuditAttributes.
+// Urls with the host of Base or of one of AllowedHosts, including their subdomains, are not reported as third-party. A
+// leading "www." of the host of Base is dropped, so "www.example.com" allows "example.com" and "cdn.example.com".
+type AuditOptions struct {
+	Base          *url.URL // url of the audited page, its host without a leading "www." is first-party
+	AllowedHosts  []string // further allowed hosts, e.g., a cdn of the site
+	SnippetLength int      // maximum number of runes of the reported values, defaultAuditSnippetLength if 0
+}
+
+// AuditFinding
+// A suspicious attribute found by AuditAttributes.
+type AuditFinding struct {
+	Category  AuditCategory
+	Node      *html.Node // the element carrying the attribute
+	Path      string     // path of the element, see GetNodePath
+	Attribute string     // key of the attribute
+	Snippet   string     // the beginning of the attribute value
+	Host      string     // the third-party host for AuditThirdParty and AuditStyleURL findings, else ""
+}
+
+// AuditReport
+// The findings of AuditAttributes in document order with their number per category.
+type AuditReport struct {
+	Findings []AuditFinding
+	Counts   map[AuditCategory]int
+}
+
+// isAllowedAuditHost
+// Returns whether host equals or is a subdomain of one of allowed.
+func isAllowedAuditHost(host string, allowed []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSuffix(a, "."))
+		if a != "" && (host == a || strings.HasSuffix(host, "."+a)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditAttributes
+// Lists all inline event handlers, javascript: urls, urls of third-party hosts, and inline styles with url() references
+// of the elements in the tree of root in a single traversal, e.g., for privacy reviews. A third-party url within an
+// inline style yields an AuditStyleURL finding with Host set instead of an additional AuditThirdParty finding.
+// See AuditReport.RemoveAttributes to remove the reported attributes.
+func AuditAttributes(root *html.Node, opts AuditOptions) AuditReport {
+	report := AuditReport{Counts: make(map[AuditCategory]int)}
+	allowed := append([]string{}, opts.AllowedHosts...)
+	if opts.Base != nil && opts.Base.Hostname() != "" {
+		host := strings.ToLower(opts.Base.Hostname())
+		if site := strings.TrimPrefix(host, "www."); strings.Contains(site, ".") {
+			host = site
+		}
+		allowed = append(allowed, host)
+	}
+	snippetLength := opts.SnippetLength
+	if snippetLength <= 0 {
+		snippetLength = defaultAuditSnippetLength
+	}
+
+	paths := newNodePathCache() // the positions among the siblings are computed once per parent
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		path := ""
+		add := func(category AuditCategory, attr html.Attribute, host string) {
+			if path == "" {
+				path = paths.path(n)
+			}
+			snippet := truncateText(strings.TrimSpace(attr.Val), snippetLength)
+			report.Findings = append(report.Findings, AuditFinding{
+				Category:  category,
+				Node:      n,
+				Path:      path,
+				Attribute: attr.Key,
+				Snippet:   snippet,
+				Host:      host,
+			})
+			report.Counts[category]++
+		}
+
+		for _, attr := range n.Attr {
+			key := strings.ToLower(attr.Key)
+			val := strings.TrimSpace(attr.Val)
+			switch {
+			case strings.HasPrefix(key, "on"):
+				add(AuditEventHandler, attr, "")
+				continue
+			case strings.HasPrefix(strings.ToLower(strings.Join(strings.Fields(val), "")), "javascript:"):
+				add(AuditJavascriptURL, attr, "")
+				continue
+			case key == "style":
+				for _, match := range auditStyleURLRegex.FindAllStringSubmatch(val, -1) {
+					host := ""
+					if hostMatch := auditURLRegex.FindStringSubmatch(match[1]); hostMatch != nil && !isAllowedAuditHost(hostMatch[1], allowed) {
+						host = strings.ToLower(hostMatch[1])
+					}
+					add(AuditStyleURL, attr, host)
+				}
+				continue
+			}
+			reported := make(map[string]bool)
+			for _, match := range auditURLRegex.FindAllStringSubmatch(val, -1) {
+				host := strings.ToLower(match[1])
+				if !reported[host] && !isAllowedAuditHost(host, allowed) {
+					reported[host] = true
+					add(AuditThirdParty, attr, host)
+				}
+			}
+		}
+		return true
+	})
+	return report
+}
+
+// RemoveAttributes
+// Removes the attributes of all findings of the given categories, or of all findings if no category is given, from
+// their elements. Returns the number of removed attributes. Frozen nodes are skipped, see FreezeTree.
+func (r AuditReport) RemoveAttributes(categories ...AuditCategory) int {
+	selected := make(map[AuditCategory]bool, len(categories))
+	for _, category := range categories {
+		selected[category] = true
+	}
+
+	removed := 0
+	for _, finding := range r.Findings {
+		if len(selected) > 0 && !selected[finding.Category] {
+			continue
+		}
+		if checkMutable(finding.Node) != nil {
+			continue
+		}
+		for i, attr := range finding.Node.Attr {
+			if attr.Key == finding.Attribute {
+				finding.Node.Attr = append(finding.Node.Attr[:i], finding.Node.Attr[i+1:]...)
+				removed++
+				break
+			}
+		}
+	}
+	return removed
+}
@@ -0,0 +1,91 @@
+package html_util
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// findingsString
+// Formats findings one per line as category, path, attribute, host, and snippet.
+func findingsString(findings []AuditFinding) string {
+	var lines []string
+	for _, f := range findings {
+		lines = append(lines, fmt.Sprintf("%v %v @%v %v %q", f.Category, f.Path, f.Attribute, f.Host, f.Snippet))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestAuditAttributes(t *testing.T) {
+	doc := parseDocument(t, `<body onload="init()">`+
+		`<a href="https://example.com/a" onClick="track(1)">own</a>`+
+		`<a href=" java script:alert(1)">js</a>`+
+		`<img src="https://cdn.example.com/x.png" srcset="https://tracker.net/1.png 1x, https://tracker.net/2.png 2x">`+
+		`<div style="background: url('https://ads.org/bg.png'), url(/local.png)" data-online="yes"></div>`+
+		`<iframe src="//widgets.social.com/embed"></iframe>`+
+		`<p title="see https://static.partner.io/info">x</p>`+
+		`</body>`)
+	base, _ := url.Parse("https://www.example.com/page")
+
+	report := AuditAttributes(doc, AuditOptions{Base: base, AllowedHosts: []string{"partner.io"}, SnippetLength: 12})
+	want := strings.Join([]string{
+		`event-handler /html/body @onload  "init()"`,
+		`event-handler /html/body/a[1] @onclick  "track(1)"`,
+		`javascript-url /html/body/a[2] @href  "java script:…"`,
+		`third-party-url /html/body/img @srcset tracker.net "https://trac…"`, // reported once per host and attribute
+		`style-url /html/body/div @style ads.org "background: …"`,
+		`style-url /html/body/div @style  "background: …"`,
+		`third-party-url /html/body/iframe @src widgets.social.com "//widgets.so…"`,
+	}, "\n")
+	if got := findingsString(report.Findings); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestAuditReportRemoveAttributes(t *testing.T) {
+	doc := parseDocument(t, `<a id="a" href="javascript:void(0)" onclick="x()" onmouseover="y()">a</a>`+
+		`<span id="s" style="background: url(https://ads.org/a.png); color: red" title="https://ads.org">s</span>`)
+	report := AuditAttributes(doc, AuditOptions{})
+	wantCounts := map[AuditCategory]int{AuditEventHandler: 2, AuditJavascriptURL: 1, AuditStyleURL: 1, AuditThirdParty: 1}
+	if fmt.Sprint(report.Counts) != fmt.Sprint(wantCounts) {
+		t.Errorf("got counts %v, want %v", report.Counts, wantCounts)
+	}
+
+	if removed := report.RemoveAttributes(AuditEventHandler, AuditStyleURL); removed != 3 {
+		t.Errorf("removed %v attributes, want 3", removed)
+	}
+	if got, want := renderNode(t, elementByID(t, doc, "a")), `<a id="a" href="javascript:void(0)">a</a>`; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if removed := report.RemoveAttributes(); removed != 2 {
+		t.Errorf("removed %v further attributes, want 2", removed)
+	}
+	if got, want := renderNode(t, elementByID(t, doc, "s")), `<span id="s">s</span>`; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	frozen := FreezeTree(parseDocument(t, `<p onclick="x()">p</p>`))
+	defer frozen.Release()
+	report = AuditAttributes(frozen.Root(), AuditOptions{})
+	if removed := report.RemoveAttributes(); removed != 0 || report.Findings[0].Node.Attr == nil {
+		t.Errorf("removed %v attributes of a frozen tree", removed)
+	}
+}
+
+func TestAuditAttributesWideNode(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 20000; i++ {
+		sb.WriteString(`<a onclick="track()">x</a>`)
+	}
+	doc := parseDocument(t, sb.String())
+	start := time.Now()
+	report := AuditAttributes(doc, AuditOptions{})
+	if report.Counts[AuditEventHandler] != 20000 || report.Findings[19999].Path != "/html/body/a[20000]" {
+		t.Fatalf("got %v findings, last one at %v", len(report.Findings), report.Findings[len(report.Findings)-1].Path)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("auditing 20000 siblings took %v", elapsed)
+	}
+}
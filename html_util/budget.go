@@ -0,0 +1,136 @@
+package html_util
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"net/url"
+	"strings"
+)
+
+// ErrBudgetExceeded
+// Returned by the budget-aware traversals if the Budget was exceeded. The accompanying result is partial.
+var ErrBudgetExceeded = errors.New("traversal budget exceeded, the result is partial")
+
+// Budget
+// Hard limits for a traversal, e.g., for pages with absurd amounts of markup. A limit of 0 means unlimited.
+type Budget struct {
+	MaxNodes     int // maximum number of visited nodes
+	MaxTextBytes int // maximum total length of the data of visited text nodes
+}
+
+// budgetCounter
+// Tracks the consumption of a Budget during a single traversal. A nil *budgetCounter is unlimited.
+type budgetCounter struct {
+	budget    Budget
+	nodes     int
+	textBytes int
+	exceeded  bool
+}
+
+func newBudgetCounter(budget Budget) *budgetCounter {
+	return &budgetCounter{budget: budget}
+}
+
+// visit
+// Counts node against the budget. Returns false if the budget is exceeded, in which case node must not be processed.
+func (c *budgetCounter) visit(node *html.Node) bool {
+	if c == nil {
+		return true
+	}
+	if c.exceeded {
+		return false
+	}
+	c.nodes++
+	if node.Type == html.TextNode {
+		c.textBytes += len(node.Data)
+	}
+	if (c.budget.MaxNodes > 0 && c.nodes > c.budget.MaxNodes) || (c.budget.MaxTextBytes > 0 && c.textBytes > c.budget.MaxTextBytes) {
+		c.exceeded = true
+		return false
+	}
+	return true
+}
+
+// done
+// Returns whether the traversal must stop.
+func (c *budgetCounter) done() bool {
+	return c != nil && c.exceeded
+}
+
+// err
+// Returns ErrBudgetExceeded if the budget was exceeded, else nil.
+func (c *budgetCounter) err() error {
+	if c.done() {
+		return ErrBudgetExceeded
+	}
+	return nil
+}
+
+// walkHtmlTreeWithCounter
+// Same as WalkHtmlTreeInclusive but aborts the entire traversal as soon as counter is exceeded.
+func walkHtmlTreeWithCounter(node *html.Node, counter *budgetCounter, f func(n *html.Node) bool) {
+	walkTree(node, func(n *html.Node) bool {
+		return counter.visit(n) && f(n)
+	}, nil, counter.done)
+}
+
+// WalkHtmlTreeWithBudget
+// Same as WalkHtmlTreeInclusive but stops as soon as budget is exceeded, in which case ErrBudgetExceeded is returned.
+// Nodes exceeding the budget are not passed to f.
+func WalkHtmlTreeWithBudget(node *html.Node, budget Budget, f func(n *html.Node) bool) error {
+	counter := newBudgetCounter(budget)
+	walkHtmlTreeWithCounter(node, counter, f)
+	return counter.err()
+}
+
+// GetNodesByConditionWithBudget
+// Same as GetNodesByCondition but stops as soon as budget is exceeded.
+// Returns the nodes found so far and ErrBudgetExceeded in that case.
+func GetNodesByConditionWithBudget(startNode *html.Node, cond func(node *html.Node) bool, budget Budget) ([]*html.Node, error) {
+	var foundNodes []*html.Node
+
+	err := WalkHtmlTreeWithBudget(startNode, budget, func(n *html.Node) bool {
+		if cond(n) {
+			foundNodes = append(foundNodes, n)
+		}
+		return true
+	})
+
+	return foundNodes, err
+}
+
+// GetInnerTextWithBudget
+// Same as GetInnerText but stops as soon as budget is exceeded.
+// Returns the text collected so far and ErrBudgetExceeded in that case.
+func GetInnerTextWithBudget(node *html.Node, budget Budget) (string, error) {
+	if node == nil {
+		return "", nil
+	}
+	counter := newBudgetCounter(budget)
+	var sb strings.Builder
+	writeInnerText(&sb, node, false, func(n *html.Node) bool {
+		return false
+	}, counter)
+	if node.Type == html.ElementNode && isPreformattedTag(node.Data) {
+		return sb.String(), counter.err()
+	}
+	return strings.TrimSpace(sb.String()), counter.err()
+}
+
+// ExtractURLsWithBudget
+// Same as ExtractURLs but stops as soon as budget is exceeded.
+// Returns the URLs found so far and ErrBudgetExceeded in that case. The lookup of the document's <base href> is
+// limited by a separate budget of the same size.
+func ExtractURLsWithBudget(root *html.Node, base *url.URL, opts URLExtractOptions, budget Budget) ([]ExtractedURL, error) {
+	var baseNode *html.Node
+	walkHtmlTreeWithCounter(root, newBudgetCounter(budget), func(n *html.Node) bool {
+		if baseNode == nil && isBaseElement(n) {
+			baseNode = n
+		}
+		return baseNode == nil
+	})
+
+	counter := newBudgetCounter(budget)
+	extracted := extractURLs(root, resolveBaseElement(baseNode, base), base, opts, counter)
+	return extracted, counter.err()
+}
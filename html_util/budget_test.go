@@ -0,0 +1,110 @@
+package html_util
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// makeWideTree
+// Returns a <body> element with n <p> children, each containing a text node and a link, i.e., 3n+1 nodes.
+func makeWideTree(n int) *html.Node {
+	body := &html.Node{Type: html.ElementNode, Data: "body"}
+	for i := 0; i < n; i++ {
+		p := &html.Node{Type: html.ElementNode, Data: "p"}
+		p.AppendChild(&html.Node{Type: html.TextNode, Data: "text "})
+		p.AppendChild(&html.Node{Type: html.ElementNode, Data: "a", Attr: []html.Attribute{{Key: "href", Val: "https://example.com/"}}})
+		body.AppendChild(p)
+	}
+	return body
+}
+
+func TestBudgetBoundsMillionNodeTree(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a tree of a million nodes")
+	}
+	root := makeWideTree(333333)
+	budget := Budget{MaxNodes: 1000}
+
+	start := time.Now()
+	visited := 0
+	err := WalkHtmlTreeWithBudget(root, budget, func(n *html.Node) bool {
+		visited++
+		return true
+	})
+	if !errors.Is(err, ErrBudgetExceeded) || visited != 1000 {
+		t.Errorf("visited %v nodes with error %v, want 1000 and ErrBudgetExceeded", visited, err)
+	}
+	nodes, err := GetNodesByConditionWithBudget(root, MakeByTagNameCondition("p"), budget)
+	if !errors.Is(err, ErrBudgetExceeded) || len(nodes) != 333 {
+		t.Errorf("found %v nodes with error %v, want 333 and ErrBudgetExceeded", len(nodes), err)
+	}
+	text, err := GetInnerTextWithBudget(root, Budget{MaxTextBytes: 50})
+	if !errors.Is(err, ErrBudgetExceeded) || text != strings.TrimSpace(strings.Repeat("text ", 10)) {
+		t.Errorf("got text %q with error %v", text, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("budget-limited traversals of a million nodes took %v", elapsed)
+	}
+
+	// without a budget, the whole tree is visited
+	visited = 0
+	if err := WalkHtmlTreeWithBudget(root, Budget{}, func(n *html.Node) bool { visited++; return true }); err != nil || visited != 999999+1 {
+		t.Errorf("visited %v nodes with error %v, want all", visited, err)
+	}
+}
+
+func TestBudgetLimits(t *testing.T) {
+	root := makeWideTree(3) // 10 nodes, 15 text bytes
+	tests := []struct {
+		budget  Budget
+		wantErr bool
+	}{
+		{Budget{}, false},
+		{Budget{MaxNodes: 10}, false}, // exactly met
+		{Budget{MaxNodes: 9}, true},
+		{Budget{MaxTextBytes: 15}, false},
+		{Budget{MaxTextBytes: 14}, true},
+	}
+	for _, tt := range tests {
+		visited := 0
+		err := WalkHtmlTreeWithBudget(root, tt.budget, func(n *html.Node) bool { visited++; return true })
+		if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrBudgetExceeded)) {
+			t.Errorf("%+v: got error %v after %v nodes", tt.budget, err, visited)
+		}
+	}
+
+	// skipped subtrees do not count
+	visited := 0
+	err := WalkHtmlTreeWithBudget(root, Budget{MaxNodes: 4}, func(n *html.Node) bool {
+		visited++
+		return n.Data != "p"
+	})
+	if err != nil || visited != 4 {
+		t.Errorf("visited %v nodes with error %v, want 4 without error", visited, err)
+	}
+}
+
+func TestExtractURLsWithBudget(t *testing.T) {
+	doc := parseDocument(t, `<head><base href="https://example.com/dir/"></head><body>`+
+		`<a href="a">a</a><a href="b">b</a><a href="c">c</a></body>`)
+
+	urls, err := ExtractURLsWithBudget(doc, nil, URLExtractOptions{}, Budget{})
+	// the <base href> itself is a url
+	if err != nil || len(urls) != 4 || urls[1].URL.String() != "https://example.com/dir/a" {
+		t.Fatalf("got %v urls with error %v", len(urls), err)
+	}
+	// document, html, head, base, body, a, text, a: the partial result is resolved against the base as well
+	urls, err = ExtractURLsWithBudget(doc, nil, URLExtractOptions{}, Budget{MaxNodes: 8})
+	if !errors.Is(err, ErrBudgetExceeded) || len(urls) != 3 || urls[2].URL.String() != "https://example.com/dir/b" {
+		t.Errorf("got %v urls with error %v, want 3 and ErrBudgetExceeded", len(urls), err)
+	}
+
+	base, _ := url.Parse("https://other.org/")
+	if urls, _ := ExtractURLsWithBudget(doc, base, URLExtractOptions{}, Budget{MaxNodes: 8}); urls[1].URL.Host != "example.com" {
+		t.Errorf("the document's base was not applied: %v", urls[1].URL)
+	}
+}
@@ -0,0 +1,266 @@
+package html_util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CellBoolSynonyms
+// Maps lower case cell texts to their boolean value, used by ParseCellBool.
+// Callers may add synonyms, e.g., CellBoolSynonyms["oui"] = true.
+var CellBoolSynonyms = map[string]bool{
+	"true":  true,
+	"false": false,
+	"yes":   true,
+	"no":    false,
+	"y":     true,
+	"n":     false,
+	"1":     true,
+	"0":     false,
+	"ja":    true,
+	"nein":  false,
+	"j":     true,
+	"✓":     true,
+	"✔":     true,
+	"☑":     true,
+	"✗":     false,
+	"✘":     false,
+	"☐":     false,
+}
+
+// ParseCellBool
+// Parses the text of a table cell as boolean by looking up the trimmed, lower case text in CellBoolSynonyms.
+func ParseCellBool(s string) (bool, error) {
+	b, ok := CellBoolSynonyms[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return false, fmt.Errorf("cannot parse '%v' as bool", s)
+	}
+	return b, nil
+}
+
+// ParseCellPercent
+// Parses the text of a table cell as percentage and returns it as fraction, i.e., "12.5%" yields 0.125.
+// See ParseCellPercentWithScale for the accepted formats.
+func ParseCellPercent(s string) (float64, error) {
+	return ParseCellPercentWithScale(s, true)
+}
+
+// ParseCellPercentWithScale
+// Parses the text of a table cell as percentage, e.g., "12.5%", "12,5 %", "-3%", or "(3.2%)" (accounting negative).
+// The percent sign is optional. Both '.' and ',' are accepted as decimal separator, see parseLocaleNumber.
+// If asFraction is true, the value is divided by 100, i.e., "(3.2%)" yields -0.032, else, it yields -3.2.
+func ParseCellPercentWithScale(s string, asFraction bool) (float64, error) {
+	text := strings.TrimSpace(s)
+
+	negative := false
+	if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") {
+		negative = true
+		text = strings.TrimSpace(text[1 : len(text)-1])
+	}
+	text = strings.TrimSpace(strings.TrimSuffix(text, "%"))
+
+	value, err := parseLocaleNumber(text)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse '%v' as percentage: %w", s, err)
+	}
+	if negative {
+		value = -value
+	}
+	if asFraction {
+		value /= 100
+	}
+	return value, nil
+}
+
+// parseLocaleNumber
+// Parses a number which may use ',' or '.' as decimal or thousands separator, e.g., "1,234.5", "1.234,5", "12,5".
+// If both separators occur, the last one is the decimal separator. If only one of them occurs, a single occurrence is
+// the decimal separator and multiple occurrences are thousands separators.
+// Whitespace (including non-breaking spaces), apostrophes used as thousands separator, a leading '+', and the unicode
+// minus sign are accepted.
+func parseLocaleNumber(s string) (float64, error) {
+	text := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u2009', '\u202f', '\'', '\u2019':
+			return -1
+		case '\u2212', '\u2013':
+			return '-'
+		}
+		return r
+	}, s)
+	text = strings.TrimPrefix(text, "+")
+
+	lastComma := strings.LastIndex(text, ",")
+	lastDot := strings.LastIndex(text, ".")
+	switch {
+	case lastComma != -1 && lastDot != -1:
+		if lastComma > lastDot {
+			text = strings.ReplaceAll(text, ".", "")
+			text = strings.Replace(text, ",", ".", 1)
+		} else {
+			text = strings.ReplaceAll(text, ",", "")
+		}
+	case lastComma != -1:
+		if strings.Count(text, ",") > 1 {
+			text = strings.ReplaceAll(text, ",", "")
+		} else {
+			text = strings.Replace(text, ",", ".", 1)
+		}
+	case lastDot != -1:
+		if strings.Count(text, ".") > 1 {
+			text = strings.ReplaceAll(text, ".", "")
+		}
+	}
+
+	if text == "" {
+		return 0, fmt.Errorf("no number in '%v'", s)
+	}
+	return strconv.ParseFloat(text, 64)
+}
+
+var valueWithUnitRegex = regexp.MustCompile(`^([+\-\x{2212}]?[0-9.,'\x{2019}\s\x{00a0}\x{202f}]*[0-9])\s*(.*?)$`)
+
+// ValueWithUnit
+// A numeric cell value together with its unit, e.g., "1.2 kg" yields Value 1.2 and Unit "kg".
+type ValueWithUnit struct {
+	Value float64 // the numeric value
+	Unit  string  // the unit following the number, "" if there is none
+	Raw   string  // the original cell text
+	Valid bool    // whether Value and Unit could be determined
+	Prose bool    // whether Value and Unit were extracted from prose by ExtractFirstNumber, see GetColumnWithUnitsLenient
+}
+
+// ColumnCellsError
+// Reports the cells of a column which could not be handled.
+type ColumnCellsError struct {
+	Key    string // key of the column
+	Rows   []int  // row indices of the offending cells, see GetRowByIndex
+	Reason string // why the cells could not be handled
+}
+
+func (e *ColumnCellsError) Error() string {
+	return fmt.Sprintf("column '%v': %v in rows %v", e.Key, e.Reason, e.Rows)
+}
+
+// ParseValueWithUnit
+// Parses a text like "1.2 kg", "850g", or "1.234,5 m²" into its numeric value and unit.
+// See parseLocaleNumber for the accepted number formats. Ranges like "10–15 kg" are rejected, see ExtractFirstNumber.
+func ParseValueWithUnit(s string) (ValueWithUnit, error) {
+	v := ValueWithUnit{Raw: s}
+	match := valueWithUnitRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return v, fmt.Errorf("cannot parse '%v' as value with unit", s)
+	}
+	if upper, _ := rangeUpperBound(match[2]); upper != -1 {
+		return v, fmt.Errorf("cannot parse '%v' as value with unit: range", s)
+	}
+	value, err := parseLocaleNumber(match[1])
+	if err != nil {
+		return v, fmt.Errorf("cannot parse '%v' as value with unit: %w", s, err)
+	}
+	v.Value = value
+	v.Unit = strings.TrimSpace(match[2])
+	v.Valid = true
+	return v, nil
+}
+
+// GetColumnWithUnits
+// Returns the data cells of the column with the given key (see GetColumnByKey) parsed by ParseValueWithUnit.
+// Cells which cannot be parsed are returned with Valid false and reported via a *ColumnCellsError.
+func (ht HtmlTable) GetColumnWithUnits(key string) ([]ValueWithUnit, error) {
+	column, j, ok := ht.GetColumnByKey(key)
+	if !ok {
+		return nil, fmt.Errorf("unknown column key: '%v'", key)
+	}
+	if j == 0 {
+		column = column[1:] // skip the index header
+	}
+
+	values := make([]ValueWithUnit, len(column))
+	var invalidRows []int
+	for i, cell := range column {
+		values[i], _ = ParseValueWithUnit(cell)
+		if !values[i].Valid {
+			invalidRows = append(invalidRows, i+1)
+		}
+	}
+
+	if len(invalidRows) > 0 {
+		return values, &ColumnCellsError{Key: key, Rows: invalidRows, Reason: "no value with unit"}
+	}
+	return values, nil
+}
+
+// GetColumnWithUnitsLenient
+// Same as GetColumnWithUnits but falls back to ExtractFirstNumber with opts for cells which ParseValueWithUnit cannot
+// parse, e.g., "approx. 1,200 units", so a few prose cells do not fail an otherwise clean column. Such cells are returned
+// with Prose true. If opts.Convention is not DecimalUnknown, it also applies to the other cells, e.g., "1,200 kg" yields
+// 1200 for DecimalPoint instead of 1.2. Cells without any number are returned with Valid false and reported via a
+// *ColumnCellsError.
+func (ht HtmlTable) GetColumnWithUnitsLenient(key string, opts NumberExtractOptions) ([]ValueWithUnit, error) {
+	values, err := ht.GetColumnWithUnits(key)
+	if values == nil {
+		return nil, err
+	}
+
+	var invalidRows []int
+	for i, v := range values {
+		if v.Valid && opts.Convention == DecimalUnknown {
+			continue
+		}
+		value, unit, err := ExtractFirstNumber(v.Raw, opts)
+		if err != nil {
+			values[i] = ValueWithUnit{Raw: v.Raw}
+			invalidRows = append(invalidRows, i+1)
+			continue
+		}
+		if v.Valid {
+			values[i].Value = value
+			continue
+		}
+		values[i] = ValueWithUnit{Value: value, Unit: unit, Raw: v.Raw, Valid: true, Prose: true}
+	}
+
+	if len(invalidRows) > 0 {
+		return values, &ColumnCellsError{Key: key, Rows: invalidRows, Reason: "no number"}
+	}
+	return values, nil
+}
+
+// ConvertColumnUnits
+// Returns the values of GetColumnWithUnits converted to targetUnit.
+// conversions maps each known unit to the factor converting it to targetUnit, e.g., {"g": 0.001} for targetUnit "kg".
+// targetUnit itself has the implicit factor 1.
+// Cells with unknown units are not converted but returned with Valid false and reported via a *ColumnCellsError.
+func (ht HtmlTable) ConvertColumnUnits(key, targetUnit string, conversions map[string]float64) ([]ValueWithUnit, error) {
+	values, err := ht.GetColumnWithUnits(key)
+	if values == nil {
+		return nil, err
+	}
+
+	var invalidRows []int
+	for i, v := range values {
+		if !v.Valid {
+			invalidRows = append(invalidRows, i+1)
+			continue
+		}
+		if v.Unit == targetUnit {
+			continue
+		}
+		factor, ok := conversions[v.Unit]
+		if !ok {
+			values[i].Valid = false
+			invalidRows = append(invalidRows, i+1)
+			continue
+		}
+		values[i].Value = v.Value * factor
+		values[i].Unit = targetUnit
+	}
+
+	if len(invalidRows) > 0 {
+		return values, &ColumnCellsError{Key: key, Rows: invalidRows, Reason: fmt.Sprintf("no value with unit convertible to '%v'", targetUnit)}
+	}
+	return values, nil
+}
@@ -0,0 +1,120 @@
+package html_util
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseCellBool(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    bool
+		wantErr bool
+	}{
+		{s: " Yes ", want: true},
+		{s: "NO", want: false},
+		{s: "✓", want: true},
+		{s: "✗", want: false},
+		{s: "nein", want: false},
+		{s: "1", want: true},
+		{s: "maybe", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCellBool(tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseCellBool(%q) = %v, %v, want %v (error %v)", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseCellPercent(t *testing.T) {
+	tests := []struct {
+		s          string
+		asFraction bool
+		want       float64
+		wantErr    bool
+	}{
+		{s: "12.5%", asFraction: true, want: 0.125},
+		{s: "12,5 %", asFraction: true, want: 0.125},
+		{s: "(3.2%)", asFraction: true, want: -0.032},
+		{s: "(3.2%)", want: -3.2},
+		{s: "−4 %", want: -4},
+		{s: "+1,234.5%", want: 1234.5},
+		{s: "1.234,5", want: 1234.5},
+		{s: "42", want: 42},
+		{s: "%", wantErr: true},
+		{s: "n/a", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCellPercentWithScale(tt.s, tt.asFraction)
+		if (err != nil) != tt.wantErr || math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("ParseCellPercentWithScale(%q, %v) = %v, %v, want %v (error %v)", tt.s, tt.asFraction, got, err, tt.want, tt.wantErr)
+		}
+	}
+	if got, _ := ParseCellPercent("50%"); got != 0.5 {
+		t.Errorf("ParseCellPercent(50%%) = %v, want 0.5", got)
+	}
+}
+
+func TestParseValueWithUnit(t *testing.T) {
+	tests := []struct {
+		s         string
+		wantValue float64
+		wantUnit  string
+		wantErr   bool
+	}{
+		{s: "1.2 kg", wantValue: 1.2, wantUnit: "kg"},
+		{s: "850g", wantValue: 850, wantUnit: "g"},
+		{s: " 1.234,5 m² ", wantValue: 1234.5, wantUnit: "m²"},
+		{s: "-3 °C", wantValue: -3, wantUnit: "°C"},
+		{s: "42", wantValue: 42},
+		{s: "kg", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		v, err := ParseValueWithUnit(tt.s)
+		if tt.wantErr {
+			if err == nil || v.Valid {
+				t.Errorf("ParseValueWithUnit(%q) = %+v, want an error", tt.s, v)
+			}
+			continue
+		}
+		if err != nil || !v.Valid || v.Value != tt.wantValue || v.Unit != tt.wantUnit || v.Raw != tt.s {
+			t.Errorf("ParseValueWithUnit(%q) = %+v, %v, want %v %q", tt.s, v, err, tt.wantValue, tt.wantUnit)
+		}
+	}
+}
+
+func TestGetAndConvertColumnWithUnits(t *testing.T) {
+	ht := parseTable(t, `<table>`+
+		`<tr><th>item</th><th>weight</th></tr>`+
+		`<tr><td>a</td><td>1.5 kg</td></tr>`+
+		`<tr><td>b</td><td>500 g</td></tr>`+
+		`<tr><td>c</td><td>n/a</td></tr>`+
+		`<tr><td>d</td><td>2 lb</td></tr>`+
+		`</table>`, true, true)
+
+	values, err := ht.GetColumnWithUnits("Weight")
+	var cellsErr *ColumnCellsError
+	if !errors.As(err, &cellsErr) || !reflect.DeepEqual(cellsErr.Rows, []int{3}) {
+		t.Fatalf("got error %v, want row 3 reported", err)
+	}
+	if len(values) != 4 || values[1].Value != 500 || values[1].Unit != "g" || values[2].Valid {
+		t.Errorf("got values %+v", values)
+	}
+
+	values, err = ht.ConvertColumnUnits("weight", "kg", map[string]float64{"g": 0.001})
+	if !errors.As(err, &cellsErr) || !reflect.DeepEqual(cellsErr.Rows, []int{3, 4}) {
+		t.Fatalf("got error %v, want rows 3 and 4 reported", err)
+	}
+	if values[0].Value != 1.5 || values[1].Value != 0.5 || values[1].Unit != "kg" || values[3].Valid || values[3].Unit != "lb" {
+		t.Errorf("got converted values %+v", values)
+	}
+
+	if _, err := ht.GetColumnWithUnits("height"); err == nil || errors.As(err, &cellsErr) {
+		t.Errorf("got error %v for an unknown column", err)
+	}
+}
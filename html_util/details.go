@@ -0,0 +1,245 @@
+package html_util
+
+import (
+	"encoding/json"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Disclosure
+// A <details> disclosure widget, see ExtractDetails.
+type Disclosure struct {
+	Node     *html.Node   // the <details> element
+	Summary  string       // visible inner text of the <summary> element, "" if there is none
+	Open     bool         // whether the element has the open attribute
+	Text     string       // visible inner text of the content, i.e., everything but the <summary> element, including nested disclosures
+	Content  []*html.Node // children of the <details> element except the <summary> element
+	Children []Disclosure // disclosures nested in the content
+}
+
+// ExtractDetails
+// Returns all <details> elements in the tree of root which are not nested in another <details> element in document
+// order, nested ones are returned as Children of their closest enclosing disclosure.
+// The summary is the first <summary> child of a <details> element, as in browsers. Texts skip hidden elements, see
+// Info.Visible, but not the content of closed disclosures.
+func ExtractDetails(root *html.Node) []Disclosure {
+	var disclosures []Disclosure
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || n.Data != "details" {
+			return true
+		}
+		disclosures = append(disclosures, makeDisclosure(n))
+		return false
+	})
+	return disclosures
+}
+
+// makeDisclosure
+// Returns the Disclosure of the <details> element, including its nested disclosures.
+func makeDisclosure(details *html.Node) Disclosure {
+	disclosure := Disclosure{
+		Node: details,
+		Open: hasAttribute(details, "open"),
+	}
+	var summary *html.Node
+	for c := details.FirstChild; c != nil; c = c.NextSibling {
+		if summary == nil && c.Type == html.ElementNode && c.Data == "summary" {
+			summary = c
+			continue
+		}
+		disclosure.Content = append(disclosure.Content, c)
+		disclosure.Children = append(disclosure.Children, ExtractDetails(c)...)
+	}
+	if summary != nil {
+		disclosure.Summary = getVisibleInnerText(summary)
+	}
+	disclosure.Text = getInnerTextExcluding(details, func(n *html.Node) bool {
+		return n == summary || !isVisibleElement(n)
+	})
+	return disclosure
+}
+
+// QA
+// A question and its answer, see ExtractFAQ.
+type QA struct {
+	Question   string
+	Answer     string
+	Node       *html.Node // the <details> element, nil if the question only occurs in JSON-LD
+	FromMarkup bool       // whether the question occurs as <details> element
+	FromJSONLD bool       // whether the question occurs in schema.org FAQPage JSON-LD
+}
+
+// ExtractFAQ
+// Returns the questions and answers of the tree of root from all <details> elements with a summary, including nested
+// ones, and from the schema.org FAQPage JSON-LD of the page, i.e., its <script type="application/ld+json"> elements.
+// Questions occurring in both sources are merged, they are compared after removing punctuation, symbols, case, and
+// whitespace differences. Questions of the markup come first in document order, the answer of the markup is preferred
+// unless it is empty. Answers of the JSON-LD are converted from html to text.
+func ExtractFAQ(root *html.Node) []QA {
+	var faq []QA
+	positions := make(map[string]int) // normalized question -> position in faq
+
+	var addDisclosures func(disclosures []Disclosure)
+	addDisclosures = func(disclosures []Disclosure) {
+		for _, disclosure := range disclosures {
+			key := normalizeQuestion(disclosure.Summary)
+			if _, ok := positions[key]; !ok && key != "" {
+				positions[key] = len(faq)
+				faq = append(faq, QA{
+					Question:   disclosure.Summary,
+					Answer:     disclosure.Text,
+					Node:       disclosure.Node,
+					FromMarkup: true,
+				})
+			}
+			addDisclosures(disclosure.Children)
+		}
+	}
+	addDisclosures(ExtractDetails(root))
+
+	for _, qa := range extractFAQJSONLD(root) {
+		key := normalizeQuestion(qa.Question)
+		if key == "" {
+			continue
+		}
+		if k, ok := positions[key]; ok {
+			faq[k].FromJSONLD = true
+			if faq[k].Answer == "" {
+				faq[k].Answer = qa.Answer
+			}
+			continue
+		}
+		positions[key] = len(faq)
+		faq = append(faq, qa)
+	}
+	return faq
+}
+
+// normalizeQuestion
+// Normalizes a question for comparison, see ExtractFAQ.
+func normalizeQuestion(question string) string {
+	return NormalizeKey(strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return ' '
+		}
+		return r
+	}, question))
+}
+
+// isJSONLDScript
+// Returns true for <script type="application/ld+json"> elements.
+func isJSONLDScript(node *html.Node) bool {
+	if node.Type != html.ElementNode || node.Data != "script" {
+		return false
+	}
+	scriptType, err := GetAttributeByKey(node, "type")
+	if err != nil {
+		return false
+	}
+	mediaType, _, _ := strings.Cut(scriptType.Val, ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), "application/ld+json")
+}
+
+// extractFAQJSONLD
+// Returns the questions of all FAQPage objects of the JSON-LD scripts in the tree of root in document order.
+// Scripts which are not valid JSON are skipped.
+func extractFAQJSONLD(root *html.Node) []QA {
+	var faq []QA
+	for _, script := range GetNodesByCondition(root, isJSONLDScript) {
+		var data interface{}
+		if err := json.Unmarshal([]byte(getDataIslandContent(script)), &data); err != nil {
+			continue
+		}
+		for _, page := range findJSONLDObjects(data, "FAQPage") {
+			for _, question := range jsonLDObjects(page["mainEntity"]) {
+				qa := QA{FromJSONLD: true}
+				qa.Question, _ = question["name"].(string)
+				for _, answer := range jsonLDObjects(question["acceptedAnswer"]) {
+					if text, ok := answer["text"].(string); ok {
+						qa.Answer = htmlToText(text)
+						break
+					}
+				}
+				qa.Question = strings.TrimSpace(qa.Question)
+				faq = append(faq, qa)
+			}
+		}
+	}
+	return faq
+}
+
+// jsonLDObjects
+// Returns value as list of objects, value may be a single object or an array.
+func jsonLDObjects(value interface{}) []map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}
+	case []interface{}:
+		var objects []map[string]interface{}
+		for _, item := range v {
+			if object, ok := item.(map[string]interface{}); ok {
+				objects = append(objects, object)
+			}
+		}
+		return objects
+	}
+	return nil
+}
+
+// findJSONLDObjects
+// Returns all objects in data whose @type is or contains typeName, searching nested objects and arrays, e.g., @graph.
+func findJSONLDObjects(data interface{}, typeName string) []map[string]interface{} {
+	var found []map[string]interface{}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if hasJSONLDType(v, typeName) {
+			return append(found, v)
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys) // deterministic order
+		for _, key := range keys {
+			found = append(found, findJSONLDObjects(v[key], typeName)...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			found = append(found, findJSONLDObjects(item, typeName)...)
+		}
+	}
+	return found
+}
+
+// hasJSONLDType
+// Returns whether the @type of object is or contains typeName.
+func hasJSONLDType(object map[string]interface{}, typeName string) bool {
+	switch t := object["@type"].(type) {
+	case string:
+		return t == typeName
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok && s == typeName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// htmlToText
+// Parses s as html fragment and returns its inner text, see GetInnerText. Returns s if it cannot be parsed.
+func htmlToText(s string) string {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		return strings.TrimSpace(s)
+	}
+	for _, n := range nodes {
+		context.AppendChild(n)
+	}
+	return GetInnerText(context)
+}
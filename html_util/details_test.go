@@ -0,0 +1,85 @@
+package html_util
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// disclosuresString
+// Formats disclosures one per line with their nesting depth as indentation.
+func disclosuresString(disclosures []Disclosure, depth int) string {
+	var lines []string
+	for _, d := range disclosures {
+		lines = append(lines, fmt.Sprintf("%v%v open=%v %q %q %v", strings.Repeat("  ", depth), nodeID(d.Node), d.Open,
+			d.Summary, d.Text, len(d.Content)))
+		if len(d.Children) > 0 {
+			lines = append(lines, disclosuresString(d.Children, depth+1))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestExtractDetails(t *testing.T) {
+	doc := parseDocument(t, `<details id="a" open><summary>Shipping <span hidden>internal</span></summary>`+
+		`<p>We ship <b>worldwide</b>.</p> <span style="display:none">draft</span>`+
+		`<details id="b"><summary>Costs?</summary> 5 EUR<br><details id="c"><summary>Express</summary> 10 EUR</details>`+
+		`</details> <summary>second summary</summary></details>`+
+		`<div><details id="d">no summary</details></div>`)
+
+	// texts are those of GetInnerText, i.e., separated by whitespace and <br> elements only
+	want := strings.Join([]string{
+		`a open=true "Shipping" "We ship worldwide. Costs? 5 EUR\nExpress 10 EUR second summary" 6`,
+		`  b open=false "Costs?" "5 EUR\nExpress 10 EUR" 3`,
+		`    c open=false "Express" "10 EUR" 1`,
+		`d open=false "" "no summary" 1`,
+	}, "\n")
+	if got := disclosuresString(ExtractDetails(doc), 0); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	// the root may be a <details> element itself
+	if got := ExtractDetails(elementByID(t, doc, "b")); len(got) != 1 || len(got[0].Children) != 1 {
+		t.Errorf("got %v disclosures for a <details> root", len(got))
+	}
+	if got := ExtractDetails(nil); got != nil {
+		t.Errorf("ExtractDetails(nil) = %v", got)
+	}
+}
+
+func TestExtractFAQ(t *testing.T) {
+	doc := parseDocument(t, `<head><script type="application/ld+json">{"@context": "https://schema.org", "@graph": [`+
+		`{"@type": "WebPage"}, {"@type": ["FAQPage"], "mainEntity": [`+
+		`{"@type": "Question", "name": "How long does shipping take", "acceptedAnswer": {"text": "<p>Two <b>days</b>.</p>"}},`+
+		`{"@type": "Question", "name": " Can I return items? ", "acceptedAnswer": [{"text": "Yes, within 30 days."}]},`+
+		`{"@type": "Question", "name": "Do you ship abroad?", "acceptedAnswer": {"text": "Only in the EU."}},`+
+		`{"@type": "Question", "acceptedAnswer": {"text": "no question"}}]}]}</script>`+
+		`<script type="application/ld+json">{invalid</script></head>`+
+		`<details id="s"><summary>How long does  shipping take?</summary>About two days.</details>`+
+		`<details id="r"><summary>can i RETURN items!</summary></details>`+
+		`<details id="n"><summary>Where are you located?</summary>Berlin <details id="x"><summary>Address?</summary>`+
+		` Main Street 1</details></details><details><summary>Where are you located</summary>duplicate</details>`)
+
+	var got []string
+	for _, qa := range ExtractFAQ(doc) {
+		got = append(got, fmt.Sprintf("%q %q [%v] markup=%v json=%v", qa.Question, qa.Answer, nodeID(qa.Node), qa.FromMarkup,
+			qa.FromJSONLD))
+	}
+	// markup wins with slightly different texts, the empty answer of the markup is taken from the JSON-LD
+	want := strings.Join([]string{
+		`"How long does shipping take?" "About two days." [s] markup=true json=true`,
+		`"can i RETURN items!" "Yes, within 30 days." [r] markup=true json=true`,
+		`"Where are you located?" "Berlin Address? Main Street 1" [n] markup=true json=false`,
+		`"Address?" "Main Street 1" [x] markup=true json=false`,
+		`"Do you ship abroad?" "Only in the EU." [] markup=false json=true`,
+	}, "\n")
+	if strings.Join(got, "\n") != want {
+		t.Errorf("got\n%v\nwant\n%v", strings.Join(got, "\n"), want)
+	}
+
+	jsonOnly := ExtractFAQ(parseDocument(t, `<script type="Application/LD+JSON; charset=utf-8">{"@type": "FAQPage",`+
+		`"mainEntity": {"name": "Q?", "acceptedAnswer": {"text": "<p>a<br>b</p>"}}}</script>`))
+	if len(jsonOnly) != 1 || jsonOnly[0].Question != "Q?" || jsonOnly[0].Answer != "a\nb" {
+		t.Errorf("got %+v", jsonOnly)
+	}
+}
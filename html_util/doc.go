@@ -0,0 +1,7 @@
+// Package html_util provides utility functions for querying and extracting data from DOM elements which are
+// represented as *html.Node trees, e.g., finding nodes by condition and parsing tables, selects, and forms.
+//
+// Concurrency: html.Node trees are not safe for concurrent mutation. The query functions of this package only read the
+// tree, so they may be called from multiple goroutines as long as nobody modifies the tree at the same time. If the
+// tree is modified concurrently, query a FrozenDoc snapshot instead, see FreezeTree.
+package html_util
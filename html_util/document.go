@@ -0,0 +1,106 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// isMetadataTag
+// Returns true for elements which belong into the <head> of a document.
+func isMetadataTag(tag string) bool {
+	return tag == "title" || tag == "meta" || tag == "link" || tag == "base" || tag == "style"
+}
+
+// EnsureDocument
+// Returns a document node with a doctype and a minimal <html>, <head>, and <body> structure containing nodes, e.g.,
+// fragments parsed via html.ParseFragment, before rendering them as a standalone file.
+// A single document node which already has an <html> element is returned unchanged. Otherwise, the children of
+// document nodes are unpacked, the first <html>, <head>, and <body> elements are reused, the children of further ones
+// are merged into them, <title>, <meta>, <link>, <base>, and <style> elements go into the <head>, and all other nodes
+// into the <body> in the given order. Doctypes are replaced by "<!DOCTYPE html>".
+// The nodes are moved, i.e., detached from their current parents, nodes of frozen trees are copied instead. nil nodes
+// are ignored.
+// The query helpers of this package do not require a document, they search the subtree of whichever node is passed.
+func EnsureDocument(nodes ...*html.Node) *html.Node {
+	var queue []*html.Node
+	for _, node := range nodes {
+		if node != nil {
+			queue = append(queue, node)
+		}
+	}
+	if len(queue) == 1 && queue[0].Type == html.DocumentNode &&
+		GetNodeByCondition(queue[0], MakeByTagNameCondition("html")) != nil {
+		return queue[0]
+	}
+
+	var htmlElement, head, body *html.Node
+	var headNodes, bodyNodes []*html.Node
+	for k := 0; k < len(queue); k++ {
+		node := queue[k]
+		if IsFrozen(node) {
+			node = CloneTree(node)
+		}
+		detachNode(node)
+
+		switch {
+		case node.Type == html.DocumentNode:
+			queue = append(queue, GetChildren(node)...)
+		case node.Type == html.DoctypeNode:
+		case node.Type != html.ElementNode:
+			bodyNodes = append(bodyNodes, node)
+		case node.Data == "html" && htmlElement == nil:
+			htmlElement = node
+			for _, c := range GetChildren(node) {
+				if c.Type != html.ElementNode || c.Data != "head" && c.Data != "body" {
+					continue
+				}
+				if c.Data == "head" && head == nil {
+					head = c
+				} else if c.Data == "body" && body == nil {
+					body = c
+				} else {
+					queue = append(queue, c) // merged into the head or body found before
+				}
+			}
+		case node.Data == "head" && head == nil:
+			head = node
+		case node.Data == "body" && body == nil:
+			body = node
+		case node.Data == "html" || node.Data == "head" || node.Data == "body":
+			queue = append(queue, GetChildren(node)...)
+		case isMetadataTag(node.Data):
+			headNodes = append(headNodes, node)
+		default:
+			bodyNodes = append(bodyNodes, node)
+		}
+	}
+
+	if htmlElement == nil {
+		htmlElement = &html.Node{Type: html.ElementNode, Data: "html", DataAtom: atom.Html}
+	}
+	if head == nil {
+		head = &html.Node{Type: html.ElementNode, Data: "head", DataAtom: atom.Head}
+	}
+	if body == nil {
+		body = &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	}
+	if head.Parent != htmlElement {
+		detachNode(head)
+		htmlElement.InsertBefore(head, htmlElement.FirstChild)
+	}
+	if body.Parent != htmlElement {
+		detachNode(body)
+		htmlElement.AppendChild(body)
+	}
+	for _, node := range headNodes {
+		head.AppendChild(node)
+	}
+	for _, node := range bodyNodes {
+		body.AppendChild(node)
+	}
+
+	doc := &html.Node{Type: html.DocumentNode}
+	doc.AppendChild(&html.Node{Type: html.DoctypeNode, Data: "html"})
+	doc.AppendChild(htmlElement)
+	return doc
+}
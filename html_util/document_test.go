@@ -0,0 +1,128 @@
+package html_util
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// parseBodyFragment
+// Parses s as children of a <body> element via html.ParseFragment and fails the test on error.
+func parseBodyFragment(tb testing.TB, s string) []*html.Node {
+	tb.Helper()
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		tb.Fatalf("cannot parse fragment: %v", err)
+	}
+	return nodes
+}
+
+func TestEnsureDocument(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes func(t *testing.T) []*html.Node
+		want  string
+	}{
+		{"fragment", func(t *testing.T) []*html.Node {
+			return parseBodyFragment(t, `<title>T</title><p>a</p>text<meta name="x" content="y"><!--c-->`)
+		}, `<!DOCTYPE html><html><head><title>T</title><meta name="x" content="y"/></head>` +
+			`<body><p>a</p>text<!--c--></body></html>`},
+		{"bare div", func(t *testing.T) []*html.Node {
+			return []*html.Node{elementByID(t, parseDocument(t, `<div id="d"><link rel="x" href="y"></div>`), "d")}
+		}, `<!DOCTYPE html><html><head></head><body><div id="d"><link rel="x" href="y"/></div></body></html>`},
+		{"loose head and body", func(t *testing.T) []*html.Node {
+			doc := parseDocument(t, `<html lang="de"><head><title>T</title></head><body class="b"><p>a</p></body></html>`)
+			head := GetElementNodesByTagName("head", doc)[0]
+			body := GetElementNodesByTagName("body", doc)[0]
+			return []*html.Node{body, nil, head, parseBodyFragment(t, `<style>p{}</style><i>i</i>`)[1]}
+		}, `<!DOCTYPE html><html><head><title>T</title></head><body class="b"><p>a</p><i>i</i></body></html>`},
+		{"merged documents", func(t *testing.T) []*html.Node {
+			return []*html.Node{
+				parseDocument(t, `<!DOCTYPE html SYSTEM "about:legacy-compat"><html lang="en"><title>A</title><p>a</p>`),
+				parseDocument(t, `<title>B</title><p>b</p>`),
+			}
+		}, `<!DOCTYPE html><html lang="en"><head><title>A</title><title>B</title></head><body><p>a</p><p>b</p></body></html>`},
+		{"frozen", func(t *testing.T) []*html.Node {
+			frozen := FreezeTree(parseDocument(t, `<p id="p">frozen</p>`))
+			t.Cleanup(frozen.Release)
+			return []*html.Node{frozen.FirstByCondition(MakeByTagNameCondition("p"))}
+		}, `<!DOCTYPE html><html><head></head><body><p id="p">frozen</p></body></html>`},
+		{"none", func(t *testing.T) []*html.Node { return nil }, `<!DOCTYPE html><html><head></head><body></body></html>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := EnsureDocument(tt.nodes(t)...)
+			if got := renderNode(t, doc); got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+			if doc.Type != html.DocumentNode || doc.Parent != nil {
+				t.Errorf("got a %v node with parent %v", doc.Type, doc.Parent)
+			}
+			// the result parses to the same document
+			if got := renderNode(t, parseDocument(t, tt.want)); got != tt.want {
+				t.Errorf("the result is no parsed document:\n%v", got)
+			}
+		})
+	}
+
+	doc := parseDocument(t, `<p>a</p>`)
+	if EnsureDocument(doc) != doc {
+		t.Error("a document was not returned unchanged")
+	}
+	// the frozen source is copied, not moved
+	frozen := FreezeTree(parseDocument(t, `<p>frozen</p>`))
+	defer frozen.Release()
+	p := frozen.FirstByCondition(MakeByTagNameCondition("p"))
+	EnsureDocument(p)
+	if p.Parent == nil || p.Parent.Data != "body" {
+		t.Error("EnsureDocument moved a node of a frozen tree")
+	}
+}
+
+func TestDocumentExtractorsOnFragments(t *testing.T) {
+	const head = `<base href="https://example.com/a/"><meta name="robots" content="noindex">` +
+		`<meta property="og:title" content="T"><meta name="theme-color" content="#fff">` +
+		`<meta name="viewport" content="width=device-width"><meta http-equiv="refresh" content="5; url=next">`
+	const body = `<a href="x">x</a>`
+
+	fragment := parseBodyFragment(t, `<div>`+head+body+`</div>`)[0]
+	roots := map[string]*html.Node{
+		"document":        parseDocument(t, `<html><head>`+head+`</head><body>`+body+`</body></html>`),
+		"bare div":        fragment,
+		"wrapped element": EnsureDocument(parseBodyFragment(t, `<div>`+head+body+`</div>`)[0]),
+	}
+	for name, root := range roots {
+		t.Run(name, func(t *testing.T) {
+			if got := GetRobotsDirectives(root); !got.NoIndex || got.NoFollow {
+				t.Errorf("GetRobotsDirectives() = %+v", got)
+			}
+			if got := ExtractMetaProperties(root).Values("og:title"); fmt.Sprint(got) != "[T]" {
+				t.Errorf("og:title = %v", got)
+			}
+			if got, ok := GetThemeColor(root); !ok || got != "#fff" {
+				t.Errorf("GetThemeColor() = %v, %v", got, ok)
+			}
+			if got, ok := GetViewportMeta(root); !ok || !got.IsMobileOptimized() {
+				t.Errorf("GetViewportMeta() = %+v, %v", got, ok)
+			}
+			delay, target, ok, err := GetMetaRefresh(root, nil)
+			if err != nil || !ok || delay.Seconds() != 5 || target.String() != "https://example.com/a/next" {
+				t.Errorf("GetMetaRefresh() = %v, %v, %v, %v", delay, target, ok, err)
+			}
+			var urls []string
+			for _, extracted := range ExtractURLs(root, nil, URLExtractOptions{Attributes: []string{"href"}}) {
+				urls = append(urls, extracted.URL.String())
+			}
+			if got, want := strings.Join(urls, " "), "https://example.com/a/ https://example.com/a/x"; got != want {
+				t.Errorf("ExtractURLs() = %v, want %v", got, want)
+			}
+		})
+	}
+	if base, _ := url.Parse("https://example.com/a/"); getDocumentBase(fragment, nil).String() != base.String() {
+		t.Errorf("base of the fragment is %v", getDocumentBase(fragment, nil))
+	}
+}
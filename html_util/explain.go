@@ -0,0 +1,149 @@
+package html_util
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// maxExplainedMatches
+// Maximum number of partial matches of a ConditionReport.
+const maxExplainedMatches = 5
+
+// ClassifyNodes
+// Returns for each of conds all nodes in the tree of root (including root) for which it yields true, in document order.
+// The tree is traversed once for all conditions.
+func ClassifyNodes(root *html.Node, conds []func(node *html.Node) bool) [][]*html.Node {
+	classes := make([][]*html.Node, len(conds))
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		for i, cond := range conds {
+			if cond(n) {
+				classes[i] = append(classes[i], n)
+			}
+		}
+		return true
+	})
+	return classes
+}
+
+// LabeledCondition
+// A sub-condition of a conjunction with a label for reports, e.g., "class=price".
+type LabeledCondition struct {
+	Label string
+	Cond  func(node *html.Node) bool
+}
+
+// ConditionCount
+// The number of nodes matching a single LabeledCondition.
+type ConditionCount struct {
+	Label string
+	Count int
+}
+
+// PartialMatch
+// A node matching some of the sub-conditions of a ConditionReport.
+type PartialMatch struct {
+	Node    *html.Node
+	Path    string   // see GetNodePath
+	Matched []string // labels of the satisfied sub-conditions
+	Missing []string // labels of the unsatisfied sub-conditions
+}
+
+// ConditionReport
+// Explains why a conjunction of conditions matches or does not match, see ExplainCondition.
+type ConditionReport struct {
+	Counts      []ConditionCount // number of matches of each sub-condition, in the order of the conditions
+	FullMatches int              // number of nodes matching all sub-conditions
+	BestPartial []PartialMatch   // first nodes in document order satisfying the most sub-conditions
+}
+
+// ExplainCondition
+// Evaluates each of conds on the tree of root in a single traversal, see ClassifyNodes, and reports how many nodes
+// satisfy each of them and which nodes satisfy most of them, e.g., to find out that a class was renamed when a query
+// combining tag, class, and attribute conditions suddenly matches nothing.
+// BestPartial holds up to 5 nodes with the highest number of satisfied sub-conditions, which are the full matches if
+// there are any, and is empty if no node satisfies any sub-condition.
+func ExplainCondition(root *html.Node, conds []LabeledCondition) ConditionReport {
+	funcs := make([]func(node *html.Node) bool, len(conds))
+	for i, cond := range conds {
+		funcs[i] = cond.Cond
+	}
+	classes := ClassifyNodes(root, funcs)
+
+	report := ConditionReport{Counts: make([]ConditionCount, len(conds))}
+	scores := make(map[*html.Node]int)
+	var candidates []*html.Node
+	for i, nodes := range classes {
+		report.Counts[i] = ConditionCount{Label: conds[i].Label, Count: len(nodes)}
+		for _, n := range nodes {
+			if scores[n] == 0 {
+				candidates = append(candidates, n)
+			}
+			scores[n]++
+		}
+	}
+
+	best := 0
+	for _, n := range candidates {
+		if scores[n] > best {
+			best = scores[n]
+		}
+		if scores[n] == len(conds) {
+			report.FullMatches++
+		}
+	}
+	if best == 0 {
+		return report
+	}
+
+	SortNodesByDocumentOrder(candidates)
+	for _, n := range candidates {
+		if scores[n] != best {
+			continue
+		}
+		match := PartialMatch{Node: n, Path: GetNodePath(n)}
+		for i, nodes := range classes {
+			if containsNode(nodes, n) {
+				match.Matched = append(match.Matched, conds[i].Label)
+			} else {
+				match.Missing = append(match.Missing, conds[i].Label)
+			}
+		}
+		report.BestPartial = append(report.BestPartial, match)
+		if len(report.BestPartial) == maxExplainedMatches {
+			break
+		}
+	}
+	return report
+}
+
+// containsNode
+// Returns whether nodes contains node.
+func containsNode(nodes []*html.Node, node *html.Node) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// String
+// Summarizes the report in a single line, e.g., for error logs.
+func (r ConditionReport) String() string {
+	var sb strings.Builder
+	for i, count := range r.Counts {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s: %d", count.Label, count.Count)
+	}
+	if len(r.Counts) > 0 {
+		sb.WriteString("; ")
+	}
+	fmt.Fprintf(&sb, "full matches: %d", r.FullMatches)
+	for _, match := range r.BestPartial {
+		fmt.Fprintf(&sb, "; %s lacks [%s]", match.Path, strings.Join(match.Missing, ", "))
+	}
+	return sb.String()
+}
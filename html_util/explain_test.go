@@ -0,0 +1,104 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestClassifyNodes(t *testing.T) {
+	doc := parseDocument(t, `<div id="a" class="x"><p id="b" class="x">1</p><p id="c">2</p></div>`)
+	a := elementByID(t, doc, "a")
+	classes := ClassifyNodes(a, []func(node *html.Node) bool{
+		MakeByClassNameCondition("x"),
+		MakeByTagNameCondition("p"),
+		MakeByTagNameCondition("table"),
+		func(node *html.Node) bool { return node.Type == html.TextNode },
+	})
+	var got []string
+	for _, nodes := range classes {
+		var ids []string
+		for _, n := range nodes {
+			if n.Type == html.TextNode {
+				ids = append(ids, n.Data)
+			} else {
+				ids = append(ids, nodeID(n))
+			}
+		}
+		got = append(got, strings.Join(ids, " "))
+	}
+	// the root is classified as well, nodes are in document order
+	want := "a b|b c||1 2"
+	if strings.Join(got, "|") != want {
+		t.Errorf("got\n%v\nwant\n%v", strings.Join(got, "|"), want)
+	}
+	if classes := ClassifyNodes(a, nil); len(classes) != 0 {
+		t.Errorf("got %v classes without conditions", len(classes))
+	}
+}
+
+func TestExplainCondition(t *testing.T) {
+	doc := parseDocument(t, `<ul><li class="product-price" data-currency="EUR">1</li>`+
+		`<li class="product-price">2</li><li class="price" data-currency="EUR">3</li>`+
+		`<span class="product-price" data-currency="EUR">4</span></ul>`)
+	tag := LabeledCondition{Label: "tag=li", Cond: MakeByTagNameCondition("li")}
+	renamed := LabeledCondition{Label: "class=price", Cond: MakeByClassNameCondition("price")}
+	currency := LabeledCondition{Label: "data-currency", Cond: func(node *html.Node) bool {
+		_, err := GetAttributeByKey(node, "data-currency")
+		return err == nil
+	}}
+	missing := LabeledCondition{Label: "data-unit", Cond: func(node *html.Node) bool {
+		_, err := GetAttributeByKey(node, "data-unit")
+		return err == nil
+	}}
+
+	tests := []struct {
+		name  string
+		conds []LabeledCondition
+		want  string
+	}{
+		{"full match", []LabeledCondition{tag, renamed, currency},
+			"tag=li: 3, class=price: 1, data-currency: 3; full matches: 1; /html/body/ul/li[3] lacks []"},
+		{"renamed class", []LabeledCondition{tag, {Label: "class=cost", Cond: MakeByClassNameCondition("cost")}, currency},
+			"tag=li: 3, class=cost: 0, data-currency: 3; full matches: 0; " +
+				"/html/body/ul/li[1] lacks [class=cost]; /html/body/ul/li[3] lacks [class=cost]"},
+		{"missing attribute", []LabeledCondition{tag, missing},
+			"tag=li: 3, data-unit: 0; full matches: 0; /html/body/ul/li[1] lacks [data-unit]; " +
+				"/html/body/ul/li[2] lacks [data-unit]; /html/body/ul/li[3] lacks [data-unit]"},
+		{"no match at all", []LabeledCondition{missing}, "data-unit: 0; full matches: 0"},
+		{"no conditions", nil, "full matches: 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExplainCondition(doc, tt.conds).String(); got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+
+	report := ExplainCondition(doc, []LabeledCondition{tag, missing, currency})
+	if len(report.BestPartial) != 2 {
+		t.Fatalf("got %v best partial matches, want 2", len(report.BestPartial))
+	}
+	match := report.BestPartial[0]
+	if match.Node.FirstChild.Data != "1" || strings.Join(match.Matched, ",") != "tag=li,data-currency" ||
+		strings.Join(match.Missing, ",") != "data-unit" {
+		t.Errorf("got match %+v", match)
+	}
+}
+
+func TestExplainConditionLimitsPartialMatches(t *testing.T) {
+	doc := parseDocument(t, strings.Repeat(`<p>x</p>`, 20))
+	report := ExplainCondition(doc, []LabeledCondition{
+		{Label: "tag=p", Cond: MakeByTagNameCondition("p")},
+		{Label: "class=y", Cond: MakeByClassNameCondition("y")},
+	})
+	if report.Counts[0].Count != 20 || len(report.BestPartial) != maxExplainedMatches {
+		t.Fatalf("got %v matches and %v partial matches", report.Counts[0].Count, len(report.BestPartial))
+	}
+	for i, match := range report.BestPartial {
+		if want := "/html/body/p[" + string(rune('1'+i)) + "]"; match.Path != want {
+			t.Errorf("partial match %v has path %v, want %v", i, match.Path, want)
+		}
+	}
+}
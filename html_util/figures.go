@@ -0,0 +1,100 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"net/url"
+	"strings"
+)
+
+// FigureImage
+// An image of a Figure.
+type FigureImage struct {
+	Node *html.Node // the <img> element
+	URL  *url.URL   // the src attribute resolved against the document base, nil if missing or unparseable
+	Alt  string     // the alt attribute
+}
+
+// Figure
+// A <figure> element with its images and caption, see ExtractFigures.
+type Figure struct {
+	Node        *html.Node    // the <figure> element
+	Images      []FigureImage // the images of the figure in document order
+	Caption     string        // the visible text of the <figcaption>, "" if there is none
+	CaptionNode *html.Node    // the <figcaption> element, nil if there is none
+}
+
+// ExtractFigures
+// Returns all <figure> elements in the tree of root in document order, each paired with its images and the visible
+// text of its <figcaption>, regardless of whether the caption precedes or follows the images. Elements hidden by their
+// markup are not part of the visible text, see Info.Visible.
+// Image URLs are resolved against base and the document's <base href>, see ExtractURLs. Images and captions of nested
+// figures only belong to the nested figure.
+func ExtractFigures(root *html.Node, base *url.URL) []Figure {
+	documentBase := getDocumentBase(root, base)
+
+	var figures []Figure
+	for _, figureNode := range GetNodesByCondition(root, MakeByTagNameCondition("figure")) {
+		figure := Figure{Node: figureNode}
+		WalkHtmlTree(figureNode, func(n *html.Node) bool {
+			if n.Type != html.ElementNode {
+				return true
+			}
+			switch n.Data {
+			case "figure":
+				return false
+			case "figcaption":
+				if figure.CaptionNode == nil {
+					figure.CaptionNode = n
+					figure.Caption = getVisibleInnerText(n)
+				}
+				return false
+			case "img":
+				figure.Images = append(figure.Images, makeFigureImage(n, documentBase))
+			}
+			return true
+		})
+		figures = append(figures, figure)
+	}
+	return figures
+}
+
+// makeFigureImage
+// Returns the FigureImage of the <img> element with its src resolved against base.
+func makeFigureImage(img *html.Node, base *url.URL) FigureImage {
+	image := FigureImage{Node: img}
+	if alt, err := GetAttributeByKey(img, "alt"); err == nil {
+		image.Alt = alt.Val
+	}
+	if src, err := GetAttributeByKey(img, "src"); err == nil && strings.TrimSpace(src.Val) != "" {
+		if u, err := url.Parse(strings.TrimSpace(src.Val)); err == nil {
+			if base != nil {
+				u = base.ResolveReference(u)
+			}
+			image.URL = u
+		}
+	}
+	return image
+}
+
+// getVisibleInnerText
+// Same as GetInnerText but skips the subtrees of elements hidden by their markup, see isVisibleElement.
+func getVisibleInnerText(node *html.Node) string {
+	return getInnerTextExcluding(node, func(n *html.Node) bool {
+		return !isVisibleElement(n)
+	})
+}
+
+// ExtractAddresses
+// Returns the visible text of all <address> elements in the tree of root in document order, see ExtractFigures.
+// Line breaks of <br> elements are preserved and each line is trimmed, see TrimBlank.
+func ExtractAddresses(root *html.Node) []string {
+	var addresses []string
+	for _, address := range GetNodesByCondition(root, MakeByTagNameCondition("address")) {
+		lines := strings.Split(getVisibleInnerText(address), "\n")
+		for i, line := range lines {
+			lines[i] = TrimBlank(line)
+		}
+		addresses = append(addresses, strings.Join(lines, "\n"))
+	}
+	return addresses
+}
@@ -0,0 +1,76 @@
+package html_util
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// figureString
+// Formats a figure as its image urls and alt texts followed by its caption.
+func figureString(figure Figure) string {
+	var parts []string
+	for _, image := range figure.Images {
+		u := "<nil>"
+		if image.URL != nil {
+			u = image.URL.String()
+		}
+		parts = append(parts, u+" "+image.Alt)
+	}
+	return strings.Join(parts, ", ") + " | " + figure.Caption
+}
+
+func TestExtractFigures(t *testing.T) {
+	doc := parseDocument(t, `<head><base href="/gallery/"></head><body>`+
+		`<figure id="after"><img src="a.jpg" alt="A"><figcaption> First <b>photo</b></figcaption></figure>`+
+		`<figure id="before"><figcaption>Second</figcaption><div><img src="/b.jpg"><img src=" c.jpg " alt="C"></div></figure>`+
+		`<figure id="outer"><img src="d.jpg"><figure><img src="e.jpg"><figcaption>Inner</figcaption></figure>`+
+		`<figcaption>Outer<span hidden> secret</span></figcaption><figcaption>Ignored</figcaption></figure>`+
+		`<figure id="bare"><img alt="no src"></figure>`+
+		`</body>`)
+	base, _ := url.Parse("https://example.com/page/index.html")
+
+	figures := ExtractFigures(doc, base)
+	want := []string{
+		"https://example.com/gallery/a.jpg A | First photo",
+		"https://example.com/b.jpg , https://example.com/gallery/c.jpg C | Second",
+		"https://example.com/gallery/d.jpg  | Outer", // images and captions of the nested figure are its own
+		"https://example.com/gallery/e.jpg  | Inner",
+		"<nil> no src | ",
+	}
+	if len(figures) != len(want) {
+		t.Fatalf("got %v figures, want %v", len(figures), len(want))
+	}
+	for i, figure := range figures {
+		if got := figureString(figure); got != want[i] {
+			t.Errorf("figure %v is\n%v\nwant\n%v", i, got, want[i])
+		}
+	}
+	if figures[0].Node != elementByID(t, doc, "after") || figures[0].CaptionNode == nil || figures[4].CaptionNode != nil {
+		t.Error("figure or caption nodes are wrong")
+	}
+	if figures[0].Images[0].Node.Data != "img" {
+		t.Errorf("image node is <%v>", figures[0].Images[0].Node.Data)
+	}
+
+	// without any base, urls stay relative
+	if got := figureString(ExtractFigures(elementByID(t, doc, "after"), nil)[0]); got != "a.jpg A | First photo" {
+		t.Errorf("got %v without base", got)
+	}
+}
+
+func TestExtractAddresses(t *testing.T) {
+	doc := parseDocument(t, `<footer><address>
+		ACME Inc.<span style="display:none"> (old name)</span><br>
+		&nbsp;Main Street 1&#8203;<br/>
+		12345 <i>Springfield</i>
+	</address><p>Other</p><address><a href="mailto:info@example.com">info@example.com</a></address></footer>`)
+
+	want := []string{"ACME Inc.\nMain Street 1\n12345 Springfield", "info@example.com"}
+	if got := ExtractAddresses(doc); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := ExtractAddresses(parseDocument(t, `<p>none</p>`)); got != nil {
+		t.Errorf("got %q for a document without addresses", got)
+	}
+}
@@ -0,0 +1,134 @@
+package html_util
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+)
+
+// voidElements
+// Elements which never have content and therefore no end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// impliedEndScopes
+// Maps a tag to the open elements its start tag implicitly closes and the elements limiting the search for them.
+var impliedEndScopes = map[string]struct {
+	closes   []string
+	boundary []string
+}{
+	"td":     {[]string{"td", "th"}, []string{"tr", "table"}},
+	"th":     {[]string{"td", "th"}, []string{"tr", "table"}},
+	"tr":     {[]string{"tr"}, []string{"tbody", "thead", "tfoot", "table"}},
+	"tbody":  {[]string{"tbody", "thead", "tfoot"}, []string{"table"}},
+	"thead":  {[]string{"tbody", "thead", "tfoot"}, []string{"table"}},
+	"tfoot":  {[]string{"tbody", "thead", "tfoot"}, []string{"table"}},
+	"li":     {[]string{"li"}, []string{"ul", "ol"}},
+	"option": {[]string{"option"}, []string{"select", "datalist"}},
+	"dt":     {[]string{"dt", "dd"}, []string{"dl"}},
+	"dd":     {[]string{"dt", "dd"}, []string{"dl"}},
+	"p":      {[]string{"p"}, []string{"div", "body", "td", "th", "li"}},
+}
+
+// filteredElement
+// An open element during ParseFiltered, node is nil for elements which are not kept.
+type filteredElement struct {
+	tag  string
+	node *html.Node
+}
+
+// ParseFiltered
+// Parses the html document read from r into a sparse tree which only contains the elements for which keep yields true,
+// including their whole subtrees, e.g., to extract only the tables and meta tags of a large document with a fraction
+// of the memory html.Parse needs. Kept elements are attached to the returned document node in document order, all
+// other elements are only tracked for correct nesting.
+// Unlike html.Parse, no elements are inserted or moved, i.e., a kept <table> without <tbody> has none, and only the
+// most common implied end tags (of cells, rows, list items, options, and paragraphs) are recognized.
+func ParseFiltered(r io.Reader, keep func(tag string) bool) (*html.Node, error) {
+	if keep == nil {
+		return nil, errors.New("keep is nil")
+	}
+	doc := &html.Node{Type: html.DocumentNode}
+	var stack []filteredElement
+
+	// returns the innermost kept element or the document
+	currentParent := func() *html.Node {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].node != nil {
+				return stack[i].node
+			}
+		}
+		return doc
+	}
+	insideKept := func() bool {
+		return len(stack) > 0 && stack[len(stack)-1].node != nil
+	}
+	// pops all elements up to and including the innermost open element with one of the tags, if it exists below the
+	// innermost boundary
+	closeElement := func(tags []string, boundary []string) {
+		for i := len(stack) - 1; i >= 0; i-- {
+			for _, tag := range tags {
+				if stack[i].tag == tag {
+					stack = stack[:i]
+					return
+				}
+			}
+			for _, tag := range boundary {
+				if stack[i].tag == tag {
+					return
+				}
+			}
+		}
+	}
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			return doc, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			// the name of a tag can only be read once, so z.Token() would find neither name nor attributes afterwards
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			if scope, ok := impliedEndScopes[tag]; ok {
+				closeElement(scope.closes, scope.boundary)
+			}
+			var node *html.Node
+			if insideKept() || keep(tag) {
+				node = &html.Node{
+					Type:     html.ElementNode,
+					DataAtom: atom.Lookup(name),
+					Data:     tag,
+				}
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					node.Attr = append(node.Attr, html.Attribute{Key: string(key), Val: string(val)})
+				}
+				currentParent().AppendChild(node)
+			}
+			if tt == html.StartTagToken && !voidElements[tag] {
+				stack = append(stack, filteredElement{tag: tag, node: node})
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			closeElement([]string{string(name)}, nil)
+		case html.TextToken, html.CommentToken:
+			if !insideKept() {
+				continue
+			}
+			nodeType := html.TextNode
+			if tt == html.CommentToken {
+				nodeType = html.CommentNode
+			}
+			currentParent().AppendChild(&html.Node{Type: nodeType, Data: string(z.Text())})
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestParseFilteredKeepsAttributes(t *testing.T) {
+	doc, err := ParseFiltered(strings.NewReader(
+		`<html><head><meta name="description" content="a &amp; b"><title>t</title></head><body>`+
+			`<div class="skipped"><table id="t" class="c"><tr><td colspan="2">x</td><td data-v='1'>y</table></div>`+
+			`</body></html>`), func(tag string) bool {
+		return tag == "meta" || tag == "table"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<meta name="description" content="a &amp; b"/>` +
+		`<table id="t" class="c"><tr><td colspan="2">x</td><td data-v="1">y</td></tr></table>`
+	var sb strings.Builder
+	for c := doc.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(renderNode(t, c))
+	}
+	if got := sb.String(); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	meta := doc.FirstChild
+	if content, err := GetAttributeByKey(meta, "content"); err != nil || content.Val != "a & b" {
+		t.Errorf("content of <meta> = %v, %v, want unescaped 'a & b'", content, err)
+	}
+}
+
+func TestParseFilteredNesting(t *testing.T) {
+	doc, err := ParseFiltered(strings.NewReader(
+		`<ul><li>1<li>2</ul><p>skipped<ul><li>3<!-- c --></ul>`), func(tag string) bool {
+		return tag == "ul"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<ul><li>1</li><li>2</li></ul><ul><li>3<!-- c --></li></ul>`
+	var sb strings.Builder
+	for c := doc.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(renderNode(t, c))
+	}
+	if got := sb.String(); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// makeLargeDocument
+// Returns a document of the given number of paragraphs with attributes and text, followed by a small table.
+func makeLargeDocument(paragraphs int) string {
+	var sb strings.Builder
+	sb.WriteString(`<html><head><meta name="description" content="large"></head><body>`)
+	for i := 0; i < paragraphs; i++ {
+		sb.WriteString(`<div class="post"><p id="p" class="text">Lorem ipsum dolor sit amet, <a href="/x">consectetur</a>.</p></div>`)
+	}
+	sb.WriteString(`<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2</td></tr></table></body></html>`)
+	return sb.String()
+}
+
+func BenchmarkParseFiltered(b *testing.B) {
+	document := makeLargeDocument(10000)
+	b.Run("html.Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := html.Parse(strings.NewReader(document)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ParseFiltered", func(b *testing.B) {
+		b.ReportAllocs()
+		keep := func(tag string) bool {
+			return tag == "meta" || tag == "table"
+		}
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseFiltered(strings.NewReader(document), keep); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package html_util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"golang.org/x/net/html"
+	"sort"
+	"strings"
+)
+
+// DefaultFingerprintAttributes
+// The attributes considered by StructuralFingerprint if FingerprintOptions.Attributes is nil.
+var DefaultFingerprintAttributes = []string{"id", "class"}
+
+// FingerprintOptions
+// Options for StructuralFingerprint and ContentFingerprint.
+// Exclude contains conditions for volatile subtrees, e.g., ad slots, which are skipped entirely if any condition
+// matches their root. Attributes are the keys of the attributes that are part of the structural fingerprint,
+// DefaultFingerprintAttributes if nil. Class tokens are sorted, so their order does not change the fingerprint.
+type FingerprintOptions struct {
+	Exclude    []func(node *html.Node) bool
+	Attributes []string
+}
+
+// isExcluded
+// Returns true if any exclude condition matches node.
+func (opts FingerprintOptions) isExcluded(node *html.Node) bool {
+	for _, cond := range opts.Exclude {
+		if cond(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// attributes
+// Returns the keys of the attributes that are part of the structural fingerprint.
+func (opts FingerprintOptions) attributes() []string {
+	if opts.Attributes == nil {
+		return DefaultFingerprintAttributes
+	}
+	return opts.Attributes
+}
+
+// StructuralFingerprint
+// Returns a stable hash of the element structure below root, i.e., tags, namespaces, the attributes of
+// FingerprintOptions.Attributes and the nesting of the elements. Text content is ignored.
+// A changed structural fingerprint means that selectors probably have to be adapted.
+func StructuralFingerprint(root *html.Node, opts FingerprintOptions) string {
+	h := sha256.New()
+	if root != nil {
+		var sb strings.Builder
+		writeStructure(&sb, root, opts, opts.attributes())
+		h.Write([]byte(sb.String()))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeStructure
+// Writes the canonical structure of node and its subtree to sb.
+func writeStructure(sb *strings.Builder, node *html.Node, opts FingerprintOptions, attributes []string) {
+	if opts.isExcluded(node) {
+		return
+	}
+	if node.Type == html.ElementNode {
+		sb.WriteString("<")
+		if node.Namespace != "" {
+			sb.WriteString(node.Namespace)
+			sb.WriteString(":")
+		}
+		sb.WriteString(node.Data)
+		for _, key := range attributes {
+			attr, err := GetAttributeByKey(node, key)
+			if err != nil {
+				continue
+			}
+			val := attr.Val
+			if key == "class" {
+				tokens := strings.Fields(val)
+				sort.Strings(tokens)
+				val = strings.Join(tokens, " ")
+			}
+			sb.WriteString(" ")
+			sb.WriteString(key)
+			sb.WriteString("=")
+			sb.WriteString(html.EscapeString(val))
+		}
+		sb.WriteString(">")
+	}
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		writeStructure(sb, c, opts, attributes)
+	}
+	if node.Type == html.ElementNode {
+		sb.WriteString("</>")
+	}
+}
+
+// getVisibleWords
+// Returns the visible text below root like GetInnerText, but separated by a space at the boundaries of block elements
+// and at line breaks.
+func getVisibleWords(root *html.Node, exclude func(n *html.Node) bool) string {
+	var sb strings.Builder
+	enter := func(n *html.Node) bool {
+		if exclude(n) {
+			return false
+		}
+		switch n.Type {
+		case html.TextNode:
+			sb.WriteString(n.Data)
+			return false
+		case html.ElementNode:
+			if isNonRenderedNode(n) {
+				return false
+			}
+			if n.Data == "br" || isBlockTag(n.Data) {
+				sb.WriteString(" ")
+			}
+		}
+		return true
+	}
+	leave := func(n *html.Node) {
+		if n.Type == html.ElementNode && isBlockTag(n.Data) {
+			sb.WriteString(" ")
+		}
+	}
+	walkTree(root, enter, leave, nil)
+	return sb.String()
+}
+
+// ContentFingerprint
+// Returns a stable hash of the visible text below root, see GetInnerText. Differences in whitespace are ignored, block
+// elements and line breaks separate words, e.g., '<h1>Title</h1>\n<p>Text</p>' and '<h1>Title</h1><p>Text</p>' have the
+// same content fingerprint.
+// A changed content fingerprint with an unchanged structural fingerprint, see StructuralFingerprint, means new data
+// within a known structure.
+func ContentFingerprint(root *html.Node, opts FingerprintOptions) string {
+	h := sha256.New()
+	if root != nil {
+		h.Write([]byte(strings.Join(strings.Fields(getVisibleWords(root, opts.isExcluded)), " ")))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,58 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"testing"
+)
+
+func TestFingerprints(t *testing.T) {
+	base := `<div id="main" class="a b"><h1>Title</h1><p>Text</p><div class="ad">Ad 1</div></div>`
+	isAd := MakeByClassNameCondition("ad")
+	opts := FingerprintOptions{Exclude: []func(node *html.Node) bool{isAd}}
+
+	tests := []struct {
+		name          string
+		html          string
+		sameStructure bool
+		sameContent   bool
+	}{
+		{"identical", base, true, true},
+		{"whitespace and class order", `<div class="b  a" id="main" data-x="1">` + "\n" + `<h1> Title </h1><p>Text</p><div class="ad">Ad 1</div></div>`, true, true},
+		{"new text", `<div id="main" class="a b"><h1>Title</h1><p>New</p><div class="ad">Ad 1</div></div>`, true, false},
+		{"volatile ad", `<div id="main" class="a b"><h1>Title</h1><p>Text</p><div class="ad"><img>Ad 2</div></div>`, true, true},
+		{"new element", `<div id="main" class="a b"><h1>Title</h1><p>Text</p><p></p><div class="ad">Ad 1</div></div>`, false, true},
+		{"changed id", `<div id="content" class="a b"><h1>Title</h1><p>Text</p><div class="ad">Ad 1</div></div>`, false, true},
+		{"changed nesting", `<div id="main" class="a b"><h1>Title<p>Text</p></h1><div class="ad">Ad 1</div></div>`, false, true},
+	}
+	baseDoc := parseDocument(t, base)
+	for _, tt := range tests {
+		doc := parseDocument(t, tt.html)
+		if same := StructuralFingerprint(doc, opts) == StructuralFingerprint(baseDoc, opts); same != tt.sameStructure {
+			t.Errorf("%v: same structural fingerprint = %v, want %v", tt.name, same, tt.sameStructure)
+		}
+		if same := ContentFingerprint(doc, opts) == ContentFingerprint(baseDoc, opts); same != tt.sameContent {
+			t.Errorf("%v: same content fingerprint = %v, want %v", tt.name, same, tt.sameContent)
+		}
+	}
+
+	sameContent := func(a, b string) bool {
+		return ContentFingerprint(parseDocument(t, a), opts) == ContentFingerprint(parseDocument(t, b), opts)
+	}
+	if sameContent(`<h1>Tit</h1><p>leText</p>`, `<h1>Title</h1><p>Text</p>`) {
+		t.Error("words moved across block boundaries have the same content fingerprint")
+	}
+	if !sameContent(`<p><b>Tit</b>le<br>Text</p>`, `<p>Title Text</p>`) {
+		t.Error("inline markup changes the content fingerprint")
+	}
+
+	withData := FingerprintOptions{Attributes: []string{"data-x"}}
+	if StructuralFingerprint(parseDocument(t, `<p data-x="1">`), withData) == StructuralFingerprint(parseDocument(t, `<p data-x="2">`), withData) {
+		t.Error("configured attributes are not part of the structural fingerprint")
+	}
+	if StructuralFingerprint(baseDoc, FingerprintOptions{}) == StructuralFingerprint(baseDoc, opts) {
+		t.Error("excluded subtrees are part of the structural fingerprint")
+	}
+	if got := StructuralFingerprint(nil, opts); len(got) != 64 {
+		t.Errorf("StructuralFingerprint(nil) = %q, want a sha256 hex digest", got)
+	}
+}
@@ -0,0 +1,774 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SelectOption
+// A single <option> of a <select> element.
+type SelectOption struct {
+	Text     string // text content of the option with collapsed whitespace
+	Value    string // the 'value' attribute of the option, or Text if it has none
+	Selected bool   // whether the option is the currently selected one
+	Explicit bool   // whether the option has the 'selected' attribute
+	Disabled bool   // whether the option or its <optgroup> is disabled
+}
+
+// SelectionSource
+// Describes how the selected option of a <select> element was determined, see SelectOptions.GetSelectionSource.
+type SelectionSource int
+
+const (
+	SelectionNone     SelectionSource = iota // no option is selected
+	SelectionExplicit                        // the selected option has the 'selected' attribute
+	SelectionFallback                        // no option has the 'selected' attribute, the first enabled option is selected
+)
+
+// SelectParseOptions
+// Options for ParseSelectOptionsWithOptions.
+type SelectParseOptions struct {
+	IncludeDisabled bool                // allow disabled options to be reported as selected, i.e., display instead of submission semantics
+	ContentTextFunc func(s string) bool // option texts which do not count as content become "", the package-level ContentTextFunc if nil
+}
+
+// SelectOptions
+// The options of a <select> element in document order.
+type SelectOptions []SelectOption
+
+// ParseSelectOptions
+// Parses all options of the html node with tag 'select' in document order.
+// The selected option is the one which would be submitted, see resolveSelectedOption, i.e., disabled options are
+// never selected. Use ParseSelectOptionsWithOptions to include them.
+// Returns an empty slice if no options were found.
+func ParseSelectOptions(selectNode *html.Node) (SelectOptions, error) {
+	return ParseSelectOptionsWithOptions(selectNode, SelectParseOptions{})
+}
+
+// ParseSelectOptionsWithOptions
+// Same as ParseSelectOptions but with configurable handling of disabled options and option texts, see
+// SelectParseOptions.
+func ParseSelectOptionsWithOptions(selectNode *html.Node, opts SelectParseOptions) (SelectOptions, error) {
+	if selectNode == nil {
+		return nil, errors.New("cannot parse nil node")
+	}
+
+	isContent := contentTextFunc(opts.ContentTextFunc)
+	optionNodes := GetNodesByCondition(selectNode, MakeByTagNameCondition("option"))
+	options := make(SelectOptions, 0, len(optionNodes))
+	for _, optionNode := range optionNodes {
+		text := GetInnerText(optionNode)
+		if !isContent(text) {
+			text = ""
+		}
+		value := text
+		if attr, err := GetAttributeByKey(optionNode, "value"); err == nil {
+			value = attr.Val
+		}
+		options = append(options, SelectOption{
+			Text:     text,
+			Value:    value,
+			Explicit: hasAttribute(optionNode, "selected"),
+			Disabled: isOptionDisabled(optionNode),
+		})
+	}
+
+	if selected, _ := resolveSelectedOption(selectNode, optionNodes, opts.IncludeDisabled); selected >= 0 {
+		options[selected].Selected = true
+	}
+	return options, nil
+}
+
+// isOptionDisabled
+// Returns whether the option has the 'disabled' attribute or belongs to a disabled <optgroup>.
+func isOptionDisabled(optionNode *html.Node) bool {
+	if hasAttribute(optionNode, "disabled") {
+		return true
+	}
+	parent := optionNode.Parent
+	return parent != nil && parent.Type == html.ElementNode && parent.Data == "optgroup" && hasAttribute(parent, "disabled")
+}
+
+// resolveSelectedOption
+// Returns the position of the selected option among optionNodes, the options of selectNode, or -1 if none is selected.
+// The selected option is the last option with attribute 'selected' if it exists, otherwise the first enabled option.
+// Unless includeDisabled is true, disabled options, e.g., the classic "Please choose" placeholder, are never selected
+// since they are not submitted, and neither is any option of a disabled <select>.
+func resolveSelectedOption(selectNode *html.Node, optionNodes []*html.Node, includeDisabled bool) (int, SelectionSource) {
+	if !includeDisabled && selectNode.Type == html.ElementNode && selectNode.Data == "select" && isControlDisabled(selectNode) {
+		return -1, SelectionNone
+	}
+	explicit := -1
+	for i, optionNode := range optionNodes {
+		if hasAttribute(optionNode, "selected") {
+			explicit = i
+		}
+	}
+	if explicit >= 0 {
+		if !includeDisabled && isOptionDisabled(optionNodes[explicit]) {
+			return -1, SelectionNone
+		}
+		return explicit, SelectionExplicit
+	}
+	for i, optionNode := range optionNodes {
+		if includeDisabled || !isOptionDisabled(optionNode) {
+			return i, SelectionFallback
+		}
+	}
+	return -1, SelectionNone
+}
+
+// GetSelectionSource
+// Returns how the selected option was determined, i.e., by its 'selected' attribute or as fallback.
+func (so SelectOptions) GetSelectionSource() SelectionSource {
+	selected, ok := so.GetSelected()
+	switch {
+	case !ok:
+		return SelectionNone
+	case selected.Explicit:
+		return SelectionExplicit
+	default:
+		return SelectionFallback
+	}
+}
+
+// GetSelected
+// Returns the selected option, or false if there are no options.
+func (so SelectOptions) GetSelected() (SelectOption, bool) {
+	for _, option := range so {
+		if option.Selected {
+			return option, true
+		}
+	}
+	return SelectOption{}, false
+}
+
+// equalFoldCollapsed
+// Compares a and b case-insensitively after trimming and collapsing whitespace.
+func equalFoldCollapsed(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+// LookupValueByText
+// Returns the value of the first option whose text equals text, compared case-insensitively after collapsing whitespace.
+func (so SelectOptions) LookupValueByText(text string) (string, bool) {
+	for _, option := range so {
+		if equalFoldCollapsed(option.Text, text) {
+			return option.Value, true
+		}
+	}
+	return "", false
+}
+
+// LookupTextByValue
+// Returns the text of the first option whose value equals value, compared case-insensitively after collapsing whitespace.
+// Multiple options may legitimately share a value, in which case the first one in document order wins.
+func (so SelectOptions) LookupTextByValue(value string) (string, bool) {
+	for _, option := range so {
+		if equalFoldCollapsed(option.Value, value) {
+			return option.Text, true
+		}
+	}
+	return "", false
+}
+
+// ParseSelectHTMLNodeByValue
+// Counterpart of ParseSelectHTMLNode keyed by the options' values.
+// Returns a map containing value: text, in which value is the 'value' attribute of an option (or its text if it has
+// none) and text is its text content, as well as the value of the currently selected option (see ParseSelectOptions).
+//
+// If multiple options have the same value, the first one in document order is kept.
+// Returns nil map and nil error if no options were found.
+func ParseSelectHTMLNodeByValue(selectNode *html.Node) (map[string]string, string, error) {
+	options, err := ParseSelectOptions(selectNode)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(options) == 0 {
+		return nil, "", nil
+	}
+
+	byValue := make(map[string]string, len(options))
+	for _, option := range options {
+		if _, ok := byValue[option.Value]; !ok {
+			byValue[option.Value] = option.Text
+		}
+	}
+
+	selected, _ := options.GetSelected()
+	return byValue, selected.Value, nil
+}
+
+// FieldConstraints
+// The html validation attributes of a form field.
+type FieldConstraints struct {
+	Required     bool           // the 'required' attribute is present
+	Pattern      *regexp.Regexp // compiled 'pattern' attribute anchored to the whole value, nil if missing or invalid
+	PatternError error          // error compiling the 'pattern' attribute, e.g., for unsupported regular expression syntax
+	Min          string         // the 'min' attribute, "" if missing
+	Max          string         // the 'max' attribute, "" if missing
+	Step         string         // the 'step' attribute, "" if missing
+	MinLength    int            // the 'minlength' attribute, -1 if missing or invalid
+	MaxLength    int            // the 'maxlength' attribute, -1 if missing or invalid
+}
+
+// Field
+// A named control of a form which contributes to its submission.
+type Field struct {
+	Node        *html.Node       // the input, select, or textarea element
+	Name        string           // the 'name' attribute
+	Type        string           // the lower case input type (default "text"), or "select" or "textarea"
+	Value       string           // the current value, for selects the value of the selected option
+	Checked     bool             // whether a checkbox or radio button is checked
+	Disabled    bool             // whether the control is disabled, also if it is within a disabled fieldset
+	Label       string           // the label text, see GetLabelForControl
+	Constraints FieldConstraints // the validation constraints
+	Fieldsets   []string         // legend texts of the enclosing fieldsets with legend, outermost first
+}
+
+// Form
+// A parsed <form> element.
+type Form struct {
+	Node           *html.Node      // the form element
+	Action         string          // the 'action' attribute, "" if missing
+	Method         string          // the upper case 'method' attribute, "GET" if missing
+	Fields         []Field         // all fields in document order
+	SubmitControls []SubmitControl // all buttons in document order, see GetSubmitControls
+}
+
+// FieldError
+// A violated validation constraint of a form field, see Form.Validate.
+type FieldError struct {
+	Field      string // name of the field
+	Constraint string // the violated constraint, e.g., "required", "pattern", or "max"
+	Value      string // the offending value
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("field '%v' violates constraint '%v' with value '%v'", e.Field, e.Constraint, e.Value)
+}
+
+// isSubmitButtonType
+// Returns true for input types which represent buttons rather than fields.
+func isSubmitButtonType(inputType string) bool {
+	return inputType == "submit" || inputType == "image" || inputType == "button" || inputType == "reset"
+}
+
+// ParseForm
+// Parses the html node with tag 'form' into its named fields, i.e., all input (excluding buttons), select, and textarea
+// elements with a 'name' attribute within the form.
+// Labels are resolved within the whole document containing formNode.
+// Invalid 'pattern' attributes do not fail parsing but are reported via FieldConstraints.PatternError.
+func ParseForm(formNode *html.Node) (*Form, error) {
+	if formNode == nil {
+		return nil, errors.New("cannot parse nil node")
+	}
+	if !(formNode.Type == html.ElementNode && formNode.Data == "form") {
+		return nil, errors.New("node is not a form node")
+	}
+
+	form := &Form{
+		Node:   formNode,
+		Method: "GET",
+	}
+	if attr, err := GetAttributeByKey(formNode, "action"); err == nil {
+		form.Action = attr.Val
+	}
+	if attr, err := GetAttributeByKey(formNode, "method"); err == nil && strings.TrimSpace(attr.Val) != "" {
+		form.Method = strings.ToUpper(strings.TrimSpace(attr.Val))
+	}
+
+	idx := NewDocumentIndex(TreeRoot(formNode))
+	controls := GetNextNodesByCondition(formNode, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && (node.Data == "input" || node.Data == "select" || node.Data == "textarea")
+	})
+	for _, control := range controls {
+		name, err := GetAttributeByKey(control, "name")
+		if err != nil || name.Val == "" {
+			continue
+		}
+
+		field := Field{
+			Node:        control,
+			Name:        name.Val,
+			Type:        control.Data,
+			Constraints: parseFieldConstraints(control),
+		}
+		field.Label, _ = idx.GetLabelForControl(control)
+		field.Disabled = isControlDisabled(control)
+		field.Fieldsets = getFieldsetLegends(control, formNode)
+
+		switch control.Data {
+		case "input":
+			field.Type = "text"
+			if attr, err := GetAttributeByKey(control, "type"); err == nil && strings.TrimSpace(attr.Val) != "" {
+				field.Type = strings.ToLower(strings.TrimSpace(attr.Val))
+			}
+			if isSubmitButtonType(field.Type) {
+				continue
+			}
+			if attr, err := GetAttributeByKey(control, "value"); err == nil {
+				field.Value = attr.Val
+			} else if field.Type == "checkbox" || field.Type == "radio" {
+				field.Value = "on"
+			}
+			_, err := GetAttributeByKey(control, "checked")
+			field.Checked = err == nil
+		case "select":
+			options, err := ParseSelectOptions(control)
+			if err != nil {
+				return nil, err
+			}
+			if selected, ok := options.GetSelected(); ok {
+				field.Value = selected.Value
+			}
+		case "textarea":
+			var sb strings.Builder
+			for _, t := range GetTextNodes(control) {
+				sb.WriteString(t.Data)
+			}
+			field.Value = sb.String()
+		}
+
+		form.Fields = append(form.Fields, field)
+	}
+	form.SubmitControls = GetSubmitControls(formNode)
+
+	return form, nil
+}
+
+// parseFieldConstraints
+// Collects the validation attributes of control.
+func parseFieldConstraints(control *html.Node) FieldConstraints {
+	constraints := FieldConstraints{
+		MinLength: -1,
+		MaxLength: -1,
+	}
+	if _, err := GetAttributeByKey(control, "required"); err == nil {
+		constraints.Required = true
+	}
+	if attr, err := GetAttributeByKey(control, "pattern"); err == nil {
+		// the pattern has to match the whole value
+		constraints.Pattern, constraints.PatternError = regexp.Compile("^(?:" + attr.Val + ")$")
+	}
+	if attr, err := GetAttributeByKey(control, "min"); err == nil {
+		constraints.Min = strings.TrimSpace(attr.Val)
+	}
+	if attr, err := GetAttributeByKey(control, "max"); err == nil {
+		constraints.Max = strings.TrimSpace(attr.Val)
+	}
+	if attr, err := GetAttributeByKey(control, "step"); err == nil {
+		constraints.Step = strings.ToLower(strings.TrimSpace(attr.Val))
+	}
+	if attr, err := GetAttributeByKey(control, "minlength"); err == nil {
+		if n, err := strconv.Atoi(strings.TrimSpace(attr.Val)); err == nil && n >= 0 {
+			constraints.MinLength = n
+		}
+	}
+	if attr, err := GetAttributeByKey(control, "maxlength"); err == nil {
+		if n, err := strconv.Atoi(strings.TrimSpace(attr.Val)); err == nil && n >= 0 {
+			constraints.MaxLength = n
+		}
+	}
+	return constraints
+}
+
+// dateInputLayouts
+// Layouts of the values of date and time input types. Values of the same type can be compared lexicographically.
+var dateInputLayouts = map[string]string{
+	"date":           "2006-01-02",
+	"month":          "2006-01",
+	"time":           "15:04",
+	"datetime-local": "2006-01-02T15:04",
+}
+
+// Validate
+// Checks the candidate submission values against the validation constraints of the form's enabled fields, i.e.,
+// required, pattern, minlength, maxlength, min, max, step, and the value formats of number, range, email, url,
+// and date and time inputs.
+// Fields sharing a name, e.g., radio buttons, are validated once as group: the group is required if one of them is, and
+// the k-th value of the name is checked against the k-th field, further values, e.g., of a <select multiple>, against
+// the last one.
+// Returns all violations in the order of the first field of each name, or nil if there are none.
+func (f *Form) Validate(values url.Values) []FieldError {
+	var fieldErrors []FieldError
+
+	var names []string
+	fieldsByName := make(map[string][]Field)
+	for _, field := range f.Fields {
+		if field.Disabled {
+			continue
+		}
+		if _, ok := fieldsByName[field.Name]; !ok {
+			names = append(names, field.Name)
+		}
+		fieldsByName[field.Name] = append(fieldsByName[field.Name], field)
+	}
+
+	for _, name := range names {
+		fields := fieldsByName[name]
+		fieldValues := values[name]
+
+		required := false
+		for _, field := range fields {
+			required = required || field.Constraints.Required
+		}
+		if required {
+			hasValue := false
+			for _, v := range fieldValues {
+				if v != "" {
+					hasValue = true
+				}
+			}
+			if !hasValue {
+				fieldErrors = append(fieldErrors, FieldError{Field: name, Constraint: "required"})
+			}
+		}
+
+		for k, v := range fieldValues {
+			field := fields[minInt(k, len(fields)-1)]
+			if v == "" || field.Type == "checkbox" || field.Type == "radio" {
+				continue
+			}
+			for _, constraint := range validateFieldValue(field.Type, field.Constraints, v) {
+				fieldErrors = append(fieldErrors, FieldError{Field: name, Constraint: constraint, Value: v})
+			}
+		}
+	}
+
+	return fieldErrors
+}
+
+// validateFieldValue
+// Returns the names of all constraints the non-empty value v violates for a field of the given type.
+func validateFieldValue(fieldType string, c FieldConstraints, v string) []string {
+	var violated []string
+
+	length := utf8.RuneCountInString(v)
+	if c.MinLength >= 0 && length < c.MinLength {
+		violated = append(violated, "minlength")
+	}
+	if c.MaxLength >= 0 && length > c.MaxLength {
+		violated = append(violated, "maxlength")
+	}
+	if c.Pattern != nil && !c.Pattern.MatchString(v) {
+		violated = append(violated, "pattern")
+	}
+
+	switch fieldType {
+	case "number", "range":
+		number, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return append(violated, "type")
+		}
+		minValue, minErr := strconv.ParseFloat(c.Min, 64)
+		if minErr == nil && number < minValue {
+			violated = append(violated, "min")
+		}
+		if maxValue, err := strconv.ParseFloat(c.Max, 64); err == nil && number > maxValue {
+			violated = append(violated, "max")
+		}
+		if step, err := strconv.ParseFloat(c.Step, 64); err == nil && step > 0 {
+			base := 0.0
+			if minErr == nil {
+				base = minValue
+			}
+			steps := (number - base) / step
+			if math.Abs(steps-math.Round(steps)) > 1e-9 {
+				violated = append(violated, "step")
+			}
+		}
+	case "email":
+		for _, address := range strings.Split(v, ",") {
+			if _, err := mail.ParseAddress(strings.TrimSpace(address)); err != nil {
+				return append(violated, "type")
+			}
+		}
+	case "url":
+		if u, err := url.Parse(v); err != nil || !u.IsAbs() {
+			violated = append(violated, "type")
+		}
+	default:
+		layout, ok := dateInputLayouts[fieldType]
+		if !ok {
+			break
+		}
+		if _, err := time.Parse(layout, v); err != nil {
+			if _, err := time.Parse(layout+":05", v); err != nil {
+				return append(violated, "type")
+			}
+		}
+		if c.Min != "" && v < c.Min {
+			violated = append(violated, "min")
+		}
+		if c.Max != "" && v > c.Max {
+			violated = append(violated, "max")
+		}
+	}
+
+	return violated
+}
+
+// SubmitControl
+// A button of a form, i.e., an input element of type submit, image, button, or reset, or a <button> element.
+type SubmitControl struct {
+	Node       *html.Node // the input or button element
+	Type       string     // the lower case type: "submit", "image", "button", or "reset", <button> defaults to "submit"
+	Name       string     // the 'name' attribute
+	Value      string     // the 'value' attribute
+	Label      string     // visible label: the value for inputs, the alt text for image inputs, the text for <button>
+	FormAction string     // the 'formaction' attribute overriding Form.Action, "" if missing
+	FormMethod string     // the upper case 'formmethod' attribute overriding Form.Method, "" if missing
+	Disabled   bool       // whether the control is disabled, also if it is within a disabled fieldset
+}
+
+// Submits
+// Returns whether activating the control submits the form, i.e., for types submit and image.
+func (sc SubmitControl) Submits() bool {
+	return sc.Type == "submit" || sc.Type == "image"
+}
+
+// GetSubmitControls
+// Returns all buttons within the tree of formNode in document order, see SubmitControl.
+func GetSubmitControls(formNode *html.Node) []SubmitControl {
+	var controls []SubmitControl
+
+	buttons := GetNextNodesByCondition(formNode, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && (node.Data == "input" || node.Data == "button")
+	})
+	for _, button := range buttons {
+		control := SubmitControl{
+			Node: button,
+			Type: "submit",
+		}
+		if attr, err := GetAttributeByKey(button, "type"); err == nil && strings.TrimSpace(attr.Val) != "" {
+			control.Type = strings.ToLower(strings.TrimSpace(attr.Val))
+		} else if button.Data == "input" {
+			control.Type = "text"
+		}
+		if !isSubmitButtonType(control.Type) {
+			continue
+		}
+
+		if attr, err := GetAttributeByKey(button, "name"); err == nil {
+			control.Name = attr.Val
+		}
+		if attr, err := GetAttributeByKey(button, "value"); err == nil {
+			control.Value = attr.Val
+		}
+		if attr, err := GetAttributeByKey(button, "formaction"); err == nil {
+			control.FormAction = attr.Val
+		}
+		if attr, err := GetAttributeByKey(button, "formmethod"); err == nil {
+			control.FormMethod = strings.ToUpper(strings.TrimSpace(attr.Val))
+		}
+		control.Disabled = isControlDisabled(button)
+
+		switch {
+		case button.Data == "button":
+			control.Label = GetInnerText(button)
+		case control.Type == "image":
+			if attr, err := GetAttributeByKey(button, "alt"); err == nil {
+				control.Label = strings.TrimSpace(attr.Val)
+			}
+		default:
+			control.Label = strings.TrimSpace(control.Value)
+		}
+
+		controls = append(controls, control)
+	}
+
+	return controls
+}
+
+// EncodeValues
+// Returns the values the form submits if submitter is activated, i.e., the values of all enabled fields (checkboxes and
+// radio buttons only if checked) plus the name and value of submitter.
+// An image submitter contributes the click coordinates 'name.x' and 'name.y' as 0, see EncodeValuesWithClick.
+// submitter may be nil, e.g., for forms submitted implicitly without a button.
+func (f *Form) EncodeValues(submitter *SubmitControl) url.Values {
+	return f.EncodeValuesWithClick(submitter, 0, 0)
+}
+
+// EncodeValuesWithClick
+// Same as EncodeValues but an image submitter contributes the click coordinates x and y.
+func (f *Form) EncodeValuesWithClick(submitter *SubmitControl, x, y int) url.Values {
+	values := make(url.Values)
+	for _, field := range f.Fields {
+		if field.Disabled {
+			continue
+		}
+		if (field.Type == "checkbox" || field.Type == "radio") && !field.Checked {
+			continue
+		}
+		values.Add(field.Name, field.Value)
+	}
+
+	if submitter != nil && !submitter.Disabled && submitter.Submits() {
+		if submitter.Type == "image" {
+			prefix := ""
+			if submitter.Name != "" {
+				prefix = submitter.Name + "."
+			}
+			values.Add(prefix+"x", strconv.Itoa(x))
+			values.Add(prefix+"y", strconv.Itoa(y))
+		} else if submitter.Name != "" {
+			values.Add(submitter.Name, submitter.Value)
+		}
+	}
+
+	return values
+}
+
+// getFieldsetLegend
+// Returns the first <legend> child of fieldset, or nil.
+func getFieldsetLegend(fieldset *html.Node) *html.Node {
+	for _, c := range GetChildren(fieldset) {
+		if c.Type == html.ElementNode && c.Data == "legend" {
+			return c
+		}
+	}
+	return nil
+}
+
+// isControlDisabled
+// Returns whether control has the 'disabled' attribute or is a descendant of a disabled <fieldset> without being a
+// descendant of that fieldset's first <legend>.
+func isControlDisabled(control *html.Node) bool {
+	if _, err := GetAttributeByKey(control, "disabled"); err == nil {
+		return true
+	}
+
+	var child *html.Node
+	for p := control; p != nil; child, p = p, p.Parent {
+		if p == control || p.Type != html.ElementNode || p.Data != "fieldset" {
+			continue
+		}
+		if _, err := GetAttributeByKey(p, "disabled"); err != nil {
+			continue
+		}
+		if legend := getFieldsetLegend(p); legend == nil || legend != child {
+			return true
+		}
+	}
+	return false
+}
+
+// getFieldsetLegends
+// Returns the legend texts of all <fieldset> elements enclosing control up to (excluding) stop, outermost first.
+// Fieldsets without legend are skipped.
+func getFieldsetLegends(control *html.Node, stop *html.Node) []string {
+	var legends []string
+	for p := control.Parent; p != nil && p != stop; p = p.Parent {
+		if p.Type != html.ElementNode || p.Data != "fieldset" {
+			continue
+		}
+		if legend := getFieldsetLegend(p); legend != nil {
+			legends = append([]string{GetInnerText(legend)}, legends...)
+		}
+	}
+	return legends
+}
+
+// fieldsetKeySeparator
+// Separates the legend texts of nested fieldsets in the keys of Form.FieldsByFieldset.
+const fieldsetKeySeparator = " > "
+
+// FieldsByFieldset
+// Groups the fields by their enclosing fieldsets. The key of a group is the chain of legend texts, outermost first,
+// joined by " > ", e.g., "Shipping > Address". Fields outside any fieldset are grouped under "".
+// See FieldsetKeys for the keys in order of their first occurrence.
+func (f *Form) FieldsByFieldset() map[string][]Field {
+	groups := make(map[string][]Field)
+	for _, field := range f.Fields {
+		key := strings.Join(field.Fieldsets, fieldsetKeySeparator)
+		groups[key] = append(groups[key], field)
+	}
+	return groups
+}
+
+// FieldsetKeys
+// Returns the keys of FieldsByFieldset in the document order of their first field.
+func (f *Form) FieldsetKeys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, field := range f.Fields {
+		key := strings.Join(field.Fieldsets, fieldsetKeySeparator)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// hasRole
+// Returns whether the 'role' attribute of node contains role.
+func hasRole(node *html.Node, role string) bool {
+	attr, err := GetAttributeByKey(node, "role")
+	if err != nil {
+		return false
+	}
+	for _, r := range strings.Fields(attr.Val) {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseListboxNode
+// Parses a custom dropdown rendered as ARIA listbox, e.g., <ul role="listbox"> with <li role="option"> children, into
+// its options in document order.
+// The value of an option is its 'data-value' attribute, else its 'id' attribute, else its text.
+// An option is selected if its 'aria-selected' attribute is "true", the last one wins if there are multiple.
+// Returns the options and the value of the selected option, or "" if no option is selected.
+func ParseListboxNode(node *html.Node) (SelectOptions, string, error) {
+	if node == nil {
+		return nil, "", errors.New("cannot parse nil node")
+	}
+
+	optionNodes := GetNextNodesByCondition(node, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && hasRole(n, "option")
+	})
+	options := make(SelectOptions, 0, len(optionNodes))
+	selected := -1
+	for i, optionNode := range optionNodes {
+		option := SelectOption{Text: GetInnerText(optionNode)}
+		if attr, err := GetAttributeByKey(optionNode, "data-value"); err == nil {
+			option.Value = attr.Val
+		} else if attr, err := GetAttributeByKey(optionNode, "id"); err == nil {
+			option.Value = attr.Val
+		} else {
+			option.Value = option.Text
+		}
+		if attr, err := GetAttributeByKey(optionNode, "aria-selected"); err == nil && strings.EqualFold(strings.TrimSpace(attr.Val), "true") {
+			selected = i
+		}
+		options = append(options, option)
+	}
+
+	if selected == -1 {
+		return options, "", nil
+	}
+	options[selected].Selected = true
+	return options, options[selected].Value, nil
+}
+
+// FindSelectLikeControls
+// Returns all native <select> elements and all elements with role="listbox" in the tree of root (including root) in
+// document order. Use ParseSelectOptions or ParseListboxNode respectively to parse them.
+func FindSelectLikeControls(root *html.Node) []*html.Node {
+	return GetNodesByCondition(root, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && (node.Data == "select" || hasRole(node, "listbox"))
+	})
+}
@@ -0,0 +1,388 @@
+package html_util
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// formErrors
+// Formats fieldErrors as "field:constraint:value" joined by spaces.
+func formErrors(fieldErrors []FieldError) string {
+	var parts []string
+	for _, e := range fieldErrors {
+		parts = append(parts, e.Field+":"+e.Constraint+":"+e.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseFirstForm
+// Parses the first form of the document s and fails the test on error.
+func parseFirstForm(tb testing.TB, s string) *Form {
+	tb.Helper()
+	forms := GetElementNodesByTagName("form", parseDocument(tb, s))
+	if len(forms) == 0 {
+		tb.Fatal("document has no form")
+	}
+	form, err := ParseForm(forms[0])
+	if err != nil {
+		tb.Fatalf("cannot parse form: %v", err)
+	}
+	return form
+}
+
+func TestParseFormConstraints(t *testing.T) {
+	form := parseFirstForm(t, `<form>`+
+		`<input name="code" required pattern="[A-Z]{3}" minlength=" 3 " maxlength="x">`+
+		`<input name="bad" pattern="(?=a)">`+
+		`<input type="NUMBER" name="n" min="1" max=" 10 " step="ANY">`+
+		`</form>`)
+	if len(form.Fields) != 3 {
+		t.Fatalf("got %v fields, want 3", len(form.Fields))
+	}
+
+	code := form.Fields[0].Constraints
+	if !code.Required || code.Pattern == nil || code.PatternError != nil || code.MinLength != 3 || code.MaxLength != -1 {
+		t.Errorf("code constraints = %+v", code)
+	}
+	if code.Pattern.MatchString("ABCD") {
+		t.Error("the pattern has to match the whole value")
+	}
+	if bad := form.Fields[1].Constraints; bad.Pattern != nil || bad.PatternError == nil {
+		t.Errorf("invalid pattern: got %v, %v, want nil and an error", bad.Pattern, bad.PatternError)
+	}
+	n := form.Fields[2]
+	if n.Type != "number" || n.Constraints.Min != "1" || n.Constraints.Max != "10" || n.Constraints.Step != "any" ||
+		n.Constraints.Required || n.Constraints.MinLength != -1 {
+		t.Errorf("number field = %v, %+v", n.Type, n.Constraints)
+	}
+}
+
+func TestFormValidateConstraints(t *testing.T) {
+	form := parseFirstForm(t, `<form>`+
+		`<input name="code" pattern="[A-Z]{3}">`+
+		`<input type="number" name="n" min="1" max="10" step="0.5">`+
+		`<input type="range" name="r" step="any">`+
+		`<input type="email" name="mail" multiple>`+
+		`<input type="url" name="u">`+
+		`<input type="date" name="d" min="2024-01-01" max="2024-12-31">`+
+		`<input type="time" name="t">`+
+		`</form>`)
+
+	tests := []struct {
+		values url.Values
+		want   string
+	}{
+		{url.Values{"code": {"ABC"}, "n": {"1.5"}, "r": {"0.123"}, "mail": {"a@b.c, d@e.f"}, "u": {"https://x.y/z"},
+			"d": {"2024-02-29"}, "t": {"12:30:15"}}, ""},
+		{url.Values{"code": {"abc"}}, "code:pattern:abc"},
+		{url.Values{"n": {"0.5"}}, "n:min:0.5"},
+		{url.Values{"n": {"11"}}, "n:max:11"},
+		{url.Values{"n": {"1.25"}}, "n:step:1.25"},
+		{url.Values{"n": {"ten"}}, "n:type:ten"},
+		{url.Values{"mail": {"a@b.c, nope"}}, "mail:type:a@b.c, nope"},
+		{url.Values{"u": {"/relative"}}, "u:type:/relative"},
+		{url.Values{"d": {"2023-12-31"}, "t": {"25:00"}}, "d:min:2023-12-31 t:type:25:00"},
+		{url.Values{"d": {"31.12.2024"}}, "d:type:31.12.2024"},
+		{url.Values{"code": {""}, "n": {""}}, ""}, // empty values are only checked by required
+	}
+	for _, tt := range tests {
+		if got := formErrors(form.Validate(tt.values)); got != tt.want {
+			t.Errorf("Validate(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestFormValidateGroupsFieldsSharingAName(t *testing.T) {
+	doc := parseDocument(t, `<form>`+
+		`<input type="radio" name="r" value="a" required><input type="radio" name="r" value="b" required>`+
+		`<input type="checkbox" name="c" value="x"><input type="checkbox" name="c" value="y" required>`+
+		`<input name="t" minlength="3"><input name="t" maxlength="1">`+
+		`<select name="s" required><option value="">-</option><option>1</option></select>`+
+		`<input name="d" required disabled>`+
+		`</form>`)
+	form, err := ParseForm(GetElementNodesByTagName("form", doc)[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		values url.Values
+		want   string
+	}{
+		{url.Values{}, "r:required: c:required: s:required:"},
+		{url.Values{"r": {"a"}, "c": {"x"}, "s": {"1"}}, ""},
+		// each value is checked against its own field, once
+		{url.Values{"r": {"b"}, "c": {"y"}, "s": {"1"}, "t": {"a"}}, "t:minlength:a"},
+		{url.Values{"r": {"b"}, "c": {"x", "y"}, "s": {"1"}, "t": {"abc", "de"}}, "t:maxlength:de"},
+		{url.Values{"r": {"b"}, "c": {"y"}, "s": {"1"}, "t": {"", "de", "fg"}}, "t:maxlength:de t:maxlength:fg"},
+	}
+	for _, tt := range tests {
+		if got := formErrors(form.Validate(tt.values)); got != tt.want {
+			t.Errorf("Validate(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestParseSelectHTMLNodeByValue(t *testing.T) {
+	doc := parseDocument(t, `<select>`+
+		`<option value="de">Germany</option>`+
+		`<option value="fr" selected>  France </option>`+
+		`<option value="de">Deutschland</option>`+
+		`<option>Other</option>`+
+		`</select><select></select>`)
+	selects := GetElementNodesByTagName("select", doc)
+
+	byValue, selected, err := ParseSelectHTMLNodeByValue(selects[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"de": "Germany", "fr": "France", "Other": "Other"} // the first of duplicate values wins
+	if len(byValue) != len(want) {
+		t.Errorf("got %v, want %v", byValue, want)
+	}
+	for value, text := range want {
+		if byValue[value] != text {
+			t.Errorf("byValue[%q] = %q, want %q", value, byValue[value], text)
+		}
+	}
+	if selected != "fr" {
+		t.Errorf("selected = %q, want fr", selected)
+	}
+
+	if byValue, selected, err := ParseSelectHTMLNodeByValue(selects[1]); byValue != nil || selected != "" || err != nil {
+		t.Errorf("empty select: got %v, %q, %v, want nil, \"\", nil", byValue, selected, err)
+	}
+	if _, _, err := ParseSelectHTMLNodeByValue(nil); err == nil {
+		t.Error("expected an error for a nil node")
+	}
+}
+
+func TestSelectOptionsLookup(t *testing.T) {
+	options, err := ParseSelectOptions(GetElementNodesByTagName("select", parseDocument(t, `<select>`+
+		`<option value="DE">Germany</option>`+
+		`<option value="de">Deutschland</option>`+
+		`<option value="us">United   States</option>`+
+		`</select>`))[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value, ok := options.LookupValueByText(" united states "); !ok || value != "us" {
+		t.Errorf("LookupValueByText = %q, %v, want us, true", value, ok)
+	}
+	if text, ok := options.LookupTextByValue("de"); !ok || text != "Germany" {
+		t.Errorf("LookupTextByValue = %q, %v, want the first matching option Germany, true", text, ok)
+	}
+	if _, ok := options.LookupValueByText("France"); ok {
+		t.Error("LookupValueByText found an unknown text")
+	}
+	if _, ok := options.LookupTextByValue("fr"); ok {
+		t.Error("LookupTextByValue found an unknown value")
+	}
+}
+
+func TestParseSelectOptionsDisabled(t *testing.T) {
+	tests := []struct {
+		name           string
+		html           string
+		wantSelected   string // value of the selected option, "" if none
+		wantSource     SelectionSource
+		wantDisplay    string // value of the selected option with IncludeDisabled
+		wantDisplaySrc SelectionSource
+		wantSelectHTML string // selected text reported by ParseSelectHTMLNode
+	}{
+		{"placeholder", `<select><option value="" disabled selected hidden>Please choose</option>` +
+			`<option value="a">A</option><option value="b">B</option></select>`,
+			"", SelectionNone, "", SelectionExplicit, ""},
+		{"disabled first option", `<select><option value="x" disabled>X</option><option value="a">A</option></select>`,
+			"a", SelectionFallback, "x", SelectionFallback, "A"},
+		{"explicit", `<select><option value="a">A</option><option value="b" selected>B</option></select>`,
+			"b", SelectionExplicit, "b", SelectionExplicit, "B"},
+		{"disabled optgroup", `<select><optgroup label="g" disabled><option value="a">A</option></optgroup>` +
+			`<option value="b">B</option></select>`,
+			"b", SelectionFallback, "a", SelectionFallback, "B"},
+		{"all disabled", `<select><option value="a" disabled>A</option><option value="b" disabled>B</option></select>`,
+			"", SelectionNone, "a", SelectionFallback, ""},
+		{"disabled select", `<select disabled><option value="a" selected>A</option></select>`,
+			"", SelectionNone, "a", SelectionExplicit, ""},
+		{"disabled fieldset", `<fieldset disabled><select><option value="a">A</option></select></fieldset>`,
+			"", SelectionNone, "a", SelectionFallback, ""},
+		{"no options", `<select></select>`, "", SelectionNone, "", SelectionNone, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selectNode := GetElementNodesByTagName("select", parseDocument(t, tt.html))[0]
+			selectedValue := func(options SelectOptions) string {
+				selected, _ := options.GetSelected()
+				return selected.Value
+			}
+
+			options, err := ParseSelectOptions(selectNode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, source := selectedValue(options), options.GetSelectionSource(); got != tt.wantSelected || source != tt.wantSource {
+				t.Errorf("selected %q from source %v, want %q from %v", got, source, tt.wantSelected, tt.wantSource)
+			}
+
+			display, err := ParseSelectOptionsWithOptions(selectNode, SelectParseOptions{IncludeDisabled: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, source := selectedValue(display), display.GetSelectionSource(); got != tt.wantDisplay || source != tt.wantDisplaySrc {
+				t.Errorf("IncludeDisabled selected %q from source %v, want %q from %v", got, source, tt.wantDisplay,
+					tt.wantDisplaySrc)
+			}
+
+			if _, selected, err := ParseSelectHTMLNode(selectNode); err != nil || selected != tt.wantSelectHTML {
+				t.Errorf("ParseSelectHTMLNode selected %q with error %v, want %q", selected, err, tt.wantSelectHTML)
+			}
+		})
+	}
+}
+
+func TestGetSubmitControlsAndEncodeValues(t *testing.T) {
+	doc := parseDocument(t, `<form action="/save" method="post">`+
+		`<input name="q" value="go">`+
+		`<input type="submit" name="op" value=" Save ">`+
+		`<button name="op" value="delete" formaction="/delete" formmethod="get">Delete <i>all</i></button>`+
+		`<input type="IMAGE" name="map" alt=" Map " src="m.png">`+
+		`<button type="reset">Reset</button>`+
+		`<input type="button" value="Help" disabled>`+
+		`<input type="checkbox" name="c">`+
+		`</form>`)
+	formNode := GetElementNodesByTagName("form", doc)[0]
+	controls := GetSubmitControls(formNode)
+
+	var got []string
+	for _, c := range controls {
+		got = append(got, strings.Join([]string{c.Type, c.Name, c.Value, c.Label, c.FormAction, c.FormMethod,
+			strconv.FormatBool(c.Disabled), strconv.FormatBool(c.Submits())}, ","))
+	}
+	want := []string{
+		"submit,op, Save ,Save,,,false,true",
+		"submit,op,delete,Delete all,/delete,GET,false,true",
+		"image,map,,Map,,,false,true",
+		"reset,,,Reset,,,false,false",
+		"button,,Help,Help,,,true,false",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got controls\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	form, err := ParseForm(formNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		submitter *SubmitControl
+		want      string
+	}{
+		{nil, "q=go"},
+		{&controls[1], "op=delete&q=go"},
+		{&controls[3], "q=go"}, // reset buttons do not submit
+		{&controls[4], "q=go"},
+	}
+	for _, tt := range tests {
+		if got := form.EncodeValues(tt.submitter).Encode(); got != tt.want {
+			t.Errorf("EncodeValues(%+v) = %q, want %q", tt.submitter, got, tt.want)
+		}
+	}
+	if got := form.EncodeValuesWithClick(&controls[2], 3, 7).Encode(); got != "map.x=3&map.y=7&q=go" {
+		t.Errorf("EncodeValuesWithClick = %q, want the click coordinates", got)
+	}
+	unnamed := controls[2]
+	unnamed.Name = ""
+	if got := form.EncodeValues(&unnamed).Encode(); got != "q=go&x=0&y=0" {
+		t.Errorf("EncodeValues of an unnamed image = %q, want plain x and y", got)
+	}
+}
+
+func TestFormFieldsets(t *testing.T) {
+	form := parseFirstForm(t, `<form>`+
+		`<input name="top" value="1">`+
+		`<fieldset><legend>Shipping <b>details</b></legend>`+
+		`<input name="name" value="n">`+
+		`<fieldset disabled><legend>Address <input name="inlegend" value="l"></legend>`+
+		`<input name="street" value="s">`+
+		`</fieldset>`+
+		`<fieldset><input name="nolegend" value="x"></fieldset>`+
+		`</fieldset>`+
+		`<input name="bottom" value="2">`+
+		`</form>`)
+
+	var got []string
+	for _, field := range form.Fields {
+		got = append(got, field.Name+":"+strings.Join(field.Fieldsets, "/")+":"+strconv.FormatBool(field.Disabled))
+	}
+	want := "top::false name:Shipping details:false inlegend:Shipping details/Address:false " +
+		"street:Shipping details/Address:true nolegend:Shipping details:false bottom::false"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got fields %q, want %q", strings.Join(got, " "), want)
+	}
+
+	keys := form.FieldsetKeys()
+	if strings.Join(keys, "|") != "|Shipping details|Shipping details > Address" {
+		t.Errorf("FieldsetKeys() = %q", keys)
+	}
+	groups := form.FieldsByFieldset()
+	for key, names := range map[string]string{
+		"":                           "top bottom",
+		"Shipping details":           "name nolegend",
+		"Shipping details > Address": "inlegend street",
+	} {
+		var got []string
+		for _, field := range groups[key] {
+			got = append(got, field.Name)
+		}
+		if strings.Join(got, " ") != names {
+			t.Errorf("FieldsByFieldset()[%q] = %v, want %v", key, got, names)
+		}
+	}
+
+	if got := form.EncodeValues(nil).Encode(); got != "bottom=2&inlegend=l&name=n&nolegend=x&top=1" {
+		t.Errorf("EncodeValues = %q, want the fields of the disabled fieldset omitted", got)
+	}
+}
+
+func TestParseListboxNode(t *testing.T) {
+	doc := parseDocument(t, `<select id="native"><option>a</option></select>`+
+		`<ul id="box" role="listbox">`+
+		`<li role="option" data-value="de" id="opt-de">Germany</li>`+
+		`<li role="option" id="opt-fr" aria-selected="TRUE">France</li>`+
+		`<li role="presentation">separator</li>`+
+		`<li role="option" aria-selected="false"> Other  <b>country</b></li>`+
+		`</ul>`+
+		`<div id="combo" role="combobox listbox"><span role="option">x</span></div>`)
+
+	controls := FindSelectLikeControls(doc)
+	var ids []string
+	for _, c := range controls {
+		ids = append(ids, nodeID(c))
+	}
+	if strings.Join(ids, " ") != "native box combo" {
+		t.Fatalf("FindSelectLikeControls() = %v, want native box combo", ids)
+	}
+
+	options, selected, err := ParseListboxNode(controls[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, o := range options {
+		got = append(got, o.Value+"="+o.Text+":"+strconv.FormatBool(o.Selected))
+	}
+	want := "de=Germany:false opt-fr=France:true Other country=Other country:false"
+	if strings.Join(got, " ") != want || selected != "opt-fr" {
+		t.Errorf("ParseListboxNode() = %q, %q, want %q, opt-fr", strings.Join(got, " "), selected, want)
+	}
+
+	if options, selected, err := ParseListboxNode(controls[2]); err != nil || len(options) != 1 || selected != "" {
+		t.Errorf("listbox without selection: got %v, %q, %v", options, selected, err)
+	}
+	if _, _, err := ParseListboxNode(nil); err == nil {
+		t.Error("expected an error for a nil node")
+	}
+}
@@ -0,0 +1,140 @@
+package html_util
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"io"
+)
+
+// ErrFrozenNode
+// Returned by mutating helpers if the node to be modified belongs to a FrozenDoc.
+var ErrFrozenNode = errors.New("node belongs to a frozen document and must not be modified")
+
+// frozenMarker
+// Linked as PrevSibling of the root of each FrozenDoc tree which was not released, see FrozenDoc.Release.
+// The marker does not reference the trees, so they are garbage collected like any other tree.
+var frozenMarker = &html.Node{Type: html.ErrorNode, Data: "frozen"}
+
+// CloneTree
+// Returns a deep copy of node and its subtree. The copy is detached, i.e., it has no parent and no siblings.
+// Attributes are copied, so modifying the copy does not affect the original tree.
+func CloneTree(node *html.Node) *html.Node {
+	if node == nil {
+		return nil
+	}
+	clone := &html.Node{
+		Type:      node.Type,
+		DataAtom:  node.DataAtom,
+		Data:      node.Data,
+		Namespace: node.Namespace,
+		Attr:      make([]html.Attribute, len(node.Attr)),
+	}
+	copy(clone.Attr, node.Attr)
+
+	var last *html.Node
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		childClone := CloneTree(c)
+		childClone.Parent = clone
+		if last == nil {
+			clone.FirstChild = childClone
+		} else {
+			last.NextSibling = childClone
+			childClone.PrevSibling = last
+		}
+		last = childClone
+	}
+	clone.LastChild = last
+	return clone
+}
+
+// FrozenDoc
+// An immutable snapshot of a html tree, see FreezeTree.
+// All methods are safe for concurrent use. The nodes returned by its methods must only be read, the mutating helpers of
+// this package reject them with ErrFrozenNode.
+type FrozenDoc struct {
+	root  *html.Node
+	index *DocumentIndex
+}
+
+// FreezeTree
+// Deep copies the tree of root once and returns it as FrozenDoc, which can be queried from multiple goroutines while
+// the original tree is modified.
+// The copy stays frozen until FrozenDoc.Release is called, even if the FrozenDoc itself is not referenced anymore, since
+// its nodes may still be. The root of the copy has no siblings, its PrevSibling marks the tree as frozen instead.
+func FreezeTree(root *html.Node) *FrozenDoc {
+	clone := CloneTree(root)
+	doc := &FrozenDoc{
+		root:  clone,
+		index: NewDocumentIndex(clone),
+	}
+	if clone != nil {
+		clone.PrevSibling = frozenMarker
+	}
+	return doc
+}
+
+// IsFrozen
+// Returns whether node belongs to the tree of a FrozenDoc.
+func IsFrozen(node *html.Node) bool {
+	if node == nil {
+		return false
+	}
+	return TreeRoot(node).PrevSibling == frozenMarker
+}
+
+// checkMutable
+// Returns ErrFrozenNode if node belongs to a FrozenDoc, else nil.
+func checkMutable(node *html.Node) error {
+	if IsFrozen(node) {
+		return ErrFrozenNode
+	}
+	return nil
+}
+
+// Release
+// Unfreezes the tree. The mutating helpers of this package accept its nodes afterwards, so they must not be used
+// concurrently anymore.
+func (doc *FrozenDoc) Release() {
+	if doc.root != nil && doc.root.PrevSibling == frozenMarker {
+		doc.root.PrevSibling = nil
+	}
+}
+
+// Root
+// Returns the root of the frozen tree.
+func (doc *FrozenDoc) Root() *html.Node {
+	return doc.root
+}
+
+// ByCondition
+// Returns all nodes of the frozen tree for which cond yields true, see GetNodesByCondition.
+func (doc *FrozenDoc) ByCondition(cond func(node *html.Node) bool) []*html.Node {
+	return GetNodesByCondition(doc.root, cond)
+}
+
+// FirstByCondition
+// Returns the first node of the frozen tree for which cond yields true, see GetNodeByCondition.
+func (doc *FrozenDoc) FirstByCondition(cond func(node *html.Node) bool) *html.Node {
+	return GetNodeByCondition(doc.root, cond)
+}
+
+// ByID
+// Returns the first element of the frozen tree with the given id, or nil.
+func (doc *FrozenDoc) ByID(id string) *html.Node {
+	return doc.index.GetElementById(id)
+}
+
+// Text
+// Returns the inner text of the frozen tree, see GetInnerText.
+func (doc *FrozenDoc) Text() string {
+	return GetInnerText(doc.root)
+}
+
+// Render
+// Renders the frozen tree to w, see html.Render.
+func (doc *FrozenDoc) Render(w io.Writer) error {
+	if doc.root == nil {
+		return errors.New("node is nil")
+	}
+	return html.Render(w, doc.root)
+}
@@ -0,0 +1,147 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFreezeTreeGuardHoldsAfterGC(t *testing.T) {
+	original := parseDocument(t, `<div id="a"><p>text</p></div>`)
+	frozen := FreezeTree(original)
+	p := frozen.FirstByCondition(MakeByTagNameCondition("p"))
+	frozen = nil // only the node is kept
+
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond) // let finalizers run
+	}
+
+	if !IsFrozen(p) {
+		t.Fatal("node of a frozen tree is not frozen anymore after garbage collection")
+	}
+	if err := RemoveNode(p); !errors.Is(err, ErrFrozenNode) {
+		t.Errorf("RemoveNode returned %v, want ErrFrozenNode", err)
+	}
+	if err := AppendChildNode(p, &html.Node{Type: html.TextNode, Data: "x"}); !errors.Is(err, ErrFrozenNode) {
+		t.Errorf("AppendChildNode returned %v, want ErrFrozenNode", err)
+	}
+	if IsFrozen(original.FirstChild) {
+		t.Error("node of the original tree is frozen")
+	}
+	runtime.KeepAlive(p)
+}
+
+func TestFreezeTreeUnreleasedIsCollected(t *testing.T) {
+	doc := parseDocument(t, makeLargeDocument(2000))
+	heapAlloc := func() uint64 {
+		var stats runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		return stats.HeapAlloc
+	}
+
+	before := heapAlloc()
+	for i := 0; i < 10; i++ {
+		FreezeTree(doc) // never released
+	}
+	afterDropped := heapAlloc()
+	kept := FreezeTree(doc)
+	snapshot := heapAlloc() - afterDropped
+
+	if afterDropped > before && afterDropped-before > 3*snapshot {
+		t.Errorf("10 unreleased frozen trees retain %v bytes, a single one takes %v bytes", afterDropped-before, snapshot)
+	}
+	runtime.KeepAlive(kept)
+}
+
+func TestFrozenDocRelease(t *testing.T) {
+	frozen := FreezeTree(parseDocument(t, `<p>text</p>`))
+	p := frozen.FirstByCondition(MakeByTagNameCondition("p"))
+	if !IsFrozen(p) {
+		t.Fatal("node is not frozen before Release")
+	}
+
+	frozen.Release()
+	if frozen.Root().PrevSibling != nil {
+		t.Error("root has a sibling after Release")
+	}
+	if IsFrozen(p) {
+		t.Error("node is frozen after Release")
+	}
+	if err := RemoveNode(p); err != nil {
+		t.Errorf("RemoveNode returned %v after Release", err)
+	}
+}
+
+func TestFreezeTreeCopiesTree(t *testing.T) {
+	original := parseDocument(t, `<div id="a"><p>text</p></div>`)
+	frozen := FreezeTree(original)
+
+	div := GetElementNodesByTagName("div", original)[0]
+	if err := RemoveNode(div); err != nil {
+		t.Fatal(err)
+	}
+	if frozen.ByID("a") == nil || frozen.Text() != "text" {
+		t.Errorf("frozen tree changed with the original, got text %q", frozen.Text())
+	}
+}
+
+func TestFrozenDocRejectsMutations(t *testing.T) {
+	frozen := FreezeTree(parseDocument(t, `<div id="a"><p>text</p></div>`))
+	defer frozen.Release()
+	div, p := frozen.ByID("a"), frozen.FirstByCondition(MakeByTagNameCondition("p"))
+	fresh := &html.Node{Type: html.ElementNode, Data: "span"}
+
+	mutations := map[string]func() error{
+		"RemoveNode":       func() error { return RemoveNode(p) },
+		"AppendChildNode":  func() error { return AppendChildNode(div, fresh) },
+		"InsertNodeBefore": func() error { return InsertNodeBefore(p, fresh) },
+		"InsertNodeAfter":  func() error { return InsertNodeAfter(p, fresh) },
+		"ReplaceNode":      func() error { return ReplaceNode(p, fresh) },
+	}
+	for name, mutate := range mutations {
+		if err := mutate(); !errors.Is(err, ErrFrozenNode) {
+			t.Errorf("%v returned %v, want ErrFrozenNode", name, err)
+		}
+	}
+	if !IsFrozen(p) || p.Parent != div || fresh.Parent != nil {
+		t.Error("a rejected mutation modified the tree")
+	}
+	if IsFrozen(fresh) {
+		t.Error("a node outside the frozen tree is frozen")
+	}
+}
+
+func TestFrozenDocConcurrentReads(t *testing.T) {
+	frozen := FreezeTree(parseDocument(t, `<ul id="list"><li>a</li><li>b</li><li>c</li></ul>`))
+	defer frozen.Release()
+
+	var wg sync.WaitGroup
+	results := make(chan string, 16)
+	for i := 0; i < cap(results); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var sb strings.Builder
+			if err := frozen.Render(&sb); err != nil {
+				results <- err.Error()
+				return
+			}
+			items := frozen.ByCondition(MakeByTagNameCondition("li"))
+			results <- fmt.Sprintf("%v %v %v %v", len(items), nodeID(frozen.ByID("list")), frozen.Text(), strings.Count(sb.String(), "<li>"))
+		}()
+	}
+	wg.Wait()
+	close(results)
+	for result := range results {
+		if result != "3 list abc 3" {
+			t.Errorf("concurrent read returned %q", result)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package html_util
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+// fuzzSeeds
+// Documents seeding the fuzz targets, covering spans, nested tables, ragged rows, and malformed markup.
+var fuzzSeeds = []string{
+	`<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2</td></tr></table>`,
+	`<table><thead><tr><th></th><th>x</th></tr></thead><tbody><tr><th scope="row">r</th><td>1</td></tr></tbody></table>`,
+	`<table><tr><td rowspan="2" colspan="2">a</td><td>b</td></tr><tr><td>c</td></tr><tr><td colspan="0">d</td></tr></table>`,
+	`<table><tr><td rowspan="0">a</td><td>b</td></tr><tr><td>c</td></tr></table>`,
+	`<table><tr><td><table><tr><td>inner</td></tr></table></td><td>outer</td></tr><tr></tr></table>`,
+	`<table><tr><td>1</td></tr><tr><td>1</td><td>2</td><td>3</td></tr><tr><td></td></tr></table>`,
+	`<table><caption>c</caption><tr><td colspan="-1" rowspan="x">a<br>b</td></tr></table>`,
+	`<tbody><tr><td>orphan</td></tr>`,
+	`<table><tr><td><select><option value="1">one</option><option selected>two</option></select></td></tr></table>`,
+	`<select><option value="a">A<option value="b" selected disabled>B</select>`,
+	`<pre>  keep
+  this</pre><p>text <script>ignored()</script> and <b>bold</b></p>`,
+	`<table><tr><td>` + strings.Repeat(`<div>`, 300) + `deep` + `</td></tr></table>`,
+	``,
+}
+
+// parseFuzzDocument
+// Parses data like a browser would, html.Parse only fails on read errors, which cannot happen for a string.
+func parseFuzzDocument(t *testing.T, data string) *html.Node {
+	doc, err := html.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Skip()
+	}
+	return doc
+}
+
+// fuzzTableOptions
+// Maps the bits of flags to the boolean fields of TableParseOptions, so the fuzzer explores all combinations.
+func fuzzTableOptions(flags uint16) TableParseOptions {
+	bit := func(i uint) bool {
+		return flags&(1<<i) != 0
+	}
+	return TableParseOptions{
+		HasHeaderRow:           bit(0),
+		HasIndexColumn:         bit(1),
+		Suffix:                 "_",
+		AllowCompositeTexts:    bit(2),
+		CompositeDelimiter:     " ",
+		ExpandSpans:            bit(3),
+		RecordSpans:            bit(4),
+		RecordProvenance:       bit(5),
+		RecordColumnMeta:       bit(6),
+		KeepEmptyRows:          bit(7),
+		RecordRawText:          bit(8),
+		NormalizeGenerated:     bit(9),
+		UseAltText:             bit(10),
+		DetectRowHeaders:       bit(11),
+		DropRepeatedHeaderRows: bit(12),
+		StrictTableNode:        bit(13),
+		MaxCells:               1 << 16,
+	}
+}
+
+func FuzzParseHtmlTable(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		for _, flags := range []uint16{0, 0b11, 0b11011, 0xffff} {
+			f.Add(seed, flags)
+		}
+	}
+	f.Fuzz(func(t *testing.T, data string, flags uint16) {
+		doc := parseFuzzDocument(t, data)
+		opts := fuzzTableOptions(flags)
+		for _, tableNode := range GetNodesByCondition(doc, MakeByTagNameCondition("table")) {
+			ht, err := ParseHtmlTableWithOptions(tableNode, opts)
+			if err != nil {
+				continue
+			}
+			// every accessor must stay within bounds of a successfully parsed table
+			for i := 0; i < len(ht.Index); i++ {
+				row, _ := ht.GetRowByIndex(i)
+				for j := range row {
+					_ = ht.GetElementByIndex(i, j)
+				}
+			}
+			for j := range ht.Headers {
+				_, _ = ht.GetColumnByIndex(j)
+			}
+			_, _ = ParseHtmlTable(tableNode, opts.HasHeaderRow, opts.HasIndexColumn, opts.Suffix)
+			_, _ = ParseHtmlTableHeaders(tableNode, opts)
+		}
+	})
+}
+
+func FuzzExpandTableSpans(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		doc := parseFuzzDocument(t, data)
+		const maxCells = 1 << 16
+		for _, tableNode := range GetNodesByCondition(doc, MakeByTagNameCondition("table")) {
+			var rows [][]*html.Node
+			for _, row := range getOwnTableRows(tableNode) {
+				rows = append(rows, getRowCells(row))
+			}
+			grid, spans, err := expandTableSpans(rows, maxCells)
+			if err != nil {
+				if !errors.Is(err, ErrTableTooLarge) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				continue
+			}
+			if len(grid) != len(rows) {
+				t.Fatalf("grid has %v rows, want %v", len(grid), len(rows))
+			}
+			cells := 0
+			for _, row := range grid {
+				if len(row) > cells {
+					cells = len(row)
+				}
+			}
+			if cells*len(rows) > maxCells {
+				t.Fatalf("grid of %v rows and %v columns exceeds %v cells", len(rows), cells, maxCells)
+			}
+			for position, span := range spans {
+				if span.RowSpan < 1 || span.ColSpan < 1 || grid[position[0]][position[1]] == nil {
+					t.Fatalf("invalid span %+v at %v", span, position)
+				}
+			}
+		}
+	})
+}
+
+func FuzzParseSelectHTMLNode(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		doc := parseFuzzDocument(t, data)
+		for _, selectNode := range GetNodesByCondition(doc, MakeByTagNameCondition("select")) {
+			_, _, _ = ParseSelectHTMLNode(selectNode)
+			_, _, _ = ParseSelectHTMLNodeByValue(selectNode)
+		}
+	})
+}
+
+func FuzzGetInnerText(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		doc := parseFuzzDocument(t, data)
+		_ = GetInnerText(doc)
+		if extracted, spans := ExtractTextWithOffsets(doc, VisibleTextOptions{}); len(spans) > 0 && spans[len(spans)-1].End > len([]rune(extracted)) {
+			t.Fatalf("span %+v exceeds text of %v runes", spans[len(spans)-1], len([]rune(extracted)))
+		}
+	})
+}
+
+func TestExpandTableSpansBoundsAmplification(t *testing.T) {
+	// about 8 KB of markup which would expand to 200 rows of 200,000 columns
+	doc := parseDocument(t, "<table>"+strings.Repeat(`<tr><td rowspan=0 colspan=1000>x</td></tr>`, 200)+"</table>")
+	tableNode := GetNodeByCondition(doc, MakeByTagNameCondition("table"))
+
+	for _, opts := range []TableParseOptions{{ExpandSpans: true}, {RecordSpans: true, MaxCells: 10000}} {
+		_, err := ParseHtmlTableWithOptions(tableNode, opts)
+		if !errors.Is(err, ErrTableTooLarge) {
+			t.Errorf("ParseHtmlTableWithOptions(%+v) error = %v, want ErrTableTooLarge", opts, err)
+		}
+	}
+	if _, err := ParseHtmlTableHeaders(tableNode, TableParseOptions{HasHeaderRow: false, ExpandSpans: true}); !errors.Is(err, ErrTableTooLarge) {
+		t.Errorf("ParseHtmlTableHeaders error = %v, want ErrTableTooLarge", err)
+	}
+	row := GetNodesByCondition(tableNode, MakeByTagNameCondition("tr"))[199]
+	if cells := GetCellsWithPositions(row); cells != nil {
+		t.Errorf("GetCellsWithPositions = %v cells, want nil", len(cells))
+	}
+}
@@ -0,0 +1,95 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"regexp"
+)
+
+// WrapTextMatches
+// Wraps every match of re within the rendered text nodes of the tree of root, e.g., to highlight search terms with
+// <mark> elements. Each text node is split at the match boundaries and every matched segment is replaced by the node
+// returned by wrap. If that node has no children, a text node with the match is appended to it.
+// Empty matches are ignored, wrap returning nil, an attached node, or a node it already returned leaves the match as
+// text. Text inside <script>, <style>, <template>, and <noscript> elements, inside elements which can only contain text
+// like <title> and <textarea>, and in frozen trees is not modified.
+// Returns the number of wrapped matches.
+func WrapTextMatches(root *html.Node, re *regexp.Regexp, wrap func(match string) *html.Node) int {
+	if root == nil || re == nil || wrap == nil {
+		return 0
+	}
+
+	// collect first, so the inserted nodes are not visited again
+	var textNodes []*html.Node
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		switch n.Type {
+		case html.TextNode:
+			if n.Parent != nil && checkMutable(n) == nil {
+				textNodes = append(textNodes, n)
+			}
+		case html.ElementNode:
+			return !isNonRenderedNode(n) && !isTextOnlyTag(n.Data)
+		}
+		return true
+	})
+
+	wrapped := 0
+	for _, textNode := range textNodes {
+		wrapped += wrapTextNodeMatches(textNode, re, wrap)
+	}
+	return wrapped
+}
+
+// isTextOnlyTag
+// Returns true for elements whose content is text only, so elements inserted into them would be rendered as text.
+func isTextOnlyTag(tag string) bool {
+	switch tag {
+	case "title", "textarea", "iframe", "xmp", "noembed", "noframes", "plaintext":
+		return true
+	}
+	return false
+}
+
+// wrapTextNodeMatches
+// Splits textNode at the matches of re and replaces the matched segments by the nodes returned by wrap.
+// Returns the number of wrapped matches.
+func wrapTextNodeMatches(textNode *html.Node, re *regexp.Regexp, wrap func(match string) *html.Node) int {
+	text := textNode.Data
+	parent := textNode.Parent
+	var segments []*html.Node
+	used := make(map[*html.Node]bool) // nodes returned by wrap which are about to be inserted
+	wrapped := 0
+	last := 0
+
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		match := text[loc[0]:loc[1]]
+		node := wrap(match)
+		if node == nil || node.Parent != nil || node.PrevSibling != nil || node.NextSibling != nil || node == textNode || used[node] {
+			continue
+		}
+		used[node] = true
+		if node.FirstChild == nil && node.Type == html.ElementNode {
+			node.AppendChild(&html.Node{Type: html.TextNode, Data: match})
+		}
+		if loc[0] > last {
+			segments = append(segments, &html.Node{Type: html.TextNode, Data: text[last:loc[0]]})
+		}
+		segments = append(segments, node)
+		last = loc[1]
+		wrapped++
+	}
+
+	if wrapped == 0 {
+		return 0
+	}
+	if last < len(text) {
+		segments = append(segments, &html.Node{Type: html.TextNode, Data: text[last:]})
+	}
+	for _, segment := range segments {
+		parent.InsertBefore(segment, textNode)
+	}
+	parent.RemoveChild(textNode)
+	return wrapped
+}
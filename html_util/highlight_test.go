@@ -0,0 +1,100 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"regexp"
+	"testing"
+)
+
+// makeMark
+// Returns a new, empty <mark> element.
+func makeMark(match string) *html.Node {
+	return &html.Node{Type: html.ElementNode, DataAtom: atom.Mark, Data: "mark"}
+}
+
+func TestWrapTextMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		html    string
+		pattern string
+		want    string
+		wraps   int
+	}{
+		{"words", `<p>Go is fun, go <b>go</b>!</p>`, `(?i)\bgo\b`,
+			`<p><mark>Go</mark> is fun, <mark>go</mark> <b><mark>go</mark></b>!</p>`, 3},
+		{"within words only at boundaries", `<p>gopher go</p>`, `\bgo\b`, `<p>gopher <mark>go</mark></p>`, 1},
+		{"whole node", `<p>match</p>`, `match`, `<p><mark>match</mark></p>`, 1},
+		{"adjacent and overlapping", `<p>aaaa</p>`, `aa|aaa`, `<p><mark>aa</mark><mark>aa</mark></p>`, 2},
+		{"empty matches", `<p>abc</p>`, `x*`, `<p>abc</p>`, 0},
+		{"partly empty matches", `<p>abc</p>`, `b*`, `<p>a<mark>b</mark>c</p>`, 1},
+		{"escaping", `<p>a &lt;b&gt; &amp; c</p>`, `<b>`, `<p>a <mark>&lt;b&gt;</mark> &amp; c</p>`, 1},
+		{"non-rendered and text-only elements", `<title>go</title><p>go</p><script>go()</script>` +
+			`<style>.go{}</style><textarea>go</textarea><noscript>go</noscript>`, `go`,
+			`<p><mark>go</mark></p><script>go()</script><style>.go{}</style><textarea>go</textarea><noscript>go</noscript>`, 1},
+		{"attributes", `<a title="go" href="/go">no</a>`, `go`, `<a title="go" href="/go">no</a>`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseDocument(t, tt.html)
+			if wraps := WrapTextMatches(doc, regexp.MustCompile(tt.pattern), makeMark); wraps != tt.wraps {
+				t.Errorf("got %v wraps, want %v", wraps, tt.wraps)
+			}
+			body := GetElementNodesByTagName("body", doc)[0]
+			got := ""
+			for c := body.FirstChild; c != nil; c = c.NextSibling {
+				got += renderNode(t, c)
+			}
+			if tt.name == "non-rendered and text-only elements" {
+				if title := GetElementNodesByTagName("title", doc)[0]; renderNode(t, title) != "<title>go</title>" {
+					t.Errorf("title was modified: %v", renderNode(t, title))
+				}
+			}
+			if got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapTextMatchesWrapFunc(t *testing.T) {
+	doc := parseDocument(t, `<p id="p">a1 b2 c3</p>`)
+	shared := makeMark("")
+	calls := 0
+	wrap := func(match string) *html.Node {
+		calls++
+		switch match {
+		case "a1":
+			return nil // left as text
+		case "b2":
+			return shared
+		}
+		return shared // already used, left as text
+	}
+	if wraps := WrapTextMatches(doc, regexp.MustCompile(`[a-z]\d`), wrap); wraps != 1 || calls != 3 {
+		t.Errorf("got %v wraps and %v calls, want 1 and 3", wraps, calls)
+	}
+	if got, want := renderNode(t, elementByID(t, doc, "p")), `<p id="p">a1 <mark>b2</mark> c3</p>`; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// nodes with children are inserted as returned
+	doc = parseDocument(t, `<p id="p">x y</p>`)
+	WrapTextMatches(doc, regexp.MustCompile(`y`), func(match string) *html.Node {
+		n := makeMark(match)
+		n.AppendChild(&html.Node{Type: html.TextNode, Data: "[" + match + "]"})
+		return n
+	})
+	if got, want := renderNode(t, elementByID(t, doc, "p")), `<p id="p">x <mark>[y]</mark></p>`; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	frozen := FreezeTree(parseDocument(t, `<p>x</p>`))
+	defer frozen.Release()
+	if wraps := WrapTextMatches(frozen.Root(), regexp.MustCompile(`x`), makeMark); wraps != 0 {
+		t.Errorf("wrapped %v matches in a frozen tree", wraps)
+	}
+	if wraps := WrapTextMatches(nil, regexp.MustCompile(`x`), makeMark); wraps != 0 {
+		t.Errorf("wrapped %v matches in nil", wraps)
+	}
+}
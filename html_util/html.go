@@ -8,23 +8,167 @@ import (
 	"golang.org/x/net/html"
 	"log"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
-var TextRegex = regexp.MustCompile("[^!-~]") // without space
+// TextRegex
+// Matches the characters which do not count as content in the legacy check, see DefaultASCIIContentText. Earlier
+// versions used it to decide which texts count as content, so replacing it, e.g., by a regex which also accepts "€",
+// still enables the legacy check as UseLegacyTextRegex does.
+var TextRegex = defaultTextRegex
+
+var defaultTextRegex = regexp.MustCompile("[^!-~]") // without space
+
+// ErrTableTooLarge
+// Returned by the table parsing functions if a table has more cells than TableParseOptions.MaxCells.
+var ErrTableTooLarge = errors.New("table exceeds the maximum number of cells")
+
+// MaxExpandedCells
+// Upper bound of the number of cells of a table after the expansion of rowspans and colspans if
+// TableParseOptions.MaxCells is 0, so a few bytes of span attributes cannot allocate gigabytes, e.g., 200 rows of
+// <td rowspan=0 colspan=1000>. Expanding a larger table yields ErrTableTooLarge.
+var MaxExpandedCells = 1 << 20
+
+// UseLegacyTextRegex
+// If true, a text only counts as content if it contains a printable ascii character, see DefaultASCIIContentText, which
+// was the behavior of earlier versions. Otherwise, every text which is not blank, see IsBlank, counts as content, e.g.,
+// "€". Replacing TextRegex has the same effect. ContentTextFunc takes precedence.
+var UseLegacyTextRegex = false
+
+// ContentTextFunc
+// Decides whether a text counts as content throughout the package, e.g., for table cells, select options, and records,
+// DefaultContentText if nil. Options like TableParseOptions.ContentTextFunc take precedence for single calls.
+// Set it once during initialization, it must not be changed while the package is in use by other goroutines.
+var ContentTextFunc func(s string) bool
+
+// DefaultContentText
+// Returns true if s is not blank, see IsBlank, e.g., for "€" or "—".
+func DefaultContentText(s string) bool {
+	return !IsBlank(s)
+}
+
+// DefaultASCIIContentText
+// Returns true if s contains at least one visible, non-space ascii character, see TextRegex, which was the behavior of
+// earlier versions, e.g., false for "€" or "—".
+func DefaultASCIIContentText(s string) bool {
+	return len(TextRegex.ReplaceAllString(s, "")) > 0
+}
+
+// isContentText
+// Returns whether s counts as content: via ContentTextFunc if set, else via DefaultASCIIContentText if
+// UseLegacyTextRegex is set or TextRegex was replaced, else via DefaultContentText.
+func isContentText(s string) bool {
+	if ContentTextFunc != nil {
+		return ContentTextFunc(s)
+	}
+	if UseLegacyTextRegex || TextRegex != defaultTextRegex {
+		return DefaultASCIIContentText(s)
+	}
+	return DefaultContentText(s)
+}
+
+// contentTextFunc
+// Returns f, or isContentText if f is nil.
+func contentTextFunc(f func(s string) bool) func(s string) bool {
+	if f == nil {
+		return isContentText
+	}
+	return f
+}
+
+// TopLeftPlaceholder
+// Key of the top-left cell of an HtmlTable if either the header row or the index column is artificial.
+// A real header or index key with the same text is made unique via the parse suffix, see ParseHtmlTableWithOptions.
+const TopLeftPlaceholder = "Index\\Header"
+
+// KeySource
+// Describes where the Headers or the Index of an HtmlTable come from, see HtmlTable.HeaderSource.
+type KeySource int
+
+const (
+	KeySourceArtificial  KeySource = iota // generated, i.e., TopLeftPlaceholder followed by "1", "2", "3", ...
+	KeySourceTHead                        // the first row, which is part of a <thead> element
+	KeySourceFirstRow                     // the first row, which is not part of a <thead> element
+	KeySourceFirstColumn                  // the first column
+	KeySourceRowHeaders                   // the first column, detected via TableParseOptions.DetectRowHeaders
+	KeySourceDerived                      // computed by a transformation of another table, e.g., Melt or Pivot
+)
 
 // WalkHtmlTree
 // Calls f on node.
 // If it returns true, call WalkHtmlTree on all of its children.
+// The traversal is iterative, so arbitrarily deep trees cannot exhaust the stack.
 func WalkHtmlTree(node *html.Node, f func(n *html.Node) bool) {
+	walkDescendants(node, f, nil, nil)
+}
+
+// walkDescendants
+// Calls enter on all descendants of node in document order without recursion. If enter returns false, the children of
+// the respective node are skipped, else, leave is called on it after its children were visited. The traversal stops as
+// soon as done returns true. leave and done may be nil.
+// As in a recursive traversal, the siblings of a node are read after its subtree was visited, so if a callback
+// detaches a node, the remaining siblings of that node are skipped and the traversal continues with its former parent's
+// next sibling.
+func walkDescendants(node *html.Node, enter func(n *html.Node) bool, leave func(n *html.Node), done func() bool) {
 	if node == nil {
 		return
 	}
-	for c := node.FirstChild; c != nil; c = c.NextSibling {
-		if f(c) {
-			WalkHtmlTree(c, f)
+	var ancestors []*html.Node // entered ancestors of n below node, the parent of n last
+	n := node.FirstChild
+	for {
+		if n == nil {
+			// all children of the innermost ancestor were visited, continue with its next sibling
+			if len(ancestors) == 0 {
+				return
+			}
+			n = ancestors[len(ancestors)-1]
+			ancestors = ancestors[:len(ancestors)-1]
+			if leave != nil {
+				leave(n)
+			}
+			n = n.NextSibling
+			continue
+		}
+		descend := enter(n)
+		if done != nil && done() {
+			return
+		}
+		if descend && n.FirstChild != nil {
+			ancestors = append(ancestors, n)
+			n = n.FirstChild
+			continue
 		}
+		if descend && leave != nil {
+			leave(n)
+		}
+		n = n.NextSibling
+	}
+}
+
+// walkTree
+// Same as walkDescendants but includes node itself.
+func walkTree(node *html.Node, enter func(n *html.Node) bool, leave func(n *html.Node), done func() bool) {
+	if node == nil || !enter(node) || (done != nil && done()) {
+		return
+	}
+	walkDescendants(node, enter, leave, done)
+	if leave != nil && !(done != nil && done()) {
+		leave(node)
+	}
+}
+
+// WalkHtmlTreeInclusive
+// Calls f on node itself first.
+// If it returns true, call WalkHtmlTree on node, i.e., continue with all of its children.
+// A false return skips the entire subtree of the respective node.
+func WalkHtmlTreeInclusive(node *html.Node, f func(n *html.Node) bool) {
+	if node == nil {
+		return
+	}
+	if f(node) {
+		WalkHtmlTree(node, f)
 	}
 }
 
@@ -42,18 +186,154 @@ func GetChildren(node *html.Node) []*html.Node {
 	return children
 }
 
+// GetNodePath
+// Returns an XPath-like path of element tags from the root of the tree of node to node, e.g., '/html/body/div[2]/p'.
+// The 1-based position among the element siblings with the same tag is appended if there is more than one of them.
+// Non-element nodes are represented as 'text()', 'comment()' or 'node()' with their position among all siblings.
+func GetNodePath(node *html.Node) string {
+	var parts []string
+	for n := node; n != nil && n.Type != html.DocumentNode; n = n.Parent {
+		parts = append(parts, getNodePathStep(n, GetChildren(n.Parent)))
+	}
+
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// getNodePathStep
+// Returns the path segment of n among siblings, the children of its parent, see GetNodePath.
+func getNodePathStep(n *html.Node, siblings []*html.Node) string {
+	var name string
+	switch n.Type {
+	case html.ElementNode:
+		name = n.Data
+	case html.TextNode:
+		name = "text()"
+	case html.CommentNode:
+		name = "comment()"
+	default:
+		name = "node()"
+	}
+
+	position, count := 0, 0
+	for _, sibling := range siblings {
+		if sibling.Type == n.Type && (n.Type != html.ElementNode || sibling.Data == n.Data) {
+			count++
+			if sibling == n {
+				position = count
+			}
+		}
+	}
+	if count > 1 {
+		name = fmt.Sprintf("%v[%v]", name, position)
+	}
+	return name
+}
+
+// nodePathCache
+// Computes the GetNodePath of many nodes of the same tree in linear time by memoizing the paths of their ancestors and
+// the path steps of all siblings at once, e.g., for the provenance of all cells of a large table.
+type nodePathCache struct {
+	paths map[*html.Node]string // node -> path without the leading '/'
+	steps map[*html.Node]string // node -> path step among its siblings, see getNodePathStep
+}
+
+func newNodePathCache() *nodePathCache {
+	return &nodePathCache{
+		paths: make(map[*html.Node]string),
+		steps: make(map[*html.Node]string),
+	}
+}
+
+// path
+// Returns GetNodePath(node).
+func (pc *nodePathCache) path(node *html.Node) string {
+	return "/" + pc.relativePath(node)
+}
+
+func (pc *nodePathCache) relativePath(n *html.Node) string {
+	if n == nil || n.Type == html.DocumentNode {
+		return ""
+	}
+	if path, ok := pc.paths[n]; ok {
+		return path
+	}
+	if n.Parent == nil {
+		return getNodePathStep(n, []*html.Node{n})
+	}
+	if _, ok := pc.steps[n]; !ok {
+		pc.addSiblingSteps(n.Parent)
+	}
+	path := pc.steps[n]
+	if parentPath := pc.relativePath(n.Parent); parentPath != "" {
+		path = parentPath + "/" + path
+	}
+	pc.paths[n] = path
+	return path
+}
+
+// addSiblingSteps
+// Computes the path steps of all children of parent, see getSiblingPathSteps.
+func (pc *nodePathCache) addSiblingSteps(parent *html.Node) {
+	children := GetChildren(parent)
+	for k, step := range getSiblingPathSteps(children) {
+		pc.steps[children[k]] = step
+	}
+}
+
+// getSiblingPathSteps
+// Returns the path step of each node of siblings in two passes, like getNodePathStep does for a single node.
+func getSiblingPathSteps(siblings []*html.Node) []string {
+	type stepKey struct {
+		nodeType html.NodeType
+		data     string
+	}
+	keyOf := func(n *html.Node) stepKey {
+		if n.Type == html.ElementNode {
+			return stepKey{n.Type, n.Data}
+		}
+		return stepKey{nodeType: n.Type}
+	}
+
+	counts := make(map[stepKey]int)
+	for _, n := range siblings {
+		counts[keyOf(n)]++
+	}
+	steps := make([]string, len(siblings))
+	positions := make(map[stepKey]int)
+	for k, n := range siblings {
+		key := keyOf(n)
+		positions[key]++
+		name := getNodePathStep(n, []*html.Node{n}) // the name without position
+		if counts[key] > 1 {
+			name = fmt.Sprintf("%v[%v]", name, positions[key])
+		}
+		steps[k] = name
+	}
+	return steps
+}
+
 // GetNodeByCondition
 // Returns the first node for which the provided condition yields true, including the start node
 func GetNodeByCondition(startNode *html.Node, cond func(node *html.Node) bool) *html.Node {
-	if startNode == nil {
-		return nil
-	}
+	var foundNode *html.Node
 
-	if cond(startNode) {
-		return startNode
-	} else {
-		return GetNextNodeByCondition(startNode, cond)
-	}
+	WalkHtmlTreeInclusive(startNode, func(n *html.Node) bool {
+		if foundNode != nil {
+			return false
+		}
+
+		if cond(n) {
+			foundNode = n
+			return false
+		}
+
+		return true
+	})
+
+	return foundNode
 }
 
 // GetNextNodeByCondition
@@ -95,15 +375,14 @@ func GetNextNodeByCondition(startNode *html.Node, cond func(node *html.Node) boo
 func GetNodesByCondition(startNode *html.Node, cond func(node *html.Node) bool) []*html.Node {
 	var foundNodes []*html.Node
 
-	if startNode == nil {
-		return foundNodes
-	}
-
-	if cond(startNode) {
-		foundNodes = append(foundNodes, startNode)
-	}
+	WalkHtmlTreeInclusive(startNode, func(n *html.Node) bool {
+		if cond(n) {
+			foundNodes = append(foundNodes, n)
+		}
+		return true
+	})
 
-	return append(foundNodes, GetNextNodesByCondition(startNode, cond)...)
+	return foundNodes
 }
 
 // GetNextNodesByCondition
@@ -139,6 +418,43 @@ func GetElementNodeByTagName(name string, startNode *html.Node) *html.Node {
 	return GetNodeByCondition(startNode, MakeByTagNameCondition(name))
 }
 
+// GetElementNodesByTagName
+// Returns all nodes with the given tag name in the tree of startNode, including startNode, in document order.
+// Each node is visited exactly once. Unlike GetNodesByCondition with MakeByTagNameCondition, the tree is walked via the
+// parent pointers of its nodes without callbacks or a stack of ancestors, which makes this the fastest way to collect
+// elements by tag name.
+func GetElementNodesByTagName(name string, startNode *html.Node) []*html.Node {
+	var foundNodes []*html.Node
+	for n := startNode; n != nil; {
+		if n.Type == html.ElementNode && n.Data == name {
+			foundNodes = append(foundNodes, n)
+		}
+		if n.FirstChild != nil {
+			n = n.FirstChild
+			continue
+		}
+		// ascend to the next ancestor with a following sibling, but never above startNode
+		for n != startNode && n.NextSibling == nil {
+			n = n.Parent
+		}
+		if n == startNode {
+			break
+		}
+		n = n.NextSibling
+	}
+	return foundNodes
+}
+
+// GetElementNodeByTagNameAndClass
+// Returns the first node with the given tag name and class name (see MakeByClassNameCondition) in the tree of
+// startNode, including startNode. Returns nil if none found.
+func GetElementNodeByTagNameAndClass(name, className string, startNode *html.Node) *html.Node {
+	byClass := MakeByClassNameCondition(className)
+	return GetNodeByCondition(startNode, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && node.Data == name && byClass(node)
+	})
+}
+
 func MakeByTagNameCondition(name string) func(node *html.Node) bool {
 	return func(node *html.Node) bool {
 		return node.Type == html.ElementNode && node.Data == name
@@ -160,7 +476,18 @@ func MakeByClassNameCondition(className string) func(node *html.Node) bool {
 	}
 }
 
+// MakeByIdCondition
+// Returns a condition matching elements whose id equals id exactly, since ids are case-sensitive.
 func MakeByIdCondition(id string) func(node *html.Node) bool {
+	return func(node *html.Node) bool {
+		attr, err := GetAttributeByKey(node, "id")
+		return err == nil && attr.Val == id
+	}
+}
+
+// MakeByIdConditionFold
+// Same as MakeByIdCondition but compares ids case-insensitively, which was the behavior of earlier versions.
+func MakeByIdConditionFold(id string) func(node *html.Node) bool {
 	return MakeByAttributeNameAndValueCondition("id", id)
 }
 
@@ -176,6 +503,81 @@ func MakeByAttributeNameAndValueCondition(attributeName, attributeValue string)
 	}
 }
 
+// decodeAttributeValue
+// Decodes html entities and numeric character references in val if decode is true, e.g., for double-escaped documents
+// in which the parsed value still contains '&amp;'.
+func decodeAttributeValue(val string, decode bool) string {
+	if decode {
+		return html.UnescapeString(val)
+	}
+	return val
+}
+
+// AttributeEquals
+// Returns whether node has the attribute key with the given value, compared case-insensitively like
+// MakeByAttributeNameAndValueCondition. If decode is true, entities in the attribute value are decoded before comparing.
+func AttributeEquals(node *html.Node, key, value string, decode bool) bool {
+	attr, err := GetAttributeByKey(node, key)
+	return err == nil && strings.EqualFold(decodeAttributeValue(attr.Val, decode), value)
+}
+
+// MakeByAttributeDecodedCondition
+// Same as MakeByAttributeNameAndValueCondition but decodes entities in the attribute value before comparing, i.e.,
+// 'Tom & Jerry' matches data-name="Tom &amp;amp; Jerry".
+func MakeByAttributeDecodedCondition(attributeName, attributeValue string) func(node *html.Node) bool {
+	return func(node *html.Node) bool {
+		return AttributeEquals(node, attributeName, attributeValue, true)
+	}
+}
+
+// MakeByAttributeTokenCondition
+// Returns a condition which is true for nodes whose attribute attributeName is a whitespace separated list containing
+// token, compared case-insensitively, e.g., rel="nofollow noopener". Entities are decoded first if decode is true.
+func MakeByAttributeTokenCondition(attributeName, token string, decode bool) func(node *html.Node) bool {
+	return func(node *html.Node) bool {
+		attr, err := GetAttributeByKey(node, attributeName)
+		if err != nil {
+			return false
+		}
+		for _, t := range strings.Fields(decodeAttributeValue(attr.Val, decode)) {
+			if strings.EqualFold(t, token) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MakeByAttributePrefixCondition
+// Returns a condition which is true for nodes whose attribute attributeName starts with prefix, compared
+// case-insensitively. Entities are decoded first if decode is true.
+func MakeByAttributePrefixCondition(attributeName, prefix string, decode bool) func(node *html.Node) bool {
+	return func(node *html.Node) bool {
+		attr, err := GetAttributeByKey(node, attributeName)
+		return err == nil && strings.HasPrefix(strings.ToLower(decodeAttributeValue(attr.Val, decode)), strings.ToLower(prefix))
+	}
+}
+
+// MakeByAttributeSuffixCondition
+// Returns a condition which is true for nodes whose attribute attributeName ends with suffix, compared
+// case-insensitively. Entities are decoded first if decode is true.
+func MakeByAttributeSuffixCondition(attributeName, suffix string, decode bool) func(node *html.Node) bool {
+	return func(node *html.Node) bool {
+		attr, err := GetAttributeByKey(node, attributeName)
+		return err == nil && strings.HasSuffix(strings.ToLower(decodeAttributeValue(attr.Val, decode)), strings.ToLower(suffix))
+	}
+}
+
+// MakeByTextContainsCondition
+// Returns a condition which is true for elements whose inner text (see GetInnerText) contains substring, compared
+// case-insensitively, e.g., to find the <dt> of a label/value pair. Note that ancestors of a match match as well.
+func MakeByTextContainsCondition(substring string) func(node *html.Node) bool {
+	substring = strings.ToLower(substring)
+	return func(node *html.Node) bool {
+		return node.Type == html.ElementNode && strings.Contains(strings.ToLower(GetInnerText(node)), substring)
+	}
+}
+
 func GetFirstTextNode(startNode *html.Node) *html.Node {
 	return GetNodeByCondition(startNode, func(node *html.Node) bool {
 		return node.Type == html.TextNode
@@ -201,6 +603,9 @@ func GetTextNodesByCondition(startNode *html.Node, cond func(s string) bool) []*
 }
 
 func MakeTextNodeCompositeWithNormalizerFunc(textNodes []*html.Node, compositeDelimiter string, normalizerFunc func(string) string) string {
+	if len(textNodes) == 0 {
+		return ""
+	}
 	s := ""
 	for i := 0; i < len(textNodes)-1; i++ {
 		s += normalizerFunc(textNodes[i].Data) + compositeDelimiter
@@ -227,15 +632,95 @@ func GetElementsInTableRowByConditionForOneOfTheElements(tableNode *html.Node, c
 	})
 }
 
+// PositionedCell
+// A cell of a table row together with its position in the table grid, see GetCellsWithPositions.
+type PositionedCell struct {
+	Node     *html.Node // the <td> or <th> element
+	Column   int        // 0-based grid column of the cell's leftmost position
+	ColSpan  int        // number of grid columns covered by the cell
+	IsHeader bool       // whether the cell is a <th> element
+}
+
+// GetCellsWithPositions
+// Returns the cells of rowNode with their 0-based grid column, which accounts for the colspan of earlier cells and,
+// if rowNode belongs to a table, for cells of earlier rows reaching into rowNode via rowspan. The grid columns are the
+// columns the table parser uses when spans are expanded, see TableParseOptions.ExpandSpans.
+// As for the parser with TableParseOptions.KeepEmptyRows, rows containing nested rows, e.g., of a nested table, have no
+// cells, i.e., their spans do not reach into later rows and nil is returned for them.
+// Returns nil if the spans of the rows up to rowNode expand to more than MaxExpandedCells cells.
+func GetCellsWithPositions(rowNode *html.Node) []PositionedCell {
+	if rowNode == nil || hasNestedRows(rowNode) {
+		return nil
+	}
+
+	rows := []*html.Node{rowNode}
+	r := 0
+	if tableNode := getEnclosingTable(rowNode.Parent); tableNode != nil {
+		ownRows := getOwnTableRows(tableNode)
+		for i, row := range ownRows {
+			if row == rowNode {
+				rows, r = ownRows[:i+1], i
+				break
+			}
+		}
+	}
+	rawCells := make([][]*html.Node, len(rows))
+	for i, row := range rows {
+		if !hasNestedRows(row) {
+			rawCells[i] = getRowCells(row)
+		}
+	}
+	grid, spans, err := expandTableSpans(rawCells, 0)
+	if err != nil {
+		return nil
+	}
+
+	var cells []PositionedCell
+	for c, cell := range grid[r] {
+		span, ok := spans[[2]int{r, c}]
+		if !ok {
+			continue // covered by a cell of an earlier row or of an earlier column
+		}
+		cells = append(cells, PositionedCell{Node: cell, Column: c, ColSpan: span.ColSpan, IsHeader: span.IsHeaderCell})
+	}
+	return cells
+}
+
+// GetRowCellsByCondition
+// Returns the cells of rowNode for which cond yields true together with their positions, see GetCellsWithPositions.
+func GetRowCellsByCondition(rowNode *html.Node, cond func(node *html.Node) bool) []PositionedCell {
+	var cells []PositionedCell
+	for _, cell := range GetCellsWithPositions(rowNode) {
+		if cond(cell.Node) {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
 // HtmlTable
 // Represents an HTML table in a struct
 // Contains only text content
 type HtmlTable struct {
-	Headers        []string              // Headers, equal to TableData[0, :] in numpy expression
-	Index          []string              // Index, equal to TableData[:, 0] in numpy expression
-	TableData      [][]string            // All data excluding headers and index
-	normalizerFunc func(s string) string // used to normalize table content (additionally to the little regex)
-	suffix         string                // suffix for recurring keys during parsing
+	Headers        []string                  // Headers, equal to TableData[0, :] in numpy expression
+	Index          []string                  // Index, equal to TableData[:, 0] in numpy expression
+	TableData      [][]string                // All data excluding headers and index
+	Spans          map[[2]int]CellSpan       // (i, j) -> span of the source cell at (i, j), only set if recorded during parsing
+	SkippedRows    []int                     // positions among the table's own <tr> elements of rows containing nested rows which were skipped, see TableParseOptions.KeepEmptyRows
+	normalizerFunc func(s string) string     // used to normalize table content (additionally to the little regex)
+	suffix         string                    // suffix for recurring keys during parsing
+	headerSource   KeySource                 // where Headers come from
+	indexSource    KeySource                 // where Index comes from
+	provenance     map[[2]int]CellProvenance // (i, j) -> source cell of the value at (i, j), only set if recorded during parsing
+	columnMeta     map[int]ColumnMeta        // j -> presentational hints of column j, only set if recorded during parsing
+	rawText        map[[2]int]string         // (i, j) -> text of the cell at (i, j) before normalization, only set if recorded during parsing
+	columnAliases  map[string][]string       // lower case column key -> alternative headers tried if the key is missing, see SetColumnAliases
+	indexAliases   map[string][]string       // lower case row key -> alternative index keys tried if the key is missing, see SetIndexAliases
+	raggedRows     []int                     // source rows with fewer cells than the widest row
+	duplicateKeys  [2]int                    // number of index and header keys which had to be made unique
+	rowHeaders     bool                      // whether Index was taken from detected row headers, see TableParseOptions.DetectRowHeaders
+	rowHeaderGaps  []int                     // rows i whose index cell is not a row header although rowHeaders is set
+	droppedRows    []int                     // source rows which were skipped as repeated header rows, see TableParseOptions.DropRepeatedHeaderRows
 }
 
 // getRowByIndex
@@ -273,10 +758,8 @@ func (ht HtmlTable) GetRowByIndex(i int) ([]string, string) {
 // Returns the reference of the first row with the given key as index if it exists, else, returns (nil, false)
 // The returned index would be the correct index to be used for getRowByIndex(idx)
 func (ht HtmlTable) getRowByKey(key string) ([]string, int, bool) {
-	for idx, idxKey := range ht.Index {
-		if strings.EqualFold(idxKey, key) {
-			return function.GetFirstReturnElement(ht.getRowByIndex(idx)).([]string), idx, true
-		}
+	if _, idx, ok := ht.ResolveRowKey(key); ok {
+		return function.GetFirstReturnElement(ht.getRowByIndex(idx)).([]string), idx, true
 	}
 	return nil, -1, false
 }
@@ -287,16 +770,14 @@ func (ht HtmlTable) GetRowByKeyNum(key string, occurrence int) ([]string, int, b
 	if occurrence == 0 {
 		return ht.GetRowByKey(key)
 	}
-	return ht.GetRowByKey(fmt.Sprintf("%v%v%v", key, ht.suffix, occurrence))
+	return ht.GetRowByKey(ht.OccurrenceKey(key, occurrence))
 }
 
 // GetRowByKey
 // Returns the copy of the row with the given key as index if it exists, else, returns (nil, false)
 func (ht HtmlTable) GetRowByKey(key string) ([]string, int, bool) {
-	for idx, idxKey := range ht.Index {
-		if strings.EqualFold(idxKey, key) {
-			return function.GetFirstReturnElement(ht.GetRowByIndex(idx)).([]string), idx, true
-		}
+	if _, idx, ok := ht.ResolveRowKey(key); ok {
+		return function.GetFirstReturnElement(ht.GetRowByIndex(idx)).([]string), idx, true
 	}
 	return nil, -1, false
 }
@@ -323,10 +804,8 @@ func (ht HtmlTable) GetColumnByIndex(j int) ([]string, string) {
 // GetColumnByKey
 // Analogous to GetRowByKey but for columns.
 func (ht HtmlTable) GetColumnByKey(key string) ([]string, int, bool) {
-	for idx, header := range ht.Headers {
-		if strings.EqualFold(header, key) {
-			return function.GetFirstReturnElement(ht.GetColumnByIndex(idx)).([]string), idx, true
-		}
+	if _, idx, ok := ht.ResolveColumnKey(key); ok {
+		return function.GetFirstReturnElement(ht.GetColumnByIndex(idx)).([]string), idx, true
 	}
 	return nil, -1, false
 }
@@ -337,7 +816,7 @@ func (ht HtmlTable) GetColumnByKeyNum(key string, occurrence int) ([]string, int
 	if occurrence == 0 {
 		return ht.GetColumnByKey(key)
 	}
-	return ht.GetColumnByKey(fmt.Sprintf("%v%v%v", key, ht.suffix, occurrence))
+	return ht.GetColumnByKey(ht.OccurrenceKey(key, occurrence))
 }
 
 // GetElementByIndex
@@ -363,13 +842,11 @@ func (ht HtmlTable) GetElementByIndex(i, j int) string {
 func (ht HtmlTable) GetElementByKeys(rowKey, columnKey string) (string, int, int, bool) {
 	row, i, ok := ht.getRowByKey(rowKey) // can use getRowByIndex without copy since we copy when using row access
 	if ok {
-		for idx, header := range ht.Headers {
-			if strings.EqualFold(header, columnKey) {
-				if idx == 0 {
-					return row[idx], i, idx, true
-				} else {
-					return row[idx-1], i, idx, true
-				}
+		if _, idx, ok := ht.ResolveColumnKey(columnKey); ok {
+			if idx == 0 {
+				return row[idx], i, idx, true
+			} else {
+				return row[idx-1], i, idx, true
 			}
 		}
 	}
@@ -380,13 +857,185 @@ func (ht HtmlTable) GetElementByKeys(rowKey, columnKey string) (string, int, int
 // Returns the element in table data with the provided row key and column key and the corresponding occurrences.
 // returns "", false if at least one key is missing.
 func (ht HtmlTable) GetElementByKeysNum(rowKey, columnKey string, rowOccurrence, columnOccurrence int) (string, int, int, bool) {
-	if rowOccurrence != 0 {
-		rowKey = fmt.Sprintf("%v%v%v", rowKey, ht.suffix, rowOccurrence)
+	return ht.GetElementByKeys(ht.OccurrenceKey(rowKey, rowOccurrence), ht.OccurrenceKey(columnKey, columnOccurrence))
+}
+
+// OccurrenceKey
+// Returns the key of the occurrence-th repetition of key in Headers or Index, i.e., key itself for occurrence 0, else,
+// key followed by the suffix of the table and occurrence, e.g., "Name_2" for suffix "_", see ParseHtmlTableWithOptions.
+func (ht HtmlTable) OccurrenceKey(key string, occurrence int) string {
+	if occurrence == 0 {
+		return key
+	}
+	return fmt.Sprintf("%v%v%v", key, ht.suffix, occurrence)
+}
+
+// SetColumnAliases
+// Configures alternative headers for column keys, e.g., {"Quantity": {"Qty", "Amount"}} for a renamed header.
+// If a key passed to GetColumnByKey, GetElementByKeys, and the other key based lookups is not a header, its aliases are
+// tried in order. A real header always takes precedence over an alias. Keys are compared case-insensitively.
+// Replaces previously configured column aliases, nil removes them.
+func (ht *HtmlTable) SetColumnAliases(aliases map[string][]string) {
+	ht.columnAliases = makeAliasMap(aliases)
+}
+
+// SetIndexAliases
+// Same as SetColumnAliases but for the row keys of GetRowByKey and the other key based lookups.
+func (ht *HtmlTable) SetIndexAliases(aliases map[string][]string) {
+	ht.indexAliases = makeAliasMap(aliases)
+}
+
+// makeAliasMap
+// Returns a copy of aliases with lower case keys, or nil if aliases is empty. The alternatives of keys which only differ
+// in case are concatenated in the order of the keys.
+func makeAliasMap(aliases map[string][]string) map[string][]string {
+	if len(aliases) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(aliases))
+	for key := range aliases {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	aliasMap := make(map[string][]string, len(aliases))
+	for _, key := range keys {
+		lowerKey := strings.ToLower(key)
+		aliasMap[lowerKey] = append(aliasMap[lowerKey], aliases[key]...)
+	}
+	return aliasMap
+}
+
+// resolveKey
+// Returns the first of keys equal to key (compared with equal), or else the first one equal to one of the aliases of
+// key in order. The aliases of key are those of all alias keys equal to key, in the order of the alias keys, so
+// normalized lookups find the aliases of a key in any spelling, e.g., "Qty:" those of "qty".
+// Returns the matched key and its position.
+func resolveKey(keys []string, key string, aliases map[string][]string, equal func(a, b string) bool) (string, int, bool) {
+	candidates := []string{key}
+	if len(aliases) > 0 {
+		aliasKeys := make([]string, 0, len(aliases))
+		for aliasKey := range aliases {
+			if equal(aliasKey, key) {
+				aliasKeys = append(aliasKeys, aliasKey)
+			}
+		}
+		sort.Strings(aliasKeys)
+		for _, aliasKey := range aliasKeys {
+			candidates = append(candidates, aliases[aliasKey]...)
+		}
+	}
+	for _, candidate := range candidates {
+		for idx, k := range keys {
+			if equal(k, candidate) {
+				return k, idx, true
+			}
+		}
+	}
+	return "", -1, false
+}
+
+// ResolveColumnKey
+// Returns the header which key matches, directly or via an alias (see SetColumnAliases), and its column index.
+func (ht HtmlTable) ResolveColumnKey(key string) (string, int, bool) {
+	return resolveKey(ht.Headers, key, ht.columnAliases, strings.EqualFold)
+}
+
+// ResolveRowKey
+// Returns the index key which key matches, directly or via an alias (see SetIndexAliases), and its row index.
+func (ht HtmlTable) ResolveRowKey(key string) (string, int, bool) {
+	return resolveKey(ht.Index, key, ht.indexAliases, strings.EqualFold)
+}
+
+// HasRealHeaders
+// Returns whether Headers were parsed from the table's header row.
+// If false, Headers are artificial, i.e., (Index\Header 1 2 3 ...), and exporters may drop them.
+func (ht HtmlTable) HasRealHeaders() bool {
+	return ht.headerSource != KeySourceArtificial
+}
+
+// HasRealIndex
+// Returns whether Index was parsed from the table's index column.
+// If false, Index is artificial, i.e., (Index\Header 1 2 3 ...), and exporters may drop it.
+func (ht HtmlTable) HasRealIndex() bool {
+	return ht.indexSource != KeySourceArtificial
+}
+
+// HeaderSource
+// Returns where Headers come from, see KeySource.
+func (ht HtmlTable) HeaderSource() KeySource {
+	return ht.headerSource
+}
+
+// IndexSource
+// Returns where Index comes from, see KeySource.
+func (ht HtmlTable) IndexSource() KeySource {
+	return ht.indexSource
+}
+
+// ArtificialColumns
+// Returns the columns j (see GetColumnByIndex) whose values were generated instead of parsed, i.e., [0] if Index is
+// artificial and nil otherwise, e.g., for exporters deciding which columns to emit.
+func (ht HtmlTable) ArtificialColumns() []int {
+	if ht.indexSource == KeySourceArtificial && len(ht.Headers) > 0 {
+		return []int{0}
 	}
-	if columnOccurrence != 0 {
-		columnKey = fmt.Sprintf("%v%v%v", columnKey, ht.suffix, columnOccurrence)
+	return nil
+}
+
+// ArtificialRows
+// Returns the rows i (see GetRowByIndex) whose values were generated instead of parsed, i.e., [0] if Headers are
+// artificial and nil otherwise.
+func (ht HtmlTable) ArtificialRows() []int {
+	if ht.headerSource == KeySourceArtificial && len(ht.Index) > 0 {
+		return []int{0}
 	}
-	return ht.GetElementByKeys(rowKey, columnKey)
+	return nil
+}
+
+// RowHeaders
+// Returns whether Index was taken from the first column because most rows start with a row header, see
+// TableParseOptions.DetectRowHeaders, and if so, the rows i (see GetElementByIndex) whose first cell is not a row
+// header, e.g., a totals row using <td>, so their index values may be data values.
+func (ht HtmlTable) RowHeaders() (bool, []int) {
+	return ht.rowHeaders, append([]int(nil), ht.rowHeaderGaps...)
+}
+
+// DroppedHeaderRows
+// Returns the 0-based positions among all parsed rows of the rows which were skipped as repeated header rows, see
+// TableParseOptions.DropRepeatedHeaderRows, the header row itself has position 0.
+func (ht HtmlTable) DroppedHeaderRows() []int {
+	return append([]int(nil), ht.droppedRows...)
+}
+
+// GetCellProvenance
+// Returns the source cell of the value at row i and column j, see GetElementByIndex for the indexing.
+// Returns false if provenance was not recorded during parsing or if the value has no source cell, e.g., artificial
+// headers and indices, or cells padded to fill ragged rows.
+// Multiple positions may share the same source cell if spans were expanded.
+func (ht HtmlTable) GetCellProvenance(i, j int) (CellProvenance, bool) {
+	prov, ok := ht.provenance[[2]int{i, j}]
+	return prov, ok
+}
+
+// GetRawElementByIndex
+// Returns the text of the cell at row i and column j before normalization, see GetElementByIndex for the indexing.
+// Returns false if raw texts were not recorded during parsing, see TableParseOptions.RecordRawText, or if the value has
+// no source cell, e.g., artificial headers and indices, or cells padded to fill ragged rows.
+func (ht HtmlTable) GetRawElementByIndex(i, j int) (string, bool) {
+	raw, ok := ht.rawText[[2]int{i, j}]
+	return raw, ok
+}
+
+// IsSpanOrigin
+// Returns whether the cell at row i and column j is the top-left cell of a (possibly merged) source cell, i.e., false
+// for positions which were only filled in because of a colspan or rowspan, and for artificial headers and indices.
+// If Spans were not recorded during parsing, every cell is considered an origin.
+func (ht HtmlTable) IsSpanOrigin(i, j int) bool {
+	if ht.Spans == nil {
+		return true
+	}
+	_, ok := ht.Spans[[2]int{i, j}]
+	return ok
 }
 
 // ParseHtmlTable
@@ -407,38 +1056,415 @@ func ParseHtmlTable(tableNode *html.Node, hasHeaderRow bool, hasIndexColumn bool
 // Content is set after normalizing with normalizerFunc
 // we append '{suffix}_{keyCount}' to keys which appear multiple times to make them unique.
 // the first occurrence does not have this.
-// TODO: describe the meaning of allowCompositeTexts and compositeDelimiter parameters
+// See TableParseOptions for the meaning of allowCompositeTexts and compositeDelimiter.
 func ParseHtmlTableWithNormalizer(tableNode *html.Node, hasHeaderRow bool, hasIndexColumn bool, suffix string, normalizerFunc func(string) string, allowCompositeTexts bool, compositeDelimiter string) (*HtmlTable, error) {
+	return ParseHtmlTableWithOptions(tableNode, TableParseOptions{
+		HasHeaderRow:        hasHeaderRow,
+		HasIndexColumn:      hasIndexColumn,
+		Suffix:              suffix,
+		NormalizerFunc:      normalizerFunc,
+		AllowCompositeTexts: allowCompositeTexts,
+		CompositeDelimiter:  compositeDelimiter,
+	})
+}
+
+// TableParseOptions
+// Configures ParseHtmlTableWithOptions.
+// The zero value parses a table without header row and index column, and with identity normalizer.
+type TableParseOptions struct {
+	HasHeaderRow           bool                                // use the first row as Headers, else, artificial headers (Index 1 2 3 ...) are generated
+	HasIndexColumn         bool                                // use the first column as Index, else, an artificial index (Index 1 2 3 ...) is generated
+	Suffix                 string                              // suffix for recurring keys, see slices.MakeUniqueStringSlice
+	NormalizerFunc         func(string) string                 // used to normalize all texts, identity if nil
+	AllowCompositeTexts    bool                                // if true, a cell's text is the composite of all its content texts instead of only the first one
+	CompositeDelimiter     string                              // delimiter between the texts of a composite text
+	ExpandSpans            bool                                // repeat the value of cells with colspan/rowspan over all positions they cover
+	RecordSpans            bool                                // record the span structure in HtmlTable.Spans, implies ExpandSpans
+	RecordProvenance       bool                                // record the source cell of each value, see HtmlTable.GetCellProvenance
+	RecordColumnMeta       bool                                // record alignment and width hints of each column, see HtmlTable.ColumnMeta
+	KeepEmptyRows          bool                                // map each <tr> of the table itself (not of nested tables) to one row, rows containing nested tables become empty
+	RecordRawText          bool                                // record the text of each cell before normalization, see HtmlTable.GetRawElementByIndex
+	NormalizeGenerated     bool                                // also normalize generated keys, i.e., artificial headers and indices and the TopLeftPlaceholder
+	MatchCaption           string                              // when locating tables, e.g., ParseFirstHtmlTableFromReader, only use tables with this caption
+	MatchHeaders           []string                            // when locating tables, e.g., ParseFirstHtmlTableFromReader, only use tables with these headers
+	MinDataTableConfidence float64                             // when locating tables, e.g., ParseAllHtmlTables, skip tables with a lower IsDataTable score
+	StrictTableNode        bool                                // only accept <table> nodes in ParseHtmlTableWithOptions, not <tbody>, <thead>, or <tfoot>
+	ColumnNormalizers      map[string]func(string) string      // normalizers of the data cells of single columns keyed by header, replacing NormalizerFunc, keys must differ after NormalizeKey
+	ColumnIndexNormalizers map[int]func(string) string         // same as ColumnNormalizers but keyed by column index, e.g., for tables without header row
+	LineBreakDelimiter     string                              // if not "", composite texts separated by a <br> are joined by it instead of CompositeDelimiter
+	UseAltText             bool                                // cells without content text yield the aria-label or alt texts of their elements, e.g., of flag icons
+	CellTextFunc           func(cell *html.Node) string        // replaces the built-in cell text extraction if not nil, the result is still normalized
+	ContentTextFunc        func(s string) bool                 // decides which texts of a cell count as content, the package-level ContentTextFunc if nil
+	OnRow                  func(rowIndex, totalRows int) error // called after the texts of each source row were extracted, a non-nil error aborts parsing
+	MaxCells               int                                 // abort parsing with ErrTableTooLarge as soon as rows times columns exceed it, also while expanding spans, unlimited (but see MaxExpandedCells) if 0
+	DetectRowHeaders       bool                                // use the first column as Index if most body rows start with a <th>, e.g., <th scope="row">, regardless of HasIndexColumn
+	DropRepeatedHeaderRows bool                                // skip body rows whose cells equal the header row except for the index cell, see HtmlTable.DroppedHeaderRows
+	Instrumentation        Instrumentation                     // receives the parse as operation "ParseHtmlTable", visiting all nodes of the table
+}
+
+// CellProvenance
+// Describes the source cell a value of an HtmlTable was parsed from.
+type CellProvenance struct {
+	Node         *html.Node // the <td> or <th> element
+	SourceRow    int        // 0-based position of the cell's row among all rows of the table
+	SourceColumn int        // 0-based position of the cell among the cells of its row, not counting spans
+	Path         string     // path of the cell, see GetNodePath
+}
+
+// CellSpan
+// Describes the merged region of a source cell of a table, see HtmlTable.Spans.
+type CellSpan struct {
+	RowSpan      int  // number of rows covered by the cell
+	ColSpan      int  // number of columns covered by the cell
+	IsHeaderCell bool // whether the cell is a <th> element
+}
+
+// normalizer
+// Returns NormalizerFunc or the identity if it is nil.
+func (opts TableParseOptions) normalizer() func(string) string {
+	if opts.NormalizerFunc == nil {
+		return func(s string) string {
+			return s
+		}
+	}
+	return opts.NormalizerFunc
+}
+
+// cellText
+// Returns the normalized (composite) text of a table cell, or "" if the cell is nil or has no content text.
+func (opts TableParseOptions) cellText(cell *html.Node) string {
+	return opts.cellTextWithNormalizer(cell, opts.normalizer())
+}
+
+// rawCellText
+// Same as cellText but without normalization.
+func (opts TableParseOptions) rawCellText(cell *html.Node) string {
+	return opts.cellTextWithNormalizer(cell, func(s string) string {
+		return s
+	})
+}
+
+// cellTextWithNormalizer
+// Returns the (composite) text of a table cell normalized by normalizerFunc, or "" if the cell is nil or has no content
+// text.
+func (opts TableParseOptions) cellTextWithNormalizer(cell *html.Node, normalizerFunc func(string) string) string {
+	if cell == nil {
+		return ""
+	}
+	if opts.CellTextFunc != nil {
+		return normalizerFunc(opts.CellTextFunc(cell))
+	}
+	isContent := contentTextFunc(opts.ContentTextFunc)
+	if !opts.AllowCompositeTexts {
+		// Single Texts
+		text := GetFirstTextNodeWithCondition(cell, isContent)
+		if text != nil {
+			return normalizerFunc(text.Data)
+		}
+	} else if opts.LineBreakDelimiter != "" {
+		if text, ok := makeCellTextWithLineBreaks(cell, opts.CompositeDelimiter, opts.LineBreakDelimiter, normalizerFunc, isContent); ok {
+			return text
+		}
+	} else {
+		texts := GetTextNodesByCondition(cell, isContent)
+		if len(texts) > 0 {
+			return MakeTextNodeCompositeWithNormalizerFunc(texts, opts.CompositeDelimiter, normalizerFunc)
+		}
+	}
+	if !opts.UseAltText {
+		return ""
+	}
+	altTexts := getAltTexts(cell)
+	if len(altTexts) == 0 {
+		return ""
+	}
+	if !opts.AllowCompositeTexts {
+		return normalizerFunc(altTexts[0])
+	}
+	for i := range altTexts {
+		altTexts[i] = normalizerFunc(altTexts[i])
+	}
+	return strings.Join(altTexts, opts.CompositeDelimiter)
+}
+
+// makeCellTextWithLineBreaks
+// Same as MakeTextNodeCompositeWithNormalizerFunc for the content texts of cell, but texts separated by a <br> element
+// are joined by lineBreakDelimiter instead of compositeDelimiter. ok is false if cell has no content text.
+func makeCellTextWithLineBreaks(cell *html.Node, compositeDelimiter, lineBreakDelimiter string, normalizerFunc func(string) string, isContent func(s string) bool) (text string, ok bool) {
+	var sb strings.Builder
+	lineBreak := false
+	WalkHtmlTreeInclusive(cell, func(n *html.Node) bool {
+		switch {
+		case n.Type == html.ElementNode && n.Data == "br":
+			lineBreak = ok
+		case n.Type == html.TextNode && isContent(n.Data):
+			if lineBreak {
+				sb.WriteString(lineBreakDelimiter)
+			} else if ok {
+				sb.WriteString(compositeDelimiter)
+			}
+			sb.WriteString(normalizerFunc(n.Data))
+			ok, lineBreak = true, false
+		}
+		return true
+	})
+	return sb.String(), ok
+}
+
+// getAltTexts
+// Returns the non-blank aria-label attributes of the elements in the tree of node and the alt attributes of its
+// <img>, <area>, and <input> elements in document order. The subtree of an element with aria-label is skipped.
+func getAltTexts(node *html.Node) []string {
+	var texts []string
+	WalkHtmlTreeInclusive(node, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if ariaLabel, err := GetAttributeByKey(n, "aria-label"); err == nil && !IsBlank(ariaLabel.Val) {
+			texts = append(texts, TrimBlank(ariaLabel.Val))
+			return false
+		}
+		if n.Data == "img" || n.Data == "area" || n.Data == "input" {
+			if alt, err := GetAttributeByKey(n, "alt"); err == nil && !IsBlank(alt.Val) {
+				texts = append(texts, TrimBlank(alt.Val))
+			}
+		}
+		return true
+	})
+	return texts
+}
+
+// columnNormalizer
+// Returns the normalizer of the data cells of column j with the given header, see GetColumnByIndex: the
+// ColumnNormalizers entry whose key equals header (compared via NormalizeKey), the ColumnIndexNormalizers entry of j, or
+// the global normalizer, in that order.
+func (opts TableParseOptions) columnNormalizer(header string, j int) func(string) string {
+	normalizedHeader := NormalizeKey(header)
+	for key, normalizerFunc := range opts.ColumnNormalizers {
+		if NormalizeKey(key) == normalizedHeader {
+			return normalizerFunc
+		}
+	}
+	if normalizerFunc, ok := opts.ColumnIndexNormalizers[j]; ok {
+		return normalizerFunc
+	}
+	return opts.normalizer()
+}
+
+// checkColumnNormalizerKeys
+// Returns an error if two keys of normalizers are equal after NormalizeKey, e.g., "Price" and "price ", since it would
+// be undefined which one applies to the column, see columnNormalizer.
+func checkColumnNormalizerKeys(normalizers map[string]func(string) string) error {
+	keys := make([]string, 0, len(normalizers))
+	for key := range normalizers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // report the same pair on every call
+	seen := make(map[string]string, len(keys))
+	for _, key := range keys {
+		normalizedKey := NormalizeKey(key)
+		if other, ok := seen[normalizedKey]; ok {
+			return fmt.Errorf("invalid argument: the column normalizer keys '%v' and '%v' refer to the same column", other, key)
+		}
+		seen[normalizedKey] = key
+	}
+	return nil
+}
+
+// generatedKey
+// Returns key, normalized if NormalizeGenerated is set.
+func (opts TableParseOptions) generatedKey(key string) string {
+	if opts.NormalizeGenerated {
+		return opts.normalizer()(key)
+	}
+	return key
+}
+
+// ParseHtmlTableWithOptions
+// Parses a given html.Node which should point to a <table> ElementNode in a html tree to an HtmlTable Struct which
+// can be used to easily look up existing indices, headers, and values.
+// <tbody>, <thead>, and <tfoot> nodes are accepted as well, e.g., for fragments returned by AJAX endpoints, unless
+// TableParseOptions.StrictTableNode is set. See TableParseOptions for the other options.
+// Normalization happens in this order: the header row and the index column are normalized with the global
+// NormalizerFunc and the keys are resolved, then the normalizer of each data column is looked up by its resolved header
+// (see TableParseOptions.ColumnNormalizers) and applied to its data cells.
+// Rows are collected in document order regardless of the elements between them and the table, i.e., implicit <tbody>
+// elements inserted by html.Parse as well as wrappers like <form> or <center> around rows are transparent. Content
+// which html.Parse moves out of the table, e.g., stray text directly under <table>, is placed before the table and is
+// therefore never attributed to a cell, and text outside of <td> and <th> elements in trees not created by html.Parse
+// is ignored as well.
+// Malformed input, e.g., ragged rows, rows without cells, or arbitrarily deep nesting, yields an error or a best-effort
+// table but never a panic. Spans expanding to more than MaxExpandedCells cells yield ErrTableTooLarge.
+func ParseHtmlTableWithOptions(tableNode *html.Node, opts TableParseOptions) (*HtmlTable, error) {
 	// first assert we are a tableNode
 	if tableNode == nil {
 		return nil, errors.New("node is nil")
 	}
-	if !(tableNode.Type == html.ElementNode && tableNode.Data == "table") {
+	if tableNode.Type != html.ElementNode || !(tableNode.Data == "table" || (!opts.StrictTableNode && isTableSection(tableNode.Data))) {
 		return nil, errors.New("node is not an table node")
 	}
 
+	if timer := startOperation(opts.Instrumentation, "ParseHtmlTable"); timer.enabled() {
+		defer timer.end(countSubtreeNodes(tableNode) + 1)
+	}
+
 	// get all row and columns to get TableData size
-	rows := GetNodesByCondition(tableNode, func(node *html.Node) bool {
-		return node.Type == html.ElementNode && node.Data == "tr" && GetNextNodeByCondition(node, MakeByTagNameCondition("tr")) == nil
-	})
+	var rows []*html.Node
+	var skippedRows []int
+	ownRows := getOwnTableRows(tableNode)
+	if opts.KeepEmptyRows {
+		rows = ownRows
+	} else {
+		rows = getTableRows(tableNode)
+		for r, row := range ownRows {
+			if hasNestedRows(row) {
+				skippedRows = append(skippedRows, r)
+			}
+		}
+	}
+	return parseHtmlTableFromRows(getEnclosingTable(tableNode), rows, skippedRows, opts)
+}
+
+// ParseHtmlTableRows
+// Same as ParseHtmlTableWithOptions but parses a run of <tr> elements, e.g., rows returned by an AJAX endpoint,
+// without requiring a surrounding table. Rows containing nested rows are skipped unless TableParseOptions.KeepEmptyRows
+// is set, their innermost rows are parsed instead.
+// Returns an error if a node is not a <tr> element.
+func ParseHtmlTableRows(rows []*html.Node, opts TableParseOptions) (*HtmlTable, error) {
+	for _, row := range rows {
+		if row == nil {
+			return nil, errors.New("node is nil")
+		}
+		if !(row.Type == html.ElementNode && row.Data == "tr") {
+			return nil, errors.New("node is not a table row node")
+		}
+	}
+
+	var parsedRows []*html.Node
+	var skippedRows []int
+	if opts.KeepEmptyRows {
+		parsedRows = rows
+	} else {
+		for r, row := range rows {
+			if hasNestedRows(row) {
+				skippedRows = append(skippedRows, r)
+				parsedRows = append(parsedRows, getTableRows(row)...)
+			} else {
+				parsedRows = append(parsedRows, row)
+			}
+		}
+	}
+
+	var tableNode *html.Node
+	if len(rows) > 0 {
+		tableNode = getEnclosingTable(rows[0])
+	}
+	return parseHtmlTableFromRows(tableNode, parsedRows, skippedRows, opts)
+}
+
+// isTableSection
+// Returns true for the tags of the row groups of a table.
+func isTableSection(tag string) bool {
+	return tag == "tbody" || tag == "thead" || tag == "tfoot"
+}
+
+// getEnclosingTable
+// Returns node if it is a <table> element, else, its closest <table> ancestor, or nil if there is none.
+func getEnclosingTable(node *html.Node) *html.Node {
+	for n := node; n != nil; n = n.Parent {
+		if n.Type == html.ElementNode && n.Data == "table" {
+			return n
+		}
+	}
+	return nil
+}
+
+// parseHtmlTableFromRows
+// Parses the collected rows of a table, see ParseHtmlTableWithOptions. tableNode is the enclosing <table> element, if
+// any, and is only used for column hints. skippedRows are the positions of rows which were skipped during collection.
+func parseHtmlTableFromRows(tableNode *html.Node, rows []*html.Node, skippedRows []int, opts TableParseOptions) (*HtmlTable, error) {
+	normalizerFunc := opts.normalizer()
+	hasHeaderRow := opts.HasHeaderRow
+	hasIndexColumn := opts.HasIndexColumn
+	suffix := opts.Suffix
+
+	if err := checkColumnNormalizerKeys(opts.ColumnNormalizers); err != nil {
+		return nil, err
+	}
 	if len(rows) == 0 {
-		return &HtmlTable{}, nil
+		return &HtmlTable{SkippedRows: skippedRows}, nil
+	}
+
+	headerSource := KeySourceArtificial
+	if hasHeaderRow {
+		headerSource = KeySourceFirstRow
+		if rows[0].Parent != nil && rows[0].Parent.Type == html.ElementNode && rows[0].Parent.Data == "thead" {
+			headerSource = KeySourceTHead
+		}
 	}
 
-	maxRows := len(rows)
-	maxColumns := 0
 	var rawTableData [][]*html.Node
+	cellCount := 0
 	// get all columns
 	for _, row := range rows {
-		cols := GetNodesByCondition(row, func(node *html.Node) bool {
-			return node.Type == html.ElementNode && ((node.Data == "td" && GetNextNodeByCondition(node, MakeByTagNameCondition("td")) == nil) ||
-				(node.Data == "th" && GetNextNodeByCondition(node, MakeByTagNameCondition("th")) == nil))
-		})
-		rawTableData = append(rawTableData, cols)
+		if opts.KeepEmptyRows && hasNestedRows(row) {
+			rawTableData = append(rawTableData, nil) // unparseable row, represented as all-empty
+			continue
+		}
+		cells := getRowCells(row)
+		rawTableData = append(rawTableData, cells)
+		if opts.MaxCells > 0 {
+			if cellCount += len(cells); cellCount > opts.MaxCells {
+				return nil, fmt.Errorf("%w: more than %v cells", ErrTableTooLarge, opts.MaxCells)
+			}
+		}
+	}
+
+	// remember the source position of each cell before spans are expanded
+	var sourcePositions map[*html.Node][2]int
+	if opts.RecordProvenance {
+		sourcePositions = make(map[*html.Node][2]int)
+		for r, cols := range rawTableData {
+			for c, cell := range cols {
+				sourcePositions[cell] = [2]int{r, c}
+			}
+		}
+	}
+
+	var rawSpans map[[2]int]CellSpan
+	if opts.ExpandSpans || opts.RecordSpans {
+		var err error
+		if rawTableData, rawSpans, err = expandTableSpans(rawTableData, opts.MaxCells); err != nil {
+			return nil, err
+		}
+	}
+
+	maxRows := len(rawTableData)
+	maxColumns := 0
+	for _, cols := range rawTableData {
 		if len(cols) > maxColumns {
 			maxColumns = len(cols)
 		}
 	}
+	// without span expansion the rows were not bounded yet, with it, this check is a no-op
+	if opts.MaxCells > 0 && maxRows*maxColumns > opts.MaxCells {
+		return nil, fmt.Errorf("%w: %v rows and %v columns exceed %v cells", ErrTableTooLarge, maxRows, maxColumns, opts.MaxCells)
+	}
+
+	var rowHeaders bool
+	var rowHeaderGaps []int
+	indexSource := KeySourceArtificial
+	if hasIndexColumn {
+		indexSource = KeySourceFirstColumn
+	}
+	if opts.DetectRowHeaders {
+		if rowHeaders, rowHeaderGaps = detectRowHeaders(rawTableData, hasHeaderRow); rowHeaders {
+			hasIndexColumn = true
+			indexSource = KeySourceRowHeaders
+		} else {
+			rowHeaderGaps = nil
+		}
+	}
 
 	hasHeader := 1
 	hasIndex := 1
@@ -448,42 +1474,34 @@ func ParseHtmlTableWithNormalizer(tableNode *html.Node, hasHeaderRow bool, hasIn
 	if !hasHeaderRow {
 		hasHeader = 0
 	}
-
-	const topLeft = "Index\\Header"
+	if maxColumns < hasIndex {
+		maxColumns = hasIndex // rows without any cell still have an (empty) index
+	}
 
 	// set headers
 	var headers []string
+	var droppedRows []int
 	if hasHeaderRow {
 		headers = make([]string, maxColumns+1-hasIndex)
 
 		if !hasIndexColumn {
-			headers[0] = topLeft
+			headers[0] = opts.generatedKey(TopLeftPlaceholder)
 		}
 
-		// Single Texts
-		if !allowCompositeTexts {
-			// set header values
-			for j, h := range rawTableData[0] {
-				hText := GetFirstTextNodeWithCondition(h, func(s string) bool {
-					return len(TextRegex.ReplaceAllString(s, "")) > 0
-				})
-				if hText != nil {
-					headers[j+1-hasIndex] = normalizerFunc(hText.Data)
-				} else {
-					headers[j+1-hasIndex] = ""
-				}
+		// set header values
+		for j, h := range rawTableData[0] {
+			headers[j+1-hasIndex] = opts.cellText(h)
+		}
+		if opts.DropRepeatedHeaderRows {
+			rawTableData, rawSpans, droppedRows = dropRepeatedHeaderRows(rawTableData, rawSpans, headers, hasIndex, opts)
+			maxRows = len(rawTableData)
+			if rowHeaders && len(droppedRows) > 0 {
+				_, rowHeaderGaps = detectRowHeaders(rawTableData, hasHeaderRow) // rows moved up
 			}
-		} else {
-			// set header values for multiple texts
-			for j, h := range rawTableData[0] {
-				hTexts := GetTextNodesByCondition(h, func(s string) bool {
-					return len(TextRegex.ReplaceAllString(s, "")) > 0
-				})
-				if hTexts != nil {
-					headers[j+1-hasIndex] = MakeTextNodeCompositeWithNormalizerFunc(hTexts, compositeDelimiter, normalizerFunc)
-				} else {
-					headers[j+1-hasIndex] = ""
-				}
+		}
+		if opts.OnRow != nil {
+			if err := opts.OnRow(0, maxRows); err != nil {
+				return nil, fmt.Errorf("table parsing aborted at row 0: %w", err)
 			}
 		}
 	} else {
@@ -491,10 +1509,10 @@ func ParseHtmlTableWithNormalizer(tableNode *html.Node, hasHeaderRow bool, hasIn
 		headers = make([]string, maxColumns+1-hasIndex)
 
 		// Add index column header
-		headers[0] = topLeft
+		headers[0] = opts.generatedKey(TopLeftPlaceholder)
 
 		for j := 1; j < len(headers); j++ {
-			headers[j] = strconv.Itoa(j)
+			headers[j] = opts.generatedKey(strconv.Itoa(j))
 		}
 	}
 
@@ -504,47 +1522,31 @@ func ParseHtmlTableWithNormalizer(tableNode *html.Node, hasHeaderRow bool, hasIn
 		index = make([]string, maxRows+1-hasHeader)
 
 		if !hasHeaderRow {
-			index[0] = topLeft
-		}
-
-		if !allowCompositeTexts {
-			// Single Texts
-			// set index values
-			for i, idxRow := range rawTableData {
-				if len(idxRow) > 0 {
-					iText := GetFirstTextNodeWithCondition(idxRow[0], func(s string) bool {
-						return len(TextRegex.ReplaceAllString(s, "")) > 0
-					})
-					if iText != nil {
-						index[i+1-hasHeader] = normalizerFunc(iText.Data)
-					} else {
-						index[i+1-hasHeader] = ""
-					}
-				}
-			}
-		} else {
-			// set index values
-			for i, idxRow := range rawTableData {
-				if len(idxRow) > 0 {
-					iTexts := GetTextNodesByCondition(idxRow[0], func(s string) bool {
-						return len(TextRegex.ReplaceAllString(s, "")) > 0
-					})
-					if iTexts != nil {
-						index[i+1-hasHeader] = MakeTextNodeCompositeWithNormalizerFunc(iTexts, compositeDelimiter, normalizerFunc)
-					} else {
-						index[i+1-hasHeader] = ""
-					}
-				}
+			index[0] = opts.generatedKey(TopLeftPlaceholder)
+		}
+
+		// set index values
+		for i, idxRow := range rawTableData {
+			if len(idxRow) > 0 {
+				index[i+1-hasHeader] = opts.cellText(idxRow[0])
 			}
 		}
 	} else {
 		hasIndex = 0
 		index = make([]string, maxRows+1-hasHeader)
-		index[0] = topLeft
+		index[0] = opts.generatedKey(TopLeftPlaceholder)
 		for i := 1; i < len(index); i++ {
-			index[i] = strconv.Itoa(i)
+			index[i] = opts.generatedKey(strconv.Itoa(i))
+		}
+	}
+
+	var raggedRows []int
+	for r, cols := range rawTableData {
+		if len(cols) < maxColumns {
+			raggedRows = append(raggedRows, r)
 		}
 	}
+	duplicateKeys := [2]int{countDuplicates(index), countDuplicates(headers)}
 
 	// make headers and index unique
 	headers, err := slices.MakeUniqueStringSlice(headers, suffix)
@@ -557,39 +1559,406 @@ func ParseHtmlTableWithNormalizer(tableNode *html.Node, hasHeaderRow bool, hasIn
 		return nil, err
 	}
 
+	// headers are resolved with the global normalizer, data cells with the normalizer of their column
+	columnNormalizers := make([]func(string) string, len(headers))
+	for j := 1; j < len(headers); j++ {
+		columnNormalizers[j] = opts.columnNormalizer(headers[j], j)
+	}
+
 	tableData := make([][]string, len(index)-1)
 	for i := 0; i < len(tableData); i++ {
 		tableData[i] = make([]string, len(headers)-1)
 		for j := 0; j < len(rawTableData[i+hasHeader])-hasIndex; j++ {
-			tdNode := rawTableData[i+hasHeader][j+hasIndex]
-
-			if !allowCompositeTexts {
-				// Single Texts
-				tdText := GetFirstTextNodeWithCondition(tdNode, func(s string) bool {
-					return len(TextRegex.ReplaceAllString(s, "")) > 0
-				})
-				if tdText != nil {
-					tableData[i][j] = normalizerFunc(tdText.Data)
+			tableData[i][j] = opts.cellTextWithNormalizer(rawTableData[i+hasHeader][j+hasIndex], columnNormalizers[j+1])
+		}
+		if opts.OnRow != nil {
+			if err := opts.OnRow(i+hasHeader, maxRows); err != nil {
+				return nil, fmt.Errorf("table parsing aborted at row %v: %w", i+hasHeader, err)
+			}
+		}
+	}
+
+	var spans map[[2]int]CellSpan
+	if opts.RecordSpans {
+		// translate source grid positions to table positions, see GetElementByIndex
+		spans = make(map[[2]int]CellSpan, len(rawSpans))
+		for pos, span := range rawSpans {
+			spans[[2]int{pos[0] + 1 - hasHeader, pos[1] + 1 - hasIndex}] = span
+		}
+	}
+
+	var provenance map[[2]int]CellProvenance
+	if opts.RecordProvenance {
+		provenance = make(map[[2]int]CellProvenance)
+		paths := newNodePathCache()
+		for r, cols := range rawTableData {
+			for c, cell := range cols {
+				if cell == nil {
+					continue // not covered by any source cell
 				}
-			} else {
-				tdTexts := GetTextNodesByCondition(tdNode, func(s string) bool {
-					return len(TextRegex.ReplaceAllString(s, "")) > 0
-				})
-				if tdTexts != nil {
-					tableData[i][j] = MakeTextNodeCompositeWithNormalizerFunc(tdTexts, compositeDelimiter, normalizerFunc)
+				sourcePos := sourcePositions[cell]
+				provenance[[2]int{r + 1 - hasHeader, c + 1 - hasIndex}] = CellProvenance{
+					Node:         cell,
+					SourceRow:    sourcePos[0],
+					SourceColumn: sourcePos[1],
+					Path:         paths.path(cell),
 				}
 			}
 		}
 	}
 
+	var rawText map[[2]int]string
+	if opts.RecordRawText {
+		rawText = make(map[[2]int]string)
+		for r, cols := range rawTableData {
+			for c, cell := range cols {
+				if cell != nil {
+					rawText[[2]int{r + 1 - hasHeader, c + 1 - hasIndex}] = opts.rawCellText(cell)
+				}
+			}
+		}
+	}
+
+	var columnMeta map[int]ColumnMeta
+	if opts.RecordColumnMeta {
+		columnMeta = make(map[int]ColumnMeta)
+		for c, meta := range makeColumnMeta(tableNode, rawTableData, hasHeader) {
+			columnMeta[c+1-hasIndex] = meta
+		}
+	}
+
 	return &HtmlTable{
-		Headers:   headers,
-		Index:     index,
-		TableData: tableData,
-		suffix:    suffix,
+		Headers:        headers,
+		Index:          index,
+		TableData:      tableData,
+		Spans:          spans,
+		SkippedRows:    skippedRows,
+		provenance:     provenance,
+		columnMeta:     columnMeta,
+		rawText:        rawText,
+		raggedRows:     raggedRows,
+		duplicateKeys:  duplicateKeys,
+		rowHeaders:     rowHeaders,
+		rowHeaderGaps:  rowHeaderGaps,
+		droppedRows:    droppedRows,
+		normalizerFunc: normalizerFunc,
+		suffix:         suffix,
+		headerSource:   headerSource,
+		indexSource:    indexSource,
 	}, nil
 }
 
+// ParseHtmlTableHeaders
+// Resolves only the Headers ParseHtmlTableWithOptions would yield for tableNode and opts without parsing the remaining
+// rows, e.g., to cheaply discover tables by their headers.
+// The header row is the first row of the table, i.e., the first <thead> row if present.
+// If the body rows have more cells than the header row, the Headers of the full parse are longer.
+// If opts.HasHeaderRow is false, the artificial headers are generated, which only requires counting cells.
+// If opts.DetectRowHeaders is set, the first cells of all rows are inspected as well.
+func ParseHtmlTableHeaders(tableNode *html.Node, opts TableParseOptions) ([]string, error) {
+	if tableNode == nil {
+		return nil, errors.New("node is nil")
+	}
+	if tableNode.Type != html.ElementNode || !(tableNode.Data == "table" || (!opts.StrictTableNode && isTableSection(tableNode.Data))) {
+		return nil, errors.New("node is not an table node")
+	}
+	if opts.DetectRowHeaders && !opts.HasIndexColumn {
+		var rawTableData [][]*html.Node
+		for _, row := range getTableRows(tableNode) {
+			rawTableData = append(rawTableData, getRowCells(row))
+		}
+		if opts.ExpandSpans || opts.RecordSpans {
+			var err error
+			if rawTableData, _, err = expandTableSpans(rawTableData, opts.MaxCells); err != nil {
+				return nil, err
+			}
+		}
+		opts.HasIndexColumn, _ = detectRowHeaders(rawTableData, opts.HasHeaderRow)
+	}
+
+	var headers []string
+	if opts.HasHeaderRow {
+		var err error
+		if headers, err = getTableHeaderTexts(tableNode, opts); err != nil {
+			return nil, err
+		}
+		if headers == nil {
+			return nil, nil
+		}
+		if !opts.HasIndexColumn {
+			headers = append([]string{opts.generatedKey(TopLeftPlaceholder)}, headers...)
+		}
+	} else {
+		var rawTableData [][]*html.Node
+		for _, row := range getTableRows(tableNode) {
+			rawTableData = append(rawTableData, getRowCells(row))
+		}
+		if len(rawTableData) == 0 {
+			return nil, nil
+		}
+		if opts.ExpandSpans || opts.RecordSpans {
+			var err error
+			if rawTableData, _, err = expandTableSpans(rawTableData, opts.MaxCells); err != nil {
+				return nil, err
+			}
+		}
+		maxColumns := 0
+		for _, cols := range rawTableData {
+			if len(cols) > maxColumns {
+				maxColumns = len(cols)
+			}
+		}
+		hasIndex := 0
+		if opts.HasIndexColumn {
+			hasIndex = 1
+		}
+		if maxColumns < hasIndex {
+			maxColumns = hasIndex
+		}
+		headers = make([]string, maxColumns+1-hasIndex)
+		headers[0] = opts.generatedKey(TopLeftPlaceholder)
+		for j := 1; j < len(headers); j++ {
+			headers[j] = opts.generatedKey(strconv.Itoa(j))
+		}
+	}
+
+	return slices.MakeUniqueStringSlice(headers, opts.Suffix)
+}
+
+// dropRepeatedHeaderRows
+// Removes the rows of rawTableData below the header row whose cells yield the same texts as headers, except for the
+// index cell, and moves the spans of the remaining rows accordingly, see TableParseOptions.DropRepeatedHeaderRows.
+// Cells are compared by their text only, so repeats using <td> instead of <th> are detected as well. Nothing is
+// dropped if all headers are empty. Returns the remaining rows, their spans, and the positions of the dropped rows.
+func dropRepeatedHeaderRows(rawTableData [][]*html.Node, rawSpans map[[2]int]CellSpan, headers []string, hasIndex int, opts TableParseOptions) ([][]*html.Node, map[[2]int]CellSpan, []int) {
+	hasContent := false
+	for _, h := range headers[1:] {
+		hasContent = hasContent || h != ""
+	}
+	if !hasContent {
+		return rawTableData, rawSpans, nil
+	}
+
+	isRepeat := func(row []*html.Node) bool {
+		for j := 1; j < len(headers); j++ {
+			text := ""
+			if c := j - 1 + hasIndex; c < len(row) {
+				text = opts.cellText(row[c])
+			}
+			if text != headers[j] {
+				return false
+			}
+		}
+		return true
+	}
+
+	kept := [][]*html.Node{rawTableData[0]}
+	positions := map[int]int{0: 0} // source row -> row after dropping
+	var dropped []int
+	for r := 1; r < len(rawTableData); r++ {
+		if isRepeat(rawTableData[r]) {
+			dropped = append(dropped, r)
+			continue
+		}
+		positions[r] = len(kept)
+		kept = append(kept, rawTableData[r])
+	}
+	if len(dropped) == 0 {
+		return rawTableData, rawSpans, nil
+	}
+
+	var spans map[[2]int]CellSpan
+	if rawSpans != nil {
+		spans = make(map[[2]int]CellSpan, len(rawSpans))
+		for pos, span := range rawSpans {
+			if r, ok := positions[pos[0]]; ok {
+				spans[[2]int{r, pos[1]}] = span
+			}
+		}
+	}
+	return kept, spans, dropped
+}
+
+// isRowHeaderCell
+// Returns true for <th> elements which do not head a column, i.e., without scope "col" or "colgroup".
+func isRowHeaderCell(cell *html.Node) bool {
+	if cell == nil || cell.Type != html.ElementNode || cell.Data != "th" {
+		return false
+	}
+	scope, err := GetAttributeByKey(cell, "scope")
+	if err != nil {
+		return true
+	}
+	s := strings.ToLower(strings.TrimSpace(scope.Val))
+	return s != "col" && s != "colgroup"
+}
+
+// detectRowHeaders
+// Returns whether more than half of the body rows of rawTableData with cells start with a row header cell, see
+// isRowHeaderCell, and the rows i (see GetElementByIndex) of the body rows with cells which do not.
+// The first row is not a body row if hasHeaderRow is set.
+func detectRowHeaders(rawTableData [][]*html.Node, hasHeaderRow bool) (bool, []int) {
+	hasHeader := 0
+	if hasHeaderRow {
+		hasHeader = 1
+	}
+	var gaps []int
+	rowsWithCells := 0
+	for r := hasHeader; r < len(rawTableData); r++ {
+		if len(rawTableData[r]) == 0 {
+			continue
+		}
+		rowsWithCells++
+		if !isRowHeaderCell(rawTableData[r][0]) {
+			gaps = append(gaps, r+1-hasHeader)
+		}
+	}
+	return rowsWithCells > 0 && 2*len(gaps) < rowsWithCells, gaps
+}
+
+// countDuplicates
+// Returns the number of elements of keys which are equal to a previous element.
+func countDuplicates(keys []string) int {
+	seen := make(map[string]bool, len(keys))
+	duplicates := 0
+	for _, key := range keys {
+		if seen[key] {
+			duplicates++
+		}
+		seen[key] = true
+	}
+	return duplicates
+}
+
+// getTableRows
+// Returns all innermost <tr> elements of the tree of tableNode, i.e., those without nested <tr> elements, except for
+// rows inside inert <template> elements, see IncludeShadowTemplates.
+func getTableRows(tableNode *html.Node) []*html.Node {
+	var rows []*html.Node
+	WalkHtmlTree(tableNode, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if n.Data == "tr" && !hasNestedRows(n) {
+			rows = append(rows, n)
+		}
+		return !isInertTemplate(n)
+	})
+	return rows
+}
+
+// getOwnTableRows
+// Returns all <tr> elements which belong to tableNode itself, i.e., not to a nested table, in document order.
+// Section elements and other wrappers between tableNode and its rows, e.g., <form>, are descended into. Rows inside
+// inert <template> elements are skipped, see IncludeShadowTemplates.
+func getOwnTableRows(tableNode *html.Node) []*html.Node {
+	var rows []*html.Node
+	WalkHtmlTree(tableNode, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if n.Data == "tr" {
+			rows = append(rows, n)
+		}
+		return n.Data != "table" && !isInertTemplate(n) // do not descend into nested tables
+	})
+	return rows
+}
+
+// hasNestedRows
+// Returns whether the tree of row contains another <tr> element, e.g., of a nested table.
+func hasNestedRows(row *html.Node) bool {
+	return GetNextNodeByCondition(row, MakeByTagNameCondition("tr")) != nil
+}
+
+// getRowCells
+// Returns all innermost <td> and <th> elements of the tree of row.
+func getRowCells(row *html.Node) []*html.Node {
+	return GetNodesByCondition(row, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && ((node.Data == "td" && GetNextNodeByCondition(node, MakeByTagNameCondition("td")) == nil) ||
+			(node.Data == "th" && GetNextNodeByCondition(node, MakeByTagNameCondition("th")) == nil))
+	})
+}
+
+// expandTableSpans
+// Expands the cells of rows according to their colspan and rowspan attributes, i.e., a cell covering multiple positions
+// of the grid is repeated at each of them. Positions which are not covered by any cell are nil.
+// Returns the expanded grid and the span of each source cell keyed by its top-left position in the grid.
+// Returns ErrTableTooLarge before the grid grows beyond maxCells cells, MaxExpandedCells if maxCells is 0, counting
+// every row up to the widest one.
+func expandTableSpans(rows [][]*html.Node, maxCells int) ([][]*html.Node, map[[2]int]CellSpan, error) {
+	if maxCells <= 0 {
+		maxCells = MaxExpandedCells
+	}
+	grid := make([][]*html.Node, len(rows))
+	spans := make(map[[2]int]CellSpan)
+	width := 0 // number of columns of the widest row
+
+	place := func(r, c int, cell *html.Node) {
+		if len(grid[r]) <= c {
+			grid[r] = append(grid[r], make([]*html.Node, c+1-len(grid[r]))...)
+		}
+		grid[r][c] = cell
+	}
+
+	for r, cells := range rows {
+		c := 0
+		for _, cell := range cells {
+			// skip positions occupied by rowspans from above
+			for c < len(grid[r]) && grid[r][c] != nil {
+				c++
+			}
+
+			rowSpan := getSpanAttribute(cell, "rowspan", 65534)
+			if rowSpan == 0 || r+rowSpan > len(rows) {
+				// rowspan 0 spans all remaining rows
+				rowSpan = len(rows) - r
+			}
+			colSpan := getSpanAttribute(cell, "colspan", 1000)
+			if colSpan == 0 {
+				colSpan = 1
+			}
+			if c+colSpan > width {
+				width = c + colSpan
+				if len(rows)*width > maxCells {
+					return nil, nil, fmt.Errorf("%w: %v rows and at least %v columns exceed %v cells", ErrTableTooLarge, len(rows), width, maxCells)
+				}
+			}
+
+			for dr := 0; dr < rowSpan; dr++ {
+				for dc := 0; dc < colSpan; dc++ {
+					place(r+dr, c+dc, cell)
+				}
+			}
+			spans[[2]int{r, c}] = CellSpan{
+				RowSpan:      rowSpan,
+				ColSpan:      colSpan,
+				IsHeaderCell: cell.Data == "th",
+			}
+			c += colSpan
+		}
+	}
+
+	return grid, spans, nil
+}
+
+// getSpanAttribute
+// Returns the value of the span attribute key of cell clamped to [0, limit], or 1 if it is missing or invalid.
+func getSpanAttribute(cell *html.Node, key string, limit int) int {
+	attr, err := GetAttributeByKey(cell, key)
+	if err != nil {
+		return 1
+	}
+	span, err := strconv.Atoi(strings.TrimSpace(attr.Val))
+	if err != nil || span < 0 {
+		return 1
+	}
+	if span > limit {
+		return limit
+	}
+	return span
+}
+
 func GetAttributeByKey(node *html.Node, key string) (html.Attribute, error) {
 	if node == nil {
 		return html.Attribute{}, errors.New("node is nil")
@@ -599,18 +1968,22 @@ func GetAttributeByKey(node *html.Node, key string) (html.Attribute, error) {
 				return attr, nil
 			}
 		}
-		return html.Attribute{}, errors.New(fmt.Sprintf("node has no attribute with key: '%v%", key))
+		return html.Attribute{}, errors.New(fmt.Sprintf("node has no attribute with key: '%v'", key))
 	}
 }
 
 // ParseSelectHTMLNode
 // Parses the html node with tag 'select' into its different options.
 // Returns a map containing key: value as strings, in which key is the content text content of the option and value is the content of the 'value' attribute of this option.
+// The content text is the first text node of the option which counts as content, see ContentTextFunc, or "" if there is
+// none.
 //
 // If multiple options have the same content text, they will be overridden and only the last one is kept.
-// Returns the currently selected option, which is the option with attribute 'selected' if it exists, otherwise the first occurring option.
+// Returns the currently selected option, which is the option with attribute 'selected' if it exists, otherwise the first
+// enabled option.
+// If multiple options have the "selected" attribute, returns the last option that has it as "selectedOption".
+// Since disabled options are never submitted, "" is returned if that option or the <select> itself is disabled.
 //
-// If multiple options have the "selected" attribute, returns the last option that has it as "selectedOption"
 // Returns nil map and nil error if no options were found.
 func ParseSelectHTMLNode(selectNode *html.Node) (map[string]string, string, error) {
 	if selectNode == nil {
@@ -625,40 +1998,26 @@ func ParseSelectHTMLNode(selectNode *html.Node) (map[string]string, string, erro
 
 	availableOptions := make(map[string]string)
 
-	firstAvailableOptionKey := ""
-
-	selectedOption := ""
-	foundSelected := false
-	for i, optionNode := range options {
+	optionTexts := make([]string, 0, len(options))
+	for _, optionNode := range options {
 		optionValueAttr, err := GetAttributeByKey(optionNode, "value")
 		if err != nil {
 			return nil, "", err
 		}
 
-		optionTextNode := GetFirstTextNode(optionNode)
-		if optionTextNode == nil {
-			return nil, "", errors.New("failed to get text of option node")
+		optionText := ""
+		if optionTextNode := GetFirstTextNodeWithCondition(optionNode, isContentText); optionTextNode != nil {
+			optionText = optionTextNode.Data
 		}
 
-		optionText := optionTextNode.Data
-
 		availableOptions[optionText] = optionValueAttr.Val
-
-		if i == 0 {
-			firstAvailableOptionKey = optionText
-		}
-
-		_, err = GetAttributeByKey(optionNode, "selected")
-		if err == nil {
-			// attribute has key 'selected'
-			selectedOption = optionText
-			foundSelected = true
-		}
+		optionTexts = append(optionTexts, optionText)
 	}
 
-	if foundSelected == false {
-		// set selected to first entry
-		selectedOption = firstAvailableOptionKey
+	// disabled options are never selected, see ParseSelectOptions
+	selectedOption := ""
+	if selected, _ := resolveSelectedOption(selectNode, options, false); selected >= 0 {
+		selectedOption = optionTexts[selected]
 	}
 
 	return availableOptions, selectedOption, nil
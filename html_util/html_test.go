@@ -0,0 +1,1443 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// parseDocument
+// Parses s as html document and fails the test on error.
+func parseDocument(tb testing.TB, s string) *html.Node {
+	tb.Helper()
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		tb.Fatalf("cannot parse document: %v", err)
+	}
+	return doc
+}
+
+// renderNode
+// Renders node as html and fails the test on error.
+func renderNode(tb testing.TB, node *html.Node) string {
+	tb.Helper()
+	var sb strings.Builder
+	if err := html.Render(&sb, node); err != nil {
+		tb.Fatalf("cannot render node: %v", err)
+	}
+	return sb.String()
+}
+
+// parseTable
+// Parses the first table of the document s via ParseHtmlTable with suffix "_" and fails the test on error.
+func parseTable(tb testing.TB, s string, hasHeaderRow, hasIndexColumn bool) *HtmlTable {
+	tb.Helper()
+	tables := GetElementNodesByTagName("table", parseDocument(tb, s))
+	if len(tables) == 0 {
+		tb.Fatal("document has no table")
+	}
+	ht, err := ParseHtmlTable(tables[0], hasHeaderRow, hasIndexColumn, "_")
+	if err != nil {
+		tb.Fatalf("cannot parse table: %v", err)
+	}
+	return ht
+}
+
+// parseTableWithOptions
+// Parses the first table of the document s via ParseHtmlTableWithOptions and fails the test on error.
+func parseTableWithOptions(tb testing.TB, s string, opts TableParseOptions) *HtmlTable {
+	tb.Helper()
+	tables := GetElementNodesByTagName("table", parseDocument(tb, s))
+	if len(tables) == 0 {
+		tb.Fatal("document has no table")
+	}
+	ht, err := ParseHtmlTableWithOptions(tables[0], opts)
+	if err != nil {
+		tb.Fatalf("cannot parse table: %v", err)
+	}
+	return ht
+}
+
+// elementByID
+// Returns the first element with the given id in the tree of root and fails the test if there is none.
+func elementByID(tb testing.TB, root *html.Node, id string) *html.Node {
+	tb.Helper()
+	node := GetNodeByCondition(root, MakeByIdCondition(id))
+	if node == nil {
+		tb.Fatalf("no element with id '%v'", id)
+	}
+	return node
+}
+
+// nodeID
+// Returns the id attribute of node, or "" if node is nil or has none.
+func nodeID(node *html.Node) string {
+	if node == nil {
+		return ""
+	}
+	attr, _ := GetAttributeByKey(node, "id")
+	return attr.Val
+}
+
+func TestWalkHtmlTreeDetachingNodeSkipsOnlyItsSiblings(t *testing.T) {
+	doc := parseDocument(t, `<div id="a"><p>1</p><b>remove</b><p>2</p></div><div id="b"><p>3</p></div>`)
+
+	var visited []string
+	WalkHtmlTree(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		visited = append(visited, n.Data)
+		if n.Data == "b" {
+			n.Parent.RemoveChild(n)
+		}
+		return true
+	})
+
+	// as in a recursive walk, the siblings following the detached <b> are skipped, but the second <div> is not
+	want := "html head body div p b div p"
+	if got := strings.Join(visited, " "); got != want {
+		t.Errorf("visited %q, want %q", got, want)
+	}
+}
+
+func TestWalkHtmlTreeDetachingAncestorContinuesWithItsFormerParent(t *testing.T) {
+	doc := parseDocument(t, `<section><div><i>x</i></div><span>skipped</span></section><footer>f</footer>`)
+
+	var visited []string
+	WalkHtmlTree(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		visited = append(visited, n.Data)
+		if n.Data == "i" {
+			div := n.Parent
+			div.Parent.RemoveChild(div)
+		}
+		return true
+	})
+
+	want := "html head body section div i footer"
+	if got := strings.Join(visited, " "); got != want {
+		t.Errorf("visited %q, want %q", got, want)
+	}
+}
+
+// makeLargeTable
+// Returns a document with a table of a header row and the given number of rows and columns.
+func makeLargeTable(tb testing.TB, rows, columns int) *html.Node {
+	tb.Helper()
+	var sb strings.Builder
+	sb.WriteString("<table><tr>")
+	for c := 0; c < columns; c++ {
+		fmt.Fprintf(&sb, "<th>col %v</th>", c)
+	}
+	sb.WriteString("</tr>")
+	for r := 0; r < rows; r++ {
+		sb.WriteString("<tr>")
+		for c := 0; c < columns; c++ {
+			fmt.Fprintf(&sb, "<td>%v.%v</td>", r, c)
+		}
+		sb.WriteString("</tr>")
+	}
+	sb.WriteString("</table>")
+	return GetElementNodesByTagName("table", parseDocument(tb, sb.String()))[0]
+}
+
+func TestParseHtmlTableMaxCellsAbortsSpanExpansionEarly(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("<table>")
+	for r := 0; r < 200; r++ {
+		sb.WriteString(`<tr><td rowspan="0" colspan="1000">x</td></tr>`)
+	}
+	sb.WriteString("</table>")
+	table := GetElementNodesByTagName("table", parseDocument(t, sb.String()))[0]
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := ParseHtmlTableWithOptions(table, TableParseOptions{HasHeaderRow: true, Suffix: "_", ExpandSpans: true, MaxCells: 1000})
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(err, ErrTableTooLarge) {
+		t.Fatalf("got error %v, want ErrTableTooLarge", err)
+	}
+	// the 200 x 1000 grid would take more than 1.6 MB of pointers alone
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("allocated %v bytes before aborting", allocated)
+	}
+}
+
+func TestParseHtmlTableMaxCellsCountsExpandedCells(t *testing.T) {
+	table := GetElementNodesByTagName("table", parseDocument(t,
+		`<table><tr><th>a</th><th>b</th></tr><tr><td colspan="3">1</td></tr><tr><td>2</td></tr></table>`))[0]
+
+	// 3 rows of 2 columns, but of 3 columns after expansion
+	if _, err := ParseHtmlTableWithOptions(table, TableParseOptions{HasHeaderRow: true, Suffix: "_", MaxCells: 6}); err != nil {
+		t.Errorf("got error %v without span expansion", err)
+	}
+	if _, err := ParseHtmlTableWithOptions(table, TableParseOptions{HasHeaderRow: true, Suffix: "_", ExpandSpans: true, MaxCells: 8}); !errors.Is(err, ErrTableTooLarge) {
+		t.Errorf("got error %v with span expansion, want ErrTableTooLarge", err)
+	}
+	if _, err := ParseHtmlTableWithOptions(table, TableParseOptions{HasHeaderRow: true, Suffix: "_", ExpandSpans: true, MaxCells: 9}); err != nil {
+		t.Errorf("got error %v for a table of exactly MaxCells cells", err)
+	}
+}
+
+func TestParseHtmlTableOnRow(t *testing.T) {
+	table := makeLargeTable(t, 5, 3)
+
+	var calls []string
+	_, err := ParseHtmlTableWithOptions(table, TableParseOptions{HasHeaderRow: true, Suffix: "_", OnRow: func(rowIndex, totalRows int) error {
+		calls = append(calls, fmt.Sprintf("%v/%v", rowIndex, totalRows))
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(calls, " "), "0/6 1/6 2/6 3/6 4/6 5/6"; got != want {
+		t.Errorf("OnRow calls %q, want %q", got, want)
+	}
+
+	errStop := errors.New("stop")
+	calls = nil
+	_, err = ParseHtmlTableWithOptions(table, TableParseOptions{HasHeaderRow: true, Suffix: "_", OnRow: func(rowIndex, totalRows int) error {
+		calls = append(calls, strconv.Itoa(rowIndex))
+		if rowIndex == 2 {
+			return errStop
+		}
+		return nil
+	}})
+	if !errors.Is(err, errStop) {
+		t.Errorf("got error %v, want the error of OnRow", err)
+	}
+	if got, want := strings.Join(calls, " "), "0 1 2"; got != want {
+		t.Errorf("OnRow calls %q after aborting, want %q", got, want)
+	}
+}
+
+func BenchmarkParseHtmlTableHooks(b *testing.B) {
+	table := makeLargeTable(b, 2000, 20)
+	for _, bm := range []struct {
+		name string
+		opts TableParseOptions
+	}{
+		{"none", TableParseOptions{HasHeaderRow: true, Suffix: "_"}},
+		{"OnRow+MaxCells", TableParseOptions{HasHeaderRow: true, Suffix: "_", OnRow: func(int, int) error { return nil }, MaxCells: 1 << 20}},
+		{"ExpandSpans", TableParseOptions{HasHeaderRow: true, Suffix: "_", ExpandSpans: true}},
+		{"ExpandSpans+OnRow+MaxCells", TableParseOptions{HasHeaderRow: true, Suffix: "_", ExpandSpans: true, OnRow: func(int, int) error { return nil }, MaxCells: 1 << 20}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseHtmlTableWithOptions(table, bm.opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestGetElementNodesByTagName(t *testing.T) {
+	doc := parseDocument(t, `<div id="1"><p><div id="2"></div></p><span><div id="3"><div id="4"></div></div></span></div><div id="5"></div>`)
+
+	ids := func(nodes []*html.Node) string {
+		var parts []string
+		for _, n := range nodes {
+			attr, _ := GetAttributeByKey(n, "id")
+			parts = append(parts, attr.Val)
+		}
+		return strings.Join(parts, " ")
+	}
+	if got, want := ids(GetElementNodesByTagName("div", doc)), "1 2 3 4 5"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// the start node is included, its siblings and ancestors are not
+	start := GetElementNodesByTagName("div", doc)[2]
+	if got, want := ids(GetElementNodesByTagName("div", start)), "3 4"; got != want {
+		t.Errorf("got %q from the third div, want %q", got, want)
+	}
+	leaf := GetElementNodesByTagName("div", doc)[3]
+	if got, want := ids(GetElementNodesByTagName("div", leaf)), "4"; got != want {
+		t.Errorf("got %q from a leaf, want %q", got, want)
+	}
+	if got := GetElementNodesByTagName("table", doc); got != nil {
+		t.Errorf("got %v for a missing tag, want nil", got)
+	}
+	if got := GetElementNodesByTagName("div", nil); got != nil {
+		t.Errorf("got %v for nil, want nil", got)
+	}
+
+	// same result as the generic search
+	if got, want := ids(GetElementNodesByTagName("div", doc)), ids(GetNodesByCondition(doc, MakeByTagNameCondition("div"))); got != want {
+		t.Errorf("got %q, GetNodesByCondition yields %q", got, want)
+	}
+}
+
+func TestGetElementNodeByTagNameAndClass(t *testing.T) {
+	doc := parseDocument(t, `<div id="1" class="item"><span id="2" class="price"></span></div>`+
+		`<div id="3" class="Card Price"><span id="4" class="price big"></span></div>`)
+
+	tests := []struct {
+		tag, class string
+		start      *html.Node
+		want       string
+	}{
+		{"span", "price", doc, "2"},
+		{"div", "price", doc, "3"}, // class names are compared case-insensitively
+		{"span", "big", doc, "4"},
+		{"div", "item", elementByID(t, doc, "3"), ""},
+		{"div", "card", elementByID(t, doc, "3"), "3"}, // the start node is included
+		{"p", "price", doc, ""},
+		{"span", "price", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := nodeID(GetElementNodeByTagNameAndClass(tt.tag, tt.class, tt.start)); got != tt.want {
+			t.Errorf("GetElementNodeByTagNameAndClass(%v, %v) = %q, want %q", tt.tag, tt.class, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkGetElementNodesByTagName(b *testing.B) {
+	doc := parseDocument(b, makeLargeDocument(10000))
+	b.Run("GetNodesByCondition", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			GetNodesByCondition(doc, MakeByTagNameCondition("a"))
+		}
+	})
+	b.Run("GetElementNodesByTagName", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			GetElementNodesByTagName("a", doc)
+		}
+	})
+}
+
+func TestContentTextHonoursTextRegex(t *testing.T) {
+	table := GetElementNodesByTagName("table", parseDocument(t,
+		`<table><tr><th>a</th><th>b</th><th>c</th></tr><tr><td>€</td><td>—</td><td>x</td></tr></table>`))[0]
+	cells := func() string {
+		ht, err := ParseHtmlTable(table, true, false, "_")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(ht.TableData[0], "|")
+	}
+	defer func(textRegex *regexp.Regexp, legacy bool) {
+		TextRegex, UseLegacyTextRegex = textRegex, legacy
+	}(TextRegex, UseLegacyTextRegex)
+
+	if got, want := cells(), "€|—|x"; got != want {
+		t.Errorf("got %q by default, want %q", got, want)
+	}
+
+	UseLegacyTextRegex = true
+	if got, want := cells(), "||x"; got != want {
+		t.Errorf("got %q with UseLegacyTextRegex, want %q", got, want)
+	}
+
+	UseLegacyTextRegex = false
+	TextRegex = regexp.MustCompile("[^!-~€]")
+	if got, want := cells(), "€||x"; got != want {
+		t.Errorf("got %q with a replaced TextRegex, want %q", got, want)
+	}
+}
+
+func TestContentTextFunc(t *testing.T) {
+	doc := parseDocument(t, `<table><tr><th>a</th><th>b</th><th>c</th></tr>`+
+		`<tr><td>—</td><td> — <i>x</i></td><td>€</td></tr></table>`+
+		`<select><option>—</option><option value="v">—</option><option>y</option></select>`+
+		`<p class="note"> — </p>`+
+		`<select><option value="">—</option><option value="a"> <!---->A</option></select>`)
+	table := GetElementNodesByTagName("table", doc)[0]
+	selectNode := GetElementNodesByTagName("select", doc)[0]
+	legacyNode := GetElementNodesByTagName("select", doc)[1]
+	dashIsEmpty := func(s string) bool { return !IsBlank(strings.ReplaceAll(s, "—", "")) }
+
+	cells := func(isContent func(s string) bool) string {
+		ht, err := ParseHtmlTableWithOptions(table, TableParseOptions{HasHeaderRow: true, Suffix: "_",
+			NormalizerFunc: TrimBlank, ContentTextFunc: isContent})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(ht.TableData[0], "|")
+	}
+	options := func(isContent func(s string) bool) string {
+		options, err := ParseSelectOptionsWithOptions(selectNode, SelectParseOptions{ContentTextFunc: isContent})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var texts []string
+		for _, option := range options {
+			texts = append(texts, option.Text+"="+option.Value)
+		}
+		return strings.Join(texts, "|")
+	}
+	legacy := func() string {
+		options, _, err := ParseSelectHTMLNode(legacyNode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fmt.Sprint(options)
+	}
+	note := func() string {
+		record, _ := ExtractRecord(doc, map[string]FieldSpec{
+			"note": {Condition: MakeByClassNameCondition("note"), Extraction: ExtractText},
+		})
+		return record["note"]
+	}
+
+	// the em dash counts as content by default and per call
+	for _, isContent := range []func(s string) bool{nil, DefaultContentText} {
+		if got, want := cells(isContent), "—|—|€"; got != want {
+			t.Errorf("got cells %q, want %q", got, want)
+		}
+		if got, want := options(isContent), "—=—|—=v|y=y"; got != want {
+			t.Errorf("got options %q, want %q", got, want)
+		}
+	}
+	if got := note(); got != "—" {
+		t.Errorf("got note %q, want —", got)
+	}
+	if got, want := legacy(), "map[A:a —:]"; got != want {
+		t.Errorf("got ParseSelectHTMLNode options %q, want %q", got, want)
+	}
+
+	// the em dash is empty per call, without affecting other calls
+	if got, want := cells(dashIsEmpty), "|x|€"; got != want {
+		t.Errorf("got cells %q, want %q", got, want)
+	}
+	if got, want := options(dashIsEmpty), "=|=v|y=y"; got != want {
+		t.Errorf("got options %q, want %q", got, want)
+	}
+	if got, want := cells(DefaultASCIIContentText), "|x|"; got != want {
+		t.Errorf("got cells %q with DefaultASCIIContentText, want %q", got, want)
+	}
+	if got := note(); got != "—" {
+		t.Errorf("got note %q after per-call options, want —", got)
+	}
+
+	// the em dash is empty for the package, per-call options take precedence
+	defer func(isContent func(s string) bool) { ContentTextFunc = isContent }(ContentTextFunc)
+	ContentTextFunc = dashIsEmpty
+	if got, want := cells(nil), "|x|€"; got != want {
+		t.Errorf("got cells %q, want %q", got, want)
+	}
+	if got, want := options(nil), "=|=v|y=y"; got != want {
+		t.Errorf("got options %q, want %q", got, want)
+	}
+	if got := note(); got != "" {
+		t.Errorf("got note %q, want none", got)
+	}
+	if got, want := legacy(), "map[: A:a]"; got != want {
+		t.Errorf("got ParseSelectHTMLNode options %q, want %q", got, want)
+	}
+	if got, want := cells(DefaultContentText), "—|—|€"; got != want {
+		t.Errorf("got cells %q with DefaultContentText, want %q", got, want)
+	}
+}
+
+func TestParseHtmlTableColumnNormalizers(t *testing.T) {
+	table := GetElementNodesByTagName("table", parseDocument(t,
+		`<table><tr><th>Price:</th><th>Name</th></tr><tr><td>5 €</td><td>Tea</td></tr></table>`))[0]
+	opts := TableParseOptions{HasHeaderRow: true, Suffix: "_", ColumnNormalizers: map[string]func(string) string{
+		"price": func(s string) string { return strings.TrimSuffix(s, " €") },
+	}}
+
+	ht, err := ParseHtmlTableWithOptions(table, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(ht.TableData[0], "|"), "5|Tea"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// keys which refer to the same column are ambiguous
+	opts.ColumnNormalizers["Price "] = strings.ToUpper
+	for i := 0; i < 10; i++ {
+		_, err := ParseHtmlTableWithOptions(table, opts)
+		if err == nil || !strings.Contains(err.Error(), "'Price ' and 'price'") {
+			t.Fatalf("got error %v, want an error naming both keys", err)
+		}
+	}
+}
+
+func TestParseHtmlTableColumnNormalizersOrdering(t *testing.T) {
+	digits := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, s)
+	}
+	trimmed := func(s string) string { return strings.Trim(TrimBlank(s), ".!") }
+
+	doc := parseDocument(t, `<table id="h"><tr><th>Item</th><th>Price!</th><th>Notes!</th><th>Stock!</th></tr>`+
+		`<tr><td>Tea!</td><td>5,00 €</td><td>Hot, sweet!</td><td>12!</td></tr></table>`+
+		`<table id="n"><tr><td>Tea!</td><td>5,00 €</td><td>Hot, sweet!</td></tr></table>`)
+
+	// the header row and the index column use the global normalizer, data columns their own or the global one
+	ht, err := ParseHtmlTableWithOptions(elementByID(t, doc, "h"), TableParseOptions{
+		HasHeaderRow: true, HasIndexColumn: true, Suffix: "_", NormalizerFunc: trimmed,
+		ColumnNormalizers:      map[string]func(string) string{"price": digits, "Notes": strings.TrimSpace},
+		ColumnIndexNormalizers: map[int]func(string) string{1: strings.ToUpper, 3: strings.ToUpper},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tableString(ht), "Item|Price|Notes|Stock\nTea|500|Hot, sweet!|12!"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	// tables without header row look up their normalizers by column index
+	ht, err = ParseHtmlTableWithOptions(elementByID(t, doc, "n"), TableParseOptions{
+		Suffix: "_", NormalizerFunc: trimmed,
+		ColumnIndexNormalizers: map[int]func(string) string{2: digits},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(ht.TableData[0], "|"), "Tea|500|Hot, sweet"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseHtmlTableCellTextOptions(t *testing.T) {
+	upper := func(s string) string { return strings.ToUpper(TrimBlank(s)) }
+	const doc = `<table><tr><th>Name</th><th>Address</th><th>Country</th></tr>` +
+		`<tr><td>Anna</td><td>Main <b>Street</b> 1<br>12345 <br><br> Berlin</td>` +
+		`<td><img src="de.svg" alt=" Germany "><span aria-label="EU"><img alt="flag"></span></td></tr>` +
+		`<tr><td>Ben</td><td><br>Elm Road 2</td><td>Austria <img alt="AT"></td></tr></table>`
+	tests := []struct {
+		name string
+		opts TableParseOptions
+		want string
+	}{
+		{"composite", TableParseOptions{AllowCompositeTexts: true, CompositeDelimiter: " "},
+			"Name|Address|Country\nAnna|Main Street 1 12345 Berlin|\nBen|Elm Road 2|Austria"},
+		{"line breaks", TableParseOptions{AllowCompositeTexts: true, CompositeDelimiter: " ", LineBreakDelimiter: ", "},
+			"Name|Address|Country\nAnna|Main Street 1, 12345, Berlin|\nBen|Elm Road 2|Austria"},
+		{"line breaks without composite texts", TableParseOptions{LineBreakDelimiter: ", "},
+			"Name|Address|Country\nAnna|Main|\nBen|Elm Road 2|Austria"},
+		{"alt texts", TableParseOptions{AllowCompositeTexts: true, CompositeDelimiter: "/", UseAltText: true},
+			"Name|Address|Country\nAnna|Main/Street/1/12345/Berlin|Germany/EU\nBen|Elm Road 2|Austria"},
+		{"first alt text", TableParseOptions{UseAltText: true, NormalizerFunc: upper},
+			"NAME|ADDRESS|COUNTRY\nANNA|MAIN|GERMANY\nBEN|ELM ROAD 2|AUSTRIA"},
+		{"cell text func", TableParseOptions{AllowCompositeTexts: true, UseAltText: true, NormalizerFunc: upper,
+			CellTextFunc: func(cell *html.Node) string { return " " + GetNodePath(cell) }},
+			"/HTML/BODY/TABLE/TBODY/TR[1]/TH[1]|/HTML/BODY/TABLE/TBODY/TR[1]/TH[2]|/HTML/BODY/TABLE/TBODY/TR[1]/TH[3]\n" +
+				"/HTML/BODY/TABLE/TBODY/TR[2]/TD[1]|/HTML/BODY/TABLE/TBODY/TR[2]/TD[2]|/HTML/BODY/TABLE/TBODY/TR[2]/TD[3]\n" +
+				"/HTML/BODY/TABLE/TBODY/TR[3]/TD[1]|/HTML/BODY/TABLE/TBODY/TR[3]/TD[2]|/HTML/BODY/TABLE/TBODY/TR[3]/TD[3]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.HasHeaderRow, tt.opts.HasIndexColumn, tt.opts.Suffix = true, true, "_"
+			if tt.opts.NormalizerFunc == nil {
+				tt.opts.NormalizerFunc = TrimBlank
+			}
+			if got := tableString(parseTableWithOptions(t, doc, tt.opts)); got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+// visitedElements
+// Walks the tree of start with walk and returns the tags of the visited elements joined by spaces. Elements with the
+// tag skip are visited, but their subtrees are not.
+func visitedElements(start *html.Node, walk func(*html.Node, func(*html.Node) bool), skip string) string {
+	var visited []string
+	walk(start, func(n *html.Node) bool {
+		if n.Type == html.ElementNode {
+			visited = append(visited, n.Data)
+		}
+		return n.Data != skip
+	})
+	return strings.Join(visited, " ")
+}
+
+func TestWalkHtmlTreeInclusiveVsExclusiveOrder(t *testing.T) {
+	doc := parseDocument(t, `<div><p><b>1</b><i>2</i></p><ul><li>3</li></ul></div><footer>sibling</footer>`)
+	div := GetElementNodesByTagName("div", doc)[0]
+
+	tests := []struct {
+		name string
+		walk func(*html.Node, func(*html.Node) bool)
+		skip string
+		want string
+	}{
+		{"exclusive", WalkHtmlTree, "", "p b i ul li"},
+		{"inclusive", WalkHtmlTreeInclusive, "", "div p b i ul li"},
+		{"exclusive skipping p", WalkHtmlTree, "p", "p ul li"},
+		{"inclusive skipping p", WalkHtmlTreeInclusive, "p", "div p ul li"},
+		{"inclusive skipping the start node", WalkHtmlTreeInclusive, "div", "div"},
+	}
+	for _, tt := range tests {
+		if got := visitedElements(div, tt.walk, tt.skip); got != tt.want {
+			t.Errorf("%v: visited %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	WalkHtmlTreeInclusive(nil, func(n *html.Node) bool {
+		t.Error("called f for nil")
+		return true
+	})
+}
+
+func TestNodeLookupsIncludeOrExcludeStartNode(t *testing.T) {
+	doc := parseDocument(t, `<div class="x"><div class="x"><span class="x"></span></div></div>`)
+	outer := GetElementNodesByTagName("div", doc)[0]
+	byClass := MakeByClassNameCondition("x")
+
+	if got := GetNodesByCondition(outer, byClass); len(got) != 3 || got[0] != outer {
+		t.Errorf("GetNodesByCondition found %v nodes, want 3 starting with the start node", len(got))
+	}
+	if got := GetNextNodesByCondition(outer, byClass); len(got) != 2 || got[0] == outer {
+		t.Errorf("GetNextNodesByCondition found %v nodes, want 2 without the start node", len(got))
+	}
+	if got := GetNodeByCondition(outer, byClass); got != outer {
+		t.Errorf("GetNodeByCondition found %v, want the start node", got)
+	}
+	if got := GetNextNodeByCondition(outer, byClass); got != outer.FirstChild {
+		t.Errorf("GetNextNodeByCondition found %v, want the first child", got)
+	}
+}
+
+func TestGetNodeByConditionStopsAtFirstMatch(t *testing.T) {
+	doc := parseDocument(t, `<ul><li id="a"><b>1</b></li><li id="b"><b>2</b></li></ul><p><b>3</b></p>`)
+	var tested []string
+	got := GetNodeByCondition(doc, func(n *html.Node) bool {
+		if n.Type == html.ElementNode {
+			tested = append(tested, n.Data)
+		}
+		return n.Data == "li"
+	})
+	if nodeID(got) != "a" {
+		t.Errorf("found %q, want the first li", nodeID(got))
+	}
+	// the subtree of the match and the nodes after it are not tested
+	if want := "html head body ul li"; strings.Join(tested, " ") != want {
+		t.Errorf("tested %q, want %q", strings.Join(tested, " "), want)
+	}
+
+	if got := GetNodeByCondition(doc, MakeByTagNameCondition("table")); got != nil {
+		t.Errorf("found %v, want nil", got.Data)
+	}
+	if got := GetNodeByCondition(nil, func(n *html.Node) bool { return true }); got != nil {
+		t.Errorf("found %v for nil", got.Data)
+	}
+}
+
+func TestParseHtmlTableSpans(t *testing.T) {
+	table := GetElementNodesByTagName("table", parseDocument(t, `<table>`+
+		`<tr><th>k</th><th>a</th><th>b</th></tr>`+
+		`<tr><td>r1</td><td rowspan="2">x</td><td>1</td></tr>`+
+		`<tr><td>r2</td><td>2</td></tr>`+
+		`<tr><th colspan="3">all</th></tr>`+
+		`</table>`))[0]
+	opts := TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_", ExpandSpans: true, RecordSpans: true}
+
+	ht, err := ParseHtmlTableWithOptions(table, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	for i, row := range ht.TableData {
+		rows = append(rows, ht.Index[i+1]+":"+strings.Join(row, ","))
+	}
+	if got, want := strings.Join(rows, " "), "r1:x,1 r2:x,2 all:all,all"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if span := ht.Spans[[2]int{1, 1}]; span.RowSpan != 2 || span.ColSpan != 1 || span.IsHeaderCell {
+		t.Errorf("got span %+v for x, want 2 rows", span)
+	}
+	if span := ht.Spans[[2]int{3, 0}]; span.ColSpan != 3 || !span.IsHeaderCell {
+		t.Errorf("got span %+v for all, want 3 header columns", span)
+	}
+	for _, pos := range [][3]int{{1, 1, 1}, {2, 1, 0}, {2, 2, 1}, {3, 0, 1}, {3, 1, 0}, {3, 2, 0}} {
+		if got := ht.IsSpanOrigin(pos[0], pos[1]); got != (pos[2] == 1) {
+			t.Errorf("IsSpanOrigin(%v, %v) = %v", pos[0], pos[1], got)
+		}
+	}
+
+	// without expansion, cells shift left as in the source
+	opts.ExpandSpans, opts.RecordSpans = false, false
+	if ht, err = ParseHtmlTableWithOptions(table, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(ht.TableData[1], ","), "2,"; got != want {
+		t.Errorf("got %q for the second row without expansion, want %q", got, want)
+	}
+	if !ht.IsSpanOrigin(2, 1) || ht.Spans != nil {
+		t.Error("spans recorded without RecordSpans")
+	}
+}
+
+func TestGetCellsWithPositions(t *testing.T) {
+	doc := parseDocument(t, `<table>`+
+		`<tr id="r0"><th colspan="2">A</th><th>B</th></tr>`+
+		`<tr id="r1"><td rowspan="2">x</td><td>1</td><td>2</td></tr>`+
+		`<tr id="r2"><td>3</td><td>4</td></tr>`+
+		`<tr id="r3"><td rowspan="2"><table><tr id="n"><td>n</td></tr></table></td><td>5</td></tr>`+
+		`<tr id="r4"><td>6</td><td>7</td></tr>`+
+		`</table>`)
+	positions := func(cells []PositionedCell) string {
+		var s []string
+		for _, cell := range cells {
+			header := ""
+			if cell.IsHeader {
+				header = "h"
+			}
+			s = append(s, fmt.Sprintf("%v@%v+%v%v", GetInnerText(cell.Node), cell.Column, cell.ColSpan, header))
+		}
+		return strings.Join(s, " ")
+	}
+
+	tests := []struct {
+		row  string
+		want string
+	}{
+		{"r0", "A@0+2h B@2+1h"},
+		{"r1", "x@0+1 1@1+1 2@2+1"},
+		{"r2", "3@1+1 4@2+1"}, // shifted right by the rowspan of x
+		{"r3", ""},            // rows containing nested rows have no cells
+		{"r4", "6@0+1 7@1+1"}, // and no spans reaching into later rows
+		{"n", "n@0+1"},
+	}
+	for _, tt := range tests {
+		if got := positions(GetCellsWithPositions(elementByID(t, doc, tt.row))); got != tt.want {
+			t.Errorf("cells of %v are %v, want %v", tt.row, got, tt.want)
+		}
+	}
+
+	// the grid columns agree with the columns of the parsed table, whose rows are the own rows of the table if
+	// KeepEmptyRows is set
+	opts := TableParseOptions{Suffix: "_", ExpandSpans: true, KeepEmptyRows: true}
+	ht, err := ParseHtmlTableWithOptions(GetElementNodesByTagName("table", doc)[0], opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range []string{"r0", "r1", "r2", "r4"} {
+		row := []int{1, 2, 3, 5}[i]
+		for _, cell := range GetCellsWithPositions(elementByID(t, doc, id)) {
+			if got := ht.GetElementByIndex(row, cell.Column+1); got != GetInnerText(cell.Node) {
+				t.Errorf("parsed table has %v at (%v, %v), want %v", got, row, cell.Column+1, GetInnerText(cell.Node))
+			}
+		}
+	}
+
+	isDigit := func(node *html.Node) bool { return strings.Trim(GetInnerText(node), "0123456789") == "" }
+	if got := positions(GetRowCellsByCondition(elementByID(t, doc, "r1"), isDigit)); got != "1@1+1 2@2+1" {
+		t.Errorf("GetRowCellsByCondition = %v, want 1@1+1 2@2+1", got)
+	}
+
+	// a detached row has no earlier rows
+	r2 := elementByID(t, doc, "r2")
+	if err := RemoveNode(r2); err != nil {
+		t.Fatal(err)
+	}
+	if got := positions(GetCellsWithPositions(r2)); got != "3@0+1 4@1+1" {
+		t.Errorf("cells of the detached row are %v, want 3@0+1 4@1+1", got)
+	}
+	if cells := GetCellsWithPositions(nil); cells != nil {
+		t.Errorf("GetCellsWithPositions(nil) = %v", cells)
+	}
+}
+
+func TestParseHtmlTableArtificialKeys(t *testing.T) {
+	table := GetElementNodesByTagName("table", parseDocument(t, `<table>`+
+		`<tr><th>name</th><th>Index\Header</th></tr>`+
+		`<tr><td>a</td><td>1</td></tr>`+
+		`</table>`))[0]
+
+	tests := []struct {
+		hasHeaderRow, hasIndexColumn bool
+		wantHeaders, wantIndex       string
+	}{
+		{true, true, `name|Index\Header`, "name|a"},
+		{true, false, `Index\Header|name|Index\Header_2`, `Index\Header|1`},
+		{false, true, `Index\Header|1`, `Index\Header|name|a`},
+		{false, false, `Index\Header|1|2`, `Index\Header|1|2`},
+	}
+	for _, tt := range tests {
+		ht, err := ParseHtmlTable(table, tt.hasHeaderRow, tt.hasIndexColumn, "_")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(ht.Headers, "|"); got != tt.wantHeaders {
+			t.Errorf("header row %v, index column %v: got headers %q, want %q", tt.hasHeaderRow, tt.hasIndexColumn, got, tt.wantHeaders)
+		}
+		if got := strings.Join(ht.Index, "|"); got != tt.wantIndex {
+			t.Errorf("header row %v, index column %v: got index %q, want %q", tt.hasHeaderRow, tt.hasIndexColumn, got, tt.wantIndex)
+		}
+		if ht.HasRealHeaders() != tt.hasHeaderRow || ht.HasRealIndex() != tt.hasIndexColumn {
+			t.Errorf("header row %v, index column %v: HasRealHeaders %v, HasRealIndex %v", tt.hasHeaderRow, tt.hasIndexColumn, ht.HasRealHeaders(), ht.HasRealIndex())
+		}
+	}
+}
+
+func TestParseHtmlTableProvenance(t *testing.T) {
+	table := GetElementNodesByTagName("table", parseDocument(t, `<table>`+
+		`<tr><th>k</th><th>a</th><th>b</th></tr>`+
+		`<tr><td>r1</td><td colspan="2">x</td></tr>`+
+		`<tr><td>r2</td><td>1</td></tr>`+
+		`</table>`))[0]
+	ht, err := ParseHtmlTableWithOptions(table, TableParseOptions{
+		HasHeaderRow: true, HasIndexColumn: true, Suffix: "_", ExpandSpans: true, RecordProvenance: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		i, j         int
+		wantOk       bool
+		wantText     string
+		wantRow      int
+		wantColumn   int
+		wantPathTail string
+	}{
+		{0, 1, true, "a", 0, 1, "/tr[1]/th[2]"},
+		{1, 0, true, "r1", 1, 0, "/tr[2]/td[1]"},
+		{1, 1, true, "x", 1, 1, "/tr[2]/td[2]"},
+		{1, 2, true, "x", 1, 1, "/tr[2]/td[2]"}, // covered by the colspan
+		{2, 1, true, "1", 2, 1, "/tr[3]/td[2]"},
+		{2, 2, false, "", 0, 0, ""}, // padded cell of a ragged row
+	}
+	for _, tt := range tests {
+		prov, ok := ht.GetCellProvenance(tt.i, tt.j)
+		if ok != tt.wantOk {
+			t.Errorf("GetCellProvenance(%v, %v) ok = %v", tt.i, tt.j, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if GetInnerText(prov.Node) != tt.wantText || prov.SourceRow != tt.wantRow || prov.SourceColumn != tt.wantColumn ||
+			!strings.HasSuffix(prov.Path, tt.wantPathTail) {
+			t.Errorf("GetCellProvenance(%v, %v) = %q %v %v %v", tt.i, tt.j, GetInnerText(prov.Node), prov.SourceRow, prov.SourceColumn, prov.Path)
+		}
+	}
+
+	if ht, err = ParseHtmlTable(table, true, true, "_"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ht.GetCellProvenance(1, 1); ok {
+		t.Error("provenance without RecordProvenance")
+	}
+}
+
+func TestParseHtmlTableHeadersMatchesFullParse(t *testing.T) {
+	documents := []struct {
+		html       string
+		hasColspan bool // without expanding spans, the header row is narrower than the body rows
+	}{
+		{`<table><thead><tr><th>A</th><th>b <i>B</i></th><th>A</th></tr></thead><tr><td>1</td><td>2</td><td>3</td></tr></table>`, false},
+		{`<table><tr><th colspan="2"> Wide </th><th>C</th></tr><tr><td>1</td><td>2</td><td>3</td></tr></table>`, true},
+		{`<table><tr><td>x</td><td>y</td></tr><tr><th scope="row">r</th><td>2</td></tr><tr><th>s</th><td>3</td></tr></table>`, false},
+		{`<table><caption>only caption</caption></table>`, false},
+	}
+	optionSets := []TableParseOptions{
+		{Suffix: "_"},
+		{HasHeaderRow: true, Suffix: "_"},
+		{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_"},
+		{HasHeaderRow: true, Suffix: "_", AllowCompositeTexts: true, CompositeDelimiter: "+", NormalizerFunc: strings.ToUpper},
+		{HasHeaderRow: true, Suffix: "_", ExpandSpans: true},
+		{HasHeaderRow: true, Suffix: "_", DetectRowHeaders: true},
+		{HasHeaderRow: true, Suffix: "_", NormalizerFunc: strings.TrimSpace, NormalizeGenerated: true},
+	}
+	for _, doc := range documents {
+		table := GetElementNodesByTagName("table", parseDocument(t, doc.html))[0]
+		for k, opts := range optionSets {
+			full, err := ParseHtmlTableWithOptions(table, opts)
+			if err != nil {
+				t.Fatalf("%v with options %v: %v", doc.html, k, err)
+			}
+			headers, err := ParseHtmlTableHeaders(table, opts)
+			if err != nil {
+				t.Fatalf("%v with options %v: %v", doc.html, k, err)
+			}
+			want := full.Headers
+			if doc.hasColspan && opts.HasHeaderRow && !opts.ExpandSpans && len(want) > len(headers) {
+				want = want[:len(headers)] // the trailing headers of the full parse are empty padding
+			}
+			if strings.Join(headers, "|") != strings.Join(want, "|") {
+				t.Errorf("%v with options %v: got headers %q, want %q", doc.html, k, headers, want)
+			}
+		}
+	}
+
+	if _, err := ParseHtmlTableHeaders(parseDocument(t, `<p>`), TableParseOptions{}); err == nil {
+		t.Error("expected an error for a non-table node")
+	}
+	if _, err := ParseHtmlTableHeaders(nil, TableParseOptions{}); err == nil {
+		t.Error("expected an error for a nil node")
+	}
+}
+
+func BenchmarkParseHtmlTableHeaders(b *testing.B) {
+	var tables []*html.Node
+	for i := 0; i < 50; i++ {
+		tables = append(tables, makeLargeTable(b, 200, 10))
+	}
+	opts := TableParseOptions{HasHeaderRow: true, Suffix: "_"}
+
+	b.Run("headers", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, table := range tables {
+				if _, err := ParseHtmlTableHeaders(table, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, table := range tables {
+				if _, err := ParseHtmlTableWithOptions(table, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+func TestAttributeConditionsDecodeEntities(t *testing.T) {
+	// the values are double-escaped, so the parsed attributes still contain entities
+	doc := parseDocument(t, `<div id="amp" data-name="Tom &amp;amp; Jerry"></div>`+
+		`<div id="apos" data-name="Rock &amp;#39;n&amp;#39; Roll"></div>`+
+		`<div id="hex" data-name="A &amp;#x26; B" rel="x&amp;#x20;nofollow"></div>`)
+
+	ids := func(cond func(node *html.Node) bool) string {
+		var ids []string
+		for _, n := range GetNodesByCondition(doc, cond) {
+			ids = append(ids, nodeID(n))
+		}
+		return strings.Join(ids, " ")
+	}
+
+	tests := []struct {
+		name string
+		cond func(decode bool) func(node *html.Node) bool
+		want string // ids matching with decoding, none match without
+	}{
+		{"equals", func(decode bool) func(node *html.Node) bool {
+			return func(n *html.Node) bool { return AttributeEquals(n, "data-name", "tom & jerry", decode) }
+		}, "amp"},
+		{"prefix", func(decode bool) func(node *html.Node) bool {
+			return MakeByAttributePrefixCondition("data-name", "rock '", decode)
+		}, "apos"},
+		{"suffix", func(decode bool) func(node *html.Node) bool {
+			return MakeByAttributeSuffixCondition("data-name", "& b", decode)
+		}, "hex"},
+		{"token", func(decode bool) func(node *html.Node) bool {
+			return MakeByAttributeTokenCondition("rel", "nofollow", decode)
+		}, "hex"},
+	}
+	for _, tt := range tests {
+		if got := ids(tt.cond(false)); got != "" {
+			t.Errorf("%v without decoding matched %q", tt.name, got)
+		}
+		if got := ids(tt.cond(true)); got != tt.want {
+			t.Errorf("%v with decoding matched %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := ids(MakeByAttributeDecodedCondition("data-name", "TOM & JERRY")); got != "amp" {
+		t.Errorf("MakeByAttributeDecodedCondition matched %q, want amp", got)
+	}
+	if got := ids(MakeByAttributeNameAndValueCondition("data-name", "Tom &amp; Jerry")); got != "amp" {
+		t.Errorf("MakeByAttributeNameAndValueCondition matched %q, want the raw value to match", got)
+	}
+}
+
+func TestParseHtmlTableKeepEmptyRows(t *testing.T) {
+	s := `<table><tr><th>K</th><th>V</th></tr><tr><td>a</td><td>1</td></tr>` +
+		`<tr><td colspan="2"><table><tr><td>x</td><td>y</td></tr></table></td></tr>` +
+		`<tr><td>b</td><td>2</td></tr><tr><td><table><tr><td>z</td></tr></table></td></tr></table>`
+
+	ht := parseTableWithOptions(t, s, TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_"})
+	if got, want := tableString(ht), "K|V\na|1\nx|y\nb|2\nz|"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	if fmt.Sprint(ht.SkippedRows) != "[2 4]" {
+		t.Errorf("SkippedRows = %v, want [2 4]", ht.SkippedRows)
+	}
+
+	// one row per <tr> of the table itself, so positions correlate with the page
+	ht = parseTableWithOptions(t, s, TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_", KeepEmptyRows: true})
+	if got, want := tableString(ht), "K|V\na|1\n|\nb|2\n_2|"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	if ht.SkippedRows != nil {
+		t.Errorf("SkippedRows = %v, want nil", ht.SkippedRows)
+	}
+}
+
+func TestNodePathCacheMatchesGetNodePath(t *testing.T) {
+	doc := parseDocument(t, `<!-- c --><div><p>a</p>text<p>b<!-- d --></p><span></span>more<table><tr><td>1</td><td>2</td></tr></table></div>`)
+	detached := &html.Node{Type: html.ElementNode, Data: "div"}
+	detached.AppendChild(&html.Node{Type: html.ElementNode, Data: "p"})
+
+	paths := newNodePathCache()
+	nodes := append(GetNodesByCondition(doc, func(*html.Node) bool { return true }), detached, detached.FirstChild)
+	for _, node := range nodes {
+		if got, want := paths.path(node), GetNodePath(node); got != want {
+			t.Errorf("cached path %q, want %q", got, want)
+		}
+	}
+}
+
+func TestParseHtmlTableRecoversMalformedNesting(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"implicit tbody", `<table><tr><th>k</th><th>v</th></tr><tr><td>a</td><td>1</td></tr></table>`,
+			"k|v\na|1"},
+		{"form around rows", `<table><tr><th>k</th><th>v</th></tr><form action="/x">` +
+			`<tr><td>a</td><td>1</td></tr><tr><td>b</td><td>2</td></tr></form></table>`,
+			"k|v\na|1\nb|2"},
+		{"text between thead and tbody", `<table><thead><tr><th>k</th><th>v</th></tr></thead>stray` +
+			`<tbody><tr><td>a</td><td>1</td></tr></tbody></table>`,
+			"k|v\na|1"},
+		{"stray text in row", `<table><tr><th>k</th><th>v</th></tr><tr>oops<td>a</td><td>1</td></tr></table>`,
+			"k|v\na|1"},
+		{"center wrapper", `<table><center><tr><th>k</th><th>v</th></tr></center>` +
+			`<tr><td>a</td><td>1</td></tr></table>`,
+			"k|v\na|1"},
+		{"rows in template", `<table><tr><th>k</th><th>v</th></tr><tr><td>a</td><td>1</td></tr>` +
+			`<template><tr><td>t</td><td>0</td></tr></template></table>`,
+			"k|v\na|1"},
+		{"unclosed cells", `<table><tr><th>k<th>v<tr><td>a<td>1<tr><td>b<td>2</table>`,
+			"k|v\na|1\nb|2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ht := parseTable(t, tt.html, true, true)
+			if got := tableString(ht); got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHtmlTableIgnoresTextOutsideCellsOfManualTrees(t *testing.T) {
+	doc := parseDocument(t, `<table><tr><th>k</th><th>v</th></tr><tr><td>a</td><td>1</td></tr></table>`)
+	row := GetElementNodesByTagName("tr", doc)[1]
+	row.InsertBefore(&html.Node{Type: html.TextNode, Data: "stray"}, row.FirstChild)
+
+	ht, err := ParseHtmlTable(GetElementNodesByTagName("table", doc)[0], true, true, "_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tableString(ht); got != "k|v\na|1" {
+		t.Errorf("got\n%v\nwant\nk|v\na|1", got)
+	}
+}
+
+func TestParseHtmlTableRecordRawText(t *testing.T) {
+	const doc = `<table><tr><th> Name </th><th>Price</th></tr>` +
+		`<tr><th> apple </th><td> 1,50 € </td></tr><tr><th>pear</th></tr></table>`
+	ht := parseTableWithOptions(t, doc, TableParseOptions{
+		HasHeaderRow: true, HasIndexColumn: true, RecordRawText: true,
+		NormalizerFunc: func(s string) string { return strings.ToUpper(TrimBlank(s)) },
+	})
+
+	tests := []struct {
+		i, j   int
+		want   string
+		wantOk bool
+	}{
+		{0, 0, " Name ", true},
+		{0, 1, "Price", true},
+		{1, 0, " apple ", true},
+		{1, 1, " 1,50 € ", true},
+		{2, 1, "", false}, // padded cell of a ragged row
+		{3, 0, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ht.GetRawElementByIndex(tt.i, tt.j)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("GetRawElementByIndex(%v, %v) = %q, %v, want %q, %v", tt.i, tt.j, got, ok, tt.want, tt.wantOk)
+		}
+	}
+	if got := ht.GetElementByIndex(1, 1); got != "1,50 €" {
+		t.Errorf("normalized value is %q, want %q", got, "1,50 €")
+	}
+
+	// the raw text moves with its cell
+	if err := ht.ReorderColumns([]string{"PRICE"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := ht.GetRawElementByIndex(1, 1); got != " 1,50 € " || !ok {
+		t.Errorf("after ReorderColumns GetRawElementByIndex(1, 1) = %q, %v", got, ok)
+	}
+
+	// without recording, no raw texts are available
+	plain := parseTable(t, doc, true, true)
+	if _, ok := plain.GetRawElementByIndex(1, 1); ok {
+		t.Error("raw text available although it was not recorded")
+	}
+}
+
+func TestParseHtmlTableNormalizeGenerated(t *testing.T) {
+	const doc = `<table><tr><td>a</td><td>b</td></tr></table>`
+	normalizer := func(s string) string { return "<" + s + ">" }
+
+	tests := []struct {
+		normalizeGenerated bool
+		want               string
+	}{
+		{false, TopLeftPlaceholder + "|1|2\n1|<a>|<b>"},
+		{true, "<" + TopLeftPlaceholder + ">|<1>|<2>\n<1>|<a>|<b>"},
+	}
+	for _, tt := range tests {
+		opts := TableParseOptions{
+			Suffix: "_", NormalizerFunc: normalizer, NormalizeGenerated: tt.normalizeGenerated, RecordRawText: true,
+		}
+		ht := parseTableWithOptions(t, doc, opts)
+		if got := tableString(ht); got != tt.want {
+			t.Errorf("NormalizeGenerated %v: got\n%v\nwant\n%v", tt.normalizeGenerated, got, tt.want)
+		}
+		if _, ok := ht.GetRawElementByIndex(0, 1); ok {
+			t.Errorf("NormalizeGenerated %v: artificial header has a raw text", tt.normalizeGenerated)
+		}
+
+		table := GetElementNodesByTagName("table", parseDocument(t, doc))[0]
+		headers, err := ParseHtmlTableHeaders(table, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := strings.Join(headers, "|"), strings.SplitN(tt.want, "\n", 2)[0]; got != want {
+			t.Errorf("NormalizeGenerated %v: ParseHtmlTableHeaders returned %v, want %v", tt.normalizeGenerated, got, want)
+		}
+	}
+}
+
+func TestParseHtmlTableWithOptionsAcceptsSections(t *testing.T) {
+	doc := parseDocument(t, `<table><thead><tr><th>k</th><th>v</th></tr></thead>`+
+		`<tbody><tr><td>a</td><td>1</td></tr><tr><td>b</td><td>2</td></tr></tbody></table>`)
+	opts := TableParseOptions{HasIndexColumn: true, Suffix: "_"}
+
+	tbody := GetElementNodesByTagName("tbody", doc)[0]
+	ht, err := ParseHtmlTableWithOptions(tbody, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tableString(ht), TopLeftPlaceholder+"|1\na|1\nb|2"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	opts.StrictTableNode = true
+	if _, err := ParseHtmlTableWithOptions(tbody, opts); err == nil {
+		t.Error("StrictTableNode accepted a <tbody> element")
+	}
+	if _, err := ParseHtmlTableHeaders(tbody, opts); err == nil {
+		t.Error("ParseHtmlTableHeaders with StrictTableNode accepted a <tbody> element")
+	}
+	if _, err := ParseHtmlTableWithOptions(GetElementNodesByTagName("tr", doc)[0], TableParseOptions{}); err == nil {
+		t.Error("a <tr> element was accepted as table")
+	}
+}
+
+func TestParseHtmlTableRows(t *testing.T) {
+	context := &html.Node{Type: html.ElementNode, Data: "tbody", DataAtom: atom.Tbody}
+	nodes, err := html.ParseFragment(strings.NewReader(`<tr><th>k</th><th>v</th></tr>`+
+		`<tr><td>a</td><td>1</td></tr><tr><td>b</td><td>2</td></tr>`), context)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ht, err := ParseHtmlTableRows(nodes, TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tableString(ht), "k|v\na|1\nb|2"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	// the result equals parsing the same rows within a table
+	table := parseTable(t, `<table>`+renderNode(t, nodes[0])+renderNode(t, nodes[1])+renderNode(t, nodes[2])+`</table>`,
+		true, true)
+	if tableString(table) != tableString(ht) {
+		t.Errorf("got\n%v\nwithin a table\n%v", tableString(ht), tableString(table))
+	}
+
+	if _, err := ParseHtmlTableRows([]*html.Node{nodes[0], nil}, TableParseOptions{}); err == nil {
+		t.Error("a nil row was accepted")
+	}
+	if _, err := ParseHtmlTableRows([]*html.Node{nodes[0].FirstChild}, TableParseOptions{}); err == nil {
+		t.Error("a <th> element was accepted as row")
+	}
+	empty, err := ParseHtmlTableRows(nil, TableParseOptions{Suffix: "_"})
+	if err != nil || len(empty.TableData) != 0 {
+		t.Errorf("no rows yield %v, %v, want an empty table", empty, err)
+	}
+}
+
+func TestParseHtmlTableRowsSkipsRowsWithNestedRows(t *testing.T) {
+	doc := parseDocument(t, `<table><tr><td>a</td><td>1</td></tr>`+
+		`<tr><td><table><tr><td>b</td><td>2</td></tr></table></td></tr></table>`)
+	outer := getOwnTableRows(GetElementNodesByTagName("table", doc)[0])
+
+	ht, err := ParseHtmlTableRows(outer, TableParseOptions{HasIndexColumn: true, Suffix: "_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tableString(ht), TopLeftPlaceholder+"|1\na|1\nb|2"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	if len(ht.SkippedRows) != 1 || ht.SkippedRows[0] != 1 {
+		t.Errorf("got skipped rows %v, want [1]", ht.SkippedRows)
+	}
+
+	kept, err := ParseHtmlTableRows(outer, TableParseOptions{HasIndexColumn: true, KeepEmptyRows: true, Suffix: "_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept.TableData) != 2 || len(kept.SkippedRows) != 0 {
+		t.Errorf("KeepEmptyRows got\n%v\nskipped %v, want 2 rows and none skipped", tableString(kept), kept.SkippedRows)
+	}
+}
+
+func TestParseHtmlTableDetectRowHeaders(t *testing.T) {
+	const sales = `<table><thead><tr><th scope="col">Region</th><th scope="col">Q1</th><th scope="col">Q2</th></tr></thead>` +
+		`<tbody><tr><th scope="row">North</th><td>10</td><td>12</td></tr>` +
+		`<tr><th scope="row">South</th><td>7</td><td>9</td></tr>` +
+		`<tr><th>East</th><td>3</td><td>4</td></tr></tbody>` +
+		`<tfoot><tr><td>Total</td><td>20</td><td>25</td></tr></tfoot></table>`
+	tests := []struct {
+		name     string
+		html     string
+		opts     TableParseOptions
+		want     string
+		wantRows bool
+		wantGaps []int
+	}{
+		{"totals row with td", sales, TableParseOptions{HasHeaderRow: true, DetectRowHeaders: true},
+			"Region|Q1|Q2\nNorth|10|12\nSouth|7|9\nEast|3|4\nTotal|20|25", true, []int{4}},
+		{"flag already set", sales, TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, DetectRowHeaders: true},
+			"Region|Q1|Q2\nNorth|10|12\nSouth|7|9\nEast|3|4\nTotal|20|25", true, []int{4}},
+		{"without detection", sales, TableParseOptions{HasHeaderRow: true},
+			TopLeftPlaceholder + "|Region|Q1|Q2\n1|North|10|12\n2|South|7|9\n3|East|3|4\n4|Total|20|25", false, nil},
+		{"without header row", `<table><tr><td>x</td><td>1</td></tr><tr><th>a</th><td>2</td></tr><tr><th>b</th><td>3</td></tr>` +
+			`<tr><th>c</th><td>4</td></tr></table>`, TableParseOptions{DetectRowHeaders: true},
+			TopLeftPlaceholder + "|1\nx|1\na|2\nb|3\nc|4", true, []int{1}},
+		{"column headers only", `<table><tr><th>k</th><th>v</th></tr><tr><th scope="col">a</th><td>1</td></tr>` +
+			`<tr><td>b</td><td>2</td></tr></table>`, TableParseOptions{HasHeaderRow: true, DetectRowHeaders: true},
+			TopLeftPlaceholder + "|k|v\n1|a|1\n2|b|2", false, nil},
+		{"half of the rows", `<table><tr><th>k</th><th>v</th></tr><tr><th>a</th><td>1</td></tr><tr><td>b</td><td>2</td></tr>` +
+			`</table>`, TableParseOptions{HasHeaderRow: true, DetectRowHeaders: true},
+			TopLeftPlaceholder + "|k|v\n1|a|1\n2|b|2", false, nil},
+		{"header row only", `<table><tr><th>k</th><th>v</th></tr></table>`,
+			TableParseOptions{HasHeaderRow: true, DetectRowHeaders: true}, TopLeftPlaceholder + "|k|v", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Suffix = "_"
+			ht := parseTableWithOptions(t, tt.html, tt.opts)
+			if got := tableString(ht); got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+			rowHeaders, gaps := ht.RowHeaders()
+			if rowHeaders != tt.wantRows || fmt.Sprint(gaps) != fmt.Sprint(tt.wantGaps) {
+				t.Errorf("RowHeaders() = %v, %v, want %v, %v", rowHeaders, gaps, tt.wantRows, tt.wantGaps)
+			}
+			for _, i := range gaps {
+				if ht.GetElementByIndex(i, 0) == "" {
+					t.Errorf("gap row %v has no index value", i)
+				}
+			}
+			if rowHeaders != ht.HasRealIndex() && !tt.opts.HasIndexColumn {
+				t.Errorf("HasRealIndex() = %v, want %v", ht.HasRealIndex(), rowHeaders)
+			}
+
+			// the headers of the full parse agree with ParseHtmlTableHeaders
+			headers, err := ParseHtmlTableHeaders(GetElementNodesByTagName("table", parseDocument(t, tt.html))[0], tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := strings.Join(headers, "|"), strings.Join(ht.Headers, "|"); got != want {
+				t.Errorf("ParseHtmlTableHeaders got\n%v\nwant\n%v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseHtmlTableDropRepeatedHeaderRows(t *testing.T) {
+	const long = `<table><thead><tr><th>Day</th><th>Min</th><th>Max</th><th>Max</th></tr></thead><tbody>` +
+		`<tr><td>1</td><td>3</td><td>9</td><td>8</td></tr>` +
+		`<tr><td>2</td><td>4</td><td>Max</td><td>Max</td></tr>` + // not a repeat, Min differs
+		`<tr><th>Day</th><th>Min</th><th>Max</th><th>Max</th></tr>` +
+		`<tr><td>3</td><td>2</td><td>7</td><td>7</td></tr>` +
+		`<tr><td>repeat</td><td> Min </td><td>Max</td><td>Max</td></tr>` + // td repeat, the index cell is ignored
+		`<tr><td>4</td><td>1</td><td>5</td><td>6</td></tr>` +
+		`</tbody></table>`
+
+	ht := parseTableWithOptions(t, long, TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_",
+		DropRepeatedHeaderRows: true, NormalizerFunc: TrimBlank, RecordSpans: true, RecordProvenance: true})
+	if got, want := tableString(ht), "Day|Min|Max|Max_2\n1|3|9|8\n2|4|Max|Max\n3|2|7|7\n4|1|5|6"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	if got := ht.DroppedHeaderRows(); fmt.Sprint(got) != "[3 5]" {
+		t.Errorf("DroppedHeaderRows() = %v, want [3 5]", got)
+	}
+	// provenance still refers to the source rows
+	if provenance, ok := ht.GetCellProvenance(4, 1); !ok || provenance.SourceRow != 6 {
+		t.Errorf("GetCellProvenance(4, 1) = %+v, %v, want source row 6", provenance, ok)
+	}
+	if len(ht.Spans) != 20 {
+		t.Errorf("got %v spans, want one per cell of the remaining rows", len(ht.Spans))
+	}
+
+	kept := parseTableWithOptions(t, long, TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_",
+		NormalizerFunc: TrimBlank})
+	if len(kept.TableData) != 6 || len(kept.DroppedHeaderRows()) != 0 {
+		t.Errorf("without DropRepeatedHeaderRows got\n%v", tableString(kept))
+	}
+
+	// with an artificial index, all cells are compared
+	ht = parseTableWithOptions(t, `<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2</td></tr>`+
+		`<tr><td>x</td><td>b</td></tr><tr><td>a</td><td>b</td></tr></table>`,
+		TableParseOptions{HasHeaderRow: true, Suffix: "_", DropRepeatedHeaderRows: true})
+	if got, want := tableString(ht), TopLeftPlaceholder+"|a|b\n1|1|2\n2|x|b"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	// row headers are detected before dropping, the gaps refer to the remaining rows
+	ht = parseTableWithOptions(t, `<table><tr><th>k</th><th>v</th></tr><tr><th>a</th><td>1</td></tr>`+
+		`<tr><th>k</th><th>v</th></tr><tr><th>b</th><td>2</td></tr><tr><td>sum</td><td>3</td></tr>`+
+		`<tr><th>c</th><td>4</td></tr></table>`,
+		TableParseOptions{HasHeaderRow: true, Suffix: "_", DetectRowHeaders: true, DropRepeatedHeaderRows: true})
+	if got, want := tableString(ht), "k|v\na|1\nb|2\nsum|3\nc|4"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	if rowHeaders, gaps := ht.RowHeaders(); !rowHeaders || fmt.Sprint(gaps) != "[3]" {
+		t.Errorf("RowHeaders() = %v, %v, want true, [3]", rowHeaders, gaps)
+	}
+
+	// nothing is dropped without header texts
+	ht = parseTableWithOptions(t, `<table><tr><th></th><th></th></tr><tr><td></td><td></td></tr></table>`,
+		TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_", DropRepeatedHeaderRows: true})
+	if len(ht.TableData) != 1 || len(ht.DroppedHeaderRows()) != 0 {
+		t.Errorf("got\n%v\ndropped %v", tableString(ht), ht.DroppedHeaderRows())
+	}
+}
+
+func TestHtmlTableKeySources(t *testing.T) {
+	const thead = `<table><thead><tr><th>k</th><th>v</th></tr></thead><tbody><tr><td>a</td><td>1</td></tr></tbody></table>`
+	const firstRow = `<table><tr><th>k</th><th>v</th></tr><tr><td>a</td><td>1</td></tr></table>`
+	const rowHeaders = `<table><tr><td>k</td><td>v</td></tr><tr><th>a</th><td>1</td></tr>` +
+		`<tr><th>b</th><td>2</td></tr></table>`
+	tests := []struct {
+		name                  string
+		html                  string
+		opts                  TableParseOptions
+		wantHeader, wantIndex KeySource
+		wantRows, wantColumns []int
+		want                  string
+	}{
+		{"thead and first column", thead, TableParseOptions{HasHeaderRow: true, HasIndexColumn: true},
+			KeySourceTHead, KeySourceFirstColumn, nil, nil, "k|v\na|1"},
+		{"first row", firstRow, TableParseOptions{HasHeaderRow: true},
+			KeySourceFirstRow, KeySourceArtificial, nil, []int{0}, TopLeftPlaceholder + "|k|v\n1|a|1"},
+		{"artificial", firstRow, TableParseOptions{HasIndexColumn: true},
+			KeySourceArtificial, KeySourceFirstColumn, []int{0}, nil, TopLeftPlaceholder + "|1\nk|v\na|1"},
+		{"all artificial", thead, TableParseOptions{},
+			KeySourceArtificial, KeySourceArtificial, []int{0}, []int{0}, TopLeftPlaceholder + "|1|2\n1|k|v\n2|a|1"},
+		{"row headers", rowHeaders, TableParseOptions{DetectRowHeaders: true},
+			KeySourceArtificial, KeySourceRowHeaders, []int{0}, nil, TopLeftPlaceholder + "|1\nk|v\na|1\nb|2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Suffix = "_"
+			ht := parseTableWithOptions(t, tt.html, tt.opts)
+			if got := tableString(ht); got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+			if ht.HeaderSource() != tt.wantHeader || ht.IndexSource() != tt.wantIndex {
+				t.Errorf("sources %v, %v, want %v, %v", ht.HeaderSource(), ht.IndexSource(), tt.wantHeader, tt.wantIndex)
+			}
+			if ht.HasRealHeaders() != (tt.wantHeader != KeySourceArtificial) ||
+				ht.HasRealIndex() != (tt.wantIndex != KeySourceArtificial) {
+				t.Errorf("HasRealHeaders() = %v, HasRealIndex() = %v", ht.HasRealHeaders(), ht.HasRealIndex())
+			}
+			if got := ht.ArtificialRows(); fmt.Sprint(got) != fmt.Sprint(tt.wantRows) {
+				t.Errorf("ArtificialRows() = %v, want %v", got, tt.wantRows)
+			}
+			if got := ht.ArtificialColumns(); fmt.Sprint(got) != fmt.Sprint(tt.wantColumns) {
+				t.Errorf("ArtificialColumns() = %v, want %v", got, tt.wantColumns)
+			}
+			// artificial keys are the placeholder followed by 1..N
+			for _, j := range ht.ArtificialColumns() {
+				column, _ := ht.GetColumnByIndex(j)
+				for i, key := range column {
+					if want := strconv.Itoa(i); i == 0 && key != TopLeftPlaceholder || i > 0 && key != want {
+						t.Errorf("artificial index key %v is %q", i, key)
+					}
+				}
+			}
+		})
+	}
+
+	// the section of the header row of ParseHtmlTableRows decides as well
+	context := &html.Node{Type: html.ElementNode, Data: "table", DataAtom: atom.Table}
+	nodes, err := html.ParseFragment(strings.NewReader(`<thead><tr><th>k</th></tr></thead><tr><td>a</td></tr>`), context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []*html.Node
+	for _, node := range nodes {
+		rows = append(rows, GetElementNodesByTagName("tr", node)...)
+	}
+	ht, err := ParseHtmlTableRows(rows, TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_"})
+	if err != nil || ht.HeaderSource() != KeySourceTHead {
+		t.Errorf("got source %v, %v, want KeySourceTHead", ht.HeaderSource(), err)
+	}
+
+	// reshaped tables have derived keys
+	long, err := parseTable(t, firstRow, true, true).Melt(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if long.HeaderSource() != KeySourceDerived || long.IndexSource() != KeySourceArtificial {
+		t.Errorf("Melt sources %v, %v", long.HeaderSource(), long.IndexSource())
+	}
+	wide, err := long.Pivot(MeltVariableColumn, MeltValueColumn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wide.HeaderSource() != KeySourceDerived || wide.IndexSource() != KeySourceDerived || wide.ArtificialColumns() != nil {
+		t.Errorf("Pivot sources %v, %v", wide.HeaderSource(), wide.IndexSource())
+	}
+}
+
+func TestHtmlTableOccurrenceKey(t *testing.T) {
+	ht := parseTableWithOptions(t, `<table><tr><th>k</th><th>v</th><th>v</th></tr><tr><td>a</td><td>1</td><td>2</td></tr>`+
+		`<tr><td>a</td><td>3</td><td>4</td></tr></table>`,
+		TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "#"})
+	if got := ht.OccurrenceKey("v", 0); got != "v" {
+		t.Errorf("OccurrenceKey(v, 0) = %v", got)
+	}
+	if got := ht.OccurrenceKey("v", 2); got != "v#2" {
+		t.Errorf("OccurrenceKey(v, 2) = %v", got)
+	}
+	value, i, j, ok := ht.GetElementByKeys(ht.OccurrenceKey("a", 2), ht.OccurrenceKey("v", 2))
+	if !ok || value != "4" || i != 2 || j != 2 {
+		t.Errorf("GetElementByKeys() = %v, %v, %v, %v", value, i, j, ok)
+	}
+	if numValue, _, _, ok := ht.GetElementByKeysNum("a", "v", 2, 2); !ok || numValue != value {
+		t.Errorf("GetElementByKeysNum() = %v, %v, want %v", numValue, ok, value)
+	}
+}
@@ -0,0 +1,234 @@
+// Package htmltest provides helpers for golden-snapshot tests of code working on *html.Node trees.
+package htmltest
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// indentation
+// Indentation per nesting level of NormalizeForSnapshot.
+const indentation = "  "
+
+// voidElements
+// Elements which have no closing tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// rawTextElements
+// Elements whose whitespace is significant or whose content is not html.
+var rawTextElements = map[string]bool{
+	"pre": true, "textarea": true, "script": true, "style": true, "plaintext": true, "listing": true, "xmp": true,
+}
+
+// NormalizeForSnapshot
+// Renders the tree of n in a stable, machine-independent form for snapshot comparisons: one node per line indented by
+// nesting level, attributes sorted by key, whitespace of text nodes collapsed and trimmed, and whitespace-only text
+// nodes dropped. Whitespace inside <pre>, <textarea>, <script>, and <style> elements is kept verbatim.
+func NormalizeForSnapshot(n *html.Node) string {
+	var sb strings.Builder
+	writeSnapshot(&sb, n, 0, false)
+	return sb.String()
+}
+
+func writeSnapshot(sb *strings.Builder, n *html.Node, depth int, raw bool) {
+	if n == nil {
+		return
+	}
+	indent := strings.Repeat(indentation, depth)
+
+	switch n.Type {
+	case html.DocumentNode:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeSnapshot(sb, c, depth, raw)
+		}
+	case html.DoctypeNode:
+		fmt.Fprintf(sb, "%v<!DOCTYPE %v>\n", indent, n.Data)
+	case html.CommentNode:
+		fmt.Fprintf(sb, "%v<!--%v-->\n", indent, strings.Join(strings.Fields(n.Data), " "))
+	case html.TextNode:
+		if raw {
+			fmt.Fprintf(sb, "%v%q\n", indent, n.Data)
+			return
+		}
+		if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+			fmt.Fprintf(sb, "%v%v\n", indent, html.EscapeString(text))
+		}
+	case html.ElementNode:
+		attrs := make([]html.Attribute, len(n.Attr))
+		copy(attrs, n.Attr)
+		sort.SliceStable(attrs, func(i, j int) bool {
+			if attrs[i].Namespace != attrs[j].Namespace {
+				return attrs[i].Namespace < attrs[j].Namespace
+			}
+			return attrs[i].Key < attrs[j].Key
+		})
+
+		sb.WriteString(indent + "<" + n.Data)
+		for _, attr := range attrs {
+			key := attr.Key
+			if attr.Namespace != "" {
+				key = attr.Namespace + ":" + key
+			}
+			fmt.Fprintf(sb, " %v=\"%v\"", key, html.EscapeString(attr.Val))
+		}
+		sb.WriteString(">\n")
+
+		if voidElements[n.Data] {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeSnapshot(sb, c, depth+1, raw || rawTextElements[n.Data])
+		}
+		fmt.Fprintf(sb, "%v</%v>\n", indent, n.Data)
+	}
+}
+
+// diffContext
+// Number of unchanged lines shown around changes by DiffSnapshots.
+const diffContext = 3
+
+// splitLines
+// Splits s into lines without their line breaks, an empty s has no lines.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// DiffSnapshots
+// Returns a unified diff from snapshot a to snapshot b, e.g., of NormalizeForSnapshot outputs, or "" if they are equal.
+func DiffSnapshots(a, b string) string {
+	if a == b {
+		return ""
+	}
+	linesA, linesB := splitLines(a), splitLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of linesA[i:] and linesB[j:]
+	lcs := make([][]int, len(linesA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(linesB)+1)
+	}
+	for i := len(linesA) - 1; i >= 0; i-- {
+		for j := len(linesB) - 1; j >= 0; j-- {
+			if linesA[i] == linesB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// edit script, deletions before insertions
+	type edit struct {
+		op         byte
+		line       string
+		posA, posB int // 0-based positions of the line in a and b before applying the edit
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(linesA) || j < len(linesB) {
+		switch {
+		case i < len(linesA) && j < len(linesB) && linesA[i] == linesB[j]:
+			edits = append(edits, edit{' ', linesA[i], i, j})
+			i, j = i+1, j+1
+		case i < len(linesA) && (j == len(linesB) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', linesA[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', linesB[j], i, j})
+			j++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- a\n+++ b\n")
+	for k := 0; k < len(edits); {
+		if edits[k].op == ' ' {
+			k++
+			continue
+		}
+		// hunk from the first change minus context to the last change plus context, merging close changes
+		start := k - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := k
+		for end < len(edits) {
+			if edits[end].op != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(edits) && edits[next].op == ' ' {
+				next++
+			}
+			if next == len(edits) || next-end > 2*diffContext {
+				end += diffContext
+				if end > len(edits) {
+					end = len(edits)
+				}
+				break
+			}
+			end = next
+		}
+
+		countA, countB := 0, 0
+		for _, e := range edits[start:end] {
+			if e.op != '+' {
+				countA++
+			}
+			if e.op != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%v +%v @@\n", formatHunkRange(edits[start].posA, countA), formatHunkRange(edits[start].posB, countB))
+		for _, e := range edits[start:end] {
+			sb.WriteByte(e.op)
+			sb.WriteString(e.line)
+			sb.WriteByte('\n')
+		}
+		k = end
+	}
+	return sb.String()
+}
+
+// formatHunkRange
+// Formats the line range of a hunk starting at the 0-based position pos like diff -u, i.e., a count of 1 is omitted
+// and an empty range refers to the line before it.
+func formatHunkRange(pos, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%v,0", pos)
+	case 1:
+		return fmt.Sprint(pos + 1)
+	default:
+		return fmt.Sprintf("%v,%v", pos+1, count)
+	}
+}
+
+// LoadFixture
+// Reads and parses the html document at path, failing the test if it cannot be read or parsed.
+func LoadFixture(t testing.TB, path string) *html.Node {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open fixture '%v': %v", path, err)
+	}
+	defer f.Close()
+
+	doc, err := html.Parse(f)
+	if err != nil {
+		t.Fatalf("failed to parse fixture '%v': %v", path, err)
+	}
+	return doc
+}
@@ -0,0 +1,135 @@
+package htmltest
+
+import (
+	"golang.org/x/net/html"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parse
+// Parses s as html document and fails the test on error.
+func parse(t *testing.T, s string) *html.Node {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestNormalizeForSnapshot(t *testing.T) {
+	doc := parse(t, "<!DOCTYPE html><div z=\"1\" a=\"&lt;2&gt;\">\n  Hello\n\t <b>big</b>   world <!--  a\n comment -->"+
+		"<br><pre>  keep\n  this </pre></div>")
+
+	want := `<!DOCTYPE html>
+<html>
+  <head>
+  </head>
+  <body>
+    <div a="&lt;2&gt;" z="1">
+      Hello
+      <b>
+        big
+      </b>
+      world
+      <!--a comment-->
+      <br>
+      <pre>
+        "  keep\n  this "
+      </pre>
+    </div>
+  </body>
+</html>
+`
+	if got := NormalizeForSnapshot(doc); got != want {
+		t.Errorf("got\n%v\nwant\n%v\ndiff\n%v", got, want, DiffSnapshots(want, got))
+	}
+
+	// attribute order and insignificant whitespace do not matter
+	other := parse(t, "<!DOCTYPE html>\n<html><body>\n<div z=\"1\" a=\"&lt;2&gt;\">Hello <b> big </b>world<!--a comment-->"+
+		"<br><pre>  keep\n  this </pre></div></body></html>")
+	if diff := DiffSnapshots(NormalizeForSnapshot(doc), NormalizeForSnapshot(other)); diff != "" {
+		t.Errorf("equivalent documents differ:\n%v", diff)
+	}
+	if got := NormalizeForSnapshot(nil); got != "" {
+		t.Errorf("NormalizeForSnapshot(nil) = %q", got)
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	lines := make([]string, 15)
+	for i := range lines {
+		lines[i] = string(rune('a' + i))
+	}
+	a := strings.Join(lines, "\n") + "\n"
+	changed := append([]string(nil), lines...)
+	changed[1], changed[12] = "B", "M"
+
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", a, a, ""},
+		{"two hunks", a, strings.Join(changed, "\n") + "\n", `--- a
++++ b
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,6 +10,6 @@
+ j
+ k
+ l
+-m
++M
+ n
+ o
+`},
+		{"merged hunk", "a\nb\nc\nd\ne\nf\n", "A\nb\nc\nd\ne\nF\n", `--- a
++++ b
+@@ -1,6 +1,6 @@
+-a
++A
+ b
+ c
+ d
+ e
+-f
++F
+`},
+		{"insertion at start", "a\nb\n", "x\na\nb\n", `--- a
++++ b
+@@ -1,2 +1,3 @@
++x
+ a
+ b
+`},
+		{"all deleted", "a\n", "", `--- a
++++ b
+@@ -1 +0,0 @@
+-a
+`},
+	}
+	for _, tt := range tests {
+		if got := DiffSnapshots(tt.a, tt.b); got != tt.want {
+			t.Errorf("%v: got\n%v\nwant\n%v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFixture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.html")
+	if err := os.WriteFile(path, []byte(`<p id="x">fixture</p>`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	doc := LoadFixture(t, path)
+	if got := NormalizeForSnapshot(doc); !strings.Contains(got, "<p id=\"x\">\n      fixture\n") {
+		t.Errorf("unexpected fixture snapshot\n%v", got)
+	}
+}
@@ -0,0 +1,153 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// DocumentIndex
+// Lookup tables over a html tree which is built once and can be reused for many lookups, e.g., resolving the labels
+// of all controls of a large form.
+// The index is not updated if the tree is modified afterwards.
+type DocumentIndex struct {
+	root      *html.Node
+	byId      map[string]*html.Node // id -> first element with that id
+	labelsFor map[string]*html.Node // id -> first <label> element with attribute for="id"
+}
+
+// NewDocumentIndex
+// Builds the index of the tree of root (including root).
+func NewDocumentIndex(root *html.Node) *DocumentIndex {
+	idx := &DocumentIndex{
+		root:      root,
+		byId:      make(map[string]*html.Node),
+		labelsFor: make(map[string]*html.Node),
+	}
+
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if attr, err := GetAttributeByKey(n, "id"); err == nil {
+			if _, ok := idx.byId[attr.Val]; !ok {
+				idx.byId[attr.Val] = n
+			}
+		}
+		if n.Data == "label" {
+			if attr, err := GetAttributeByKey(n, "for"); err == nil {
+				if _, ok := idx.labelsFor[attr.Val]; !ok {
+					idx.labelsFor[attr.Val] = n
+				}
+			}
+		}
+		return true
+	})
+
+	return idx
+}
+
+// Root
+// Returns the root node the index was built from.
+func (idx *DocumentIndex) Root() *html.Node {
+	return idx.root
+}
+
+// GetElementById
+// Returns the first element in document order with the given id, or nil.
+func (idx *DocumentIndex) GetElementById(id string) *html.Node {
+	return idx.byId[id]
+}
+
+// GetLabelForControl
+// Returns the visible label text of the form control and the node it was taken from.
+// The label is resolved in this order:
+//   - the first <label> element with attribute 'for' matching the control's id
+//   - the closest ancestor <label> element wrapping the control
+//   - the elements referenced by the control's 'aria-labelledby' attribute, their texts are joined by a space and the
+//     first referenced element is returned
+//   - the control's 'aria-label' attribute, the returned node is the control itself
+//
+// Text of the control itself and of nested select, textarea, and button elements is not part of the label text.
+// Returns "", nil if the control has no label.
+func (idx *DocumentIndex) GetLabelForControl(control *html.Node) (string, *html.Node) {
+	if control == nil {
+		return "", nil
+	}
+
+	if id, err := GetAttributeByKey(control, "id"); err == nil && id.Val != "" {
+		if label, ok := idx.labelsFor[id.Val]; ok {
+			return getLabelText(label, control), label
+		}
+	}
+
+	for p := control.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "label" {
+			return getLabelText(p, control), p
+		}
+	}
+
+	if labelledBy, err := GetAttributeByKey(control, "aria-labelledby"); err == nil {
+		var texts []string
+		var first *html.Node
+		for _, id := range strings.Fields(labelledBy.Val) {
+			if labelNode := idx.GetElementById(id); labelNode != nil {
+				if first == nil {
+					first = labelNode
+				}
+				if text := getLabelText(labelNode, control); text != "" {
+					texts = append(texts, text)
+				}
+			}
+		}
+		if first != nil {
+			return strings.Join(texts, " "), first
+		}
+	}
+
+	if ariaLabel, err := GetAttributeByKey(control, "aria-label"); err == nil && strings.TrimSpace(ariaLabel.Val) != "" {
+		return strings.Join(strings.Fields(ariaLabel.Val), " "), control
+	}
+
+	return "", nil
+}
+
+// CollectIDs
+// Returns the first element in document order for each id in the tree of root (including root), and the ids which are
+// used by more than one element in order of their first duplicate. Ids are case-sensitive, so 'Id' and 'id' differ.
+// Empty id attributes are no ids and therefore ignored.
+func CollectIDs(root *html.Node) (map[string]*html.Node, []string) {
+	ids := make(map[string]*html.Node)
+	var duplicates []string
+	isDuplicate := make(map[string]bool)
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if attr, err := GetAttributeByKey(n, "id"); err == nil && attr.Val != "" {
+			if _, ok := ids[attr.Val]; !ok {
+				ids[attr.Val] = n
+			} else if !isDuplicate[attr.Val] {
+				isDuplicate[attr.Val] = true
+				duplicates = append(duplicates, attr.Val)
+			}
+		}
+		return true
+	})
+	return ids, duplicates
+}
+
+// GetLabelForControl
+// Returns the visible label text of the form control within the document of root and the node it was taken from.
+// See DocumentIndex.GetLabelForControl for the resolution rules.
+// Builds a DocumentIndex for each call, use DocumentIndex.GetLabelForControl when resolving multiple labels.
+func GetLabelForControl(root *html.Node, control *html.Node) (string, *html.Node) {
+	return NewDocumentIndex(root).GetLabelForControl(control)
+}
+
+// getLabelText
+// Returns the inner text of label without the texts of control and of nested select, textarea, and button elements.
+func getLabelText(label *html.Node, control *html.Node) string {
+	return getInnerTextExcluding(label, func(n *html.Node) bool {
+		return n == control || (n.Type == html.ElementNode && (n.Data == "select" || n.Data == "textarea" || n.Data == "button"))
+	})
+}
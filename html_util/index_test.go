@@ -0,0 +1,88 @@
+package html_util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetLabelForControl(t *testing.T) {
+	doc := parseDocument(t, `<form>`+
+		`<label id="l-for" for="name">Full <b>name</b></label><input id="name">`+
+		`<label id="l-wrap">E-mail <input id="mail"> <button>check</button></label>`+
+		`<label id="l-both" for="both">Explicit</label><label>Wrapping <input id="both"></label>`+
+		`<span id="first">Street</span><span id="second">and number</span>`+
+		`<input id="labelled" aria-labelledby="missing first second" aria-label="ignored">`+
+		`<input id="aria" aria-label="  Search   term ">`+
+		`<input id="none"><input aria-labelledby="missing">`+
+		`</form>`)
+	idx := NewDocumentIndex(doc)
+
+	tests := []struct {
+		control   string
+		wantText  string
+		wantLabel string
+	}{
+		{"name", "Full name", "l-for"},
+		{"mail", "E-mail", "l-wrap"},   // the texts of the control and of nested buttons are excluded
+		{"both", "Explicit", "l-both"}, // label[for] beats a wrapping label
+		{"labelled", "Street and number", "first"},
+		{"aria", "Search term", "aria"},
+		{"none", "", ""},
+	}
+	for _, tt := range tests {
+		control := idx.GetElementById(tt.control)
+		text, label := idx.GetLabelForControl(control)
+		if text != tt.wantText || nodeID(label) != tt.wantLabel {
+			t.Errorf("GetLabelForControl(%v) = %q, %v, want %q, %v", tt.control, text, nodeID(label), tt.wantText, tt.wantLabel)
+		}
+		if text2, label2 := GetLabelForControl(doc, control); text2 != text || label2 != label {
+			t.Errorf("GetLabelForControl(root, %v) = %q, %v, want the result of the index", tt.control, text2, nodeID(label2))
+		}
+	}
+
+	unresolved := GetElementNodesByTagName("input", doc)[6]
+	if text, label := idx.GetLabelForControl(unresolved); text != "" || label != nil {
+		t.Errorf("unresolvable aria-labelledby: got %q, %v, want no label", text, label)
+	}
+	if text, label := idx.GetLabelForControl(nil); text != "" || label != nil {
+		t.Errorf("nil control: got %q, %v, want no label", text, label)
+	}
+}
+
+func TestCollectIDs(t *testing.T) {
+	doc := parseDocument(t, `<div id="main"><p id="Intro" class="first">a</p><p id="intro">b</p>`+
+		`<p id="intro" class="second">c</p><p id="">d</p><p id="">e</p><span id="main"></span><span id="Intro"></span></div>`)
+	ids, duplicates := CollectIDs(doc)
+
+	if len(ids) != 3 || ids["main"].Data != "div" || GetInnerText(ids["Intro"]) != "a" || GetInnerText(ids["intro"]) != "b" {
+		t.Errorf("got ids %v", ids)
+	}
+	if _, ok := ids[""]; ok {
+		t.Error("empty id is collected")
+	}
+	// in order of the first duplicate, case variants are no duplicates of each other
+	if got := strings.Join(duplicates, " "); got != "intro main Intro" {
+		t.Errorf("got duplicates %q, want intro main Intro", got)
+	}
+
+	// the index and the id conditions agree with the case-sensitive ids
+	idx := NewDocumentIndex(doc)
+	for id, node := range ids {
+		if got := idx.GetElementById(id); got != node {
+			t.Errorf("GetElementById(%v) = %v, want the first element with the id", id, got)
+		}
+		if got := GetNodeByCondition(doc, MakeByIdCondition(id)); got != node {
+			t.Errorf("MakeByIdCondition(%v) matched %v", id, GetInnerText(got))
+		}
+	}
+	if got := len(GetNodesByCondition(doc, MakeByIdCondition("INTRO"))); got != 0 {
+		t.Errorf("MakeByIdCondition(INTRO) matched %v elements", got)
+	}
+	if got := len(GetNodesByCondition(doc, MakeByIdConditionFold("INTRO"))); got != 4 {
+		t.Errorf("MakeByIdConditionFold(INTRO) matched %v elements, want 4", got)
+	}
+
+	if ids, duplicates := CollectIDs(nil); len(ids) != 0 || duplicates != nil {
+		t.Errorf("CollectIDs(nil) = %v, %v", ids, duplicates)
+	}
+}
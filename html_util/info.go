@@ -0,0 +1,234 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// InfoSession
+// Caches the computed properties of nodes, see Info, e.g., for the duration of one traversal with conditions that
+// need several text-dependent properties.
+// The cached values are not updated if the tree is modified, use a new session after mutations.
+// A session must not be used from multiple goroutines concurrently.
+type InfoSession struct {
+	infos   map[*html.Node]*Info
+	indices map[*html.Node]*DocumentIndex // tree root -> index, for resolving labels and aria references
+}
+
+// NewInfoSession
+// Returns an empty session.
+func NewInfoSession() *InfoSession {
+	return &InfoSession{
+		infos:   make(map[*html.Node]*Info),
+		indices: make(map[*html.Node]*DocumentIndex),
+	}
+}
+
+// NodeInfo
+// Returns the computed properties of n, which are evaluated lazily and cached within the session.
+func (s *InfoSession) NodeInfo(n *html.Node) *Info {
+	if info, ok := s.infos[n]; ok {
+		return info
+	}
+	info := &Info{Node: n, session: s}
+	s.infos[n] = info
+	return info
+}
+
+// Condition
+// Returns a condition, e.g., for GetNodesByCondition, evaluating f on the Info of each node within this session.
+func (s *InfoSession) Condition(f func(info *Info) bool) func(node *html.Node) bool {
+	return func(node *html.Node) bool {
+		return f(s.NodeInfo(node))
+	}
+}
+
+// index
+// Returns the DocumentIndex of the tree of n, built once per session.
+func (s *InfoSession) index(n *html.Node) *DocumentIndex {
+	root := TreeRoot(n)
+	idx, ok := s.indices[root]
+	if !ok {
+		idx = NewDocumentIndex(root)
+		s.indices[root] = idx
+	}
+	return idx
+}
+
+// NodeInfo
+// Returns the computed properties of n within a new InfoSession, i.e., cached only for the returned Info.
+// Use InfoSession.NodeInfo to share the cache between nodes.
+func NodeInfo(n *html.Node) *Info {
+	return NewInfoSession().NodeInfo(n)
+}
+
+// Info
+// Lazily computed and cached properties of a node, see InfoSession.
+type Info struct {
+	Node    *html.Node
+	session *InfoSession
+
+	text              *string
+	accessibleName    *string
+	depth             *int
+	childElementCount *int
+	classes           map[string]bool
+	visible           *bool
+}
+
+// Text
+// Returns the inner text of the node, see GetInnerText.
+func (info *Info) Text() string {
+	if info.text == nil {
+		text := GetInnerText(info.Node)
+		info.text = &text
+	}
+	return *info.text
+}
+
+// Depth
+// Returns the number of ancestors of the node.
+func (info *Info) Depth() int {
+	if info.depth == nil {
+		depth := 0
+		if info.Node != nil && info.Node.Parent != nil {
+			depth = info.session.NodeInfo(info.Node.Parent).Depth() + 1
+		}
+		info.depth = &depth
+	}
+	return *info.depth
+}
+
+// ChildElementCount
+// Returns the number of element children of the node.
+func (info *Info) ChildElementCount() int {
+	if info.childElementCount == nil {
+		count := 0
+		for _, c := range GetChildren(info.Node) {
+			if c.Type == html.ElementNode {
+				count++
+			}
+		}
+		info.childElementCount = &count
+	}
+	return *info.childElementCount
+}
+
+// Classes
+// Returns the set of lower case class names of the node. The set must not be modified.
+func (info *Info) Classes() map[string]bool {
+	if info.classes == nil {
+		info.classes = make(map[string]bool)
+		if info.Node != nil {
+			if attr, err := GetAttributeByKey(info.Node, "class"); err == nil {
+				for _, className := range strings.Fields(attr.Val) {
+					info.classes[strings.ToLower(className)] = true
+				}
+			}
+		}
+	}
+	return info.classes
+}
+
+// HasClass
+// Returns whether the node has the class name, compared case-insensitively.
+func (info *Info) HasClass(className string) bool {
+	return info.Classes()[strings.ToLower(className)]
+}
+
+// Visible
+// Returns whether the node would be rendered, judged by its markup and that of its ancestors: it is hidden by the
+// 'hidden' attribute, aria-hidden="true", an inline style with display: none or visibility: hidden, <input
+// type="hidden">, and by being part of a non-rendered element like <script> or <template>. Stylesheets are not
+// evaluated.
+func (info *Info) Visible() bool {
+	if info.visible == nil {
+		visible := isVisibleElement(info.Node)
+		if visible && info.Node.Parent != nil {
+			visible = info.session.NodeInfo(info.Node.Parent).Visible()
+		}
+		info.visible = &visible
+	}
+	return *info.visible
+}
+
+// isVisibleElement
+// Returns whether node itself does not hide its subtree, see Info.Visible.
+func isVisibleElement(node *html.Node) bool {
+	if node == nil {
+		return false
+	}
+	if node.Type != html.ElementNode {
+		return true
+	}
+	if isNonRenderedNode(node) || hasAttribute(node, "hidden") || AttributeEquals(node, "aria-hidden", "true", false) {
+		return false
+	}
+	if node.Data == "input" && AttributeEquals(node, "type", "hidden", false) {
+		return false
+	}
+	display := strings.ToLower(getStyleProperty(node, "display"))
+	visibility := strings.ToLower(getStyleProperty(node, "visibility"))
+	return display != "none" && visibility != "hidden" && visibility != "collapse"
+}
+
+// AccessibleName
+// Returns a simplified accessible name of the node, resolved in this order: the texts of the elements referenced by
+// aria-labelledby, aria-label, the label of form controls (see DocumentIndex.GetLabelForControl), the alt attribute of
+// images, the inner text, and the title attribute. Whitespace is collapsed.
+func (info *Info) AccessibleName() string {
+	if info.accessibleName == nil {
+		name := info.computeAccessibleName()
+		info.accessibleName = &name
+	}
+	return *info.accessibleName
+}
+
+// computeAccessibleName
+// See AccessibleName.
+func (info *Info) computeAccessibleName() string {
+	node := info.Node
+	if node == nil || node.Type != html.ElementNode {
+		return ""
+	}
+	collapse := func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	}
+
+	switch node.Data {
+	case "input", "select", "textarea", "meter", "progress", "output":
+		if label, _ := info.session.index(node).GetLabelForControl(node); label != "" {
+			return collapse(label)
+		}
+	default:
+		if labelledBy, err := GetAttributeByKey(node, "aria-labelledby"); err == nil {
+			var texts []string
+			for _, id := range strings.Fields(labelledBy.Val) {
+				if labelNode := info.session.index(node).GetElementById(id); labelNode != nil {
+					if text := info.session.NodeInfo(labelNode).Text(); text != "" {
+						texts = append(texts, text)
+					}
+				}
+			}
+			if len(texts) > 0 {
+				return collapse(strings.Join(texts, " "))
+			}
+		}
+		if ariaLabel, err := GetAttributeByKey(node, "aria-label"); err == nil && strings.TrimSpace(ariaLabel.Val) != "" {
+			return collapse(ariaLabel.Val)
+		}
+	}
+
+	if node.Data == "img" || node.Data == "area" || (node.Data == "input" && AttributeEquals(node, "type", "image", false)) {
+		if alt, err := GetAttributeByKey(node, "alt"); err == nil && strings.TrimSpace(alt.Val) != "" {
+			return collapse(alt.Val)
+		}
+	}
+	if text := collapse(info.Text()); text != "" {
+		return text
+	}
+	if title, err := GetAttributeByKey(node, "title"); err == nil {
+		return collapse(title.Val)
+	}
+	return ""
+}
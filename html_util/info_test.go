@@ -0,0 +1,146 @@
+package html_util
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestNodeInfoProperties(t *testing.T) {
+	doc := parseDocument(t, `<div id="a" class="Card  big"><h2 id="t">Title</h2><p>Some <b>text</b></p>`+
+		`<span id="h" hidden><i id="hi">x</i></span><span id="s" style="display: None">y</span>`+
+		`<label for="in">Name</label><input id="in"><img id="img" alt=" Logo "><button id="btn" aria-labelledby="t h">`+
+		`Go</button><a id="title" title="Home"></a></div>`)
+	session := NewInfoSession()
+	info := func(id string) *Info {
+		return session.NodeInfo(elementByID(t, doc, id))
+	}
+
+	a := info("a")
+	if a != info("a") {
+		t.Error("the session returns different Info for the same node")
+	}
+	if got := a.Depth(); got != 3 {
+		t.Errorf("Depth = %v, want 3", got)
+	}
+	if got := a.ChildElementCount(); got != 9 {
+		t.Errorf("ChildElementCount = %v, want 9", got)
+	}
+	if !a.HasClass("card") || !a.HasClass("BIG") || a.HasClass("small") || len(a.Classes()) != 2 {
+		t.Errorf("Classes = %v", a.Classes())
+	}
+	if got := info("t").Text(); got != "Title" {
+		t.Errorf("Text = %q", got)
+	}
+
+	visible := map[string]bool{"a": true, "t": true, "h": false, "hi": false, "s": false, "in": true}
+	for id, want := range visible {
+		if got := info(id).Visible(); got != want {
+			t.Errorf("Visible of %v = %v, want %v", id, got, want)
+		}
+	}
+
+	names := map[string]string{"t": "Title", "in": "Name", "img": "Logo", "btn": "Title x", "title": "Home", "h": "x"}
+	for id, want := range names {
+		if got := info(id).AccessibleName(); got != want {
+			t.Errorf("AccessibleName of %v = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestNodeInfoSessionIsScoped(t *testing.T) {
+	doc := parseDocument(t, `<p id="p">old</p>`)
+	p := elementByID(t, doc, "p")
+	session := NewInfoSession()
+	if got := session.NodeInfo(p).Text(); got != "old" {
+		t.Fatalf("Text = %q", got)
+	}
+	p.FirstChild.Data = "new"
+	if got := session.NodeInfo(p).Text(); got != "old" {
+		t.Errorf("cached Text = %q, want the stale value old", got)
+	}
+	if got := NewInfoSession().NodeInfo(p).Text(); got != "new" {
+		t.Errorf("Text in a new session = %q, want new", got)
+	}
+	if got := NodeInfo(p).Text(); got != "new" {
+		t.Errorf("NodeInfo(p).Text() = %q, want new", got)
+	}
+}
+
+func TestNodeInfoNilNode(t *testing.T) {
+	info := NodeInfo(nil)
+	if info.Visible() || info.AccessibleName() != "" || info.Depth() != 0 || len(info.Classes()) != 0 {
+		t.Error("Info of nil has properties")
+	}
+}
+
+// makeInfoBenchmarkDocument
+// Returns a document of nested sections whose texts are expensive to compute repeatedly.
+func makeInfoBenchmarkDocument(tb testing.TB) *html.Node {
+	var sb strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&sb, `<div class="section"><h2>Section %v</h2>`, i)
+		for k := 0; k < 5; k++ {
+			fmt.Fprintf(&sb, `<div class="item"><p>item %v of section %v with some text</p><p>more</p><p>end</p></div>`, k, i)
+		}
+		sb.WriteString(`</div>`)
+	}
+	return parseDocument(tb, sb.String())
+}
+
+// infoBenchmarkConditions
+// Three text-dependent conditions combined, each computing its properties from scratch if session is nil, else sharing
+// them via the session.
+func infoBenchmarkConditions(session *InfoSession) func(node *html.Node) bool {
+	info := NodeInfo
+	if session != nil {
+		info = session.NodeInfo
+	}
+	conditions := []func(node *html.Node) bool{
+		func(node *html.Node) bool { return node.Type == html.ElementNode && node.Data == "div" },
+		func(node *html.Node) bool { return info(node).Visible() },
+		func(node *html.Node) bool { return strings.Contains(info(node).Text(), "item 3") },
+		func(node *html.Node) bool { return len(info(node).Text()) > 20 },
+		func(node *html.Node) bool { return strings.Contains(info(node).AccessibleName(), "section") },
+	}
+	return func(node *html.Node) bool {
+		for _, cond := range conditions {
+			if !cond(node) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func TestInfoBenchmarkConditionsAgree(t *testing.T) {
+	doc := makeInfoBenchmarkDocument(t)
+	direct := GetNodesByCondition(doc, infoBenchmarkConditions(nil))
+	cached := GetNodesByCondition(doc, infoBenchmarkConditions(NewInfoSession()))
+	// each section and its fourth item
+	if len(direct) != 400 || len(cached) != len(direct) {
+		t.Fatalf("conditions matched %v and %v nodes, want 400", len(direct), len(cached))
+	}
+	for k := range direct {
+		if direct[k] != cached[k] {
+			t.Fatalf("conditions disagree on match %v", k)
+		}
+	}
+}
+
+func BenchmarkNodeInfoConditions(b *testing.B) {
+	doc := makeInfoBenchmarkDocument(b)
+	b.Run("recomputed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			GetNodesByCondition(doc, infoBenchmarkConditions(nil))
+		}
+	})
+	b.Run("session", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			GetNodesByCondition(doc, infoBenchmarkConditions(NewInfoSession()))
+		}
+	})
+}
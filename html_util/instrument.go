@@ -0,0 +1,126 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"sync"
+	"time"
+)
+
+// Instrumentation
+// Receives the timing of the traversal-heavy operations it is passed to, e.g., via TableParseOptions.Instrumentation,
+// to find slow extraction steps. End reports the number of nodes the operation visited. Implementations must be safe
+// for concurrent use if they are shared between goroutines. Operations without Instrumentation do not measure anything.
+type Instrumentation interface {
+	Start(operation string)
+	End(operation string, visitedNodes int, duration time.Duration)
+}
+
+// InstrumentationFunc
+// An Instrumentation which calls the function at the end of each operation, e.g., to update an expvar or a metrics
+// histogram.
+type InstrumentationFunc func(operation string, visitedNodes int, duration time.Duration)
+
+// Start
+// Does nothing.
+func (f InstrumentationFunc) Start(operation string) {}
+
+// End
+// Calls f.
+func (f InstrumentationFunc) End(operation string, visitedNodes int, duration time.Duration) {
+	f(operation, visitedNodes, duration)
+}
+
+// OperationRecord
+// A finished operation recorded by RecordingInstrumentation.
+type OperationRecord struct {
+	Operation    string
+	VisitedNodes int
+	Duration     time.Duration
+}
+
+// RecordingInstrumentation
+// An Instrumentation which records all finished operations, safe for concurrent use. The zero value is ready to use.
+type RecordingInstrumentation struct {
+	mu      sync.Mutex
+	records []OperationRecord
+}
+
+// Start
+// Does nothing.
+func (r *RecordingInstrumentation) Start(operation string) {}
+
+// End
+// Records the operation.
+func (r *RecordingInstrumentation) End(operation string, visitedNodes int, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, OperationRecord{Operation: operation, VisitedNodes: visitedNodes, Duration: duration})
+}
+
+// Records
+// Returns a copy of the recorded operations in the order they finished.
+func (r *RecordingInstrumentation) Records() []OperationRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]OperationRecord(nil), r.records...)
+}
+
+// operationTimer
+// Measures a single operation for an Instrumentation, all methods are no-ops without Instrumentation.
+type operationTimer struct {
+	instrumentation Instrumentation
+	operation       string
+	start           time.Time
+}
+
+// startOperation
+// Notifies instrumentation, if any, about the start of operation.
+func startOperation(instrumentation Instrumentation, operation string) operationTimer {
+	if instrumentation == nil {
+		return operationTimer{}
+	}
+	instrumentation.Start(operation)
+	return operationTimer{instrumentation: instrumentation, operation: operation, start: time.Now()}
+}
+
+// enabled
+// Returns whether the operation is measured, i.e., whether visited nodes need to be counted.
+func (t operationTimer) enabled() bool {
+	return t.instrumentation != nil
+}
+
+// counter
+// Returns an unlimited counter of the visited nodes if the operation is measured, else nil.
+func (t operationTimer) counter() *budgetCounter {
+	if !t.enabled() {
+		return nil
+	}
+	return newBudgetCounter(Budget{})
+}
+
+// end
+// Notifies the instrumentation, if any, about the end of the operation.
+func (t operationTimer) end(visitedNodes int) {
+	if t.enabled() {
+		t.instrumentation.End(t.operation, visitedNodes, time.Since(t.start))
+	}
+}
+
+// GetNodesByConditionInstrumented
+// Same as GetNodesByCondition but reports the traversal as operation "GetNodesByCondition" to instrumentation, see
+// Instrumentation. The visited nodes are all nodes of the tree of startNode including startNode.
+func GetNodesByConditionInstrumented(startNode *html.Node, cond func(node *html.Node) bool, instrumentation Instrumentation) []*html.Node {
+	timer := startOperation(instrumentation, "GetNodesByCondition")
+	counter := timer.counter()
+	var foundNodes []*html.Node
+	walkHtmlTreeWithCounter(startNode, counter, func(n *html.Node) bool {
+		if cond(n) {
+			foundNodes = append(foundNodes, n)
+		}
+		return true
+	})
+	if counter != nil {
+		timer.end(counter.nodes)
+	}
+	return foundNodes
+}
@@ -0,0 +1,159 @@
+package html_util
+
+import (
+	"expvar"
+	"fmt"
+	"golang.org/x/net/html"
+	"sync"
+	"testing"
+	"time"
+)
+
+// instrumentFixture
+// A document with 31 nodes: the document, html, head, title and its text, and 26 nodes in the body including it. Its
+// <script> and <template> elements have 1 and 2 nodes below them, the table has 13 nodes including the implied tbody.
+const instrumentFixture = `<html><head><title>T</title></head><body><p>a <a href="/x">x</a><br></p>` +
+	`<script>var s;</script><template><a href="/t">t</a></template>` +
+	`<table id="t"><tr><th>k</th><th>v</th></tr><tr><td>a</td><td><a href="/y">1</a></td></tr></table>` +
+	`<div><img src="/i.png"></div></body></html>`
+
+// countVisitedNodes
+// Returns the number of nodes of the tree of n including n, where the children of nodes for which descend returns false
+// are not counted.
+func countVisitedNodes(n *html.Node, descend func(n *html.Node) bool) int {
+	count := 1
+	if descend(n) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			count += countVisitedNodes(c, descend)
+		}
+	}
+	return count
+}
+
+func TestInstrumentationVisitedNodes(t *testing.T) {
+	doc := parseDocument(t, instrumentFixture)
+	table := elementByID(t, doc, "t")
+	all := func(n *html.Node) bool { return true }
+	if total, nodes := countVisitedNodes(doc, all), countVisitedNodes(table, all); total != 31 || nodes != 13 {
+		t.Fatalf("the fixture has %v nodes and the table %v, want 31 and 13", total, nodes)
+	}
+	rendered := func(n *html.Node) bool {
+		return n.Type != html.TextNode && !(n.Type == html.ElementNode && (isNonRenderedNode(n) || n.Data == "br"))
+	}
+	notTemplate := func(n *html.Node) bool { return n.Data != "template" }
+
+	recorder := &RecordingInstrumentation{}
+	links := GetNodesByConditionInstrumented(doc, MakeByTagNameCondition("a"), recorder)
+	if len(links) != len(GetNodesByCondition(doc, MakeByTagNameCondition("a"))) {
+		t.Errorf("found %v links", len(links))
+	}
+	if _, err := ParseHtmlTableWithOptions(table, TableParseOptions{HasHeaderRow: true, Suffix: "_",
+		Instrumentation: recorder}); err != nil {
+		t.Fatal(err)
+	}
+	// the template content is not visited
+	urls := ExtractURLs(doc, nil, URLExtractOptions{AllowedSchemes: []string{""}, Instrumentation: recorder})
+	if len(urls) != 3 {
+		t.Errorf("extracted %v urls, want 3", len(urls))
+	}
+	// the content of <script> and <template> is skipped
+	text, _ := ExtractTextWithOffsets(doc, VisibleTextOptions{Instrumentation: recorder})
+	if text != "T\na x\nk\nv\na\n1" {
+		t.Errorf("got text %q", text)
+	}
+
+	want := []string{
+		fmt.Sprint("GetNodesByCondition ", countVisitedNodes(doc, all)),
+		fmt.Sprint("ParseHtmlTable ", countVisitedNodes(table, all)),
+		fmt.Sprint("ExtractURLs ", countVisitedNodes(doc, notTemplate)),
+		fmt.Sprint("ExtractTextWithOffsets ", countVisitedNodes(doc, rendered)),
+	}
+	records := recorder.Records()
+	if len(records) != len(want) {
+		t.Fatalf("got %v records, want %v", len(records), len(want))
+	}
+	for k, record := range records {
+		if got := record.Operation + " " + fmt.Sprint(record.VisitedNodes); got != want[k] {
+			t.Errorf("got record %q, want %q", got, want[k])
+		}
+		if record.Duration < 0 {
+			t.Errorf("record %q has a negative duration", record.Operation)
+		}
+	}
+
+	// without instrumentation, the results are the same
+	if got := GetNodesByConditionInstrumented(doc, MakeByTagNameCondition("a"), nil); len(got) != len(links) {
+		t.Errorf("found %v links without instrumentation", len(got))
+	}
+	if got, _ := ExtractTextWithOffsets(doc, VisibleTextOptions{}); got != text {
+		t.Errorf("got text %q without instrumentation", got)
+	}
+}
+
+func TestInstrumentationFunc(t *testing.T) {
+	doc := parseDocument(t, instrumentFixture)
+	visited := new(expvar.Map).Init()
+	calls := new(expvar.Int)
+	metrics := InstrumentationFunc(func(operation string, visitedNodes int, duration time.Duration) {
+		visited.Add(operation, int64(visitedNodes))
+		calls.Add(1)
+	})
+	metrics.Start("ignored")
+	GetNodesByConditionInstrumented(doc, MakeByTagNameCondition("p"), metrics)
+	GetNodesByConditionInstrumented(GetElementNodesByTagName("body", doc)[0], MakeByTagNameCondition("p"), metrics)
+	if got := visited.Get("GetNodesByCondition").String(); got != "57" || calls.Value() != 2 {
+		t.Errorf("got %v visited nodes in %v calls, want 57 in 2", got, calls.Value())
+	}
+}
+
+func TestRecordingInstrumentationConcurrent(t *testing.T) {
+	var recorder RecordingInstrumentation
+	doc := parseDocument(t, instrumentFixture)
+	var wg sync.WaitGroup
+	for k := 0; k < 8; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			GetNodesByConditionInstrumented(doc, func(n *html.Node) bool { return n.Type == html.TextNode }, &recorder)
+		}()
+	}
+	wg.Wait()
+	records := recorder.Records()
+	if len(records) != 8 {
+		t.Fatalf("got %v records, want 8", len(records))
+	}
+	for _, record := range records {
+		if record.VisitedNodes != 31 {
+			t.Errorf("got %v visited nodes, want 31", record.VisitedNodes)
+		}
+	}
+	// Records returns a copy
+	records[0].Operation = "changed"
+	if recorder.Records()[0].Operation != "GetNodesByCondition" {
+		t.Error("the records were modified via the result of Records")
+	}
+}
+
+func BenchmarkInstrumentation(b *testing.B) {
+	doc := parseDocument(b, makeLargeDocument(10000))
+	cond := MakeByTagNameCondition("a")
+	b.Run("GetNodesByCondition", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			GetNodesByCondition(doc, cond)
+		}
+	})
+	b.Run("without instrumentation", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			GetNodesByConditionInstrumented(doc, cond, nil)
+		}
+	})
+	b.Run("with InstrumentationFunc", func(b *testing.B) {
+		b.ReportAllocs()
+		metrics := InstrumentationFunc(func(string, int, time.Duration) {})
+		for i := 0; i < b.N; i++ {
+			GetNodesByConditionInstrumented(doc, cond, metrics)
+		}
+	})
+}
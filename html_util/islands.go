@@ -0,0 +1,76 @@
+package html_util
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// isDataIsland
+// Returns true for <script> elements with type application/json or text/json, ignoring parameters like charset.
+func isDataIsland(node *html.Node) bool {
+	if node.Type != html.ElementNode || node.Data != "script" {
+		return false
+	}
+	scriptType, err := GetAttributeByKey(node, "type")
+	if err != nil {
+		return false
+	}
+	mediaType, _, _ := strings.Cut(scriptType.Val, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	return mediaType == "application/json" || mediaType == "text/json"
+}
+
+// getDataIslandContent
+// Returns the raw content of the script element, which html.Parse does not decode.
+func getDataIslandContent(script *html.Node) string {
+	var sb strings.Builder
+	for c := script.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// ExtractDataIslands
+// Returns the content of all <script type="application/json"> and <script type="text/json"> elements in the tree of
+// root, e.g., the __NEXT_DATA__ of Next.js pages, keyed by their id. Scripts without id are keyed by their position
+// among all data islands in document order, e.g., "[2]".
+// The content is passed through verbatim, so escapes like \u003c stay intact. Scripts whose content is not valid JSON
+// are skipped.
+func ExtractDataIslands(root *html.Node) map[string]json.RawMessage {
+	islands := make(map[string]json.RawMessage)
+	for i, script := range GetNodesByCondition(root, isDataIsland) {
+		content := getDataIslandContent(script)
+		if !json.Valid([]byte(content)) {
+			continue
+		}
+		key := fmt.Sprintf("[%v]", i)
+		if id, err := GetAttributeByKey(script, "id"); err == nil && id.Val != "" {
+			key = id.Val
+		}
+		if _, ok := islands[key]; !ok {
+			islands[key] = json.RawMessage(content)
+		}
+	}
+	return islands
+}
+
+// ExtractDataIsland
+// Unmarshals the content of the first data island with the given id (see ExtractDataIslands) into out.
+// Returns an error if there is no such island or its content cannot be unmarshaled into out.
+func ExtractDataIsland(root *html.Node, id string, out interface{}) error {
+	byId := MakeByIdCondition(id)
+	script := GetNodeByCondition(root, func(node *html.Node) bool {
+		return byId(node) && isDataIsland(node)
+	})
+	if script == nil {
+		return fmt.Errorf("no data island with id '%v'", id)
+	}
+	if err := json.Unmarshal([]byte(getDataIslandContent(script)), out); err != nil {
+		return fmt.Errorf("cannot unmarshal data island '%v': %w", id, err)
+	}
+	return nil
+}
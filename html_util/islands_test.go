@@ -0,0 +1,81 @@
+package html_util
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestExtractDataIslands(t *testing.T) {
+	doc := parseDocument(t, `<head>`+
+		`<script id="__NEXT_DATA__" type="application/json">{"props":{"title":"a \u003c/script> b"}}</script>`+
+		`<script type="Application/JSON; charset=utf-8"> [1, 2] </script>`+
+		`<script type="text/javascript">var x = {"a": 1};</script>`+
+		`<script type="text/json">{"html": "&amp; stays"}</script>`+
+		`<script type="application/json">{invalid</script>`+
+		`<script id="__NEXT_DATA__" type="application/json">{"second": true}</script>`+
+		`<script type="application/ld+json">{"@type": "Thing"}</script>`+
+		`</head>`)
+
+	islands := ExtractDataIslands(doc)
+	var keys []string
+	for key := range islands {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if got := strings.Join(keys, " "); got != "[1] [2] __NEXT_DATA__" {
+		t.Fatalf("got keys %v, want [1] [2] __NEXT_DATA__", got)
+	}
+
+	want := map[string]string{
+		"__NEXT_DATA__": `{"props":{"title":"a \u003c/script> b"}}`, // the first island of an id, escapes intact
+		"[1]":           `[1, 2]`,
+		"[2]":           `{"html": "&amp; stays"}`, // script content is not decoded
+	}
+	for key, content := range want {
+		if got := string(islands[key]); got != content {
+			t.Errorf("island %v is %v, want %v", key, got, content)
+		}
+	}
+
+	var data struct {
+		Props struct {
+			Title string `json:"title"`
+		} `json:"props"`
+	}
+	if err := json.Unmarshal(islands["__NEXT_DATA__"], &data); err != nil || data.Props.Title != "a </script> b" {
+		t.Errorf("unmarshaled title %q with error %v", data.Props.Title, err)
+	}
+
+	if islands := ExtractDataIslands(parseDocument(t, `<p>no islands</p>`)); len(islands) != 0 {
+		t.Errorf("got %v islands, want none", len(islands))
+	}
+}
+
+func TestExtractDataIsland(t *testing.T) {
+	doc := parseDocument(t, `<script id="state" type="text/javascript">{"a": 1}</script>`+
+		`<script id="state" type="application/json">{"count": 3, "name": "x&y"}</script>`+
+		`<script id="broken" type="application/json">{"count": "three"}</script>`)
+
+	var state struct {
+		Count int    `json:"count"`
+		Name  string `json:"name"`
+	}
+	if err := ExtractDataIsland(doc, "state", &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Count != 3 || state.Name != "x&y" {
+		t.Errorf("got %+v", state)
+	}
+
+	if err := ExtractDataIsland(doc, "missing", &state); err == nil {
+		t.Error("no error for a missing island")
+	}
+	err := ExtractDataIsland(doc, "broken", &state)
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Errorf("got %v for an island of the wrong type, want a wrapped UnmarshalTypeError", err)
+	}
+}
@@ -0,0 +1,136 @@
+package html_util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// JSONTreeOptions
+// Options for NodeToJSON.
+// OmitWhitespaceText skips text nodes containing only whitespace. MaxDepth limits the depth of the serialized tree,
+// i.e., the children of nodes at depth MaxDepth (the root has depth 0) are omitted and the nodes are marked as
+// truncated, 0 means unlimited.
+type JSONTreeOptions struct {
+	OmitWhitespaceText bool
+	MaxDepth           int
+}
+
+// jsonNode
+// The JSON representation of a html.Node.
+type jsonNode struct {
+	Type       string          `json:"type"`
+	Tag        string          `json:"tag,omitempty"`
+	Namespace  string          `json:"namespace,omitempty"`
+	Attributes []jsonAttribute `json:"attributes,omitempty"`
+	Data       string          `json:"data,omitempty"`
+	Children   []*jsonNode     `json:"children,omitempty"`
+	Truncated  bool            `json:"truncated,omitempty"`
+}
+
+// jsonAttribute
+// The JSON representation of a html.Attribute. A list of attributes keeps their order and duplicates.
+type jsonAttribute struct {
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key"`
+	Val       string `json:"val"`
+}
+
+var nodeTypeNames = map[html.NodeType]string{
+	html.ErrorNode:    "error",
+	html.TextNode:     "text",
+	html.DocumentNode: "document",
+	html.ElementNode:  "element",
+	html.CommentNode:  "comment",
+	html.DoctypeNode:  "doctype",
+	html.RawNode:      "raw",
+}
+
+// NodeToJSON
+// Serializes n and its subtree as nested JSON objects with the fields type, tag, namespace, attributes, data, and
+// children, e.g., to store problematic subtrees for debugging. See NodeFromJSON for the reverse.
+func NodeToJSON(n *html.Node, opts JSONTreeOptions) ([]byte, error) {
+	if n == nil {
+		return nil, errors.New("node is nil")
+	}
+	return json.Marshal(makeJSONNode(n, opts, 0))
+}
+
+// makeJSONNode
+// Returns the JSON representation of n at the given depth.
+func makeJSONNode(n *html.Node, opts JSONTreeOptions, depth int) *jsonNode {
+	jn := &jsonNode{
+		Type:      nodeTypeNames[n.Type],
+		Namespace: n.Namespace,
+	}
+	if n.Type == html.ElementNode {
+		jn.Tag = n.Data
+	} else {
+		jn.Data = n.Data
+	}
+	for _, attr := range n.Attr {
+		jn.Attributes = append(jn.Attributes, jsonAttribute{Namespace: attr.Namespace, Key: attr.Key, Val: attr.Val})
+	}
+	if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+		jn.Truncated = n.FirstChild != nil
+		return jn
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if opts.OmitWhitespaceText && c.Type == html.TextNode && IsBlank(c.Data) {
+			continue
+		}
+		jn.Children = append(jn.Children, makeJSONNode(c, opts, depth+1))
+	}
+	return jn
+}
+
+// NodeFromJSON
+// Reconstructs a tree serialized with NodeToJSON and returns its root, which has no parent.
+// Truncated subtrees stay truncated.
+func NodeFromJSON(data []byte) (*html.Node, error) {
+	var jn jsonNode
+	if err := json.Unmarshal(data, &jn); err != nil {
+		return nil, err
+	}
+	return makeNodeFromJSON(&jn)
+}
+
+// makeNodeFromJSON
+// Returns the html.Node for jn with its children attached.
+func makeNodeFromJSON(jn *jsonNode) (*html.Node, error) {
+	n := &html.Node{Namespace: jn.Namespace}
+	found := false
+	for nodeType, name := range nodeTypeNames {
+		if name == jn.Type {
+			n.Type, found = nodeType, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown node type '%v'", jn.Type)
+	}
+	if n.Type == html.ElementNode {
+		n.Data = jn.Tag
+		if n.Namespace == "" {
+			n.DataAtom = atom.Lookup([]byte(jn.Tag))
+		}
+	} else {
+		n.Data = jn.Data
+	}
+	for _, attr := range jn.Attributes {
+		n.Attr = append(n.Attr, html.Attribute{Namespace: attr.Namespace, Key: attr.Key, Val: attr.Val})
+	}
+	for _, jc := range jn.Children {
+		if jc == nil {
+			continue
+		}
+		c, err := makeNodeFromJSON(jc)
+		if err != nil {
+			return nil, err
+		}
+		n.AppendChild(c)
+	}
+	return n, nil
+}
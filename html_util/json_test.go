@@ -0,0 +1,106 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"testing"
+)
+
+// checkTreePointers
+// Fails the test if a Parent, sibling, or child pointer in the tree of root is inconsistent.
+func checkTreePointers(tb testing.TB, root *html.Node) {
+	tb.Helper()
+	WalkHtmlTree(root, func(n *html.Node) bool {
+		var prev *html.Node
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Parent != n || c.PrevSibling != prev {
+				tb.Fatalf("inconsistent pointers at child %v of %v", c.Data, n.Data)
+			}
+			prev = c
+		}
+		if n.LastChild != prev {
+			tb.Fatalf("LastChild of %v is inconsistent", n.Data)
+		}
+		return true
+	})
+}
+
+func TestNodeJSONRoundTrip(t *testing.T) {
+	doc := parseDocument(t, `<!DOCTYPE html><html><head><title>t</title></head><body>`+
+		`<!-- note --><p class="a" class="b">x &amp; y</p><svg viewBox="0 0 1 1"><circle r="1"/></svg>`+
+		`<table><tr><th>k</th><th>v</th></tr><tr><td>a</td><td>1</td></tr></table></body></html>`)
+
+	data, err := NodeToJSON(doc, JSONTreeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NodeFromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTreePointers(t, restored)
+	if got, want := renderNode(t, restored), renderNode(t, doc); got != want {
+		t.Errorf("round trip changed the tree\ngot\n%v\nwant\n%v", got, want)
+	}
+	if circle := GetNodeByCondition(restored, MakeByTagNameCondition("circle")); circle == nil || circle.Namespace != "svg" {
+		t.Error("namespace of svg element was lost")
+	}
+
+	// a restored table can be parsed directly
+	table := GetElementNodesByTagName("table", restored)[0]
+	ht, err := ParseHtmlTable(table, true, true, "_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tableString(ht); got != "k|v\na|1" {
+		t.Errorf("got\n%v\nwant\nk|v\na|1", got)
+	}
+}
+
+func TestNodeToJSONOptions(t *testing.T) {
+	doc := parseDocument(t, `<div id="root"> <p>a <b>b</b></p> </div>`)
+	root := elementByID(t, doc, "root")
+
+	tests := []struct {
+		name string
+		opts JSONTreeOptions
+		want string
+	}{
+		{"defaults", JSONTreeOptions{}, `{"type":"element","tag":"div","attributes":[{"key":"id","val":"root"}],` +
+			`"children":[{"type":"text","data":" "},{"type":"element","tag":"p","children":[{"type":"text","data":"a "},` +
+			`{"type":"element","tag":"b","children":[{"type":"text","data":"b"}]}]},{"type":"text","data":" "}]}`},
+		{"omit whitespace", JSONTreeOptions{OmitWhitespaceText: true}, `{"type":"element","tag":"div",` +
+			`"attributes":[{"key":"id","val":"root"}],"children":[{"type":"element","tag":"p","children":[` +
+			`{"type":"text","data":"a "},{"type":"element","tag":"b","children":[{"type":"text","data":"b"}]}]}]}`},
+		{"max depth", JSONTreeOptions{OmitWhitespaceText: true, MaxDepth: 1}, `{"type":"element","tag":"div",` +
+			`"attributes":[{"key":"id","val":"root"}],"children":[{"type":"element","tag":"p","truncated":true}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := NodeToJSON(root, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got\n%s\nwant\n%v", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeJSONErrors(t *testing.T) {
+	if _, err := NodeToJSON(nil, JSONTreeOptions{}); err == nil {
+		t.Error("NodeToJSON(nil) returned no error")
+	}
+	for _, data := range []string{`{"type":"unknown"}`, `{"type":"element","children":[{"type":"x"}]}`, `[`} {
+		if _, err := NodeFromJSON([]byte(data)); err == nil {
+			t.Errorf("NodeFromJSON(%v) returned no error", data)
+		}
+	}
+	n, err := NodeFromJSON([]byte(`{"type":"element","tag":"p","children":[null,{"type":"text","data":"x"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := renderNode(t, n); got != "<p>x</p>" {
+		t.Errorf("null children are not skipped: %v", got)
+	}
+}
@@ -0,0 +1,206 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"strings"
+)
+
+// LazyRuleKind
+// Determines how a LazyRule resolves a lazy-loaded media element.
+type LazyRuleKind int
+
+const (
+	// LazyAttributeRule copies the value of the source attribute to the target attribute, e.g., <img data-src> to src.
+	LazyAttributeRule LazyRuleKind = iota
+	// LazyBackgroundRule appends a background-image declaration with the value of the source attribute to the style
+	// attribute, e.g., for <div data-bg>.
+	LazyBackgroundRule
+	// LazyNoscriptRule replaces a placeholder element with the element of the same tag inside the directly following
+	// <noscript> sibling, which is removed.
+	LazyNoscriptRule
+)
+
+// LazyRule
+// A rule of ResolveLazyMedia. Tag restricts the rule to elements with the given tag, an empty Tag matches all elements.
+// SourceAttribute and TargetAttribute are ignored by LazyNoscriptRule, TargetAttribute is ignored by
+// LazyBackgroundRule.
+type LazyRule struct {
+	Kind            LazyRuleKind
+	Tag             string
+	SourceAttribute string
+	TargetAttribute string
+}
+
+// DefaultLazyRules
+// Rules for common lazy-loading conventions.
+var DefaultLazyRules = []LazyRule{
+	{Kind: LazyNoscriptRule, Tag: "img"},
+	{Kind: LazyAttributeRule, Tag: "img", SourceAttribute: "data-src", TargetAttribute: "src"},
+	{Kind: LazyAttributeRule, Tag: "img", SourceAttribute: "data-lazy-src", TargetAttribute: "src"},
+	{Kind: LazyAttributeRule, Tag: "img", SourceAttribute: "data-original", TargetAttribute: "src"},
+	{Kind: LazyAttributeRule, Tag: "img", SourceAttribute: "data-srcset", TargetAttribute: "srcset"},
+	{Kind: LazyAttributeRule, Tag: "img", SourceAttribute: "data-lazy-srcset", TargetAttribute: "srcset"},
+	{Kind: LazyAttributeRule, Tag: "source", SourceAttribute: "data-srcset", TargetAttribute: "srcset"},
+	{Kind: LazyAttributeRule, Tag: "iframe", SourceAttribute: "data-src", TargetAttribute: "src"},
+	{Kind: LazyAttributeRule, Tag: "video", SourceAttribute: "data-poster", TargetAttribute: "poster"},
+	{Kind: LazyBackgroundRule, SourceAttribute: "data-bg"},
+	{Kind: LazyBackgroundRule, SourceAttribute: "data-background-image"},
+}
+
+// ResolveLazyMedia
+// Rewrites lazy-loaded media elements below and including root to their standard form according to rules, e.g., see
+// DefaultLazyRules, which allows ExtractURLs to find the actual media. Rules are applied in order, so the noscript rules
+// should come first. Returns the number of nodes fixed. Frozen trees are not modified and yield 0.
+func ResolveLazyMedia(root *html.Node, rules []LazyRule) int {
+	if root == nil || IsFrozen(root) {
+		return 0
+	}
+	fixed := 0
+	for _, rule := range rules {
+		nodes := GetNodesByCondition(root, func(node *html.Node) bool {
+			return node.Type == html.ElementNode && (rule.Tag == "" || node.Data == rule.Tag)
+		})
+		for _, node := range nodes {
+			if applyLazyRule(node, rule) {
+				fixed++
+			}
+		}
+	}
+	return fixed
+}
+
+// applyLazyRule
+// Applies rule to node. Returns true if node was changed.
+func applyLazyRule(node *html.Node, rule LazyRule) bool {
+	switch rule.Kind {
+	case LazyAttributeRule:
+		source, err := GetAttributeByKey(node, rule.SourceAttribute)
+		if err != nil || strings.TrimSpace(source.Val) == "" {
+			return false
+		}
+		if target, err := GetAttributeByKey(node, rule.TargetAttribute); err == nil && !isPlaceholderURL(target.Val) {
+			return false
+		}
+		setAttribute(node, rule.TargetAttribute, strings.TrimSpace(source.Val))
+		return true
+	case LazyBackgroundRule:
+		source, err := GetAttributeByKey(node, rule.SourceAttribute)
+		if err != nil || strings.TrimSpace(source.Val) == "" || getStyleProperty(node, "background-image") != "" {
+			return false
+		}
+		declaration := "background-image: url('" + strings.TrimSpace(source.Val) + "')"
+		if style, err := GetAttributeByKey(node, "style"); err == nil && strings.TrimSpace(style.Val) != "" {
+			declaration = strings.TrimRight(strings.TrimSpace(style.Val), ";") + "; " + declaration
+		}
+		setAttribute(node, "style", declaration)
+		return true
+	case LazyNoscriptRule:
+		return liftNoscriptElement(node)
+	}
+	return false
+}
+
+// isPlaceholderURL
+// Returns true for empty urls and inline data urls, which lazy-loading scripts use as placeholders.
+func isPlaceholderURL(val string) bool {
+	val = strings.TrimSpace(val)
+	return val == "" || strings.HasPrefix(strings.ToLower(val), "data:") || val == "#"
+}
+
+// liftNoscriptElement
+// Replaces placeholder with the element of the same tag inside the <noscript> element directly following it.
+// Returns true if placeholder was replaced.
+func liftNoscriptElement(placeholder *html.Node) bool {
+	if placeholder.Parent == nil {
+		return false
+	}
+	noscript := placeholder.NextSibling
+	for noscript != nil && noscript.Type == html.TextNode && strings.TrimSpace(noscript.Data) == "" {
+		noscript = noscript.NextSibling
+	}
+	if noscript == nil || noscript.Type != html.ElementNode || noscript.Data != "noscript" {
+		return false
+	}
+
+	cond := MakeByTagNameCondition(placeholder.Data)
+	replacement := GetNodeByCondition(noscript, cond)
+	if replacement == nil {
+		nodes, err := parseNoscriptPayload(noscript)
+		if err != nil {
+			return false
+		}
+		for _, n := range nodes {
+			if replacement = GetNodeByCondition(n, cond); replacement != nil {
+				break
+			}
+		}
+	}
+	if replacement == nil {
+		return false
+	}
+
+	detachNode(replacement)
+	parent := placeholder.Parent
+	parent.InsertBefore(replacement, placeholder)
+	parent.RemoveChild(placeholder)
+	parent.RemoveChild(noscript)
+	return true
+}
+
+// hasNoscriptPayload
+// Returns true if noscript contains only text nodes and at least one of them contains markup, i.e., its content was
+// parsed as raw text, which html.Parse does with scripting enabled.
+func hasNoscriptPayload(noscript *html.Node) bool {
+	hasMarkup := false
+	for c := noscript.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.TextNode {
+			return false
+		}
+		hasMarkup = hasMarkup || strings.Contains(c.Data, "<")
+	}
+	return hasMarkup
+}
+
+// parseNoscriptPayload
+// Parses the concatenated text children of noscript as a fragment in the context of a <body> element.
+func parseNoscriptPayload(noscript *html.Node) ([]*html.Node, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	var raw strings.Builder
+	for c := noscript.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			raw.WriteString(c.Data)
+		}
+	}
+	return html.ParseFragment(strings.NewReader(raw.String()), context)
+}
+
+// ExpandNoscript
+// Replaces the raw text content of all <noscript> elements in the tree of root, which html.Parse produces with scripting
+// enabled, by the parsed nodes in place, so extractors like ExtractURLs or ParseHtmlTable find the fallback content.
+// The <noscript> elements themselves are kept, hence GetInnerText still skips their content.
+// Returns the number of expanded elements, and ErrFrozenNode if a <noscript> element with a payload is frozen.
+func ExpandNoscript(root *html.Node) (int, error) {
+	noscripts := GetNodesByCondition(root, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == "noscript" && hasNoscriptPayload(n)
+	})
+
+	expanded := 0
+	for _, noscript := range noscripts {
+		if err := checkMutable(noscript); err != nil {
+			return expanded, err
+		}
+		nodes, err := parseNoscriptPayload(noscript)
+		if err != nil {
+			return expanded, err
+		}
+		for noscript.FirstChild != nil {
+			noscript.RemoveChild(noscript.FirstChild)
+		}
+		for _, n := range nodes {
+			noscript.AppendChild(n)
+		}
+		expanded++
+	}
+	return expanded, nil
+}
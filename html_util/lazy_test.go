@@ -0,0 +1,152 @@
+package html_util
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestResolveLazyMedia(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantFixed int
+		want      string
+	}{
+		{"data-src", `<img data-src="a.png">`, 1, `<img data-src="a.png" src="a.png"/>`},
+		{"placeholder src", `<img src="data:image/gif;base64,R0lG" data-src=" a.png ">`, 1,
+			`<img src="a.png" data-src=" a.png "/>`},
+		{"real src kept", `<img src="b.png" data-src="a.png">`, 0, `<img src="b.png" data-src="a.png"/>`},
+		{"srcset", `<img data-srcset="a.png 1x, b.png 2x">`, 1,
+			`<img data-srcset="a.png 1x, b.png 2x" srcset="a.png 1x, b.png 2x"/>`},
+		{"iframe and poster", `<iframe data-src="f.html"></iframe><video data-poster="p.jpg"></video>`, 2,
+			`<iframe data-src="f.html" src="f.html"></iframe><video data-poster="p.jpg" poster="p.jpg"></video>`},
+		{"empty source", `<img data-src="  ">`, 0, `<img data-src="  "/>`},
+		{"background", `<div data-bg="bg.jpg" style="color: red;"></div>`, 1,
+			`<div data-bg="bg.jpg" style="color: red; background-image: url(&#39;bg.jpg&#39;)"></div>`},
+		{"existing background", `<div data-bg="bg.jpg" style="background-image: url(x.jpg)"></div>`, 0,
+			`<div data-bg="bg.jpg" style="background-image: url(x.jpg)"></div>`},
+		{"noscript", `<img class="lazy" src="data:,"> <noscript><img src="real.png" alt="r"></noscript><p>x</p>`, 1,
+			`<img src="real.png" alt="r"/> <p>x</p>`},
+		{"noscript without img", `<img src="data:,"><noscript><p>enable js</p></noscript>`, 0,
+			`<img src="data:,"/><noscript><p>enable js</p></noscript>`},
+		{"not followed by noscript", `<img src="data:,"><span></span><noscript><img src="x.png"></noscript>`, 0,
+			`<img src="data:,"/><span></span><noscript><img src="x.png"></noscript>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseDocument(t, `<div id="root">`+tt.input+`</div>`)
+			root := elementByID(t, doc, "root")
+			if fixed := ResolveLazyMedia(root, DefaultLazyRules); fixed != tt.wantFixed {
+				t.Errorf("ResolveLazyMedia fixed %v nodes, want %v", fixed, tt.wantFixed)
+			}
+			var got string
+			for c := root.FirstChild; c != nil; c = c.NextSibling {
+				got += renderNode(t, c)
+			}
+			if got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLazyMediaCustomRulesAndOrder(t *testing.T) {
+	doc := parseDocument(t, `<div id="root"><span data-src="a.png"></span><img data-src="b.png"></div>`)
+	root := elementByID(t, doc, "root")
+	rules := []LazyRule{{Kind: LazyAttributeRule, SourceAttribute: "data-src", TargetAttribute: "data-resolved"}}
+
+	if fixed := ResolveLazyMedia(root, rules); fixed != 2 {
+		t.Errorf("a rule without tag fixed %v nodes, want 2", fixed)
+	}
+	if fixed := ResolveLazyMedia(root, rules); fixed != 0 {
+		t.Errorf("resolving twice fixed %v nodes, want 0", fixed)
+	}
+	want := `<div id="root"><span data-src="a.png" data-resolved="a.png"></span>` +
+		`<img data-src="b.png" data-resolved="b.png"/></div>`
+	if got := renderNode(t, root); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestResolveLazyMediaNoscriptReplacementKeepsLazyRules(t *testing.T) {
+	// the noscript rule comes first, so the attribute rules apply to the lifted element
+	doc := parseDocument(t, `<div id="root"><img src="data:,"><noscript><img data-src="real.png"></noscript></div>`)
+	root := elementByID(t, doc, "root")
+	if fixed := ResolveLazyMedia(root, DefaultLazyRules); fixed != 2 {
+		t.Errorf("ResolveLazyMedia fixed %v nodes, want 2", fixed)
+	}
+	want := `<div id="root"><img data-src="real.png" src="real.png"/></div>`
+	if got := renderNode(t, root); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestResolveLazyMediaSkipsFrozenAndNil(t *testing.T) {
+	if fixed := ResolveLazyMedia(nil, DefaultLazyRules); fixed != 0 {
+		t.Errorf("ResolveLazyMedia(nil) = %v, want 0", fixed)
+	}
+	frozen := FreezeTree(parseDocument(t, `<img data-src="a.png">`))
+	defer frozen.Release()
+	if fixed := ResolveLazyMedia(frozen.Root(), DefaultLazyRules); fixed != 0 {
+		t.Errorf("ResolveLazyMedia on a frozen tree = %v, want 0", fixed)
+	}
+	if img := frozen.FirstByCondition(MakeByTagNameCondition("img")); hasAttribute(img, "src") {
+		t.Error("frozen tree was modified")
+	}
+}
+
+func TestExpandNoscript(t *testing.T) {
+	doc := parseDocument(t, `<div id="gallery"><img src="data:,"><noscript><img src="/full.png?w=1&amp;h=2" alt="Full"></noscript></div>`+
+		`<noscript><table><tr><th>Item</th><th>Price</th></tr><tr><td>Tea</td><td>3</td></tr></table></noscript>`+
+		`<noscript>enable javascript</noscript><noscript></noscript>`)
+	base, _ := url.Parse("https://example.com/")
+
+	if urls := ExtractURLs(doc, base, URLExtractOptions{}); len(urls) != 0 {
+		t.Fatalf("found %v urls in raw noscript text", len(urls))
+	}
+	if expanded, err := ExpandNoscript(doc); expanded != 2 || err != nil {
+		t.Fatalf("ExpandNoscript = %v, %v, want 2, nil", expanded, err)
+	}
+
+	urls := ExtractURLs(doc, base, URLExtractOptions{})
+	if len(urls) != 1 || urls[0].URL.String() != "https://example.com/full.png?w=1&h=2" {
+		t.Errorf("got urls %v, want the decoded fallback image url", urls)
+	}
+	tables, err := ParseAllHtmlTables(doc, TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_"})
+	if err != nil || len(tables) != 1 {
+		t.Fatalf("got %v tables with error %v", len(tables), err)
+	}
+	if price, _, _, ok := tables[0].GetElementByKeys("Tea", "Price"); !ok || price != "3" {
+		t.Errorf("price of tea is %q, %v", price, ok)
+	}
+
+	// the noscript elements are kept, their content is still not rendered text
+	if got := GetInnerText(elementByID(t, doc, "gallery")); got != "" {
+		t.Errorf("GetInnerText = %q", got)
+	}
+	if got, want := renderNode(t, elementByID(t, doc, "gallery")),
+		`<div id="gallery"><img src="data:,"/><noscript><img src="/full.png?w=1&amp;h=2" alt="Full"/></noscript></div>`; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	// expanding again is a no-op
+	if expanded, err := ExpandNoscript(doc); expanded != 0 || err != nil {
+		t.Errorf("second ExpandNoscript = %v, %v", expanded, err)
+	}
+	if got := strings.Count(renderNode(t, doc), "<img"); got != 2 {
+		t.Errorf("got %v images after expanding twice", got)
+	}
+}
+
+func TestExpandNoscriptFrozen(t *testing.T) {
+	frozen := FreezeTree(parseDocument(t, `<noscript><img src="x.png"></noscript>`))
+	defer frozen.Release()
+	if expanded, err := ExpandNoscript(frozen.Root()); expanded != 0 || !errors.Is(err, ErrFrozenNode) {
+		t.Errorf("ExpandNoscript = %v, %v, want 0, ErrFrozenNode", expanded, err)
+	}
+	if expanded, err := ExpandNoscript(nil); expanded != 0 || err != nil {
+		t.Errorf("ExpandNoscript(nil) = %v, %v", expanded, err)
+	}
+}
@@ -0,0 +1,253 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Link
+// A link found by StreamExtractLinks.
+type Link struct {
+	URL      *url.URL // href resolved against the base url
+	Href     string   // the raw href attribute
+	Tag      string   // "a", "area", or "link"
+	Rel      string   // the rel attribute
+	Text     string   // text content of an <a> element with collapsed whitespace, "" for <area> and <link>
+	HasImage bool     // whether the <a> element contains <img> elements
+	AltText  bool     // whether Text was taken from the alt attributes of the images because the <a> element has no text
+}
+
+// StreamExtractLinks
+// Tokenizes the html document read from r without building a tree and calls emit with every <a>, <area>, and <link>
+// element with a non-empty, parsable href in document order, until emit returns false.
+// Hrefs are resolved against base and the href of the first <base> element, which applies to all links following it.
+// The text of an <a> element is collected until its end tag, the next <a> start tag, which implicitly closes it, or
+// the end of the document, so the element is emitted at that point. If it has no text, the alt attributes of its <img>
+// elements are used instead. The content of <script> and <style> elements is ignored.
+// Returns nil at the end of the document or if emit stops the extraction, else the read error.
+func StreamExtractLinks(r io.Reader, base *url.URL, emit func(Link) bool) error {
+	documentBase := base
+	baseSeen := false
+
+	var anchor *Link // the open <a> element, if it has a href
+	anchorOpen := false
+	var text strings.Builder
+	var altTexts []string // alt attributes of the images of the open <a> element
+	rawDepth := 0         // number of open <script> and <style> elements
+
+	// emits the open <a> element, returns false if the extraction must stop
+	closeAnchor := func() bool {
+		if !anchorOpen {
+			return true
+		}
+		anchorOpen = false
+		if anchor == nil {
+			return true
+		}
+		anchor.Text = strings.Join(strings.Fields(text.String()), " ")
+		if anchor.Text == "" && len(altTexts) > 0 {
+			anchor.Text = strings.Join(strings.Fields(strings.Join(altTexts, " ")), " ")
+			anchor.AltText = anchor.Text != ""
+		}
+		link := *anchor
+		anchor = nil
+		text.Reset()
+		altTexts = nil
+		return emit(link)
+	}
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return err
+			}
+			closeAnchor()
+			return nil
+		case html.TextToken:
+			if anchor != nil && rawDepth == 0 {
+				text.Write(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "a":
+				if !closeAnchor() {
+					return nil
+				}
+			case "script", "style":
+				if rawDepth > 0 {
+					rawDepth--
+				}
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			switch tag {
+			case "script", "style":
+				if tt == html.StartTagToken {
+					rawDepth++
+				}
+				continue
+			case "img":
+				if anchor != nil {
+					anchor.HasImage = true
+					for hasAttr {
+						var key, val []byte
+						key, val, hasAttr = z.TagAttr()
+						if string(key) == "alt" {
+							altTexts = append(altTexts, string(val))
+						}
+					}
+				}
+				continue
+			case "a", "area", "link", "base":
+			default:
+				continue
+			}
+
+			var href, rel string
+			hasHref := false
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch string(key) {
+				case "href":
+					if !hasHref {
+						href, hasHref = string(val), true
+					}
+				case "rel":
+					rel = string(val)
+				}
+			}
+
+			if tag == "base" {
+				if hasHref && !baseSeen {
+					baseSeen = true
+					if baseHref, err := url.Parse(strings.TrimSpace(href)); err == nil {
+						if documentBase == nil {
+							documentBase = baseHref
+						} else {
+							documentBase = documentBase.ResolveReference(baseHref)
+						}
+					}
+				}
+				continue
+			}
+			if tag == "a" && !closeAnchor() {
+				return nil
+			}
+
+			var link *Link
+			if trimmed := strings.TrimSpace(href); hasHref && trimmed != "" {
+				if u, err := url.Parse(trimmed); err == nil {
+					if documentBase != nil {
+						u = documentBase.ResolveReference(u)
+					}
+					link = &Link{URL: u, Href: href, Tag: tag, Rel: rel}
+				}
+			}
+			if tag == "a" {
+				// <a/> opens an element as <a> does, the self-closing flag only applies to void elements
+				anchor, anchorOpen = link, true
+				continue
+			}
+			if link != nil && !emit(*link) {
+				return nil
+			}
+		}
+	}
+}
+
+// AnchorTextClass
+// Describes how useful the text of a link is, e.g., for link graph analysis, see ClassifyAnchorText.
+type AnchorTextClass int
+
+const (
+	AnchorDescriptive AnchorTextClass = iota // the text describes the link target
+	AnchorGeneric                            // a generic phrase like "click here" or "weiterlesen", or a page number
+	AnchorURLLike                            // the text is a url or domain, e.g., "https://example.com/a" or "www.example.com"
+	AnchorEmpty                              // no text and no images
+	AnchorImageOnly                          // no text besides the alt attributes of images
+)
+
+// GenericAnchorPhrases
+// Lower case anchor texts without surrounding punctuation which are classified as AnchorGeneric. Add phrases, e.g., of
+// further languages, during initialization, it must not be changed while the package is in use by other goroutines.
+var GenericAnchorPhrases = map[string]bool{
+	// english
+	"click here": true, "click": true, "here": true, "this": true, "link": true, "this link": true, "this page": true,
+	"more": true, "read more": true, "learn more": true, "see more": true, "show more": true, "view more": true,
+	"more info": true, "more information": true, "find out more": true, "details": true, "view details": true,
+	"continue": true, "continue reading": true, "full story": true, "read the full story": true, "go": true,
+	"next": true, "previous": true, "prev": true, "back": true, "home": true, "homepage": true, "website": true,
+	"view": true, "view all": true, "see all": true, "show all": true, "download": true, "source": true, "open": true,
+	"page": true, "top": true, "back to top": true, "read": true, "info": true,
+	// german
+	"hier": true, "hier klicken": true, "klicken sie hier": true, "mehr": true,
+	"weiter": true, "weiterlesen": true, "mehr lesen": true, "mehr erfahren": true, "mehr info": true,
+	"mehr infos": true, "mehr informationen": true, "details anzeigen": true, "zurück": true, "vor": true,
+	"nächste": true, "nächste seite": true, "vorherige": true, "vorherige seite": true, "diesen link": true,
+	"dieser link": true, "seite": true, "startseite": true, "alle anzeigen": true, "mehr anzeigen": true,
+	"jetzt lesen": true, "zum artikel": true, "quelle": true, "herunterladen": true, "nach oben": true,
+	"lesen": true, "artikel lesen": true,
+}
+
+// urlLikeTextRegex
+// Matches texts consisting of a single url with scheme, a url starting with www., or a domain name with optional path.
+var urlLikeTextRegex = regexp.MustCompile(`(?i)^([a-z][a-z0-9+.-]*://\S+|www\.\S+|[a-z0-9-]+(\.[a-z0-9-]+)*\.[a-z]{2,}(/\S*)?)$`)
+
+// normalizeAnchorText
+// Returns text in lower case with collapsed whitespace and without leading and trailing punctuation and symbols, e.g.,
+// "» Read more…" yields "read more".
+func normalizeAnchorText(text string) string {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	return strings.TrimFunc(text, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r)
+	})
+}
+
+// ClassifyAnchorText
+// Classifies the text of link, see AnchorTextClass. Texts taken from alt attributes, see Link.AltText, are
+// AnchorImageOnly. Texts which consist of punctuation and symbols only, e.g., "»", or of digits only, e.g., page
+// numbers, and texts contained in GenericAnchorPhrases after normalization are AnchorGeneric. Texts matching a url or
+// the link's href are AnchorURLLike.
+func ClassifyAnchorText(link Link) AnchorTextClass {
+	text := strings.TrimSpace(link.Text)
+	switch {
+	case link.AltText || text == "" && link.HasImage:
+		return AnchorImageOnly
+	case IsBlank(text):
+		return AnchorEmpty
+	case text == strings.TrimSpace(link.Href) || urlLikeTextRegex.MatchString(text):
+		return AnchorURLLike
+	}
+
+	normalized := normalizeAnchorText(text)
+	if normalized == "" || GenericAnchorPhrases[normalized] {
+		return AnchorGeneric
+	}
+	if strings.IndexFunc(normalized, func(r rune) bool { return !unicode.IsDigit(r) }) == -1 {
+		return AnchorGeneric
+	}
+	return AnchorDescriptive
+}
+
+// FilterLinks
+// Returns the links for which keep yields true in their original order, e.g., to keep only descriptive ones:
+// FilterLinks(links, func(l Link) bool { return ClassifyAnchorText(l) == AnchorDescriptive }).
+func FilterLinks(links []Link, keep func(Link) bool) []Link {
+	var kept []Link
+	for _, link := range links {
+		if keep(link) {
+			kept = append(kept, link)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,237 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// streamLinks
+// Returns the links StreamExtractLinks emits for document, one per line, and fails the test on error.
+func streamLinks(tb testing.TB, document string, base *url.URL) string {
+	tb.Helper()
+	var lines []string
+	err := StreamExtractLinks(strings.NewReader(document), base, func(link Link) bool {
+		lines = append(lines, fmt.Sprintf("%v %v rel=%q %q image=%v alt=%v", link.Tag, link.URL, link.Rel, link.Text,
+			link.HasImage, link.AltText))
+		return true
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestStreamExtractLinks(t *testing.T) {
+	base, _ := url.Parse("https://example.com/dir/page.html")
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{"nested elements", `<a href="a.html" rel="next"> Read <b>the <i>full</i></b>
+			story </a><a href="/b">x &amp; y</a>`, strings.Join([]string{
+			`a https://example.com/dir/a.html rel="next" "Read the full story" image=false alt=false`,
+			`a https://example.com/b rel="" "x & y" image=false alt=false`,
+		}, "\n")},
+		{"base before links", `<head><link rel="stylesheet" href="s.css"><base href="/other/"><base href="/ignored/">` +
+			`</head><a href="x">x</a><area href="map">`, strings.Join([]string{
+			`link https://example.com/dir/s.css rel="stylesheet" "" image=false alt=false`,
+			`a https://example.com/other/x rel="" "x" image=false alt=false`,
+			`area https://example.com/other/map rel="" "" image=false alt=false`,
+		}, "\n")},
+		{"unclosed anchors", `<p><a href="1">one<p><a href="2">two</p><a href="3">three`, strings.Join([]string{
+			`a https://example.com/dir/1 rel="" "one" image=false alt=false`,
+			`a https://example.com/dir/2 rel="" "two" image=false alt=false`,
+			`a https://example.com/dir/3 rel="" "three" image=false alt=false`,
+		}, "\n")},
+		{"images", `<a href="i"><img src="a.png" alt="Logo"> <img alt=" of us "></a><a href="j"><img alt="x">y</a>` +
+			`<a href="k"><img src="b.png"></a>`, strings.Join([]string{
+			`a https://example.com/dir/i rel="" "Logo of us" image=true alt=true`,
+			`a https://example.com/dir/j rel="" "y" image=true alt=false`,
+			`a https://example.com/dir/k rel="" "" image=true alt=false`,
+		}, "\n")},
+		{"skipped", `<a>no href</a><a href="  ">blank</a><a href="http://[::1">invalid</a>` +
+			`<a href="s"><script>var a = "</a>";</script><style>a {}</style>text</a>`,
+			`a https://example.com/dir/s rel="" "text" image=false alt=false`},
+		{"self-closing", `<link rel="icon" href="/favicon.ico"/><a href="t"/>after`, strings.Join([]string{
+			`link https://example.com/favicon.ico rel="icon" "" image=false alt=false`,
+			`a https://example.com/dir/t rel="" "after" image=false alt=false`,
+		}, "\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streamLinks(t, tt.document, base); got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+
+	// without base url, the <base> element is the base
+	want := `a https://cdn.example.org/a/b rel="" "b" image=false alt=false`
+	if got := streamLinks(t, `<base href="https://cdn.example.org/a/"><a href="b">b</a>`, nil); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	want = `a c rel="" "c" image=false alt=false`
+	if got := streamLinks(t, `<a href="c">c</a>`, nil); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestStreamExtractLinksStopsAndFails(t *testing.T) {
+	emitted := 0
+	err := StreamExtractLinks(strings.NewReader(`<a href="1">1</a><a href="2">2</a><link href="3">`), nil,
+		func(link Link) bool {
+			emitted++
+			return false
+		})
+	if err != nil || emitted != 1 {
+		t.Errorf("emitted %v links with error %v, want 1 without error", emitted, err)
+	}
+
+	readErr := errors.New("connection reset")
+	err = StreamExtractLinks(iotest.TimeoutReader(strings.NewReader(`<a href="1">1</a>`)), nil, func(link Link) bool {
+		return true
+	})
+	if !errors.Is(err, iotest.ErrTimeout) {
+		t.Errorf("got error %v, want the read error", err)
+	}
+	err = StreamExtractLinks(iotest.ErrReader(readErr), nil, func(link Link) bool { return true })
+	if !errors.Is(err, readErr) {
+		t.Errorf("got error %v, want %v", err, readErr)
+	}
+}
+
+func TestStreamExtractLinksMatchesTree(t *testing.T) {
+	base, _ := url.Parse("https://example.com/")
+	document := `<base href="/base/">` + makeLargeDocument(50) + `<map><area href="/area"></map><a href="rel#f">x</a>`
+
+	streamed := make(map[string]bool)
+	if err := StreamExtractLinks(strings.NewReader(document), base, func(link Link) bool {
+		link.URL.Fragment = ""
+		streamed[link.URL.String()] = true
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want []string
+	for u := range streamed {
+		got = append(got, u)
+	}
+	for _, extracted := range ExtractURLs(doc, base, URLExtractOptions{Attributes: []string{"href"}}) {
+		if extracted.Occurrences[0].Node.Data != "base" {
+			want = append(want, extracted.URL.String())
+		}
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
+
+func BenchmarkStreamExtractLinks(b *testing.B) {
+	document := makeLargeDocument(10000)
+	base, _ := url.Parse("https://example.com/")
+	b.Run("html.Parse+ExtractURLs", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			doc, err := html.Parse(strings.NewReader(document))
+			if err != nil {
+				b.Fatal(err)
+			}
+			ExtractURLs(doc, base, URLExtractOptions{Attributes: []string{"href"}})
+		}
+	})
+	b.Run("StreamExtractLinks", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := StreamExtractLinks(strings.NewReader(document), base, func(link Link) bool {
+				return true
+			}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestClassifyAnchorTextSample(t *testing.T) {
+	data, err := os.ReadFile("testdata/anchor_texts.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	classes := map[string]AnchorTextClass{"descriptive": AnchorDescriptive, "generic": AnchorGeneric,
+		"urllike": AnchorURLLike, "empty": AnchorEmpty, "imageonly": AnchorImageOnly}
+	counts := make(map[AnchorTextClass]int)
+	for number, line := range strings.Split(string(data), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		columns := append(strings.Split(line, "\t"), "", "", "")
+		want, ok := classes[columns[0]]
+		if !ok {
+			t.Fatalf("line %v has unknown class %q", number+1, columns[0])
+		}
+		flags := "," + columns[3] + ","
+		link := Link{Text: columns[1], Href: columns[2], Tag: "a", HasImage: strings.Contains(flags, ",image,"),
+			AltText: strings.Contains(flags, ",alt,")}
+		if got := ClassifyAnchorText(link); got != want {
+			t.Errorf("line %v: ClassifyAnchorText(%q, %q) = %v, want %v", number+1, link.Text, link.Href, got, want)
+		}
+		counts[want]++
+	}
+	for name, class := range classes {
+		if counts[class] < 3 {
+			t.Errorf("the sample has %v %v texts, want at least 3", counts[class], name)
+		}
+	}
+}
+
+func TestClassifyAnchorTextExtendablePhrases(t *testing.T) {
+	link := Link{Text: "Lire la suite", Href: "/article"}
+	if got := ClassifyAnchorText(link); got != AnchorDescriptive {
+		t.Fatalf("got %v before adding the phrase", got)
+	}
+	GenericAnchorPhrases["lire la suite"] = true
+	defer delete(GenericAnchorPhrases, "lire la suite")
+	if got := ClassifyAnchorText(link); got != AnchorGeneric {
+		t.Errorf("got %v after adding the phrase, want AnchorGeneric", got)
+	}
+}
+
+func TestFilterLinks(t *testing.T) {
+	var links []Link
+	err := StreamExtractLinks(strings.NewReader(`<a href="/a">Annual report</a><a href="/b">click here</a>`+
+		`<a href="/c"><img alt="Logo"></a><a href="https://example.com/d">https://example.com/d</a><a href="/e"></a>`+
+		`<a href="/f">Weiterlesen »</a><a href="/g">Opening hours</a>`), nil, func(link Link) bool {
+		links = append(links, link)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	descriptive := FilterLinks(links, func(link Link) bool { return ClassifyAnchorText(link) == AnchorDescriptive })
+	var got []string
+	for _, link := range descriptive {
+		got = append(got, link.Href)
+	}
+	if strings.Join(got, " ") != "/a /g" {
+		t.Errorf("got %v, want /a /g", got)
+	}
+	if kept := FilterLinks(links, func(Link) bool { return false }); len(kept) != 0 {
+		t.Errorf("kept %v links", len(kept))
+	}
+	if kept := FilterLinks(nil, func(Link) bool { return true }); len(kept) != 0 {
+		t.Errorf("kept %v links of none", len(kept))
+	}
+}
@@ -0,0 +1,234 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"sort"
+	"strings"
+)
+
+// ErrNodeNotFound
+// Matched by errors.Is for all NodeError values.
+var ErrNodeNotFound = errors.New("node not found")
+
+// NodeError
+// Returned by FindNode and the other Find* helpers if no node matches, describes the failed lookup.
+type NodeError struct {
+	Label   string     // describes the condition, e.g., "price cell"
+	Root    *html.Node // the node the lookup started at
+	Scanned int        // number of nodes the condition was evaluated on
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("no node matching '%v' in %v (%v nodes scanned)", e.Label, describeNode(e.Root), e.Scanned)
+}
+
+// Is
+// Reports whether target is ErrNodeNotFound.
+func (e *NodeError) Is(target error) bool {
+	return target == ErrNodeNotFound
+}
+
+// describeNode
+// Returns a short description of node for messages, e.g., `<div id="main">`, "#document", or "nil".
+func describeNode(node *html.Node) string {
+	if node == nil {
+		return "nil"
+	}
+	switch node.Type {
+	case html.DocumentNode:
+		return "#document"
+	case html.TextNode:
+		return "#text"
+	case html.CommentNode:
+		return "#comment"
+	case html.ElementNode:
+		if id, err := GetAttributeByKey(node, "id"); err == nil && id.Val != "" {
+			return fmt.Sprintf("<%v id=%q>", node.Data, id.Val)
+		}
+		return fmt.Sprintf("<%v>", node.Data)
+	}
+	return fmt.Sprintf("#node(%v)", node.Type)
+}
+
+// FindNode
+// Same as GetNodeByCondition but returns a *NodeError naming label, root, and the number of scanned nodes instead of
+// nil if no node matches, so failed lookups in chains are reported where they happen.
+func FindNode(root *html.Node, cond func(*html.Node) bool, label string) (*html.Node, error) {
+	scanned := 0
+	found := GetNodeByCondition(root, func(node *html.Node) bool {
+		scanned++
+		return cond(node)
+	})
+	if found == nil {
+		return nil, &NodeError{Label: label, Root: root, Scanned: scanned}
+	}
+	return found, nil
+}
+
+// MustFindNode
+// Same as FindNode but panics with the *NodeError if no node matches.
+func MustFindNode(root *html.Node, cond func(*html.Node) bool, label string) *html.Node {
+	found, err := FindNode(root, cond, label)
+	if err != nil {
+		panic(err)
+	}
+	return found
+}
+
+// FindElementByTagName
+// Same as GetElementNodeByTagName but returns a *NodeError labeled "<name>" if there is no such element, see FindNode.
+func FindElementByTagName(name string, root *html.Node) (*html.Node, error) {
+	return FindNode(root, MakeByTagNameCondition(name), fmt.Sprintf("<%v>", name))
+}
+
+// FindElementByTagNameAndClass
+// Same as GetElementNodeByTagNameAndClass but returns a *NodeError labeled "<name>.className" if there is no such
+// element, see FindNode.
+func FindElementByTagNameAndClass(name, className string, root *html.Node) (*html.Node, error) {
+	byTagName, byClassName := MakeByTagNameCondition(name), MakeByClassNameCondition(className)
+	return FindNode(root, func(node *html.Node) bool {
+		return byTagName(node) && byClassName(node)
+	}, fmt.Sprintf("<%v>.%v", name, className))
+}
+
+// FindElementById
+// Returns the first element with the given id, see MakeByIdCondition, or a *NodeError labeled "#id", see FindNode.
+func FindElementById(id string, root *html.Node) (*html.Node, error) {
+	return FindNode(root, MakeByIdCondition(id), "#"+id)
+}
+
+// collapseWhitespace
+// Collapses all whitespace runs of s into single spaces and trims it.
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// extractText
+// Implements FindText with a label for the error.
+func extractText(root *html.Node, cond func(*html.Node) bool, label string, normalize func(string) string) (string, error) {
+	node, err := FindNode(root, cond, label)
+	if err != nil {
+		return "", err
+	}
+	if normalize == nil {
+		normalize = collapseWhitespace
+	}
+	return normalize(GetInnerText(node)), nil
+}
+
+// extractAttr
+// Implements FindAttr with a label for the error.
+func extractAttr(root *html.Node, cond func(*html.Node) bool, label, attrKey string) (string, error) {
+	node, err := FindNode(root, cond, label)
+	if err != nil {
+		return "", err
+	}
+	attr, err := GetAttributeByKey(node, attrKey)
+	if err != nil {
+		return "", fmt.Errorf("%v matching '%v' has no attribute '%v'", describeNode(node), label, attrKey)
+	}
+	return attr.Val, nil
+}
+
+// FindText
+// Returns the inner text (see GetInnerText) of the first node matching cond in the tree of root, normalized by
+// normalize, which collapses all whitespace into single spaces if nil. Returns a *NodeError if no node matches.
+func FindText(root *html.Node, cond func(*html.Node) bool, normalize func(string) string) (string, error) {
+	return extractText(root, cond, "condition", normalize)
+}
+
+// FindAttr
+// Returns the value of the attribute attrKey of the first node matching cond in the tree of root.
+// Returns a *NodeError if no node matches, and an error if the node has no such attribute.
+func FindAttr(root *html.Node, cond func(*html.Node) bool, attrKey string) (string, error) {
+	return extractAttr(root, cond, "condition", attrKey)
+}
+
+// ExtractionErrors
+// The errors of a batched extraction keyed by the label of the failed condition, see FindTexts and FindAttrs.
+type ExtractionErrors map[string]error
+
+func (e ExtractionErrors) Error() string {
+	errs := e.Unwrap()
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap
+// Returns the single errors ordered by label.
+func (e ExtractionErrors) Unwrap() []error {
+	labels := make([]string, 0, len(e))
+	for label := range e {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	errs := make([]error, len(labels))
+	for i, label := range labels {
+		errs[i] = e[label]
+	}
+	return errs
+}
+
+// Is
+// Reports whether any of the single errors matches target, see errors.Is. Unlike Unwrap, this also works with
+// toolchains older than Go 1.20, whose errors.Is does not unwrap multiple errors.
+func (e ExtractionErrors) Is(target error) bool {
+	for _, err := range e.Unwrap() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As
+// Finds the first single error, ordered by label, which matches target, see errors.As and Is.
+func (e ExtractionErrors) As(target interface{}) bool {
+	for _, err := range e.Unwrap() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindTexts
+// Same as FindText for each labeled condition of conds. Returns the texts keyed by label and, if any condition
+// fails, ExtractionErrors with the errors keyed by label. The texts of the other conditions are returned nonetheless.
+func FindTexts(root *html.Node, conds map[string]func(*html.Node) bool, normalize func(string) string) (map[string]string, error) {
+	return extractBatch(conds, func(label string, cond func(*html.Node) bool) (string, error) {
+		return extractText(root, cond, label, normalize)
+	})
+}
+
+// FindAttrs
+// Same as FindAttr for each labeled condition of conds, see FindTexts.
+func FindAttrs(root *html.Node, conds map[string]func(*html.Node) bool, attrKey string) (map[string]string, error) {
+	return extractBatch(conds, func(label string, cond func(*html.Node) bool) (string, error) {
+		return extractAttr(root, cond, label, attrKey)
+	})
+}
+
+// extractBatch
+// Calls extract for each labeled condition of conds and collects the values and errors by label.
+func extractBatch(conds map[string]func(*html.Node) bool, extract func(label string, cond func(*html.Node) bool) (string, error)) (map[string]string, error) {
+	values := make(map[string]string, len(conds))
+	errs := make(ExtractionErrors)
+	for label, cond := range conds {
+		value, err := extract(label, cond)
+		if err != nil {
+			errs[label] = err
+			continue
+		}
+		values[label] = value
+	}
+	if len(errs) > 0 {
+		return values, errs
+	}
+	return values, nil
+}
@@ -0,0 +1,146 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestFindNode(t *testing.T) {
+	doc := parseDocument(t, `<div id="main"><p class="a">1</p><span class="price">2</span></div><!-- c -->`)
+	main := elementByID(t, doc, "main")
+
+	found, err := FindNode(main, MakeByClassNameCondition("price"), "price")
+	if err != nil || found.Data != "span" {
+		t.Fatalf("got %v with error %v", found, err)
+	}
+
+	tests := []struct {
+		name string
+		find func() (*html.Node, error)
+		want string
+	}{
+		{"condition", func() (*html.Node, error) { return FindNode(main, MakeByClassNameCondition("cost"), "price cell") },
+			`no node matching 'price cell' in <div id="main"> (5 nodes scanned)`},
+		{"tag name", func() (*html.Node, error) { return FindElementByTagName("table", doc) },
+			`no node matching '<table>' in #document (10 nodes scanned)`},
+		{"tag name and class", func() (*html.Node, error) { return FindElementByTagNameAndClass("p", "price", main) },
+			`no node matching '<p>.price' in <div id="main"> (5 nodes scanned)`},
+		{"id", func() (*html.Node, error) { return FindElementById("missing", main.FirstChild) },
+			`no node matching '#missing' in <p> (2 nodes scanned)`},
+		{"text root", func() (*html.Node, error) { return FindElementById("x", main.FirstChild.FirstChild) },
+			`no node matching '#x' in #text (1 nodes scanned)`},
+		{"nil root", func() (*html.Node, error) { return FindElementByTagName("p", nil) },
+			`no node matching '<p>' in nil (0 nodes scanned)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := tt.find()
+			if found != nil || err == nil {
+				t.Fatalf("got %v with error %v, want an error", found, err)
+			}
+			if got := err.Error(); got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+			var nodeErr *NodeError
+			if !errors.Is(err, ErrNodeNotFound) || !errors.As(err, &nodeErr) {
+				t.Errorf("%v is not a *NodeError matching ErrNodeNotFound", err)
+			}
+		})
+	}
+
+	if found, err := FindElementById("main", doc); err != nil || found != main {
+		t.Errorf("FindElementById returned %v with error %v", found, err)
+	}
+	if found, err := FindElementByTagNameAndClass("p", "a", doc); err != nil || found != main.FirstChild {
+		t.Errorf("FindElementByTagNameAndClass returned %v with error %v", found, err)
+	}
+}
+
+func TestMustFindNode(t *testing.T) {
+	doc := parseDocument(t, `<p id="p">x</p>`)
+	if got := MustFindNode(doc, MakeByIdCondition("p"), "paragraph"); nodeID(got) != "p" {
+		t.Errorf("got %v", got)
+	}
+
+	defer func() {
+		err, ok := recover().(error)
+		if !ok || !errors.Is(err, ErrNodeNotFound) {
+			t.Fatalf("recovered %v, want a *NodeError", err)
+		}
+		if want := "no node matching 'heading' in #document (6 nodes scanned)"; err.Error() != want {
+			t.Errorf("got %v, want %v", err, want)
+		}
+	}()
+	MustFindNode(doc, MakeByTagNameCondition("h1"), "heading")
+	t.Error("MustFindNode did not panic")
+}
+
+func TestFindTextAndAttr(t *testing.T) {
+	doc := parseDocument(t, `<h1 class="title">  Great
+		<b>Tea</b>  </h1><a class="shop" href="/buy">Buy</a><span class="price">5,00 €</span>`)
+
+	if got, err := FindText(doc, MakeByClassNameCondition("title"), nil); err != nil || got != "Great Tea" {
+		t.Errorf("got %q with error %v", got, err)
+	}
+	if got, err := FindText(doc, MakeByClassNameCondition("price"), strings.ToUpper); err != nil || got != "5,00 €" {
+		t.Errorf("got %q with error %v", got, err)
+	}
+	if got, err := FindAttr(doc, MakeByClassNameCondition("shop"), "href"); err != nil || got != "/buy" {
+		t.Errorf("got %q with error %v", got, err)
+	}
+
+	_, err := FindText(doc, MakeByClassNameCondition("rating"), nil)
+	if !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("got error %v, want ErrNodeNotFound", err)
+	}
+	_, err = FindAttr(doc, MakeByClassNameCondition("shop"), "title")
+	if want := "<a> matching 'condition' has no attribute 'title'"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %v", err, want)
+	}
+}
+
+func TestFindTextsAndAttrs(t *testing.T) {
+	doc := parseDocument(t, `<h1 id="name"> Tea </h1><span class="price" data-v="5">5 €</span><a id="shop">shop</a>`)
+	conds := map[string]func(*html.Node) bool{
+		"name":   MakeByIdCondition("name"),
+		"price":  MakeByClassNameCondition("price"),
+		"rating": MakeByClassNameCondition("rating"),
+		"shop":   MakeByIdCondition("shop"),
+	}
+
+	texts, err := FindTexts(doc, conds, nil)
+	if got := fmt.Sprint(texts); got != "map[name:Tea price:5 € shop:shop]" {
+		t.Errorf("got texts %v", got)
+	}
+	var extractionErrs ExtractionErrors
+	if !errors.As(err, &extractionErrs) || len(extractionErrs) != 1 || !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("got error %v, want ExtractionErrors with a single not found error", err)
+	}
+	if want := "no node matching 'rating' in #document (10 nodes scanned)"; err.Error() != want {
+		t.Errorf("got\n%v\nwant\n%v", err, want)
+	}
+
+	values, err := FindAttrs(doc, conds, "data-v")
+	if got := fmt.Sprint(values); got != "map[price:5]" {
+		t.Errorf("got values %v", got)
+	}
+	want := strings.Join([]string{
+		"<h1 id=\"name\"> matching 'name' has no attribute 'data-v'",
+		"no node matching 'rating' in #document (10 nodes scanned)",
+		"<a id=\"shop\"> matching 'shop' has no attribute 'data-v'",
+	}, "\n")
+	if err == nil || err.Error() != want {
+		t.Errorf("got\n%v\nwant\n%v", err, want)
+	}
+	var nodeErr *NodeError
+	if !errors.As(err, &nodeErr) || nodeErr.Label != "rating" {
+		t.Errorf("got %v, want the *NodeError of rating", nodeErr)
+	}
+
+	if values, err := FindTexts(doc, nil, nil); err != nil || len(values) != 0 {
+		t.Errorf("got %v with error %v without conditions", values, err)
+	}
+}
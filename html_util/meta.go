@@ -0,0 +1,446 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RobotsDirectives
+// The indexing directives of a document, see GetRobotsDirectives.
+type RobotsDirectives struct {
+	NoIndex         bool   // the page must not be indexed
+	NoFollow        bool   // the links of the page must not be followed
+	NoArchive       bool   // no cached copy must be shown
+	NoSnippet       bool   // no text snippet or preview must be shown
+	NoImageIndex    bool   // the images of the page must not be indexed
+	NoTranslate     bool   // no translation must be offered
+	MaxSnippet      int    // maximum snippet length in characters, -1 for no limit
+	MaxImagePreview string // "none", "standard", "large", or "" if not specified
+	MaxVideoPreview int    // maximum video preview length in seconds, -1 for no limit
+}
+
+// imagePreviewRestriction
+// Orders the values of max-image-preview from most to least restrictive.
+var imagePreviewRestriction = map[string]int{
+	"none":     0,
+	"standard": 1,
+	"large":    2,
+}
+
+// DefaultRobotsMetaNames
+// Names of the meta elements GetRobotsDirectives interprets.
+var DefaultRobotsMetaNames = []string{"robots", "googlebot", "bingbot"}
+
+// GetRobotsDirectives
+// Interprets the content of all <meta name="robots"> elements and their googlebot and bingbot variants in the tree of
+// root, see GetRobotsDirectivesForNames.
+func GetRobotsDirectives(root *html.Node) RobotsDirectives {
+	return GetRobotsDirectivesForNames(root, DefaultRobotsMetaNames...)
+}
+
+// GetRobotsDirectivesForNames
+// Interprets the comma separated content of all <meta> elements in the tree of root whose name is one of names,
+// compared case-insensitively. Directives are case-insensitive, 'none' equals 'noindex, nofollow'.
+// Conflicting directives across multiple meta elements resolve to the most restrictive one.
+func GetRobotsDirectivesForNames(root *html.Node, names ...string) RobotsDirectives {
+	directives := RobotsDirectives{
+		MaxSnippet:      -1,
+		MaxVideoPreview: -1,
+	}
+
+	metas := GetNodesByCondition(root, func(node *html.Node) bool {
+		if node.Type != html.ElementNode || node.Data != "meta" {
+			return false
+		}
+		name, err := GetAttributeByKey(node, "name")
+		if err != nil {
+			return false
+		}
+		for _, n := range names {
+			if strings.EqualFold(strings.TrimSpace(name.Val), n) {
+				return true
+			}
+		}
+		return false
+	})
+
+	for _, meta := range metas {
+		content, err := GetAttributeByKey(meta, "content")
+		if err != nil {
+			continue
+		}
+		for _, directive := range strings.Split(content.Val, ",") {
+			key, value, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), ":")
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			switch key {
+			case "none":
+				directives.NoIndex = true
+				directives.NoFollow = true
+			case "noindex":
+				directives.NoIndex = true
+			case "nofollow":
+				directives.NoFollow = true
+			case "noarchive", "nocache":
+				directives.NoArchive = true
+			case "nosnippet":
+				directives.NoSnippet = true
+			case "noimageindex":
+				directives.NoImageIndex = true
+			case "notranslate":
+				directives.NoTranslate = true
+			case "max-snippet":
+				directives.MaxSnippet = mostRestrictiveLimit(directives.MaxSnippet, value)
+			case "max-video-preview":
+				directives.MaxVideoPreview = mostRestrictiveLimit(directives.MaxVideoPreview, value)
+			case "max-image-preview":
+				restriction, ok := imagePreviewRestriction[value]
+				if ok && (directives.MaxImagePreview == "" || restriction < imagePreviewRestriction[directives.MaxImagePreview]) {
+					directives.MaxImagePreview = value
+				}
+			}
+		}
+	}
+
+	return directives
+}
+
+// mostRestrictiveLimit
+// Returns the smaller of the limits current and value, in which -1 means no limit. Invalid values are ignored.
+func mostRestrictiveLimit(current int, value string) int {
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < -1 {
+		return current
+	}
+	if current == -1 || (limit != -1 && limit < current) {
+		return limit
+	}
+	return current
+}
+
+// IsNofollowLink
+// Returns whether node is an <a>, <area>, or <link> element whose 'rel' attribute contains 'nofollow'.
+func IsNofollowLink(node *html.Node) bool {
+	if node == nil || node.Type != html.ElementNode || (node.Data != "a" && node.Data != "area" && node.Data != "link") {
+		return false
+	}
+	rel, err := GetAttributeByKey(node, "rel")
+	if err != nil {
+		return false
+	}
+	for _, token := range strings.Fields(rel.Val) {
+		if strings.EqualFold(token, "nofollow") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetHttpEquiv
+// Returns the content of the first <meta> element in the tree of root whose http-equiv attribute equals name,
+// compared case-insensitively, e.g., "refresh" or "content-security-policy".
+func GetHttpEquiv(root *html.Node, name string) (string, bool) {
+	meta := GetNodeByCondition(root, func(node *html.Node) bool {
+		if node.Type != html.ElementNode || node.Data != "meta" {
+			return false
+		}
+		httpEquiv, err := GetAttributeByKey(node, "http-equiv")
+		return err == nil && strings.EqualFold(strings.TrimSpace(httpEquiv.Val), name) && hasAttribute(node, "content")
+	})
+	if meta == nil {
+		return "", false
+	}
+	content, _ := GetAttributeByKey(meta, "content")
+	return content.Val, true
+}
+
+// hasAttribute
+// Returns whether node has an attribute with the given key.
+func hasAttribute(node *html.Node, key string) bool {
+	_, err := GetAttributeByKey(node, key)
+	return err == nil
+}
+
+// GetMetaRefresh
+// Interprets the first <meta http-equiv="refresh"> element in the tree of root as browsers do, e.g.,
+// content="5; url=/next". The delay is followed by ';' or ',' and an optional url which may be prefixed by 'url='
+// and enclosed in quotes. Missing separators, spaces, and unterminated quotes are tolerated, fractions of the delay are
+// ignored, and delays exceeding the range of time.Duration are capped at its maximum.
+// The target is resolved against the href of the first <base> element in the tree of root, if any, which is resolved
+// against base if base is not nil. Without url, the target is base itself, i.e., the page reloads. ok is false if there is no refresh or its delay is malformed, err is set if the url cannot be parsed.
+func GetMetaRefresh(root *html.Node, base *url.URL) (delay time.Duration, target *url.URL, ok bool, err error) {
+	content, found := GetHttpEquiv(root, "refresh")
+	if !found {
+		return 0, nil, false, nil
+	}
+	delay, rawURL, ok := parseRefreshContent(content)
+	if !ok {
+		return 0, nil, false, nil
+	}
+	if rawURL == "" {
+		return delay, base, true, nil
+	}
+	if documentBase := getDocumentBase(root, base); documentBase != nil {
+		target, err = documentBase.Parse(rawURL)
+	} else {
+		target, err = url.Parse(rawURL)
+	}
+	if err != nil {
+		return delay, nil, true, err
+	}
+	return delay, target, true, nil
+}
+
+// parseRefreshContent
+// Splits the content of a refresh meta element into delay and url following the parsing rules of the html standard.
+func parseRefreshContent(content string) (time.Duration, string, bool) {
+	isSpace := func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r'
+	}
+	s := strings.TrimLeftFunc(content, isSpace)
+
+	digits := 0
+	for digits < len(s) && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 && !strings.HasPrefix(s, ".") {
+		return 0, "", false
+	}
+	delay := time.Duration(0)
+	if digits > 0 {
+		seconds, err := strconv.ParseInt(s[:digits], 10, 64)
+		if err != nil || seconds > int64(math.MaxInt64/time.Second) {
+			delay = math.MaxInt64
+		} else {
+			delay = time.Duration(seconds) * time.Second
+		}
+	}
+
+	// fractions are ignored
+	s = strings.TrimLeft(s[digits:], "0123456789.")
+	if s == "" {
+		return delay, "", true
+	}
+	if !isSpace(rune(s[0])) && s[0] != ';' && s[0] != ',' {
+		return 0, "", false
+	}
+	s = strings.TrimLeftFunc(s, isSpace)
+	if strings.HasPrefix(s, ";") || strings.HasPrefix(s, ",") {
+		s = strings.TrimLeftFunc(s[1:], isSpace)
+	}
+
+	if len(s) >= 3 && strings.EqualFold(s[:3], "url") {
+		rest := strings.TrimLeftFunc(s[3:], isSpace)
+		if strings.HasPrefix(rest, "=") {
+			s = strings.TrimLeftFunc(rest[1:], isSpace)
+		}
+	}
+	if strings.HasPrefix(s, "'") || strings.HasPrefix(s, "\"") {
+		quote := s[:1]
+		s = s[1:]
+		if end := strings.Index(s, quote); end >= 0 {
+			s = s[:end]
+		}
+	}
+	return delay, strings.TrimRightFunc(s, isSpace), true
+}
+
+// makeMetaNameCondition
+// Returns a condition matching <meta> elements with a content attribute whose name equals name case-insensitively.
+func makeMetaNameCondition(name string) func(node *html.Node) bool {
+	return func(node *html.Node) bool {
+		if node.Type != html.ElementNode || node.Data != "meta" {
+			return false
+		}
+		metaName, err := GetAttributeByKey(node, "name")
+		return err == nil && strings.EqualFold(strings.TrimSpace(metaName.Val), name) && hasAttribute(node, "content")
+	}
+}
+
+// ViewportDirectives
+// The directives of a <meta name="viewport"> element, see GetViewportMeta.
+// Scales are 0 if absent, Width and Height keep their raw value, e.g., "device-width" or "980".
+type ViewportDirectives struct {
+	Width        string
+	Height       string
+	InitialScale float64
+	MinimumScale float64
+	MaximumScale float64
+	UserScalable bool              // true unless disabled via user-scalable=no or a value between -1 and 1
+	Other        map[string]string // all other directives, e.g., viewport-fit, with lowercase keys
+}
+
+// IsMobileOptimized
+// Returns whether the viewport adapts to the device, i.e., its width is device-width or it sets an initial scale.
+func (v ViewportDirectives) IsMobileOptimized() bool {
+	return strings.EqualFold(v.Width, "device-width") || v.InitialScale > 0
+}
+
+// GetViewportMeta
+// Interprets the content of the first <meta name="viewport"> element in the tree of root. ok is false if there is none.
+// The content is tokenized as Chromium does: directives are separated by commas or semicolons, or by whitespace after
+// a value, any spaces and '=' between a key and its value are skipped, keys and keywords are case-insensitive, and
+// unknown or malformed directives are ignored. A key without '=' takes the value of the next '=' before the next comma
+// or semicolon, e.g., "shrink-to-fit initial-scale=1" sets shrink-to-fit to 1.
+func GetViewportMeta(root *html.Node) (directives ViewportDirectives, ok bool) {
+	meta := GetNodeByCondition(root, makeMetaNameCondition("viewport"))
+	if meta == nil {
+		return ViewportDirectives{}, false
+	}
+	content, _ := GetAttributeByKey(meta, "content")
+
+	directives.UserScalable = true
+	for _, kv := range tokenizeViewportContent(content.Val) {
+		key, value := kv[0], kv[1]
+		switch key {
+		case "width":
+			directives.Width = value
+		case "height":
+			directives.Height = value
+		case "initial-scale":
+			directives.InitialScale = parseViewportScale(value)
+		case "minimum-scale":
+			directives.MinimumScale = parseViewportScale(value)
+		case "maximum-scale":
+			directives.MaximumScale = parseViewportScale(value)
+		case "user-scalable":
+			directives.UserScalable = parseViewportBool(value)
+		default:
+			if directives.Other == nil {
+				directives.Other = make(map[string]string)
+			}
+			directives.Other[key] = value
+		}
+	}
+	return directives, true
+}
+
+// isViewportSeparator
+// Returns true for the characters separating the keys and values of viewport directives.
+func isViewportSeparator(c byte) bool {
+	return c == ',' || c == ';' || c == '=' || c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == 0
+}
+
+// tokenizeViewportContent
+// Splits the content of a viewport meta element into key-value pairs with lowercase keys, see GetViewportMeta.
+func tokenizeViewportContent(content string) [][2]string {
+	var pairs [][2]string
+	i := 0
+	for i < len(content) {
+		for i < len(content) && isViewportSeparator(content[i]) {
+			i++
+		}
+		keyStart := i
+		for i < len(content) && !isViewportSeparator(content[i]) {
+			i++
+		}
+		keyEnd := i
+		// skip to the '=', but not past the end of the directive
+		for i < len(content) && content[i] != '=' && content[i] != ',' && content[i] != ';' {
+			i++
+		}
+		for i < len(content) && isViewportSeparator(content[i]) && content[i] != ',' && content[i] != ';' {
+			i++
+		}
+		valueStart := i
+		for i < len(content) && !isViewportSeparator(content[i]) {
+			i++
+		}
+		if keyStart < keyEnd {
+			pairs = append(pairs, [2]string{strings.ToLower(content[keyStart:keyEnd]), content[valueStart:i]})
+		}
+	}
+	return pairs
+}
+
+// parseViewportNumber
+// Parses a decimal number of a viewport directive, e.g., "1", "-.5", or "2e1". Words like "NaN" or "Inf" and hex
+// numbers are no numbers, numbers too large for a float64 yield an infinity.
+func parseViewportNumber(value string) (float64, bool) {
+	if value == "" || strings.ContainsAny(value, "xXpP") || !strings.ContainsAny(value[:1], "0123456789.+-") {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil && !math.IsInf(number, 0) {
+		return 0, false
+	}
+	return number, true
+}
+
+// parseViewportScale
+// Parses a scale as browsers do: yes is 1, device-width and device-height are 10, numbers are clamped to 0.1-10, and
+// anything else, including no, is the minimum 0.1. Returns 0 for empty values.
+func parseViewportScale(value string) float64 {
+	switch strings.ToLower(value) {
+	case "":
+		return 0
+	case "yes":
+		return 1
+	case "device-width", "device-height":
+		return 10
+	}
+	scale, ok := parseViewportNumber(value)
+	if !ok || scale < 0.1 {
+		return 0.1
+	}
+	if scale > 10 {
+		return 10
+	}
+	return scale
+}
+
+// parseViewportBool
+// Parses the value of user-scalable as browsers do: yes, device-width, device-height, and numbers with an absolute
+// value of at least 1 are true, everything else, including no, is false.
+func parseViewportBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "yes", "device-width", "device-height":
+		return true
+	}
+	number, ok := parseViewportNumber(value)
+	return ok && (number >= 1 || number <= -1)
+}
+
+// ThemeColor
+// The content of a <meta name="theme-color"> element and its optional media query, e.g., "(prefers-color-scheme: dark)".
+type ThemeColor struct {
+	Color string
+	Media string
+}
+
+// GetThemeColors
+// Returns the theme colors of all <meta name="theme-color"> elements in the tree of root in document order.
+func GetThemeColors(root *html.Node) []ThemeColor {
+	var colors []ThemeColor
+	for _, meta := range GetNodesByCondition(root, makeMetaNameCondition("theme-color")) {
+		content, _ := GetAttributeByKey(meta, "content")
+		color := ThemeColor{Color: strings.TrimSpace(content.Val)}
+		if color.Color == "" {
+			continue
+		}
+		if media, err := GetAttributeByKey(meta, "media"); err == nil {
+			color.Media = strings.TrimSpace(media.Val)
+		}
+		colors = append(colors, color)
+	}
+	return colors
+}
+
+// GetThemeColor
+// Returns the first theme color without media query, or the first media-qualified one if there is none.
+// ok is false if the document declares no theme color. See GetThemeColors for all variants.
+func GetThemeColor(root *html.Node) (string, bool) {
+	colors := GetThemeColors(root)
+	for _, color := range colors {
+		if color.Media == "" {
+			return color.Color, true
+		}
+	}
+	if len(colors) > 0 {
+		return colors[0].Color, true
+	}
+	return "", false
+}
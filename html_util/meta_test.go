@@ -0,0 +1,221 @@
+package html_util
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"math"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetRobotsDirectives(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		want RobotsDirectives
+	}{
+		{"none", `<meta name="description" content="noindex">`, RobotsDirectives{MaxSnippet: -1, MaxVideoPreview: -1}},
+		{"spaces and casing", `<meta name=" ROBOTS " content=" NoIndex ,nofollow,  NOARCHIVE">`,
+			RobotsDirectives{NoIndex: true, NoFollow: true, NoArchive: true, MaxSnippet: -1, MaxVideoPreview: -1}},
+		{"none directive", `<meta name="googlebot" content="none"><meta name="otherbot" content="nosnippet">`,
+			RobotsDirectives{NoIndex: true, NoFollow: true, MaxSnippet: -1, MaxVideoPreview: -1}},
+		{"limits", `<meta name="robots" content="max-snippet : 50, max-image-preview:large, max-video-preview:-1">`,
+			RobotsDirectives{MaxSnippet: 50, MaxImagePreview: "large", MaxVideoPreview: -1}},
+		{"most restrictive", `<meta name="robots" content="max-snippet:-1, max-image-preview:large, max-video-preview:30">` +
+			`<meta name="bingbot" content="max-snippet:20, max-image-preview:standard, max-video-preview:x">` +
+			`<meta name="googlebot" content="max-snippet:40, max-image-preview:huge, nocache, noimageindex, notranslate">`,
+			RobotsDirectives{NoArchive: true, NoImageIndex: true, NoTranslate: true, MaxSnippet: 20, MaxImagePreview: "standard", MaxVideoPreview: 30}},
+	}
+	for _, tt := range tests {
+		if got := GetRobotsDirectives(parseDocument(t, `<head>`+tt.head+`</head>`)); got != tt.want {
+			t.Errorf("%v: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	doc := parseDocument(t, `<meta name="robots" content="noindex"><meta name="otherbot" content="nofollow">`)
+	if got := GetRobotsDirectivesForNames(doc, "OtherBot"); got.NoIndex || !got.NoFollow {
+		t.Errorf("GetRobotsDirectivesForNames = %+v, want only nofollow", got)
+	}
+}
+
+func TestIsNofollowLink(t *testing.T) {
+	doc := parseDocument(t, `<a id="1" rel="noopener NoFollow" href="x">a</a><a id="2" rel="nofollower">b</a>`+
+		`<link id="3" rel="nofollow"><span id="4" rel="nofollow"></span><a id="5">c</a>`)
+	want := map[string]bool{"1": true, "2": false, "3": true, "4": false, "5": false}
+	for _, node := range GetNodesByCondition(doc, func(n *html.Node) bool { return nodeID(n) != "" }) {
+		if got := IsNofollowLink(node); got != want[nodeID(node)] {
+			t.Errorf("IsNofollowLink(#%v) = %v", nodeID(node), got)
+		}
+	}
+	if IsNofollowLink(nil) {
+		t.Error("IsNofollowLink(nil) = true")
+	}
+}
+
+func TestGetMetaRefresh(t *testing.T) {
+	base, _ := url.Parse("https://example.com/dir/page.html")
+	tests := []struct {
+		content   string
+		wantDelay time.Duration
+		wantURL   string
+		wantOk    bool
+	}{
+		{"5; url=/next", 5 * time.Second, "https://example.com/next", true},
+		{"0;URL='next.html'", 0, "https://example.com/dir/next.html", true},
+		{`0; url="a b.html"`, 0, "https://example.com/dir/a%20b.html", true},
+		{"3,url=x.html", 3 * time.Second, "https://example.com/dir/x.html", true},
+		{"3 url = x.html ", 3 * time.Second, "https://example.com/dir/x.html", true},
+		{"  10 ; 'x.html", 10 * time.Second, "https://example.com/dir/x.html", true},
+		{"1.5; url=x.html", time.Second, "https://example.com/dir/x.html", true},
+		{".5; url=x.html", 0, "https://example.com/dir/x.html", true},
+		{"0; urlx.html", 0, "https://example.com/dir/urlx.html", true},
+		{"0; https://other.org/", 0, "https://other.org/", true},
+		{"30", 30 * time.Second, "https://example.com/dir/page.html", true},
+		{"99999999999999999999; url=x", math.MaxInt64, "https://example.com/dir/x", true},
+		{"", 0, "", false},
+		{"url=x.html", 0, "", false},
+		{"-1; url=x.html", 0, "", false},
+		{"5x; url=x.html", 0, "", false},
+	}
+	for _, tt := range tests {
+		doc := parseDocument(t, `<meta http-equiv="Refresh" content="`+html.EscapeString(tt.content)+`">`)
+		delay, target, ok, err := GetMetaRefresh(doc, base)
+		if err != nil {
+			t.Errorf("GetMetaRefresh(%q) returned %v", tt.content, err)
+			continue
+		}
+		gotURL := ""
+		if target != nil {
+			gotURL = target.String()
+		}
+		if delay != tt.wantDelay || gotURL != tt.wantURL || ok != tt.wantOk {
+			t.Errorf("GetMetaRefresh(%q) = %v, %q, %v, want %v, %q, %v", tt.content, delay, gotURL, ok,
+				tt.wantDelay, tt.wantURL, tt.wantOk)
+		}
+	}
+}
+
+func TestGetMetaRefreshWithoutBaseAndErrors(t *testing.T) {
+	doc := parseDocument(t, `<meta http-equiv="refresh" content="0; url=next.html">`)
+	if _, target, ok, err := GetMetaRefresh(doc, nil); !ok || err != nil || target.String() != "next.html" {
+		t.Errorf("without base got %v, %v, %v, want next.html", target, ok, err)
+	}
+	// the <base> element applies as for links
+	doc = parseDocument(t, `<base href="/other/"><meta http-equiv="refresh" content="0; url=next.html">`)
+	page, _ := url.Parse("https://example.com/dir/page.html")
+	if _, target, _, _ := GetMetaRefresh(doc, page); target.String() != "https://example.com/other/next.html" {
+		t.Errorf("with <base> got %v, want https://example.com/other/next.html", target)
+	}
+	doc = parseDocument(t, `<meta http-equiv="refresh" content="0; url=http://[::1">`)
+	if _, _, ok, err := GetMetaRefresh(doc, nil); !ok || err == nil {
+		t.Errorf("malformed url got %v, %v, want ok and an error", ok, err)
+	}
+	doc = parseDocument(t, `<meta name="refresh" content="0">`)
+	if _, _, ok, _ := GetMetaRefresh(doc, nil); ok {
+		t.Error("a <meta name=\"refresh\"> element is a refresh")
+	}
+}
+
+func TestGetHttpEquiv(t *testing.T) {
+	doc := parseDocument(t, `<meta http-equiv="X-UA-Compatible"><meta http-equiv=" x-ua-compatible " content="IE=edge">`+
+		`<meta http-equiv="content-security-policy" content="">`)
+	tests := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{"X-UA-Compatible", "IE=edge", true}, // the first element has no content
+		{"Content-Security-Policy", "", true},
+		{"refresh", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := GetHttpEquiv(doc, tt.name); got != tt.want || ok != tt.wantOk {
+			t.Errorf("GetHttpEquiv(%v) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestGetViewportMeta(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+		mobile  bool
+	}{
+		{"width=device-width, initial-scale=1", "width=device-width initial=1 scalable=true", true},
+		{"width = device-width ; initial-scale = 1.0 ;", "width=device-width initial=1 scalable=true", true},
+		{"WIDTH=Device-Width user-scalable=no", "width=Device-Width scalable=false", true},
+		{"width=980", "width=980 scalable=true", false},
+		{"initial-scale=yes, maximum-scale=device-width, minimum-scale=no", "initial=1 min=0.1 max=10 scalable=true", true},
+		{"initial-scale=20, maximum-scale=0.01", "initial=10 max=0.1 scalable=true", true},
+		{"initial-scale=NaN, maximum-scale=Inf, minimum-scale=0x1p0", "initial=0.1 min=0.1 max=0.1 scalable=true", true},
+		{"maximum-scale=1e400", "max=10 scalable=true", false},
+		{"user-scalable=0", "scalable=false", false},
+		{"user-scalable=-1", "scalable=true", false},
+		{"user-scalable=yes", "scalable=true", false},
+		{"user-scalable=nan", "scalable=false", false},
+		{",,;; width==device-width viewport-fit=cover shrink-to-fit", "width=device-width scalable=true " +
+			"other=map[shrink-to-fit: viewport-fit:cover]", true},
+		{"shrink-to-fit initial-scale=2, width", "scalable=true other=map[shrink-to-fit:2]", false}, // as in Chromium
+		{"", "scalable=true", false},
+	}
+	for _, tt := range tests {
+		doc := parseDocument(t, `<head><meta name=" Viewport " content="`+tt.content+`"><meta name="viewport" content="width=1"></head>`)
+		v, ok := GetViewportMeta(doc)
+		if !ok {
+			t.Fatalf("no viewport for %q", tt.content)
+		}
+		var parts []string
+		for _, p := range []struct {
+			name  string
+			value interface{}
+			set   bool
+		}{
+			{"width", v.Width, v.Width != ""},
+			{"height", v.Height, v.Height != ""},
+			{"initial", v.InitialScale, v.InitialScale != 0},
+			{"min", v.MinimumScale, v.MinimumScale != 0},
+			{"max", v.MaximumScale, v.MaximumScale != 0},
+			{"scalable", v.UserScalable, true},
+			{"other", v.Other, v.Other != nil},
+		} {
+			if p.set {
+				parts = append(parts, fmt.Sprintf("%v=%v", p.name, p.value))
+			}
+		}
+		if got := strings.Join(parts, " "); got != tt.want {
+			t.Errorf("GetViewportMeta(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+		if got := v.IsMobileOptimized(); got != tt.mobile {
+			t.Errorf("IsMobileOptimized of %q = %v, want %v", tt.content, got, tt.mobile)
+		}
+	}
+
+	if _, ok := GetViewportMeta(parseDocument(t, `<meta name="viewport"><meta name="description" content="x">`)); ok {
+		t.Error("found a viewport without content")
+	}
+}
+
+func TestGetThemeColor(t *testing.T) {
+	doc := parseDocument(t, `<head><meta name="theme-color" content="  " >`+
+		`<meta name="theme-color" media="(prefers-color-scheme: dark)" content="#000">`+
+		`<meta name="Theme-Color" content=" #fff ">`+
+		`<meta name="theme-color" media=" (prefers-color-scheme: light) " content="white"></head>`)
+
+	want := []ThemeColor{{"#000", "(prefers-color-scheme: dark)"}, {"#fff", ""}, {"white", "(prefers-color-scheme: light)"}}
+	if got := GetThemeColors(doc); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("GetThemeColors = %v, want %v", got, want)
+	}
+	// the color without media query is preferred
+	if color, ok := GetThemeColor(doc); !ok || color != "#fff" {
+		t.Errorf("GetThemeColor = %q, %v, want #fff", color, ok)
+	}
+	media := parseDocument(t, `<meta name="theme-color" media="(prefers-color-scheme: dark)" content="#000">`)
+	if color, ok := GetThemeColor(media); !ok || color != "#000" {
+		t.Errorf("GetThemeColor = %q, %v, want the media-qualified #000", color, ok)
+	}
+	if color, ok := GetThemeColor(parseDocument(t, `<p>none</p>`)); ok || color != "" {
+		t.Errorf("GetThemeColor = %q, %v without theme color", color, ok)
+	}
+}
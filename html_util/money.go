@@ -0,0 +1,342 @@
+package html_util
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DecimalConvention
+// The separator convention of a number, see Money.
+type DecimalConvention int
+
+const (
+	DecimalUnknown DecimalConvention = iota // the number has no separators which reveal the convention
+	DecimalPoint                            // '.' separates decimals, ',' thousands, e.g., "1,299.00"
+	DecimalComma                            // ',' separates decimals, '.' thousands, e.g., "1.299,00"
+)
+
+// CurrencySymbols
+// Maps lower case currency symbols and abbreviations to ISO 4217 codes, used by ParseMoney.
+// Callers may add symbols, e.g., CurrencySymbols["lei"] = "RON".
+var CurrencySymbols = map[string]string{
+	"$": "USD", "us$": "USD", "€": "EUR", "eur": "EUR", "£": "GBP", "¥": "JPY", "円": "JPY",
+	"元": "CNY", "cn¥": "CNY", "rmb": "CNY", "₹": "INR", "rs": "INR", "rs.": "INR", "₽": "RUB", "руб": "RUB",
+	"руб.": "RUB", "₩": "KRW", "₺": "TRY", "tl": "TRY", "₪": "ILS", "₫": "VND", "₴": "UAH", "₦": "NGN",
+	"₱": "PHP", "฿": "THB", "₡": "CRC", "₲": "PYG", "₸": "KZT", "₾": "GEL", "zł": "PLN", "kč": "CZK",
+	"ft": "HUF", "lei": "RON", "лв": "BGN", "лв.": "BGN", "kn": "HRK", "fr": "CHF", "fr.": "CHF", "sfr": "CHF",
+	"sfr.": "CHF", "chf": "CHF", "kr": "SEK", "kr.": "DKK", "r$": "BRL", "c$": "CAD", "ca$": "CAD", "can$": "CAD",
+	"a$": "AUD", "au$": "AUD", "nz$": "NZD", "hk$": "HKD", "s$": "SGD", "mx$": "MXN", "nt$": "TWD", "r": "ZAR",
+}
+
+// lakhGroupingCurrencies
+// Currencies whose amounts are also grouped in lakhs and crores, e.g., "12,34,567", see ParseMoney.
+var lakhGroupingCurrencies = map[string]bool{"INR": true, "PKR": true, "NPR": true, "BDT": true, "LKR": true}
+
+// ambiguousCurrencySymbols
+// Currencies sharing a symbol, the first one is used unless MoneyHints.DefaultCurrency is one of the others.
+var ambiguousCurrencySymbols = map[string][]string{
+	"$":   {"USD", "CAD", "AUD", "NZD", "HKD", "SGD", "MXN", "ARS", "CLP", "COP", "TWD"},
+	"¥":   {"JPY", "CNY"},
+	"kr":  {"SEK", "NOK", "DKK", "ISK"},
+	"kr.": {"DKK", "NOK", "SEK", "ISK"},
+	"fr":  {"CHF", "XOF", "XAF"},
+	"fr.": {"CHF", "XOF", "XAF"},
+	"r":   {"ZAR", "BRL"},
+}
+
+// MoneyHints
+// Resolves ambiguities in ParseMoney.
+type MoneyHints struct {
+	DefaultCurrency  string // ISO 4217 code of amounts without currency, also preferred among currencies sharing a symbol, e.g., "CAD" for "$"
+	DecimalSeparator rune   // '.' or ',' if a single separator followed by three digits, e.g., "1,299", separates decimals, else, it separates thousands
+}
+
+// Money
+// An amount of money parsed by ParseMoney. The amount is kept in minor units to avoid floating point errors.
+type Money struct {
+	Amount     int64             // amount in units of 10^-Exponent, e.g., 129900 for "1,299.00"
+	Exponent   int               // number of decimal digits of the amount, e.g., 2 for "1,299.00" and 0 for "1,299"
+	Currency   string            // ISO 4217 code, "" if neither the text nor MoneyHints.DefaultCurrency name one
+	Convention DecimalConvention // separator convention of the number
+	Raw        string            // the original text
+	Valid      bool              // whether the amount could be determined
+}
+
+// Float64
+// Returns the amount as float, e.g., 1299 for "1,299.00". Use Amount and Exponent for exact arithmetic.
+func (m Money) Float64() float64 {
+	return float64(m.Amount) / math.Pow10(m.Exponent)
+}
+
+// isMoneyNumberRune
+// Returns true for the characters within the number of a money text, i.e., digits, separators, and spaces.
+func isMoneyNumberRune(r rune) bool {
+	return isASCIIDigit(r) || r == '.' || r == ',' || isThousandsSpace(r)
+}
+
+// isThousandsSpace
+// Returns true for the characters besides '.' and ',' which separate thousands, i.e., spaces and apostrophes.
+func isThousandsSpace(r rune) bool {
+	return r == ' ' || r == '\u00a0' || r == '\u2009' || r == '\u202f' || r == '\'' || r == '\u2019'
+}
+
+// isASCIIDigit
+// Returns true for '0' to '9'.
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// ParseMoney
+// Parses a price like "$1,299.00", "1.299,00 €", "CHF 99.–", "-12.50 USD", or "(3,000)" (accounting negative).
+// The currency may precede or follow the number and is resolved via its ISO 4217 code in upper case or via
+// CurrencySymbols, symbols shared by several currencies, e.g., "$", prefer MoneyHints.DefaultCurrency.
+// The decimal separator is determined as in parseLocaleNumber, except that a single separator followed by exactly three
+// digits, e.g., "1,299", is a thousands separator unless it follows more than three digits or only a zero, e.g., "0.500",
+// or MoneyHints.DecimalSeparator says otherwise. Dashes as decimals, e.g., "99.–" or "99,-", mean zero minor units.
+// Apostrophes and spaces are accepted as thousands separators.
+// Returns an error if there is no number, if thousands separators do not split it into groups of three digits, e.g.,
+// "1,2,3", if the currency is unknown, or if the amount does not fit into an int64. Amounts in rupees, e.g., "₹1,23,456",
+// may be grouped in lakhs and crores instead.
+func ParseMoney(s string, hints MoneyHints) (Money, error) {
+	m := Money{Raw: s}
+	text := strings.TrimSpace(s)
+
+	negative := false
+	if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") {
+		negative = true
+		text = strings.TrimSpace(text[1 : len(text)-1])
+	}
+
+	// the number spans from the first to the last digit, followed by an optional dash as decimals
+	first := strings.IndexFunc(text, isASCIIDigit)
+	if first == -1 {
+		return m, fmt.Errorf("cannot parse '%v' as money: no number", s)
+	}
+	last := strings.LastIndexFunc(text, isASCIIDigit) + 1
+	number := text[first:last]
+	if strings.IndexFunc(number, func(r rune) bool { return !isMoneyNumberRune(r) }) != -1 {
+		return m, fmt.Errorf("cannot parse '%v' as money: unexpected characters in number '%v'", s, number)
+	}
+	prefix, suffix := text[:first], text[last:]
+	dashDecimals := false
+	for _, dash := range []string{".–", ".-", ".—", ",–", ",-", ",—"} {
+		if strings.HasPrefix(suffix, dash) {
+			number += dash[:1]
+			suffix = suffix[len(dash):]
+			dashDecimals = true
+			break
+		}
+	}
+
+	// signs may precede or follow the currency, e.g., "-$5" or "$-5", or follow the number, e.g., "5-"
+	isSign := func(r rune) bool {
+		return r == '-' || r == '−' || r == '–' || r == '+'
+	}
+	if p := strings.TrimRightFunc(prefix, unicode.IsSpace); strings.IndexFunc(p, isSign) != -1 {
+		negative = negative || strings.ContainsAny(p, "-−–")
+		prefix = strings.Map(func(r rune) rune {
+			if isSign(r) {
+				return -1
+			}
+			return r
+		}, prefix)
+	} else if t := strings.TrimSpace(suffix); !dashDecimals && (strings.HasPrefix(t, "-") || strings.HasPrefix(t, "−")) {
+		negative = true
+		suffix = strings.TrimLeft(t, "-−")
+	}
+
+	currency, err := resolveCurrency(strings.TrimSpace(prefix)+" "+strings.TrimSpace(suffix), hints.DefaultCurrency)
+	if err != nil {
+		return m, fmt.Errorf("cannot parse '%v' as money: %w", s, err)
+	}
+
+	amount, exponent, convention, err := parseMoneyAmount(number, dashDecimals, hints.DecimalSeparator,
+		lakhGroupingCurrencies[strings.ToUpper(currency)])
+	if err != nil {
+		return m, fmt.Errorf("cannot parse '%v' as money: %w", s, err)
+	}
+
+	if negative {
+		amount = -amount
+	}
+	m.Amount, m.Exponent, m.Currency, m.Convention, m.Valid = amount, exponent, currency, convention, true
+	return m, nil
+}
+
+// parseMoneyAmount
+// Returns the amount of number in minor units, the number of decimal digits, and the separator convention, see
+// ParseMoney. A trailing separator is a decimal separator with "00" as decimals if dashDecimals is set. If lakhGrouping
+// is set, the integer part may also be grouped in lakhs and crores, see hasValidLakhGrouping.
+func parseMoneyAmount(number string, dashDecimals bool, decimalSeparator rune, lakhGrouping bool) (int64, int, DecimalConvention, error) {
+	digits := strings.Map(func(r rune) rune {
+		if isThousandsSpace(r) {
+			return -1
+		}
+		return r
+	}, number)
+
+	convention := DecimalUnknown
+	decimal := -1 // index of the decimal separator in digits
+	lastComma := strings.LastIndex(digits, ",")
+	lastDot := strings.LastIndex(digits, ".")
+	switch {
+	case dashDecimals:
+		decimal = len(digits) - 1
+	case lastComma != -1 && lastDot != -1:
+		decimal = lastComma
+		if lastDot > lastComma {
+			decimal = lastDot
+		}
+	case lastComma != -1 || lastDot != -1:
+		separator := lastComma
+		if lastDot != -1 {
+			separator = lastDot
+		}
+		// a single separator followed by three digits separates thousands, unless it follows more than three digits or
+		// no digit other than a zero, e.g., "0.500"
+		single := strings.Count(digits, digits[separator:separator+1]) == 1
+		leadingZero := separator == 0 || digits[:separator] == "0"
+		if single && (len(digits)-separator-1 != 3 || separator > 3 || leadingZero || rune(digits[separator]) == decimalSeparator) {
+			decimal = separator
+		} else if digits[separator] == ',' {
+			convention = DecimalPoint // ',' separates thousands
+		} else {
+			convention = DecimalComma
+		}
+	}
+	if decimal != -1 {
+		if digits[decimal] == '.' {
+			convention = DecimalPoint
+		} else {
+			convention = DecimalComma
+		}
+	}
+
+	integer, fraction := digits, ""
+	if decimal != -1 {
+		integer, fraction = digits[:decimal], digits[decimal+1:]
+		if dashDecimals {
+			fraction = "00"
+		}
+	}
+	thousands := ","
+	if convention == DecimalComma {
+		thousands = "."
+	}
+	if !hasValidGrouping(integer, thousands) && !(lakhGrouping && hasValidLakhGrouping(integer, thousands)) {
+		return 0, 0, convention, fmt.Errorf("misplaced separators in '%v'", number)
+	}
+	integer = strings.ReplaceAll(integer, thousands, "")
+	if integer == "" {
+		integer = "0"
+	}
+	if strings.IndexFunc(integer+fraction, func(r rune) bool { return r < '0' || r > '9' }) != -1 {
+		return 0, 0, convention, fmt.Errorf("misplaced separators in '%v'", number)
+	}
+
+	amount, err := strconv.ParseInt(integer+fraction, 10, 64)
+	if err != nil {
+		return 0, 0, convention, fmt.Errorf("amount '%v' out of range", number)
+	}
+	return amount, len(fraction), convention, nil
+}
+
+// hasValidGrouping
+// Returns true if the thousands separators in integer, if any, split it into a leading group of one to three digits and
+// further groups of exactly three digits, e.g., "1,234,567", but not "1,2,3" or "12,34".
+func hasValidGrouping(integer, thousands string) bool {
+	if !strings.Contains(integer, thousands) {
+		return true
+	}
+	groups := strings.Split(integer, thousands)
+	if len(groups[0]) < 1 || len(groups[0]) > 3 {
+		return false
+	}
+	for _, group := range groups[1:] {
+		if len(group) != 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// hasValidLakhGrouping
+// Returns true if the thousands separators in integer split it into a leading group of one or two digits, further groups
+// of exactly two digits, and a last group of three digits, e.g., "12,34,567", as in the Indian numbering system.
+func hasValidLakhGrouping(integer, thousands string) bool {
+	groups := strings.Split(integer, thousands)
+	if len(groups) < 2 || len(groups[0]) < 1 || len(groups[0]) > 2 || len(groups[len(groups)-1]) != 3 {
+		return false
+	}
+	for _, group := range groups[1 : len(groups)-1] {
+		if len(group) != 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveCurrency
+// Returns the ISO 4217 code of the currency text surrounding a number, see ParseMoney, or defaultCurrency if there is
+// none.
+func resolveCurrency(text, defaultCurrency string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return defaultCurrency, nil
+	}
+
+	var code, symbol string
+	for _, token := range strings.Fields(text) {
+		if len(token) == 3 && strings.ToUpper(token) == token && strings.IndexFunc(token, func(r rune) bool { return r < 'A' || r > 'Z' }) == -1 {
+			code = token
+		} else if symbol == "" {
+			symbol = strings.ToLower(token)
+		} else {
+			return "", fmt.Errorf("unknown currency '%v'", text)
+		}
+	}
+	if code != "" {
+		return code, nil // the code is more specific than a symbol next to it, e.g., "$ USD"
+	}
+
+	currency, ok := CurrencySymbols[symbol]
+	if !ok {
+		return "", fmt.Errorf("unknown currency '%v'", text)
+	}
+	for _, candidate := range ambiguousCurrencySymbols[symbol] {
+		if candidate == strings.ToUpper(defaultCurrency) {
+			return candidate, nil
+		}
+	}
+	return currency, nil
+}
+
+// GetColumnByKeyAsMoney
+// Returns the data cells of the column with the given key (see GetColumnByKey) parsed by ParseMoney.
+// Cells which cannot be parsed are returned with Valid false and reported via a *ColumnCellsError.
+func (ht HtmlTable) GetColumnByKeyAsMoney(key string, hints MoneyHints) ([]Money, error) {
+	column, j, ok := ht.GetColumnByKey(key)
+	if !ok {
+		return nil, fmt.Errorf("unknown column key: '%v'", key)
+	}
+	if j == 0 {
+		column = column[1:] // skip the index header
+	}
+
+	values := make([]Money, len(column))
+	var invalidRows []int
+	for i, cell := range column {
+		values[i], _ = ParseMoney(cell, hints)
+		if !values[i].Valid {
+			invalidRows = append(invalidRows, i+1)
+		}
+	}
+
+	if len(invalidRows) > 0 {
+		return values, &ColumnCellsError{Key: key, Rows: invalidRows, Reason: "no amount of money"}
+	}
+	return values, nil
+}
@@ -0,0 +1,185 @@
+package html_util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		s              string
+		hints          MoneyHints
+		wantAmount     int64
+		wantExponent   int
+		wantCurrency   string
+		wantConvention DecimalConvention
+		wantErr        bool
+	}{
+		// english conventions
+		{s: "$1,299.00", wantAmount: 129900, wantExponent: 2, wantCurrency: "USD", wantConvention: DecimalPoint},
+		{s: "1,234,567.89 USD", wantAmount: 123456789, wantExponent: 2, wantCurrency: "USD", wantConvention: DecimalPoint},
+		{s: "£0.99", wantAmount: 99, wantExponent: 2, wantCurrency: "GBP", wantConvention: DecimalPoint},
+		{s: "£5", wantAmount: 5, wantCurrency: "GBP"},
+		{s: "US$ 10", wantAmount: 10, wantCurrency: "USD"},
+		{s: "CA$12.50", wantAmount: 1250, wantExponent: 2, wantCurrency: "CAD", wantConvention: DecimalPoint},
+		{s: "A$ 7.5", wantAmount: 75, wantExponent: 1, wantCurrency: "AUD", wantConvention: DecimalPoint},
+		{s: "¥1,000", wantAmount: 1000, wantCurrency: "JPY", wantConvention: DecimalPoint},
+		{s: "1000 JPY", wantAmount: 1000, wantCurrency: "JPY"},
+		{s: "₹1,23,456.50", wantAmount: 12345650, wantExponent: 2, wantCurrency: "INR", wantConvention: DecimalPoint},
+		{s: "Rs. 1,500", wantAmount: 1500, wantCurrency: "INR", wantConvention: DecimalPoint},
+		{s: "INR 12,34,56,789", wantAmount: 123456789, wantCurrency: "INR", wantConvention: DecimalPoint},
+		{s: "₹1,234,567", wantAmount: 1234567, wantCurrency: "INR", wantConvention: DecimalPoint},
+
+		// continental conventions
+		{s: "1.299,00 €", wantAmount: 129900, wantExponent: 2, wantCurrency: "EUR", wantConvention: DecimalComma},
+		{s: "€1.299,00", wantAmount: 129900, wantExponent: 2, wantCurrency: "EUR", wantConvention: DecimalComma},
+		{s: "12.345.678,90 EUR", wantAmount: 1234567890, wantExponent: 2, wantCurrency: "EUR", wantConvention: DecimalComma},
+		{s: "1 299,00 €", wantAmount: 129900, wantExponent: 2, wantCurrency: "EUR", wantConvention: DecimalComma},
+		{s: "1\u00a0299,00\u00a0€", wantAmount: 129900, wantExponent: 2, wantCurrency: "EUR", wantConvention: DecimalComma},
+		{s: "1\u202f299,00\u202f€", wantAmount: 129900, wantExponent: 2, wantCurrency: "EUR", wantConvention: DecimalComma},
+		{s: "0,5 €", wantAmount: 5, wantExponent: 1, wantCurrency: "EUR", wantConvention: DecimalComma},
+		{s: "0,500 €", wantAmount: 500, wantExponent: 3, wantCurrency: "EUR", wantConvention: DecimalComma},
+		{s: "EUR 1.000", wantAmount: 1000, wantCurrency: "EUR", wantConvention: DecimalComma},
+		{s: "R$ 1.234,56", wantAmount: 123456, wantExponent: 2, wantCurrency: "BRL", wantConvention: DecimalComma},
+		{s: "₺1.234,56", wantAmount: 123456, wantExponent: 2, wantCurrency: "TRY", wantConvention: DecimalComma},
+		{s: "12,50 zł", wantAmount: 1250, wantExponent: 2, wantCurrency: "PLN", wantConvention: DecimalComma},
+		{s: "1 234,56 Kč", wantAmount: 123456, wantExponent: 2, wantCurrency: "CZK", wantConvention: DecimalComma},
+		{s: "1.500 руб.", wantAmount: 1500, wantCurrency: "RUB", wantConvention: DecimalComma},
+		{s: "kr 1 234,50", wantAmount: 123450, wantExponent: 2, wantCurrency: "SEK", wantConvention: DecimalComma},
+		{s: "1.234,50 kr.", wantAmount: 123450, wantExponent: 2, wantCurrency: "DKK", wantConvention: DecimalComma},
+
+		// swiss conventions and dashes as decimals
+		{s: "CHF 99.–", wantAmount: 9900, wantExponent: 2, wantCurrency: "CHF", wantConvention: DecimalPoint},
+		{s: "CHF 1'234.50", wantAmount: 123450, wantExponent: 2, wantCurrency: "CHF", wantConvention: DecimalPoint},
+		{s: "1'234'567 CHF", wantAmount: 1234567, wantCurrency: "CHF"},
+		{s: "Fr. 12.50", wantAmount: 1250, wantExponent: 2, wantCurrency: "CHF", wantConvention: DecimalPoint},
+		{s: "99,- €", wantAmount: 9900, wantExponent: 2, wantCurrency: "EUR", wantConvention: DecimalComma},
+		{s: "5.— $", wantAmount: 500, wantExponent: 2, wantCurrency: "USD", wantConvention: DecimalPoint},
+
+		// signs
+		{s: "-12.50 USD", wantAmount: -1250, wantExponent: 2, wantCurrency: "USD", wantConvention: DecimalPoint},
+		{s: "-$5.00", wantAmount: -500, wantExponent: 2, wantCurrency: "USD", wantConvention: DecimalPoint},
+		{s: "$-5.00", wantAmount: -500, wantExponent: 2, wantCurrency: "USD", wantConvention: DecimalPoint},
+		{s: "− 3,50 €", wantAmount: -350, wantExponent: 2, wantCurrency: "EUR", wantConvention: DecimalComma},
+		{s: "5.00-", wantAmount: -500, wantExponent: 2, wantConvention: DecimalPoint},
+		{s: "+3 €", wantAmount: 3, wantCurrency: "EUR"},
+		{s: "(3,000)", wantAmount: -3000, wantConvention: DecimalPoint},
+		{s: "(£1,000.00)", wantAmount: -100000, wantExponent: 2, wantCurrency: "GBP", wantConvention: DecimalPoint},
+
+		// ambiguous separators
+		{s: "1,299", wantAmount: 1299, wantConvention: DecimalPoint},
+		{s: "1,299", hints: MoneyHints{DecimalSeparator: ','}, wantAmount: 1299, wantExponent: 3, wantConvention: DecimalComma},
+		{s: "1,299", hints: MoneyHints{DecimalSeparator: '.'}, wantAmount: 1299, wantConvention: DecimalPoint},
+		{s: "1.299", wantAmount: 1299, wantConvention: DecimalComma},
+		{s: "1.299", hints: MoneyHints{DecimalSeparator: '.'}, wantAmount: 1299, wantExponent: 3, wantConvention: DecimalPoint},
+		{s: "1,23", wantAmount: 123, wantExponent: 2, wantConvention: DecimalComma},
+		{s: "1234.567", wantAmount: 1234567, wantExponent: 3, wantConvention: DecimalPoint},
+		{s: "1234,567 €", wantAmount: 1234567, wantExponent: 3, wantCurrency: "EUR", wantConvention: DecimalComma},
+
+		// ambiguous symbols and default currencies
+		{s: "$5", hints: MoneyHints{DefaultCurrency: "cad"}, wantAmount: 5, wantCurrency: "CAD"},
+		{s: "$5", hints: MoneyHints{DefaultCurrency: "EUR"}, wantAmount: 5, wantCurrency: "USD"},
+		{s: "¥1,000", hints: MoneyHints{DefaultCurrency: "CNY"}, wantAmount: 1000, wantCurrency: "CNY", wantConvention: DecimalPoint},
+		{s: "kr 100", hints: MoneyHints{DefaultCurrency: "NOK"}, wantAmount: 100, wantCurrency: "NOK"},
+		{s: "R 150", wantAmount: 150, wantCurrency: "ZAR"},
+		{s: "42", hints: MoneyHints{DefaultCurrency: "EUR"}, wantAmount: 42, wantCurrency: "EUR"},
+		{s: "42", wantAmount: 42},
+		{s: "$ USD 5", wantAmount: 5, wantCurrency: "USD"},
+
+		// misplaced grouping separators
+		{s: "$1,2,3", wantErr: true},
+		{s: "$12,34,567", wantErr: true},
+		{s: "₹12,3,456", wantErr: true},
+		{s: "₹1,23,45,6", wantErr: true},
+		{s: "₹123,45,678", wantErr: true},
+		{s: "1.2.3 €", wantErr: true},
+		{s: "1,23.45 €", wantErr: true},
+		{s: "1234,567.00 €", wantErr: true},
+		{s: "1.2.3,4", wantErr: true},
+
+		// no amount or unknown currencies
+		{s: "", wantErr: true},
+		{s: "free", wantErr: true},
+		{s: "€", wantErr: true},
+		{s: "12 xyz", wantErr: true},
+		{s: "12 foo bar", wantErr: true},
+		{s: "1-2 €", wantErr: true},
+		{s: "$99999999999999999999", wantErr: true},
+	}
+	for _, tt := range tests {
+		m, err := ParseMoney(tt.s, tt.hints)
+		if m.Raw != tt.s {
+			t.Errorf("ParseMoney(%q) has raw text %q", tt.s, m.Raw)
+		}
+		if tt.wantErr {
+			if err == nil || m.Valid {
+				t.Errorf("ParseMoney(%q) = %+v, want an error", tt.s, m)
+			}
+			continue
+		}
+		if err != nil || !m.Valid || m.Amount != tt.wantAmount || m.Exponent != tt.wantExponent ||
+			m.Currency != tt.wantCurrency || m.Convention != tt.wantConvention {
+			t.Errorf("ParseMoney(%q) = %+v, %v, want %v, %v, %q, %v", tt.s, m, err, tt.wantAmount, tt.wantExponent,
+				tt.wantCurrency, tt.wantConvention)
+		}
+	}
+}
+
+func TestParseMoneyCustomSymbols(t *testing.T) {
+	if _, err := ParseMoney("12 lari", MoneyHints{}); err == nil {
+		t.Fatal("no error for an unknown symbol")
+	}
+	CurrencySymbols["lari"] = "GEL"
+	defer delete(CurrencySymbols, "lari")
+	if m, err := ParseMoney("12 Lari", MoneyHints{}); err != nil || m.Currency != "GEL" {
+		t.Errorf("got %+v, %v, want GEL", m, err)
+	}
+}
+
+func TestMoneyFloat64(t *testing.T) {
+	tests := map[string]float64{"$1,299.00": 1299, "0,5 €": 0.5, "-12.50 USD": -12.5, "1.299": 1299}
+	for s, want := range tests {
+		if m, _ := ParseMoney(s, MoneyHints{}); m.Float64() != want {
+			t.Errorf("ParseMoney(%q).Float64() = %v, want %v", s, m.Float64(), want)
+		}
+	}
+}
+
+func TestGetColumnByKeyAsMoney(t *testing.T) {
+	ht := parseTable(t, `<table><tr><th>Plan</th><th>Price</th></tr><tr><td>Basic</td><td>$9.99</td></tr>`+
+		`<tr><td>Pro</td><td>$1,299.00</td></tr><tr><td>Team</td><td>on request</td></tr>`+
+		`<tr><td>Legacy</td><td>-</td></tr><tr><td>Agency</td><td>$49</td></tr></table>`, true, true)
+
+	values, err := ht.GetColumnByKeyAsMoney("price", MoneyHints{DefaultCurrency: "CAD"})
+	var cellsErr *ColumnCellsError
+	if !errors.As(err, &cellsErr) || cellsErr.Key != "price" || len(cellsErr.Rows) != 2 ||
+		cellsErr.Rows[0] != 3 || cellsErr.Rows[1] != 4 {
+		t.Fatalf("got error %v, want rows [3 4]", err)
+	}
+	want := []Money{
+		{Amount: 999, Exponent: 2, Currency: "CAD", Convention: DecimalPoint, Raw: "$9.99", Valid: true},
+		{Amount: 129900, Exponent: 2, Currency: "CAD", Convention: DecimalPoint, Raw: "$1,299.00", Valid: true},
+		{Raw: "on request"},
+		{Raw: "-"},
+		{Amount: 49, Currency: "CAD", Raw: "$49", Valid: true},
+	}
+	if len(values) != len(want) {
+		t.Fatalf("got %v values, want %v", len(values), len(want))
+	}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Errorf("row %v: got %+v, want %+v", i+1, values[i], want[i])
+		}
+	}
+
+	if _, err := ht.GetColumnByKeyAsMoney("cost", MoneyHints{}); err == nil {
+		t.Error("no error for an unknown column")
+	}
+	// the index column skips its header
+	index := parseTable(t, `<table><tr><th>Price</th><th>Plan</th></tr><tr><td>€5</td><td>Basic</td></tr></table>`,
+		true, true)
+	if values, err := index.GetColumnByKeyAsMoney("price", MoneyHints{}); err != nil || len(values) != 1 ||
+		values[0].Amount != 5 || values[0].Currency != "EUR" {
+		t.Errorf("got %+v, %v", values, err)
+	}
+}
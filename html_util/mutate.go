@@ -0,0 +1,171 @@
+package html_util
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+)
+
+var (
+	ErrCyclicInsertion    = errors.New("cannot insert a node into itself or its own subtree")
+	ErrCrossTreeInsertion = errors.New("cannot move a node attached to a different tree, detach or clone it first")
+)
+
+// TreeRoot
+// Returns the root of the tree containing n, i.e., the ancestor without parent, or n itself if it has no parent.
+func TreeRoot(n *html.Node) *html.Node {
+	for n != nil && n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+// SameTree
+// Returns whether a and b are non-nil and belong to the same tree.
+func SameTree(a, b *html.Node) bool {
+	return a != nil && b != nil && TreeRoot(a) == TreeRoot(b)
+}
+
+// isAncestorOrSelf
+// Returns whether ancestor is node or one of its ancestors.
+func isAncestorOrSelf(ancestor, node *html.Node) bool {
+	for n := node; n != nil; n = n.Parent {
+		if n == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// detachNode
+// Removes node from its parent, if any, and clears its sibling pointers.
+func detachNode(node *html.Node) {
+	if node.Parent != nil {
+		node.Parent.RemoveChild(node)
+	}
+	node.PrevSibling, node.NextSibling = nil, nil
+}
+
+// prepareInsertion
+// Validates that newNode can become a child of parent and detaches it from its current position.
+// Rejects frozen nodes, insertions creating a cycle, and moves of nodes attached to a different tree.
+func prepareInsertion(parent, newNode *html.Node) error {
+	if parent == nil || newNode == nil {
+		return errors.New("node is nil")
+	}
+	if err := checkMutable(parent); err != nil {
+		return err
+	}
+	if err := checkMutable(newNode); err != nil {
+		return err
+	}
+	if isAncestorOrSelf(newNode, parent) {
+		return ErrCyclicInsertion
+	}
+	if newNode.Parent != nil && !SameTree(newNode, parent) {
+		return ErrCrossTreeInsertion
+	}
+	detachNode(newNode)
+	return nil
+}
+
+// InsertNodeBefore
+// Inserts newNode as previous sibling of ref. If newNode is attached within the same tree, it is moved.
+// Returns ErrCyclicInsertion, ErrCrossTreeInsertion, or ErrFrozenNode for invalid insertions.
+func InsertNodeBefore(ref, newNode *html.Node) error {
+	if ref == nil || ref.Parent == nil {
+		return errors.New("reference node has no parent")
+	}
+	if ref == newNode {
+		return nil
+	}
+	if err := prepareInsertion(ref.Parent, newNode); err != nil {
+		return err
+	}
+	ref.Parent.InsertBefore(newNode, ref)
+	return nil
+}
+
+// InsertNodeAfter
+// Inserts newNode as next sibling of ref. If newNode is attached within the same tree, it is moved.
+// Returns ErrCyclicInsertion, ErrCrossTreeInsertion, or ErrFrozenNode for invalid insertions.
+func InsertNodeAfter(ref, newNode *html.Node) error {
+	if ref == nil || ref.Parent == nil {
+		return errors.New("reference node has no parent")
+	}
+	if ref == newNode {
+		return nil
+	}
+	if err := prepareInsertion(ref.Parent, newNode); err != nil {
+		return err
+	}
+	ref.Parent.InsertBefore(newNode, ref.NextSibling) // nil appends
+	return nil
+}
+
+// AppendChildNode
+// Appends child as last child of parent. If child is attached within the same tree, it is moved.
+// Returns ErrCyclicInsertion, ErrCrossTreeInsertion, or ErrFrozenNode for invalid insertions.
+func AppendChildNode(parent, child *html.Node) error {
+	if err := prepareInsertion(parent, child); err != nil {
+		return err
+	}
+	parent.AppendChild(child)
+	return nil
+}
+
+// ReplaceNode
+// Replaces oldNode with newNode, oldNode is detached afterwards. If newNode is attached within the same tree, it is moved.
+// Returns ErrCyclicInsertion, ErrCrossTreeInsertion, or ErrFrozenNode for invalid replacements.
+func ReplaceNode(oldNode, newNode *html.Node) error {
+	if oldNode == nil || oldNode.Parent == nil {
+		return errors.New("node has no parent")
+	}
+	if oldNode == newNode {
+		return nil
+	}
+	parent := oldNode.Parent
+	if err := checkMutable(oldNode); err != nil {
+		return err
+	}
+	if err := prepareInsertion(parent, newNode); err != nil {
+		return err
+	}
+	parent.InsertBefore(newNode, oldNode)
+	parent.RemoveChild(oldNode)
+	return nil
+}
+
+// RemoveNode
+// Detaches node from its parent. Returns ErrFrozenNode for frozen nodes.
+func RemoveNode(node *html.Node) error {
+	if node == nil {
+		return errors.New("node is nil")
+	}
+	if err := checkMutable(node); err != nil {
+		return err
+	}
+	detachNode(node)
+	return nil
+}
+
+// setAttribute
+// Sets the value of the first attribute of node with the given key, or appends the attribute if there is none.
+// Further attributes with the same key, e.g., from malformed input, are removed.
+func setAttribute(node *html.Node, key, val string) {
+	found := false
+	attrs := node.Attr[:0]
+	for _, attr := range node.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			if found {
+				continue
+			}
+			found = true
+			attr.Val = val
+		}
+		attrs = append(attrs, attr)
+	}
+	node.Attr = attrs
+	if !found {
+		node.Attr = append(node.Attr, html.Attribute{Key: key, Val: val})
+	}
+}
@@ -0,0 +1,114 @@
+package html_util
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"testing"
+	"time"
+)
+
+func TestTreeRootAndSameTree(t *testing.T) {
+	doc := parseDocument(t, `<div id="a"><p id="b">x</p></div>`)
+	other := parseDocument(t, `<p id="c"></p>`)
+	b := elementByID(t, doc, "b")
+	detached := &html.Node{Type: html.ElementNode, Data: "span"}
+
+	if TreeRoot(b) != doc || TreeRoot(detached) != detached || TreeRoot(nil) != nil {
+		t.Error("TreeRoot returned the wrong root")
+	}
+	if !SameTree(b, doc) || SameTree(b, elementByID(t, other, "c")) || SameTree(b, nil) || SameTree(nil, nil) {
+		t.Error("SameTree returned a wrong result")
+	}
+}
+
+func TestMutationRejectsCycles(t *testing.T) {
+	doc := parseDocument(t, `<div id="a"><section id="b"><p id="c">x</p></section></div>`)
+	a, b, c := elementByID(t, doc, "a"), elementByID(t, doc, "b"), elementByID(t, doc, "c")
+
+	mutations := map[string]func() error{
+		"AppendChildNode into descendant": func() error { return AppendChildNode(c, a) },
+		"AppendChildNode into itself":     func() error { return AppendChildNode(b, b) },
+		"InsertNodeBefore":                func() error { return InsertNodeBefore(c, b) },
+		"InsertNodeAfter":                 func() error { return InsertNodeAfter(c, a) },
+		"ReplaceNode":                     func() error { return ReplaceNode(c, b) },
+	}
+	for name, mutate := range mutations {
+		if err := mutate(); !errors.Is(err, ErrCyclicInsertion) {
+			t.Errorf("%v returned %v, want ErrCyclicInsertion", name, err)
+		}
+	}
+
+	// the tree is unchanged, so walking it terminates instead of looping forever
+	done := make(chan int)
+	go func() {
+		count := 0
+		WalkHtmlTree(doc, func(n *html.Node) bool {
+			count++
+			return true
+		})
+		done <- count
+	}()
+	select {
+	case count := <-done:
+		if got := renderNode(t, elementByID(t, doc, "a")); got != `<div id="a"><section id="b"><p id="c">x</p></section></div>` {
+			t.Errorf("tree changed to %v after %v visited nodes", got, count)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("walking the tree did not terminate")
+	}
+}
+
+func TestMutationMovesAndRejectsCrossTreeNodes(t *testing.T) {
+	doc := parseDocument(t, `<div id="a"><p id="b">1</p><p id="c">2</p></div>`)
+	other := parseDocument(t, `<span id="d">3</span>`)
+	a, b, c := elementByID(t, doc, "a"), elementByID(t, doc, "b"), elementByID(t, doc, "c")
+	d := elementByID(t, other, "d")
+
+	if err := AppendChildNode(a, d); !errors.Is(err, ErrCrossTreeInsertion) {
+		t.Errorf("AppendChildNode of an attached node of another tree returned %v", err)
+	}
+	if err := InsertNodeBefore(b, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := InsertNodeAfter(b, b); err != nil {
+		t.Errorf("InsertNodeAfter(b, b) returned %v, want a no-op", err)
+	}
+	clone := CloneTree(d)
+	if err := ReplaceNode(b, clone); err != nil {
+		t.Fatal(err)
+	}
+	if got := renderNode(t, a); got != `<div id="a"><p id="c">2</p><span id="d">3</span></div>` {
+		t.Errorf("got %v", got)
+	}
+	if b.Parent != nil || b.PrevSibling != nil || b.NextSibling != nil {
+		t.Error("the replaced node is still attached")
+	}
+	if err := InsertNodeBefore(b, c); err == nil {
+		t.Error("InsertNodeBefore a detached reference node succeeded")
+	}
+	if err := RemoveNode(nil); err == nil {
+		t.Error("RemoveNode(nil) succeeded")
+	}
+}
+
+func TestSetAttributeCollapsesDuplicates(t *testing.T) {
+	img := GetElementNodesByTagName("img", parseDocument(t, `<img src="a" alt="x" src="b" xlink:src="c" src="d">`))[0]
+	setAttribute(img, "src", "new")
+	if got, want := renderNode(t, img), `<img src="new" alt="x" xlink:src="c"/>`; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	setAttribute(img, "title", "t")
+	if got, want := renderNode(t, img), `<img src="new" alt="x" xlink:src="c" title="t"/>`; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// mutations writing attributes collapse the duplicates of malformed input
+	doc := parseDocument(t, `<img data-src="lazy.jpg" src="" src="other.gif">`)
+	if got := ResolveLazyMedia(doc, DefaultLazyRules); got != 1 {
+		t.Fatalf("applied %v rules, want 1", got)
+	}
+	img = GetElementNodesByTagName("img", doc)[0]
+	if got, want := renderNode(t, img), `<img data-src="lazy.jpg" src="lazy.jpg"/>`; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+package html_util
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strconv"
+	"strings"
+)
+
+// maxSummaryAttributes
+// Maximum number of attributes per element shown by RenderNodeSummary.
+const maxSummaryAttributes = 3
+
+// maxSummaryAttributeLength
+// Maximum number of runes of an attribute value shown by RenderNodeSummary.
+const maxSummaryAttributeLength = 32
+
+// truncateText
+// Returns s cut to at most max runes followed by an ellipsis if it is longer, or s itself if max is not positive.
+func truncateText(s string, max int) string {
+	if max <= 0 {
+		return s
+	}
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max]) + "…"
+	}
+	return s
+}
+
+// countSubtreeNodes
+// Returns the number of nodes in the subtree of node, excluding node.
+func countSubtreeNodes(node *html.Node) int {
+	count := 0
+	WalkHtmlTree(node, func(n *html.Node) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// RenderNodeSummary
+// Renders a deterministic, indented sketch of n and its subtree for log messages, one node per line: elements as
+// opening tags with at most 3 attributes and shortened values, texts quoted with collapsed whitespace and cut to
+// maxTextPerNode runes, and comments. Blank texts are omitted.
+// The children of nodes at depth maxDepth, where n has depth 0, are replaced by a "… (N more nodes)" line. The output
+// is cut at a line boundary to at most maxTotalBytes bytes, ending with a "… (truncated)" line.
+// A limit of 0 or less means unlimited.
+func RenderNodeSummary(n *html.Node, maxDepth, maxTextPerNode, maxTotalBytes int) string {
+	if n == nil {
+		return "nil"
+	}
+	const truncated = "… (truncated)"
+
+	var sb strings.Builder
+	full := false
+	write := func(depth int, line string) {
+		if full {
+			return
+		}
+		line = strings.Repeat("  ", depth) + line + "\n"
+		if maxTotalBytes > 0 && sb.Len()+len(line)+len(truncated) > maxTotalBytes {
+			full = true
+			if sb.Len()+len(truncated) <= maxTotalBytes {
+				sb.WriteString(truncated)
+			}
+			return
+		}
+		sb.WriteString(line)
+	}
+
+	var render func(node *html.Node, depth int)
+	render = func(node *html.Node, depth int) {
+		switch node.Type {
+		case html.TextNode:
+			if IsBlank(node.Data) {
+				return
+			}
+			write(depth, strconv.Quote(truncateText(strings.Join(strings.Fields(node.Data), " "), maxTextPerNode)))
+			return
+		case html.CommentNode:
+			write(depth, "<!-- "+truncateText(strings.TrimSpace(node.Data), maxTextPerNode)+" -->")
+			return
+		case html.ElementNode:
+			write(depth, summarizeStartTag(node))
+		case html.DocumentNode:
+			write(depth, "#document")
+		case html.DoctypeNode:
+			write(depth, "<!DOCTYPE "+node.Data+">")
+			return
+		default:
+			return
+		}
+
+		if maxDepth > 0 && depth >= maxDepth {
+			if count := countSubtreeNodes(node); count > 0 {
+				write(depth+1, fmt.Sprintf("… (%v more nodes)", count))
+			}
+			return
+		}
+		for c := node.FirstChild; c != nil && !full; c = c.NextSibling {
+			render(c, depth+1)
+		}
+	}
+	render(n, 0)
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// summarizeStartTag
+// Returns the opening tag of the element node with at most maxSummaryAttributes attributes in source order.
+func summarizeStartTag(node *html.Node) string {
+	var sb strings.Builder
+	sb.WriteString("<" + node.Data)
+	for i, attr := range node.Attr {
+		if i == maxSummaryAttributes {
+			sb.WriteString(" …")
+			break
+		}
+		key := attr.Key
+		if attr.Namespace != "" {
+			key = attr.Namespace + ":" + key
+		}
+		sb.WriteString(" " + key + "=" + strconv.Quote(truncateText(attr.Val, maxSummaryAttributeLength)))
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
@@ -0,0 +1,95 @@
+package html_util
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRenderNodeSummary(t *testing.T) {
+	doc := parseDocument(t, `<!DOCTYPE html><div id="main" class="a b" data-x="`+strings.Repeat("x", 40)+`" title="t">`+
+		`<p>Some   long
+		text in a paragraph</p>  <!--  note  --><ul><li>1</li><li><b>2</b></li></ul></div>`)
+	main := elementByID(t, doc, "main")
+
+	tests := []struct {
+		name                                    string
+		maxDepth, maxTextPerNode, maxTotalBytes int
+		want                                    string
+	}{
+		{"unlimited", 0, 0, 0, strings.Join([]string{
+			`<div id="main" class="a b" data-x="` + strings.Repeat("x", 32) + `…" …>`,
+			`  <p>`,
+			`    "Some long text in a paragraph"`,
+			`  <!-- note -->`,
+			`  <ul>`,
+			`    <li>`,
+			`      "1"`,
+			`    <li>`,
+			`      <b>`,
+			`        "2"`,
+		}, "\n")},
+		{"depth and text", 1, 9, 0, strings.Join([]string{
+			`<div id="main" class="a b" data-x="` + strings.Repeat("x", 32) + `…" …>`,
+			`  <p>`,
+			`    … (1 more nodes)`,
+			`  <!-- note -->`,
+			`  <ul>`,
+			`    … (5 more nodes)`,
+		}, "\n")},
+		{"text length", 2, 4, 0, strings.Join([]string{
+			`<div id="main" class="a b" data-x="` + strings.Repeat("x", 32) + `…" …>`,
+			`  <p>`,
+			`    "Some…"`,
+			`  <!-- note -->`,
+			`  <ul>`,
+			`    <li>`,
+			`      … (1 more nodes)`,
+			`    <li>`,
+			`      … (2 more nodes)`,
+		}, "\n")},
+		{"total bytes", 1, 0, 121, strings.Join([]string{
+			`<div id="main" class="a b" data-x="` + strings.Repeat("x", 32) + `…" …>`,
+			`  <p>`,
+			`    … (1 more nodes)`,
+			`… (truncated)`,
+		}, "\n")},
+		{"too small for the marker", 0, 0, 5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderNodeSummary(main, tt.maxDepth, tt.maxTextPerNode, tt.maxTotalBytes)
+			if got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+			if tt.maxTotalBytes > 0 && len(got) > tt.maxTotalBytes {
+				t.Errorf("got %v bytes, want at most %v", len(got), tt.maxTotalBytes)
+			}
+		})
+	}
+
+	want := "#document\n  <!DOCTYPE html>\n  <html>\n    … (13 more nodes)"
+	if got := RenderNodeSummary(doc, 1, 0, 0); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	if got := RenderNodeSummary(nil, 0, 0, 0); got != "nil" {
+		t.Errorf("got %q for nil", got)
+	}
+}
+
+func TestRenderNodeSummaryLargeDocument(t *testing.T) {
+	doc := parseDocument(t, makeLargeDocument(5000)+`<p>`+strings.Repeat("€", 100000)+`</p>`)
+	first := RenderNodeSummary(doc, 4, 20, 2000)
+	if len(first) > 2000 || !utf8.ValidString(first) || !strings.HasSuffix(first, "… (truncated)") {
+		t.Errorf("summary of %v bytes is not bounded:\n%v", len(first), first)
+	}
+	if second := RenderNodeSummary(doc, 4, 20, 2000); second != first {
+		t.Error("summaries of the same tree differ")
+	}
+
+	p := GetElementNodesByTagName("p", doc)
+	text := RenderNodeSummary(p[len(p)-1], 0, 3, 0)
+	if want := "<p>\n  \"€€€…\""; text != want {
+		t.Errorf("got %q, want %q", text, want)
+	}
+}
@@ -0,0 +1,260 @@
+package html_util
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RangeMode
+// How ExtractFirstNumber handles ranges like "10–15" or "10 to 15".
+type RangeMode int
+
+const (
+	RangeReject RangeMode = iota // a range is an error
+	RangeLower                   // the lower bound, i.e., the first number of the range
+	RangeUpper                   // the upper bound, i.e., the second number of the range
+	RangeMean                    // the mean of both bounds
+)
+
+// rangeSeparators
+// The words and dashes which join the bounds of a range, see ExtractFirstNumber.
+var rangeSeparators = []string{"-", "–", "—", "to", "bis", "..."}
+
+// NumberExtractOptions
+// Configures ExtractFirstNumber.
+type NumberExtractOptions struct {
+	Convention DecimalConvention // fixed separator convention, DecimalUnknown guesses it per number as ParseMoney does
+	Ranges     RangeMode         // how ranges are handled
+}
+
+// ExtractFirstNumber
+// Returns the first number in a text which embeds it in prose, e.g., "approx. 1,200 units" yields 1200 and "units",
+// together with its unit, i.e., the token directly following the number without trailing punctuation, e.g., "%" for
+// "up to 15 %", or if there is none, the token directly preceding it, e.g., "$" for "from $9.99".
+// Separators follow NumberExtractOptions.Convention. If it is DecimalUnknown, the convention is guessed as in ParseMoney,
+// e.g., a single separator following at most and followed by exactly three digits, e.g., "1,200", separates thousands.
+// Thousands separators must split the number into groups of three digits, e.g., not "1.2.3", and must not follow the
+// decimal separator, e.g., not "1,234.5" for DecimalComma. Spaces and apostrophes are accepted as thousands separators if
+// they are followed by exactly three digits, e.g., "1 200 000". A number may start with its decimal separator, e.g., ".5",
+// unless it follows a letter or digit.
+// A '-', '+', or '−' directly before the number is its sign unless it follows a letter or digit.
+// A second number joined by a dash, "to", or "bis" forms a range, which is handled per NumberExtractOptions.Ranges. The
+// upper bound may have a sign, e.g., "-5 to -3 °C".
+// Numbers joined to letters or digits by hyphens are codes or dates and are skipped, e.g., "ISO-9001", "2024-01-02",
+// or "1-2-3", so are ranges whose upper bound has a leading zero, e.g., "2024-01".
+// Returns an error if there is no number, if its separators are misplaced, or if it is a rejected range.
+func ExtractFirstNumber(s string, opts NumberExtractOptions) (float64, string, error) {
+	start, end := -1, 0
+	for {
+		i := strings.IndexFunc(s[end:], isASCIIDigit)
+		if i == -1 {
+			return 0, "", fmt.Errorf("no number in '%v'", s)
+		}
+		start = end + i
+		end = scanNumber(s, start)
+		codeEnd, isCode := hyphenatedCodeEnd(s, start, end)
+		if !isCode {
+			break
+		}
+		end = codeEnd
+	}
+	number := s[start:end]
+	if start > 0 && (s[start-1] == '.' || s[start-1] == ',') {
+		if p, _ := utf8.DecodeLastRuneInString(s[:start-1]); !unicode.IsLetter(p) && !unicode.IsDigit(p) {
+			start--
+			number = "0" + s[start:end]
+		}
+	}
+	value, err := parseExtractedNumber(number, opts.Convention)
+	if err != nil {
+		return 0, "", fmt.Errorf("cannot extract number from '%v': %w", s, err)
+	}
+
+	before := s[:start]
+	if r, size := utf8.DecodeLastRuneInString(before); r == '-' || r == '+' || r == '−' {
+		if p, _ := utf8.DecodeLastRuneInString(before[:len(before)-size]); !unicode.IsLetter(p) && !unicode.IsDigit(p) {
+			before = before[:len(before)-size]
+			if r != '+' {
+				value = -value
+			}
+		}
+	}
+
+	after := s[end:]
+	if upperStart, negative := rangeUpperBound(after); upperStart != -1 {
+		upperEnd := upperStart + scanNumber(after[upperStart:], 0)
+		upper, err := parseExtractedNumber(after[upperStart:upperEnd], opts.Convention)
+		if err != nil {
+			return 0, "", fmt.Errorf("cannot extract number from '%v': %w", s, err)
+		}
+		if negative {
+			upper = -upper
+		}
+		switch opts.Ranges {
+		case RangeReject:
+			return 0, "", fmt.Errorf("cannot extract number from '%v': ranges are not accepted", s)
+		case RangeUpper:
+			value = upper
+		case RangeMean:
+			value = (value + upper) / 2
+		}
+		after = after[upperEnd:]
+	}
+
+	unit := strings.TrimRightFunc(firstToken(after), func(r rune) bool {
+		return r == '.' || r == ',' || r == ';' || r == ':' || r == '!' || r == '?' || r == ')'
+	})
+	if r, _ := utf8.DecodeRuneInString(unit); isASCIIDigit(r) {
+		unit = "" // another number, e.g., "2" in "1 2 3"
+	}
+	if unit == "" && !strings.HasSuffix(before, " ") {
+		fields := strings.Fields(before)
+		if len(fields) > 0 {
+			unit = strings.TrimLeft(fields[len(fields)-1], "(")
+		}
+	}
+	return value, unit, nil
+}
+
+// scanNumber
+// Returns the end of the number starting with the digit at s[start], see ExtractFirstNumber.
+func scanNumber(s string, start int) int {
+	end := start
+	group := 0 // number of digits since the last separator
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		switch {
+		case isASCIIDigit(r):
+			group++
+		case r == '.' || r == ',':
+			if next, _ := utf8.DecodeRuneInString(s[end+size:]); !isASCIIDigit(next) {
+				return end
+			}
+			group = 0
+		case isThousandsSpace(r):
+			rest := s[end+size:]
+			if group > 3 || len(rest) < 3 || strings.IndexFunc(rest[:3], func(r rune) bool { return !isASCIIDigit(r) }) != -1 {
+				return end
+			}
+			if next, _ := utf8.DecodeRuneInString(rest[3:]); isASCIIDigit(next) {
+				return end
+			}
+			group = 0
+		default:
+			return end
+		}
+		end += size
+	}
+	return end
+}
+
+// parseExtractedNumber
+// Parses a number found by scanNumber per convention, see ExtractFirstNumber.
+func parseExtractedNumber(number string, convention DecimalConvention) (float64, error) {
+	if convention == DecimalUnknown {
+		amount, exponent, _, err := parseMoneyAmount(number, false, 0, false)
+		if err != nil {
+			return 0, err
+		}
+		return float64(amount) / math.Pow10(exponent), nil
+	}
+
+	thousands, decimal := ",", "."
+	if convention == DecimalComma {
+		thousands, decimal = ".", ","
+	}
+	integer := strings.Map(func(r rune) rune {
+		if isThousandsSpace(r) {
+			return -1
+		}
+		return r
+	}, number)
+	if i := strings.Index(integer, decimal); i != -1 {
+		if strings.IndexFunc(integer[i+len(decimal):], func(r rune) bool { return !isASCIIDigit(r) }) != -1 {
+			return 0, fmt.Errorf("misplaced separators in '%v'", number)
+		}
+		integer = integer[:i]
+	}
+	if !hasValidGrouping(integer, thousands) {
+		return 0, fmt.Errorf("misplaced separators in '%v'", number)
+	}
+	text := strings.Map(func(r rune) rune {
+		if isThousandsSpace(r) || string(r) == thousands {
+			return -1
+		}
+		if string(r) == decimal {
+			return '.'
+		}
+		return r
+	}, number)
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("misplaced separators in '%v'", number)
+	}
+	return value, nil
+}
+
+// hyphenatedCodeEnd
+// Returns the end of the token containing the number s[start:end] and true if the number is part of a code or date
+// joined by hyphens, see ExtractFirstNumber.
+func hyphenatedCodeEnd(s string, start, end int) (int, bool) {
+	isCode := false
+	if strings.HasSuffix(s[:start], "-") {
+		p, _ := utf8.DecodeLastRuneInString(s[:start-1])
+		isCode = unicode.IsLetter(p) || unicode.IsDigit(p)
+	}
+	if rest := s[end:]; !isCode && len(rest) > 1 && rest[0] == '-' && isASCIIDigit(rune(rest[1])) {
+		upper := rest[1:]
+		n := strings.IndexFunc(upper, func(r rune) bool { return !isASCIIDigit(r) })
+		if n == -1 {
+			n = len(upper)
+		}
+		// a leading zero or a third part, e.g., "2024-01" or "2024-01-02"
+		isCode = (upper[0] == '0' && n > 1) || (len(upper) > n+1 && upper[n] == '-' && isASCIIDigit(rune(upper[n+1])))
+	}
+	if !isCode {
+		return end, false
+	}
+	if i := strings.IndexFunc(s[end:], unicode.IsSpace); i != -1 {
+		return end + i, true
+	}
+	return len(s), true
+}
+
+// rangeUpperBound
+// Returns the index of the digits of the upper bound of a range if the text following a number continues it, e.g.,
+// " to 15 kg", else -1, and whether the upper bound is negative, e.g., " to -3 °C".
+func rangeUpperBound(after string) (int, bool) {
+	rest := strings.TrimLeftFunc(after, unicode.IsSpace)
+	for _, separator := range rangeSeparators {
+		if !strings.HasPrefix(rest, separator) {
+			continue
+		}
+		upper := strings.TrimLeftFunc(rest[len(separator):], unicode.IsSpace)
+		if unicode.IsLetter([]rune(separator)[0]) && len(upper) == len(rest)-len(separator) {
+			continue // words must be separated from the bounds, e.g., not "10 tons"
+		}
+		negative := false
+		if r, size := utf8.DecodeRuneInString(upper); r == '-' || r == '+' || r == '−' {
+			upper, negative = upper[size:], r != '+'
+		}
+		if r, _ := utf8.DecodeRuneInString(upper); isASCIIDigit(r) {
+			return len(after) - len(upper), negative
+		}
+	}
+	return -1, false
+}
+
+// firstToken
+// Returns the leading run of non-space characters of s after skipping leading whitespace.
+func firstToken(s string) string {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	if i := strings.IndexFunc(s, unicode.IsSpace); i != -1 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,198 @@
+package html_util
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractFirstNumber(t *testing.T) {
+	tests := []struct {
+		s         string
+		opts      NumberExtractOptions
+		wantValue float64
+		wantUnit  string
+		wantErr   bool
+	}{
+		{s: "approx. 1,200 units", wantValue: 1200, wantUnit: "units"},
+		{s: "up to 15 %", wantValue: 15, wantUnit: "%"},
+		{s: "from $9.99", wantValue: 9.99, wantUnit: "$"},
+		{s: "1 200 000 people", wantValue: 1200000, wantUnit: "people"},
+		{s: "1.299,50 €", wantValue: 1299.5, wantUnit: "€"},
+		{s: "1234,567 kg", wantValue: 1234.567, wantUnit: "kg"},
+		{s: "-5 °C", wantValue: -5, wantUnit: "°C"},
+		{s: "1,234,567.8", opts: NumberExtractOptions{Convention: DecimalPoint}, wantValue: 1234567.8},
+		{s: "1.234,5", opts: NumberExtractOptions{Convention: DecimalComma}, wantValue: 1234.5},
+		{s: "10–15 kg", opts: NumberExtractOptions{Ranges: RangeLower}, wantValue: 10, wantUnit: "kg"},
+		{s: "10 to 15 kg", opts: NumberExtractOptions{Ranges: RangeUpper}, wantValue: 15, wantUnit: "kg"},
+		{s: "10-15 kg", opts: NumberExtractOptions{Ranges: RangeMean}, wantValue: 12.5, wantUnit: "kg"},
+		{s: "10-15 kg", wantErr: true},
+		{s: "no number", wantErr: true},
+
+		// misplaced grouping separators
+		{s: "v1.2.3", wantErr: true},
+		{s: "1,2,3", wantErr: true},
+		{s: "12,34,567", wantErr: true},
+		{s: "1,2,3", opts: NumberExtractOptions{Convention: DecimalPoint}, wantErr: true},
+		{s: "1234.567,8", opts: NumberExtractOptions{Convention: DecimalComma}, wantErr: true},
+
+		// hyphenated codes and dates are no numbers or ranges
+		{s: "2024-01-02", opts: NumberExtractOptions{Ranges: RangeLower}, wantErr: true},
+		{s: "2024-01", opts: NumberExtractOptions{Ranges: RangeLower}, wantErr: true},
+		{s: "ISO-9001", wantErr: true},
+		{s: "ISO-9001 certified, 20 staff", wantValue: 20, wantUnit: "staff"},
+		{s: "on 2024-01-02: 15 items", opts: NumberExtractOptions{Ranges: RangeLower}, wantValue: 15, wantUnit: "items"},
+		{s: "1-2-3 go, then 4 laps", wantValue: 4, wantUnit: "laps"},
+
+		// ranges
+		{s: "10 to 15 kg", wantErr: true},
+		{s: "10 tons", wantValue: 10, wantUnit: "tons"},
+		{s: "10...15 m", opts: NumberExtractOptions{Ranges: RangeMean}, wantValue: 12.5, wantUnit: "m"},
+		{s: "10 bis 15 Stück", opts: NumberExtractOptions{Ranges: RangeUpper}, wantValue: 15, wantUnit: "Stück"},
+		{s: "10 - 15 %", opts: NumberExtractOptions{Ranges: RangeUpper}, wantValue: 15, wantUnit: "%"},
+		{s: "10—15", opts: NumberExtractOptions{Ranges: RangeUpper}, wantValue: 15},
+		{s: "1.5-2 kg", opts: NumberExtractOptions{Ranges: RangeUpper}, wantValue: 2, wantUnit: "kg"},
+		{s: "1,5–2,5 kg", opts: NumberExtractOptions{Convention: DecimalComma, Ranges: RangeMean}, wantValue: 2, wantUnit: "kg"},
+		{s: "-5 to 5 °C", opts: NumberExtractOptions{Ranges: RangeLower}, wantValue: -5, wantUnit: "°C"},
+		{s: "-5 to -3 °C", opts: NumberExtractOptions{Ranges: RangeMean}, wantValue: -4, wantUnit: "°C"},
+		{s: "-5 to -3 °C", opts: NumberExtractOptions{Ranges: RangeUpper}, wantValue: -3, wantUnit: "°C"},
+		{s: "10 to 1.2.3", opts: NumberExtractOptions{Ranges: RangeUpper}, wantErr: true},
+
+		// separator ambiguity
+		{s: "1,200", wantValue: 1200},
+		{s: "1,20 m", wantValue: 1.2, wantUnit: "m"},
+		{s: "1.200", wantValue: 1200},
+		{s: "1.200", opts: NumberExtractOptions{Convention: DecimalComma}, wantValue: 1200},
+		{s: "1.200", opts: NumberExtractOptions{Convention: DecimalPoint}, wantValue: 1.2},
+		{s: "1,200", opts: NumberExtractOptions{Convention: DecimalComma}, wantValue: 1.2},
+		{s: "1'234.5 CHF", wantValue: 1234.5, wantUnit: "CHF"},
+		{s: "1 234,5", wantValue: 1234.5},
+		{s: "12345 678", wantValue: 12345},
+		{s: "0,500 l", wantValue: 0.5, wantUnit: "l"},
+		{s: "1,234.5", opts: NumberExtractOptions{Convention: DecimalComma}, wantErr: true},
+		{s: "1.234,5", opts: NumberExtractOptions{Convention: DecimalPoint}, wantErr: true},
+		{s: "99999999999999999999", wantErr: true},
+
+		// signs, leading decimal separators, and units
+		{s: "−7", wantValue: -7},
+		{s: "+7", wantValue: 7},
+		{s: ".5 l", wantValue: 0.5, wantUnit: "l"},
+		{s: ",5 l", wantValue: 0.5, wantUnit: "l"},
+		{s: "-.5", wantValue: -0.5},
+		{s: "approx.5", wantValue: 5, wantUnit: "approx."},
+		{s: "x-15 y", wantErr: true},
+		{s: "(approx. 7)", wantValue: 7},
+		{s: "page 3.", wantValue: 3},
+		{s: "3 x 4", wantValue: 3, wantUnit: "x"},
+		{s: "1 2 3", wantValue: 1},
+	}
+	for _, tt := range tests {
+		value, unit, err := ExtractFirstNumber(tt.s, tt.opts)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ExtractFirstNumber(%q, %+v) = %v, %q, want an error", tt.s, tt.opts, value, unit)
+			}
+			continue
+		}
+		if err != nil || value != tt.wantValue || unit != tt.wantUnit {
+			t.Errorf("ExtractFirstNumber(%q, %+v) = %v, %q, %v, want %v, %q", tt.s, tt.opts, value, unit, err, tt.wantValue, tt.wantUnit)
+		}
+	}
+}
+
+func TestGetColumnWithUnitsLenient(t *testing.T) {
+	ht := parseTable(t, `<table>`+
+		`<tr><th>item</th><th>stock</th></tr>`+
+		`<tr><td>a</td><td>1,200 units</td></tr>`+
+		`<tr><td>b</td><td>approx. 1,200 units</td></tr>`+
+		`<tr><td>c</td><td>n/a</td></tr>`+
+		`<tr><td>d</td><td>10–15 units</td></tr>`+
+		`<tr><td>e</td><td>up to 15 %</td></tr>`+
+		`</table>`, true, true)
+
+	values, err := ht.GetColumnWithUnitsLenient("Stock", NumberExtractOptions{})
+	var cellsErr *ColumnCellsError
+	if !errors.As(err, &cellsErr) || !reflect.DeepEqual(cellsErr.Rows, []int{3, 4}) || cellsErr.Reason != "no number" {
+		t.Fatalf("got error %v, want rows 3 and 4 reported", err)
+	}
+	// clean cells are parsed by ParseValueWithUnit, where a single comma separates decimals
+	want := []ValueWithUnit{
+		{Value: 1.2, Unit: "units", Raw: "1,200 units", Valid: true},
+		{Value: 1200, Unit: "units", Raw: "approx. 1,200 units", Valid: true, Prose: true},
+		{Raw: "n/a"},
+		{Raw: "10–15 units"},
+		{Value: 15, Unit: "%", Raw: "up to 15 %", Valid: true, Prose: true},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", values, want)
+	}
+
+	// ranges per the options
+	values, err = ht.GetColumnWithUnitsLenient("stock", NumberExtractOptions{Ranges: RangeMean})
+	if !errors.As(err, &cellsErr) || !reflect.DeepEqual(cellsErr.Rows, []int{3}) {
+		t.Fatalf("got error %v, want row 3 reported", err)
+	}
+	if values[3].Value != 12.5 || values[3].Unit != "units" || !values[3].Prose {
+		t.Errorf("got range %+v", values[3])
+	}
+
+	// a fixed convention applies to all cells
+	values, _ = ht.GetColumnWithUnitsLenient("stock", NumberExtractOptions{Convention: DecimalPoint})
+	if values[0].Value != 1200 || values[0].Unit != "units" || values[0].Prose || values[1].Value != 1200 {
+		t.Errorf("got %+v with DecimalPoint", values[:2])
+	}
+	values, err = ht.GetColumnWithUnitsLenient("stock", NumberExtractOptions{Convention: DecimalComma})
+	if !errors.As(err, &cellsErr) || !reflect.DeepEqual(cellsErr.Rows, []int{3, 4}) || values[0].Value != 1.2 {
+		t.Errorf("got %+v, %v with DecimalComma", values, err)
+	}
+
+	// a column without numbers reports all rows
+	values, err = ht.GetColumnWithUnitsLenient("item", NumberExtractOptions{})
+	if !errors.As(err, &cellsErr) || len(values) != 5 {
+		t.Errorf("got %v, %v for a column without numbers", values, err)
+	}
+	ht = parseTable(t, `<table><tr><th>w</th></tr><tr><td>1 kg</td></tr><tr><td>about 2 kg</td></tr></table>`, true, false)
+	if values, err := ht.GetColumnWithUnitsLenient("w", NumberExtractOptions{}); err != nil || values[1].Value != 2 {
+		t.Errorf("got %+v, %v", values, err)
+	}
+	if _, err := ht.GetColumnWithUnitsLenient("height", NumberExtractOptions{}); err == nil || errors.As(err, &cellsErr) {
+		t.Errorf("got error %v for an unknown column", err)
+	}
+}
+
+func FuzzExtractFirstNumber(f *testing.F) {
+	for _, seed := range []string{"approx. 1,200 units", "10–15 kg", "-5 to -3 °C", "1.299,50 €", "ISO-9001 20 staff",
+		"2024-01-02", ".5 l", "1 200 000", "v1.2.3", "10 to 1.2.3", "−7", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		var values [4]float64
+		var errs [4]error
+		for mode := RangeReject; mode <= RangeMean; mode++ {
+			value, unit, err := ExtractFirstNumber(s, NumberExtractOptions{Ranges: mode})
+			if err == nil && (math.IsNaN(value) || math.IsInf(value, 0) || unit != strings.TrimSpace(unit)) {
+				t.Fatalf("ExtractFirstNumber(%q) = %v, %q", s, value, unit)
+			}
+			values[mode], errs[mode] = value, err
+		}
+		switch {
+		case errs[RangeLower] != nil:
+			// no number, then no mode yields one
+			if errs[RangeReject] == nil || errs[RangeUpper] == nil || errs[RangeMean] == nil {
+				t.Fatalf("ExtractFirstNumber(%q) yields %v, %v", s, values, errs)
+			}
+		case errs[RangeReject] == nil:
+			// without a range, all modes yield the number
+			if values[RangeLower] != values[RangeReject] || values[RangeUpper] != values[RangeReject] ||
+				values[RangeMean] != values[RangeReject] {
+				t.Fatalf("ExtractFirstNumber(%q) yields %v", s, values)
+			}
+		default:
+			if errs[RangeUpper] != nil || errs[RangeMean] != nil || values[RangeMean] != (values[RangeLower]+values[RangeUpper])/2 {
+				t.Fatalf("ExtractFirstNumber(%q) yields %v, %v", s, values, errs)
+			}
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"strconv"
+	"strings"
+)
+
+// MetaAttributeForm
+// The attribute a <meta> element names its property with, see MetaEntry.
+type MetaAttributeForm string
+
+const (
+	MetaName     MetaAttributeForm = "name"     // e.g., <meta name="description">
+	MetaProperty MetaAttributeForm = "property" // e.g., <meta property="og:image"> as used by OpenGraph
+)
+
+// MetaEntry
+// The content of a single <meta> element with a name or property attribute.
+type MetaEntry struct {
+	Key   string            // the trimmed, lowercase name or property
+	Value string            // the content attribute
+	Form  MetaAttributeForm // the attribute the key was taken from
+	Node  *html.Node
+}
+
+// MetaProperties
+// All named <meta> elements of a document in document order, see ExtractMetaProperties.
+// Repeated properties, e.g., multiple og:image or article:tag elements, are all kept.
+type MetaProperties struct {
+	entries []MetaEntry
+}
+
+// ExtractMetaProperties
+// Collects all <meta> elements with a content attribute and a property or name attribute in the tree of root.
+// If an element has both, the property attribute is used.
+func ExtractMetaProperties(root *html.Node) MetaProperties {
+	var properties MetaProperties
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || n.Data != "meta" {
+			return true
+		}
+		content, err := GetAttributeByKey(n, "content")
+		if err != nil {
+			return true
+		}
+		for _, form := range []MetaAttributeForm{MetaProperty, MetaName} {
+			attr, err := GetAttributeByKey(n, string(form))
+			if err != nil {
+				continue
+			}
+			if key := strings.ToLower(strings.TrimSpace(attr.Val)); key != "" {
+				properties.entries = append(properties.entries, MetaEntry{Key: key, Value: content.Val, Form: form, Node: n})
+				break
+			}
+		}
+		return true
+	})
+	return properties
+}
+
+// Entries
+// Returns all entries in document order.
+func (p MetaProperties) Entries() []MetaEntry {
+	return append([]MetaEntry{}, p.entries...)
+}
+
+// AllProperties
+// Returns all entries grouped by their key, each group in document order.
+func (p MetaProperties) AllProperties() map[string][]MetaEntry {
+	all := make(map[string][]MetaEntry)
+	for _, entry := range p.entries {
+		all[entry.Key] = append(all[entry.Key], entry)
+	}
+	return all
+}
+
+// Get
+// Returns the value of the first entry with the given key, compared case-insensitively. As specified by OpenGraph, the
+// first occurrence of a repeated property takes precedence.
+func (p MetaProperties) Get(key string) (string, bool) {
+	key = strings.ToLower(key)
+	for _, entry := range p.entries {
+		if entry.Key == key {
+			return entry.Value, true
+		}
+	}
+	return "", false
+}
+
+// Values
+// Returns the values of all entries with the given key in document order, e.g., all article:tag values.
+func (p MetaProperties) Values(key string) []string {
+	key = strings.ToLower(key)
+	var values []string
+	for _, entry := range p.entries {
+		if entry.Key == key {
+			values = append(values, entry.Value)
+		}
+	}
+	return values
+}
+
+// OpenGraphImage
+// An og:image with its structured properties. Width and Height are 0 if absent, malformed, or negative.
+type OpenGraphImage struct {
+	URL       string
+	SecureURL string
+	Type      string
+	Alt       string
+	Width     int
+	Height    int
+}
+
+// OpenGraphImages
+// Returns the og:image entries with their structured properties in document order.
+// Following OpenGraph, each og:image starts a new image and the subsequent og:image:* entries, e.g., og:image:width,
+// describe the latest image. og:image:url starts a new image unless it directly follows an og:image.
+// Structured properties before the first og:image are ignored.
+func (p MetaProperties) OpenGraphImages() []OpenGraphImage {
+	var images []OpenGraphImage
+	previous := ""
+	for _, entry := range p.entries {
+		value := strings.TrimSpace(entry.Value)
+		switch entry.Key {
+		case "og:image":
+			images = append(images, OpenGraphImage{URL: value})
+		case "og:image:url":
+			if previous == "og:image" {
+				images[len(images)-1].URL = value
+			} else {
+				images = append(images, OpenGraphImage{URL: value})
+			}
+		default:
+			if !strings.HasPrefix(entry.Key, "og:image:") || len(images) == 0 {
+				break
+			}
+			image := &images[len(images)-1]
+			switch strings.TrimPrefix(entry.Key, "og:image:") {
+			case "secure_url":
+				image.SecureURL = value
+			case "type":
+				image.Type = value
+			case "alt":
+				image.Alt = value
+			case "width":
+				image.Width = parseOpenGraphDimension(value)
+			case "height":
+				image.Height = parseOpenGraphDimension(value)
+			}
+		}
+		previous = entry.Key
+	}
+	return images
+}
+
+// parseOpenGraphDimension
+// Parses the value of og:image:width or og:image:height, returns 0 if it is malformed or negative.
+func parseOpenGraphDimension(value string) int {
+	dimension, err := strconv.Atoi(value)
+	if err != nil || dimension < 0 {
+		return 0
+	}
+	return dimension
+}
+
+// LargestOpenGraphImage
+// Returns the og:image with the largest area according to its og:image:width and og:image:height, an image with only a
+// width is assumed to be square. Ties and images without dimensions resolve to the first one. ok is false without og:image.
+func (p MetaProperties) LargestOpenGraphImage() (image OpenGraphImage, ok bool) {
+	images := p.OpenGraphImages()
+	if len(images) == 0 {
+		return OpenGraphImage{}, false
+	}
+	size := func(image OpenGraphImage) float64 { // large dimensions would overflow an int
+		if image.Height > 0 {
+			return float64(image.Width) * float64(image.Height)
+		}
+		return float64(image.Width) * float64(image.Width)
+	}
+	largest := images[0]
+	for _, image := range images[1:] {
+		if size(image) > size(largest) {
+			largest = image
+		}
+	}
+	return largest, true
+}
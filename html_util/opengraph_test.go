@@ -0,0 +1,90 @@
+package html_util
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const openGraphDocument = `<head>` +
+	`<meta property="og:title" content="First"><meta property="OG:Title " content="Second">` +
+	`<meta name="description" content="Desc"><meta name="keywords">` +
+	`<meta property="article:tag" name="keywords" content="go">` +
+	`<meta property="article:tag" content="html"><meta property="" name="author" content="Ann">` +
+	`<meta property="og:image:width" content="10">` + // before the first image, ignored
+	`<meta property="og:image" content=" https://example.com/small.png ">` +
+	`<meta property="og:image:width" content="200"><meta property="og:image:height" content="100">` +
+	`<meta property="og:image" content="https://example.com/large.png">` +
+	`<meta property="og:image:url" content="https://example.com/large-url.png">` +
+	`<meta property="og:image:secure_url" content="https://example.com/secure.png">` +
+	`<meta property="og:image:width" content="300"><meta property="og:image:alt" content="Large">` +
+	`<meta property="og:image:type" content="image/png">` +
+	`<meta property="og:image:url" content="https://example.com/third.png">` +
+	`<meta property="og:image:width" content="-400"><meta property="og:image:height" content="1000px">` +
+	`</head>`
+
+func TestExtractMetaProperties(t *testing.T) {
+	doc := parseDocument(t, openGraphDocument)
+	properties := ExtractMetaProperties(doc)
+
+	all := properties.AllProperties()
+	var titles []string
+	for _, entry := range all["og:title"] {
+		titles = append(titles, entry.Value)
+	}
+	if strings.Join(titles, " ") != "First Second" {
+		t.Errorf("got titles %v, want First Second in document order", titles)
+	}
+	if len(all["keywords"]) != 0 || len(all["author"]) != 1 || all["author"][0].Form != MetaName {
+		t.Error("meta elements without content or with an empty property are collected wrongly")
+	}
+	// the property attribute takes precedence over the name attribute
+	if tags := all["article:tag"]; len(tags) != 2 || tags[0].Form != MetaProperty || tags[0].Node == nil {
+		t.Errorf("got article:tag entries %+v", tags)
+	}
+
+	// the first occurrence wins as specified by OpenGraph
+	if title, ok := properties.Get("OG:TITLE"); !ok || title != "First" {
+		t.Errorf("Get(OG:TITLE) = %q, %v", title, ok)
+	}
+	if _, ok := properties.Get("og:missing"); ok {
+		t.Error("found a missing property")
+	}
+	if got := strings.Join(properties.Values("article:tag"), " "); got != "go html" {
+		t.Errorf("Values(article:tag) = %v", got)
+	}
+	if got := len(properties.Entries()); got != 19 {
+		t.Errorf("got %v entries, want 19", got)
+	}
+}
+
+func TestOpenGraphImages(t *testing.T) {
+	properties := ExtractMetaProperties(parseDocument(t, openGraphDocument))
+	var got []string
+	for _, image := range properties.OpenGraphImages() {
+		got = append(got, fmt.Sprintf("%v %v %v %v %vx%v", image.URL, image.SecureURL, image.Type, image.Alt, image.Width, image.Height))
+	}
+	// og:image:url directly after og:image refines it, a later one starts a new image
+	want := []string{
+		"https://example.com/small.png    200x100",
+		"https://example.com/large-url.png https://example.com/secure.png image/png Large 300x0",
+		"https://example.com/third.png    0x0",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// an image with only a width is square, 300x300 beats 200x100
+	if largest, ok := properties.LargestOpenGraphImage(); !ok || largest.Width != 300 {
+		t.Errorf("LargestOpenGraphImage = %+v, %v", largest, ok)
+	}
+	huge := ExtractMetaProperties(parseDocument(t, `<meta property="og:image" content="a">`+
+		`<meta property="og:image:width" content="4000000000"><meta property="og:image:height" content="4000000000">`+
+		`<meta property="og:image" content="b"><meta property="og:image:width" content="10">`))
+	if largest, _ := huge.LargestOpenGraphImage(); largest.URL != "a" {
+		t.Errorf("LargestOpenGraphImage = %v, want the huge image a", largest.URL)
+	}
+	if _, ok := ExtractMetaProperties(parseDocument(t, `<p>none</p>`)).LargestOpenGraphImage(); ok {
+		t.Error("found an image in a document without og:image")
+	}
+}
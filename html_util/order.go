@@ -0,0 +1,151 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"reflect"
+	"sort"
+)
+
+// getSiblingPath
+// Returns the positions among their siblings of node and all of its ancestors, starting at the root of its tree.
+func getSiblingPath(node *html.Node) []int {
+	var path []int
+	for n := node; n.Parent != nil; n = n.Parent {
+		position := 0
+		for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+			position++
+		}
+		path = append(path, position)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// compareTrees
+// Orders the trees of the roots a and b arbitrarily but consistently within one process.
+func compareTrees(a, b *html.Node) int {
+	pa, pb := reflect.ValueOf(a).Pointer(), reflect.ValueOf(b).Pointer()
+	switch {
+	case pa < pb:
+		return -1
+	case pa > pb:
+		return 1
+	}
+	return 0
+}
+
+// CompareDocumentOrder
+// Returns -1 if a precedes b in a pre-order traversal of their tree, i.e., in document order, 1 if a follows b, and 0 if
+// a and b are the same node. An ancestor precedes its descendants.
+// Nodes of different trees are ordered by their trees in an arbitrary but consistent order within one process, nil
+// precedes all nodes. Use SortNodesByDocumentOrder to sort many nodes.
+func CompareDocumentOrder(a, b *html.Node) int {
+	switch {
+	case a == b:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if rootA, rootB := TreeRoot(a), TreeRoot(b); rootA != rootB {
+		return compareTrees(rootA, rootB)
+	}
+
+	pathA, pathB := getSiblingPath(a), getSiblingPath(b)
+	for i := 0; i < len(pathA) && i < len(pathB); i++ {
+		if pathA[i] != pathB[i] {
+			if pathA[i] < pathB[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	if len(pathA) < len(pathB) {
+		return -1 // a is an ancestor of b
+	}
+	return 1
+}
+
+// SortNodesByDocumentOrder
+// Sorts nodes in document order, see CompareDocumentOrder, e.g., to merge the results of several queries.
+// Each involved tree is traversed once, so sorting many nodes does not walk their ancestors repeatedly.
+func SortNodesByDocumentOrder(nodes []*html.Node) {
+	roots := make(map[*html.Node]*html.Node, len(nodes)) // node -> root of its tree
+	positions := make(map[*html.Node]int)                // node -> pre-order position within its tree
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		root := TreeRoot(node)
+		roots[node] = root
+		if _, ok := positions[root]; ok {
+			continue // tree already traversed
+		}
+		position := 0
+		WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+			positions[n] = position
+			position++
+			return true
+		})
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		if roots[a] != roots[b] {
+			return compareTrees(roots[a], roots[b]) < 0
+		}
+		return positions[a] < positions[b]
+	})
+}
+
+// NodeGroup
+// A parent node and those of its children which were matched, in document order.
+type NodeGroup struct {
+	Parent *html.Node
+	Nodes  []*html.Node
+}
+
+// GroupNodesByParent
+// Groups nodes by their direct parent, e.g., the cells of several rows by their <tr>. Nodes within a group and the
+// groups, by their parent, are ordered in document order, see SortNodesByDocumentOrder.
+// Grouping is strictly by the direct parent, so nodes of the same row wrapped in different elements form separate
+// groups. Duplicates and nil nodes are dropped, nodes without parent form a group with a nil Parent, which comes first.
+func GroupNodesByParent(nodes []*html.Node) []NodeGroup {
+	sorted := make([]*html.Node, 0, len(nodes))
+	seen := make(map[*html.Node]bool, len(nodes))
+	for _, node := range nodes {
+		if node != nil && !seen[node] {
+			seen[node] = true
+			sorted = append(sorted, node)
+		}
+	}
+	SortNodesByDocumentOrder(sorted)
+
+	var parents []*html.Node
+	children := make(map[*html.Node][]*html.Node)
+	for _, node := range sorted {
+		if _, ok := children[node.Parent]; !ok {
+			parents = append(parents, node.Parent)
+		}
+		children[node.Parent] = append(children[node.Parent], node)
+	}
+	SortNodesByDocumentOrder(parents)
+
+	groups := make([]NodeGroup, 0, len(parents))
+	for _, parent := range parents {
+		groups = append(groups, NodeGroup{Parent: parent, Nodes: children[parent]})
+	}
+	return groups
+}
+
+// GetNodesByConditionGrouped
+// Returns all nodes matching cond in the tree of root grouped by their direct parent, see GroupNodesByParent.
+func GetNodesByConditionGrouped(root *html.Node, cond func(*html.Node) bool) []NodeGroup {
+	return GroupNodesByParent(GetNodesByCondition(root, cond))
+}
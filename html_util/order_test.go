@@ -0,0 +1,139 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCompareDocumentOrder(t *testing.T) {
+	doc := parseDocument(t, `<div id="a"><p id="b"><i id="c">x</i></p><p id="d"></p></div><span id="e"></span>`)
+	var nodes []*html.Node // all nodes in pre-order
+	WalkHtmlTreeInclusive(doc, func(n *html.Node) bool {
+		nodes = append(nodes, n)
+		return true
+	})
+
+	for i, a := range nodes {
+		for j, b := range nodes {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := CompareDocumentOrder(a, b); got != want {
+				t.Fatalf("CompareDocumentOrder(%v, %v) = %v, want %v", i, j, got, want)
+			}
+		}
+	}
+
+	other := parseDocument(t, `<p id="x"></p>`)
+	x, a := elementByID(t, other, "x"), elementByID(t, doc, "a")
+	order := CompareDocumentOrder(x, a)
+	if order == 0 || CompareDocumentOrder(a, x) != -order || CompareDocumentOrder(other, doc) != order {
+		t.Errorf("trees are not ordered consistently: %v, %v, %v", order, CompareDocumentOrder(a, x), CompareDocumentOrder(other, doc))
+	}
+	if CompareDocumentOrder(nil, a) != -1 || CompareDocumentOrder(a, nil) != 1 || CompareDocumentOrder(nil, nil) != 0 {
+		t.Error("nil does not precede all nodes")
+	}
+}
+
+func TestSortNodesByDocumentOrder(t *testing.T) {
+	doc := parseDocument(t, makeLargeDocument(50))
+	other := parseDocument(t, `<p>other</p>`)
+	var nodes []*html.Node
+	for _, root := range []*html.Node{doc, other} {
+		WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+			nodes = append(nodes, n)
+			return true
+		})
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for round := 0; round < 20; round++ {
+		var sample []*html.Node
+		for k := rnd.Intn(40); k >= 0; k-- {
+			sample = append(sample, nodes[rnd.Intn(len(nodes))]) // possibly with duplicates
+		}
+		sample = append(sample, nil)
+		rnd.Shuffle(len(sample), func(i, j int) { sample[i], sample[j] = sample[j], sample[i] })
+
+		SortNodesByDocumentOrder(sample)
+		if sample[0] != nil {
+			t.Fatal("nil is not sorted first")
+		}
+		for i := 1; i < len(sample); i++ {
+			if CompareDocumentOrder(sample[i-1], sample[i]) > 0 {
+				t.Fatalf("round %v: nodes %v and %v are out of order", round, i-1, i)
+			}
+		}
+	}
+}
+
+func BenchmarkSortNodesByDocumentOrder(b *testing.B) {
+	doc := parseDocument(b, makeLargeDocument(2000))
+	links := GetNodesByCondition(doc, MakeByTagNameCondition("a"))
+	paragraphs := GetNodesByCondition(doc, MakeByTagNameCondition("p"))
+	merged := append(append([]*html.Node{}, links...), paragraphs...)
+	for _, tt := range []struct {
+		name string
+		sort func(nodes []*html.Node)
+	}{
+		{"SortNodesByDocumentOrder", SortNodesByDocumentOrder},
+		{"CompareDocumentOrder", func(nodes []*html.Node) {
+			sort.SliceStable(nodes, func(i, j int) bool { return CompareDocumentOrder(nodes[i], nodes[j]) < 0 })
+		}},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			nodes := make([]*html.Node, len(merged))
+			for i := 0; i < b.N; i++ {
+				copy(nodes, merged)
+				tt.sort(nodes)
+			}
+		})
+	}
+}
+
+// groupsString
+// Formats groups as the id of their parent followed by the ids of their nodes, one group per line.
+func groupsString(groups []NodeGroup) string {
+	var lines []string
+	for _, group := range groups {
+		line := nodeID(group.Parent) + ":"
+		for _, n := range group.Nodes {
+			line += " " + nodeID(n)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestGroupNodesByParent(t *testing.T) {
+	doc := parseDocument(t, `<table><tr id="r1"><td id="a" class="price">1</td><td id="b">x</td><td id="c" class="price">2</td></tr>`+
+		`<tr id="r2"><td id="d" class="price">3</td><td id="w"><span id="e" class="price">4</span></td></tr></table>`)
+	prices := GetNodesByCondition(doc, MakeByClassNameCondition("price"))
+
+	// a wrapper is a parent of its own, so e forms a separate group
+	want := "r1: a c\nr2: d\nw: e"
+	if got := groupsString(GetNodesByConditionGrouped(doc, MakeByClassNameCondition("price"))); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	// input order, duplicates, and nil do not matter
+	shuffled := []*html.Node{prices[3], nil, prices[1], prices[2], prices[0], prices[1]}
+	if got := groupsString(GroupNodesByParent(shuffled)); got != want {
+		t.Errorf("got\n%v\nwant\n%v for shuffled input", got, want)
+	}
+
+	// detached nodes form a group with a nil parent, which comes first
+	detached := &html.Node{Type: html.ElementNode, Data: "td", Attr: []html.Attribute{{Key: "id", Val: "z"}}}
+	if got := groupsString(GroupNodesByParent([]*html.Node{prices[0], detached})); got != ": z\nr1: a" {
+		t.Errorf("got\n%v\nwant the detached node first", got)
+	}
+	if groups := GroupNodesByParent(nil); len(groups) != 0 {
+		t.Errorf("got %v groups for no nodes", len(groups))
+	}
+}
@@ -0,0 +1,223 @@
+package html_util
+
+import (
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/net/html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PayloadEncoding
+// The set of encodings DecodeAttributePayload removed from a value, 0 if the value was not encoded.
+type PayloadEncoding int
+
+const (
+	PayloadPercent   PayloadEncoding = 1 << iota // percent-encoding, e.g., "https%3A%2F%2Fexample.com"
+	PayloadBase64                                // standard base64, with or without padding
+	PayloadBase64URL                             // url-safe base64, i.e., '-' and '_' instead of '+' and '/', with or without padding
+)
+
+// String
+// Names the encodings joined by '+', e.g., "percent+base64", or "none".
+func (e PayloadEncoding) String() string {
+	var names []string
+	for _, encoding := range []struct {
+		flag PayloadEncoding
+		name string
+	}{{PayloadPercent, "percent"}, {PayloadBase64, "base64"}, {PayloadBase64URL, "base64url"}} {
+		if e&encoding.flag != 0 {
+			names = append(names, encoding.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "+")
+}
+
+// PayloadOptions
+// Configures DecodeAttributePayload.
+type PayloadOptions struct {
+	MaxDepth    int  // maximum number of nested encodings to remove, 3 if not positive
+	AllowBinary bool // accept base64 which does not decode to text, which then ends the decoding, else, such values are kept
+}
+
+var percentEscapeRegex = regexp.MustCompile(`%[0-9A-Fa-f]{2}`)
+
+// DecodeAttributePayload
+// Returns the value of the attribute key of node with nested percent-encoding and base64 removed, e.g.,
+// data-config="eyJwcmljZSI6IDV9" yields `{"price": 5}`, together with the set of removed encodings.
+// Layers are removed from the outside in until the value is not encoded anymore or PayloadOptions.MaxDepth is reached.
+// A value is percent-encoded if it contains '%' followed by two hex digits, '+' is kept as is. A value is base64 if it
+// consists of at least 8 characters of a base64 alphabet, optionally padded, and decodes to valid UTF-8 text without
+// control characters (see PayloadOptions.AllowBinary). Values of less than 16 characters must also contain a digit,
+// '+', '/', '_', or padding, and decode to ASCII text, so ordinary words and ids like "Question", "fileType", or
+// "Two-byte" are not mistaken for base64.
+// Returns an error if node has no attribute key. A value without encoding is returned as is with encoding 0.
+func DecodeAttributePayload(node *html.Node, key string, opts PayloadOptions) ([]byte, PayloadEncoding, error) {
+	attr, err := GetAttributeByKey(node, key)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot decode attribute '%v': %w", key, err)
+	}
+	payload, encoding := decodePayload(attr.Val, opts)
+	return payload, encoding, nil
+}
+
+// decodePayload
+// Removes the encodings of value, see DecodeAttributePayload.
+func decodePayload(value string, opts PayloadOptions) ([]byte, PayloadEncoding) {
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = 3
+	}
+
+	payload := []byte(value)
+	var encoding PayloadEncoding
+	for depth := 0; depth < maxDepth; depth++ {
+		text := string(payload)
+		if percentEscapeRegex.MatchString(text) {
+			if decoded, err := url.PathUnescape(text); err == nil {
+				payload = []byte(decoded)
+				encoding |= PayloadPercent
+				continue
+			}
+		}
+		decoded, base64Encoding, ok := decodeBase64Payload(text)
+		if !ok {
+			break
+		}
+		isText := isTextPayload(decoded) && (!isShortPayload(text) || isASCIIPayload(decoded))
+		if isShortPayload(text) && !strings.ContainsAny(text, "0123456789+/_=") {
+			break
+		}
+		if !isText && !opts.AllowBinary {
+			break
+		}
+		payload = decoded
+		encoding |= base64Encoding
+		if !isText {
+			break
+		}
+	}
+	return payload, encoding
+}
+
+// decodeBase64Payload
+// Decodes text as standard or url-safe base64 with optional padding, see DecodeAttributePayload.
+func decodeBase64Payload(text string) ([]byte, PayloadEncoding, bool) {
+	text = strings.TrimSpace(text)
+	unpadded := strings.TrimRight(text, "=")
+	if len(unpadded) < 8 || len(unpadded)%4 == 1 || len(text)-len(unpadded) > 2 {
+		return nil, 0, false
+	}
+
+	standard := strings.ContainsAny(unpadded, "+/")
+	urlSafe := strings.ContainsAny(unpadded, "-_")
+	if standard && urlSafe {
+		return nil, 0, false
+	}
+	encoding, flag := base64.RawStdEncoding, PayloadBase64
+	if urlSafe {
+		encoding, flag = base64.RawURLEncoding, PayloadBase64URL
+	}
+	decoded, err := encoding.DecodeString(unpadded)
+	if err != nil {
+		return nil, 0, false
+	}
+	return decoded, flag, true
+}
+
+// isTextPayload
+// Returns true if payload is valid UTF-8 without control characters besides tabs and newlines.
+func isTextPayload(payload []byte) bool {
+	if !utf8.Valid(payload) {
+		return false
+	}
+	for _, r := range string(payload) {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// isShortPayload
+// Returns true if the base64 text is too short to rule out ordinary words by the decoded text alone, see
+// DecodeAttributePayload.
+func isShortPayload(text string) bool {
+	return len(strings.TrimSpace(text)) < 16
+}
+
+// isASCIIPayload
+// Returns true if payload only consists of ASCII characters.
+func isASCIIPayload(payload []byte) bool {
+	for _, b := range payload {
+		if b >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultRedirectParams
+// Query parameters ExtractRedirectTarget checks if paramNames is nil, in this order.
+var DefaultRedirectParams = []string{
+	"url", "u", "target", "dest", "destination", "redirect", "redirect_url", "redirect_uri", "redir", "goto", "to",
+	"out", "link", "next", "continue", "return", "returnurl", "q",
+}
+
+// ExtractRedirectTarget
+// Returns the destination of a tracking or redirect link like "/out?u=https%3A%2F%2Fexample.com", i.e., the value of
+// the first query parameter of paramNames (DefaultRedirectParams if nil) which is an absolute http or https url once
+// its remaining encodings are removed as in DecodeAttributePayload, e.g., double percent-encoding or base64.
+// Values which are absolute paths, e.g., "/account", are resolved against link.URL.
+// Returns false if link has no such parameter.
+func ExtractRedirectTarget(link Link, paramNames []string) (*url.URL, bool) {
+	source := link.URL
+	if source == nil {
+		var err error
+		if source, err = url.Parse(strings.TrimSpace(link.Href)); err != nil {
+			return nil, false
+		}
+	}
+	if paramNames == nil {
+		paramNames = DefaultRedirectParams
+	}
+
+	query := source.Query()
+	for _, name := range paramNames {
+		for _, value := range query[name] {
+			if target, ok := parseRedirectTarget(source, value); ok {
+				return target, true
+			}
+			// only decode further if necessary, since decoding would also alter escapes within a plain url
+			if payload, encoding := decodePayload(strings.TrimSpace(value), PayloadOptions{}); encoding != 0 {
+				if target, ok := parseRedirectTarget(source, string(payload)); ok {
+					return target, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// parseRedirectTarget
+// Parses value as destination of a redirect from source, see ExtractRedirectTarget.
+func parseRedirectTarget(source *url.URL, value string) (*url.URL, bool) {
+	value = strings.TrimSpace(value)
+	target, err := url.Parse(value)
+	if err != nil {
+		return nil, false
+	}
+	if !target.IsAbs() && strings.HasPrefix(value, "/") {
+		target = source.ResolveReference(target)
+	}
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, false
+	}
+	return target, true
+}
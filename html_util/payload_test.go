@@ -0,0 +1,148 @@
+package html_util
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDecodeAttributePayload(t *testing.T) {
+	b64 := base64.StdEncoding.EncodeToString
+	rawURL64 := base64.RawURLEncoding.EncodeToString
+	tests := []struct {
+		value        string
+		opts         PayloadOptions
+		want         string
+		wantEncoding PayloadEncoding
+	}{
+		// base64
+		{value: "eyJwcmljZSI6IDV9", want: `{"price": 5}`, wantEncoding: PayloadBase64},
+		{value: b64([]byte(`{"price":5}`)), want: `{"price":5}`, wantEncoding: PayloadBase64},
+		{value: " SGVsbG8gV29ybGQ= ", want: "Hello World", wantEncoding: PayloadBase64},
+		{value: "SGVsbG8gV29ybGQ", want: "Hello World", wantEncoding: PayloadBase64},
+		{value: "eyJxIjoiPz8-PiJ9", want: `{"q":"??>>"}`, wantEncoding: PayloadBase64URL},
+		// without '-' and '_', both alphabets agree
+		{value: rawURL64([]byte(`{"name":"Grüße","ok":true}`)), want: `{"name":"Grüße","ok":true}`,
+			wantEncoding: PayloadBase64},
+		{value: "SGVsbG8gV29ybGQ===", want: "SGVsbG8gV29ybGQ==="},
+		{value: "eyJxIjoiPz8-Pi+9", want: "eyJxIjoiPz8-Pi+9"}, // both alphabets
+
+		// percent-encoding
+		{value: "https%3A%2F%2Fexample.com%2Fa%3Fb%3D1", want: "https://example.com/a?b=1", wantEncoding: PayloadPercent},
+		{value: "https%253A%252F%252Fexample.com", want: "https://example.com", wantEncoding: PayloadPercent},
+		{value: "a+b%20c", want: "a+b c", wantEncoding: PayloadPercent},
+		{value: "50%25 off", want: "50% off", wantEncoding: PayloadPercent},
+		{value: "100%", want: "100%"},
+
+		// nested combinations
+		{value: url.QueryEscape(b64([]byte(`{"a":"ü"}`))), want: `{"a":"ü"}`, wantEncoding: PayloadPercent | PayloadBase64},
+		{value: b64([]byte(url.QueryEscape("https://example.com/?a=b"))), want: "https://example.com/?a=b",
+			wantEncoding: PayloadPercent | PayloadBase64},
+		{value: b64([]byte(b64([]byte("hello world!")))), want: "hello world!", wantEncoding: PayloadBase64},
+		{value: b64([]byte(b64([]byte(b64([]byte("deep payload")))))), opts: PayloadOptions{MaxDepth: 2},
+			want: b64([]byte("deep payload")), wantEncoding: PayloadBase64},
+		{value: url.QueryEscape(url.QueryEscape(base64.URLEncoding.EncodeToString([]byte("https://x.com/?x=1&y=2!")))),
+			want: "https://x.com/?x=1&y=2!", wantEncoding: PayloadPercent | PayloadBase64URL},
+
+		// binary
+		{value: "AAECAwQFBgc=", want: "AAECAwQFBgc="},
+		{value: "AAECAwQFBgc=", opts: PayloadOptions{AllowBinary: true}, want: "\x00\x01\x02\x03\x04\x05\x06\x07",
+			wantEncoding: PayloadBase64},
+
+		// ordinary words and ids are kept
+		{value: "navigation", want: "navigation"},
+		{value: "Question", want: "Question"},
+		{value: "Quantity", want: "Quantity"},
+		{value: "RemoveChild", want: "RemoveChild"},
+		{value: "fileType", want: "fileType"},
+		{value: "Two-byte", want: "Two-byte"},
+		{value: "1234567890", want: "1234567890"},
+		{value: "main-content", want: "main-content"},
+		{value: "", want: ""},
+	}
+	for _, tt := range tests {
+		doc := parseDocument(t, `<div data-payload="`+strings.ReplaceAll(tt.value, `"`, "&quot;")+`"></div>`)
+		div := GetElementNodesByTagName("div", doc)[0]
+		got, encoding, err := DecodeAttributePayload(div, "data-payload", tt.opts)
+		if err != nil || string(got) != tt.want || encoding != tt.wantEncoding {
+			t.Errorf("DecodeAttributePayload(%q, %+v) = %q, %v, %v, want %q, %v", tt.value, tt.opts, got, encoding, err,
+				tt.want, tt.wantEncoding)
+		}
+	}
+
+	div := GetElementNodesByTagName("div", parseDocument(t, `<div></div>`))[0]
+	if _, _, err := DecodeAttributePayload(div, "data-payload", PayloadOptions{}); err == nil {
+		t.Error("got no error for a missing attribute")
+	}
+}
+
+func TestPayloadEncodingString(t *testing.T) {
+	tests := map[PayloadEncoding]string{
+		0:                                 "none",
+		PayloadBase64:                     "base64",
+		PayloadPercent | PayloadBase64URL: "percent+base64url",
+		PayloadPercent | PayloadBase64 | PayloadBase64URL: "percent+base64+base64url",
+	}
+	for encoding, want := range tests {
+		if got := encoding.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestExtractRedirectTarget(t *testing.T) {
+	source, _ := url.Parse("https://shop.example.com/out?u=%2Faccount&next=%2F%2Fcdn.example.com%2Fx")
+	tests := []struct {
+		name   string
+		link   Link
+		params []string
+		want   string
+	}{
+		{"percent-encoded", Link{Href: "/out?u=https%3A%2F%2Ftarget.com%2Fp%3Fa%3D1"}, nil, "https://target.com/p?a=1"},
+		{"double percent-encoded", Link{Href: "https://t.co/r?url=https%253A%252F%252Fx.com%252Fp"}, nil, "https://x.com/p"},
+		{"base64", Link{Href: "/r?u=" + base64.URLEncoding.EncodeToString([]byte("https://x.com/?a=1&b=2"))}, nil,
+			"https://x.com/?a=1&b=2"},
+		{"percent-encoded base64", Link{Href: "/r?dest=" + url.QueryEscape(url.QueryEscape(
+			base64.StdEncoding.EncodeToString([]byte("https://x.com/?a=1&b=2"))))}, nil, "https://x.com/?a=1&b=2"},
+		{"plain", Link{Href: "https://www.google.com/url?q=https://example.com/&sa=D"}, nil, "https://example.com/"},
+		{"escapes within the target", Link{Href: "/r?u=https%3A%2F%2Fx.com%2Fa%2520b"}, nil, "https://x.com/a%20b"},
+		{"first valid value", Link{Href: "/r?u=notaurl&u=https://b.com&q=https://c.com"}, nil, "https://b.com"},
+		{"parameter order", Link{Href: "/r?q=https://c.com&u=https://b.com"}, nil, "https://b.com"},
+		{"custom parameters", Link{Href: "/r?u=https://b.com&site=https://c.com"}, []string{"site"}, "https://c.com"},
+		{"resolved path", Link{URL: source, Href: "ignored"}, []string{"u"}, "https://shop.example.com/account"},
+		{"protocol-relative", Link{URL: source}, []string{"next"}, "https://cdn.example.com/x"},
+		{"relative source", Link{Href: "/r?to=/account"}, nil, ""},
+		{"javascript", Link{Href: "/r?u=javascript:alert(1)"}, nil, ""},
+		{"ftp", Link{Href: "/r?next=ftp://a.com"}, nil, ""},
+		{"no parameter", Link{Href: "/r?x=https://a.com"}, nil, ""},
+		{"no parameters", Link{Href: "/r?u=https://a.com"}, []string{}, ""},
+		{"unparsable href", Link{Href: "%zz?u=https://a.com"}, nil, ""},
+	}
+	for _, tt := range tests {
+		target, ok := ExtractRedirectTarget(tt.link, tt.params)
+		got := ""
+		if target != nil {
+			got = target.String()
+		}
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("%v: got %q, %v, want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func FuzzDecodeAttributePayload(f *testing.F) {
+	for _, seed := range []string{"eyJwcmljZSI6IDV9", "https%253A%252F%252Fexample.com", "eyJhIjoiw7wifQ%3D%3D",
+		"Question", "AAECAwQFBgc=", "100%", "%zz", ""} {
+		f.Add(seed, 0)
+	}
+	f.Fuzz(func(t *testing.T, value string, maxDepth int) {
+		payload, encoding := decodePayload(value, PayloadOptions{MaxDepth: maxDepth % 8})
+		if encoding == 0 && string(payload) != value {
+			t.Fatalf("decodePayload(%q) = %q without encoding", value, payload)
+		}
+		if encoding&(PayloadBase64|PayloadBase64URL) != 0 && !isTextPayload(payload) {
+			t.Fatalf("decodePayload(%q) = %q, which is binary", value, payload)
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ImagePrefs
+// Configures the source selection of CanonicalizeImages.
+type ImagePrefs struct {
+	Formats       []string   // accepted mime types of <source> elements in order of preference, e.g., "image/webp", all types are accepted if nil
+	ViewportWidth int        // width in css pixels media queries and srcset are evaluated for, sources with a media query are skipped if 0
+	PixelDensity  float64    // device pixel ratio for srcset selection, 1 if 0
+	LazyRules     []LazyRule // applied to each <picture> element before the selection, DefaultLazyRules if nil
+}
+
+// mediaFeatureRegex
+// Matches a min-width or max-width feature of a media query, e.g., "(min-width: 40em)".
+var mediaFeatureRegex = regexp.MustCompile(`^\(\s*(min|max)-width\s*:\s*([0-9.]+)\s*(px|em|rem)?\s*\)$`)
+
+// CanonicalizeImages
+// Reduces every <picture> element in the tree of root to its <img> element with the single image url a browser with
+// the given preferences would load: the <source> elements are evaluated in order and the first one whose media query
+// matches and whose type is accepted is chosen, among the following sources with the same media query the one of the
+// most preferred format, see ImagePrefs.Formats. Without such a source, the <img> element itself is used. The image is
+// selected from the srcset of the chosen element for the pixel density, falling back to the src of the <img> element.
+// The url is resolved against base and the document's <base href>, written to the src attribute of the <img> element,
+// whose srcset and sizes attributes are removed, as are all <source> elements of the picture.
+// Media queries support min-width and max-width in px, em, and rem, joined by 'and', and comma-separated alternatives.
+// Returns the number of simplified pictures. Frozen trees are not modified and yield 0.
+func CanonicalizeImages(root *html.Node, base *url.URL, prefs ImagePrefs) int {
+	if root == nil || IsFrozen(root) {
+		return 0
+	}
+	lazyRules := prefs.LazyRules
+	if lazyRules == nil {
+		lazyRules = DefaultLazyRules
+	}
+	documentBase := getDocumentBase(root, base)
+
+	simplified := 0
+	for _, picture := range GetNodesByCondition(root, MakeByTagNameCondition("picture")) {
+		ResolveLazyMedia(picture, lazyRules)
+
+		var img *html.Node
+		var sources []*html.Node
+		for c := picture.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "source":
+				sources = append(sources, c)
+			case "img":
+				if img == nil {
+					img = c
+				}
+			}
+		}
+		if img == nil {
+			continue
+		}
+
+		chosen := img
+		if source := selectPictureSource(sources, prefs); source != nil {
+			chosen = source
+		}
+		rawURL := ""
+		if srcset, err := GetAttributeByKey(chosen, "srcset"); err == nil {
+			rawURL = selectSrcsetCandidate(parseSrcset(srcset.Val), prefs)
+		}
+		if rawURL == "" {
+			if src, err := GetAttributeByKey(img, "src"); err == nil {
+				rawURL = strings.TrimSpace(src.Val)
+			}
+		}
+		if rawURL == "" {
+			continue
+		}
+		if u, err := url.Parse(rawURL); err == nil && documentBase != nil {
+			rawURL = documentBase.ResolveReference(u).String()
+		}
+
+		setAttribute(img, "src", rawURL)
+		removeAttribute(img, "srcset")
+		removeAttribute(img, "sizes")
+		for _, source := range sources {
+			picture.RemoveChild(source)
+		}
+		simplified++
+	}
+	return simplified
+}
+
+// removeAttribute
+// Removes all attributes of node with the given key.
+func removeAttribute(node *html.Node, key string) {
+	attrs := node.Attr[:0]
+	for _, attr := range node.Attr {
+		if attr.Namespace != "" || attr.Key != key {
+			attrs = append(attrs, attr)
+		}
+	}
+	node.Attr = attrs
+}
+
+// selectPictureSource
+// Returns the <source> element a browser with the given preferences would use, see CanonicalizeImages, or nil.
+func selectPictureSource(sources []*html.Node, prefs ImagePrefs) *html.Node {
+	formatRank := func(source *html.Node) int {
+		attr, err := GetAttributeByKey(source, "type")
+		mediaType := ""
+		if err == nil {
+			mediaType, _, _ = strings.Cut(attr.Val, ";")
+			mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		}
+		if prefs.Formats == nil || mediaType == "" {
+			return len(prefs.Formats) // accepted, least preferred
+		}
+		for i, format := range prefs.Formats {
+			if strings.EqualFold(format, mediaType) {
+				return i
+			}
+		}
+		return -1 // not accepted
+	}
+	media := func(source *html.Node) string {
+		if attr, err := GetAttributeByKey(source, "media"); err == nil {
+			return strings.TrimSpace(attr.Val)
+		}
+		return ""
+	}
+
+	var chosen *html.Node
+	chosenRank := 0
+	for _, source := range sources {
+		if !hasAttribute(source, "srcset") {
+			continue
+		}
+		rank := formatRank(source)
+		if rank < 0 {
+			continue
+		}
+		if chosen == nil {
+			if matchesMediaQuery(media(source), prefs.ViewportWidth) {
+				chosen, chosenRank = source, rank
+			}
+		} else if media(source) == media(chosen) && rank < chosenRank {
+			chosen, chosenRank = source, rank
+		}
+	}
+	return chosen
+}
+
+// matchesMediaQuery
+// Returns whether the media query list matches a screen of the given width, see CanonicalizeImages. An empty query
+// always matches, other queries never match if width is 0 or if they cannot be evaluated.
+func matchesMediaQuery(query string, width int) bool {
+	if query == "" {
+		return true
+	}
+	if width <= 0 {
+		return false
+	}
+	for _, alternative := range strings.Split(strings.ToLower(query), ",") {
+		if matchesMediaQueryAlternative(strings.TrimSpace(alternative), width) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMediaQueryAlternative
+// Returns whether a single media query without commas matches a screen of the given width.
+func matchesMediaQueryAlternative(query string, width int) bool {
+	if query == "" {
+		return false
+	}
+	for _, part := range strings.Split(query, " and ") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "all", "screen", "only screen":
+			continue
+		}
+		match := mediaFeatureRegex.FindStringSubmatch(part)
+		if match == nil {
+			return false
+		}
+		limit, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			return false
+		}
+		if match[3] == "em" || match[3] == "rem" {
+			limit *= 16
+		}
+		if match[1] == "min" && float64(width) < limit || match[1] == "max" && float64(width) > limit {
+			return false
+		}
+	}
+	return true
+}
+
+// selectSrcsetCandidate
+// Returns the url of the candidate of a srcset a browser with the given preferences would load: the candidate with the
+// smallest density of at least the pixel density, else, the one with the highest density. Width descriptors are
+// converted to densities relative to the viewport width, without it, the widest candidate is taken. Candidates without
+// descriptor have density 1.
+// Returns "" if there is no candidate.
+func selectSrcsetCandidate(candidates []srcsetCandidate, prefs ImagePrefs) string {
+	pixelDensity := prefs.PixelDensity
+	if pixelDensity <= 0 {
+		pixelDensity = 1
+	}
+
+	best, bestDensity := "", 0.0
+	for _, candidate := range candidates {
+		if strings.TrimSpace(candidate.URL) == "" {
+			continue
+		}
+		density := 1.0
+		descriptor := strings.ToLower(candidate.Descriptor)
+		if value, err := strconv.ParseFloat(strings.TrimSuffix(descriptor, "x"), 64); err == nil && strings.HasSuffix(descriptor, "x") {
+			density = value
+		} else if value, err := strconv.ParseFloat(strings.TrimSuffix(descriptor, "w"), 64); err == nil && strings.HasSuffix(descriptor, "w") {
+			if prefs.ViewportWidth > 0 {
+				density = value / float64(prefs.ViewportWidth)
+			} else {
+				density, pixelDensity = value, math.Inf(1) // without viewport width, take the widest candidate
+			}
+		}
+
+		switch {
+		case best == "":
+			best, bestDensity = candidate.URL, density
+		case bestDensity < pixelDensity && density > bestDensity:
+			best, bestDensity = candidate.URL, density // too small so far, take any larger candidate
+		case density >= pixelDensity && density < bestDensity:
+			best, bestDensity = candidate.URL, density // large enough, but smaller than the current one
+		}
+	}
+	return strings.TrimSpace(best)
+}
@@ -0,0 +1,114 @@
+package html_util
+
+import (
+	"net/url"
+	"testing"
+)
+
+// pictureFixture
+// Art-directed pictures with AVIF, WebP, and JPEG sources, a lazy-loaded picture, and a picture without sources.
+const pictureFixture = `<picture id="hero">` +
+	`<source media="(max-width: 599px)" type="image/avif" srcset="hero-narrow.avif 1x, hero-narrow@2x.avif 2x">` +
+	`<source media="(max-width: 599px)" type="image/webp" srcset="hero-narrow.webp 1x, hero-narrow@2x.webp 2x">` +
+	`<source media="(min-width: 600px) and (max-width: 62.5em)" type="image/webp" srcset="hero-mid.webp">` +
+	`<source type="image/avif" srcset="hero-400.avif 400w, hero-800.avif 800w, hero-1600.avif 1600w">` +
+	`<source type="image/webp" srcset="hero-400.webp 400w, hero-800.webp 800w, hero-1600.webp 1600w">` +
+	`<img src="hero.jpg" srcset="hero-small.jpg 480w, hero-large.jpg 1200w" sizes="100vw" alt="Hero">` +
+	`</picture>` +
+	`<picture id="lazy"><source type="image/webp" data-srcset="/lazy.webp"><img data-src="lazy.jpg"></picture>` +
+	`<picture id="plain"><img src="plain.jpg"></picture>` +
+	`<picture id="empty"><source srcset="orphan.webp"></picture>`
+
+func TestCanonicalizeImages(t *testing.T) {
+	base, _ := url.Parse("https://example.com/articles/")
+	tests := []struct {
+		name  string
+		prefs ImagePrefs
+		hero  string
+		lazy  string // src of the lazy picture
+	}{
+		{"narrow avif", ImagePrefs{Formats: []string{"image/avif", "image/webp", "image/jpeg"}, ViewportWidth: 375,
+			PixelDensity: 2}, "hero-narrow@2x.avif", "https://example.com/lazy.webp"},
+		{"narrow webp only", ImagePrefs{Formats: []string{"image/webp", "image/jpeg"}, ViewportWidth: 375},
+			"hero-narrow.webp", "https://example.com/lazy.webp"},
+		{"narrow preferring webp", ImagePrefs{Formats: []string{"image/webp", "image/avif"}, ViewportWidth: 375},
+			"hero-narrow.webp", "https://example.com/lazy.webp"},
+		{"medium in em", ImagePrefs{Formats: []string{"image/avif", "image/webp"}, ViewportWidth: 1000},
+			"hero-mid.webp", "https://example.com/lazy.webp"},
+		{"wide by width descriptors", ImagePrefs{Formats: []string{"image/avif", "image/webp"}, ViewportWidth: 1200},
+			"hero-1600.avif", "https://example.com/lazy.webp"},
+		{"wide high density", ImagePrefs{Formats: []string{"image/webp"}, ViewportWidth: 1440, PixelDensity: 1},
+			"hero-1600.webp", "https://example.com/lazy.webp"},
+		{"jpeg only", ImagePrefs{Formats: []string{"image/jpeg"}, ViewportWidth: 400}, "hero-small.jpg",
+			"https://example.com/articles/lazy.jpg"},
+		{"jpeg too small", ImagePrefs{Formats: []string{"image/jpeg"}, ViewportWidth: 500}, "hero-large.jpg",
+			"https://example.com/articles/lazy.jpg"},
+		{"no viewport", ImagePrefs{Formats: []string{"image/jpeg"}}, "hero-large.jpg", "https://example.com/articles/lazy.jpg"},
+		{"all formats", ImagePrefs{ViewportWidth: 375}, "hero-narrow.avif", "https://example.com/lazy.webp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseDocument(t, pictureFixture)
+			if got := CanonicalizeImages(doc, base, tt.prefs); got != 3 {
+				t.Errorf("simplified %v pictures, want 3", got)
+			}
+			want := map[string]string{
+				"hero":  `<picture id="hero"><img src="https://example.com/articles/` + tt.hero + `" alt="Hero"/></picture>`,
+				"lazy":  `<picture id="lazy"><img data-src="lazy.jpg" src="` + tt.lazy + `"/></picture>`,
+				"plain": `<picture id="plain"><img src="https://example.com/articles/plain.jpg"/></picture>`,
+				"empty": `<picture id="empty"><source srcset="orphan.webp"/></picture>`,
+			}
+			for id, html := range want {
+				if got := renderNode(t, elementByID(t, doc, id)); got != html {
+					t.Errorf("got\n%v\nwant\n%v", got, html)
+				}
+			}
+		})
+	}
+}
+
+func TestCanonicalizeImagesDocumentBaseAndFrozen(t *testing.T) {
+	const document = `<base href="https://cdn.example.org/img/"><picture><source srcset="a.webp"><img src="a.jpg"></picture>`
+	doc := parseDocument(t, document)
+	if got := CanonicalizeImages(doc, nil, ImagePrefs{}); got != 1 {
+		t.Fatalf("simplified %v pictures, want 1", got)
+	}
+	want := `<picture><img src="https://cdn.example.org/img/a.webp"/></picture>`
+	if got := renderNode(t, GetElementNodesByTagName("picture", doc)[0]); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	frozen := FreezeTree(parseDocument(t, document))
+	defer frozen.Release()
+	if got := CanonicalizeImages(frozen.Root(), nil, ImagePrefs{}); got != 0 {
+		t.Errorf("simplified %v pictures of a frozen tree", got)
+	}
+	if got := CanonicalizeImages(nil, nil, ImagePrefs{}); got != 0 {
+		t.Errorf("simplified %v pictures of nil", got)
+	}
+}
+
+func TestMatchesMediaQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		width int
+		want  bool
+	}{
+		{"", 0, true},
+		{"(min-width: 600px)", 0, false},
+		{"(min-width: 600px)", 600, true},
+		{"(min-width:600px)", 599, false},
+		{"screen and (max-width: 40em)", 640, true},
+		{"only screen and (max-width: 40rem)", 641, false},
+		{"(max-width: 300px), (min-width: 1000px)", 1200, true},
+		{"(max-width: 300px), (min-width: 1000px)", 500, false},
+		{"print", 500, false},
+		{"(orientation: landscape)", 500, false},
+		{"not all and (min-width: 100px)", 500, false},
+	}
+	for _, tt := range tests {
+		if got := matchesMediaQuery(tt.query, tt.width); got != tt.want {
+			t.Errorf("matchesMediaQuery(%q, %v) = %v, want %v", tt.query, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"io"
+)
+
+// ErrInputTooLarge
+// Returned if a document read from an io.Reader exceeds the provided size limit.
+var ErrInputTooLarge = errors.New("input exceeds size limit")
+
+// countingReader
+// Counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// ParseDocumentFromReader
+// Parses the html document read from r.
+// Returns an error wrapping ErrInputTooLarge if r provides more than maxBytes bytes. maxBytes <= 0 means no limit.
+func ParseDocumentFromReader(r io.Reader, maxBytes int64) (*html.Node, error) {
+	if r == nil {
+		return nil, errors.New("reader is nil")
+	}
+	if maxBytes <= 0 {
+		return html.Parse(r)
+	}
+
+	// read one more byte than allowed to be able to tell truncation from a document of exactly maxBytes
+	cr := &countingReader{r: io.LimitReader(r, maxBytes+1)}
+	doc, err := html.Parse(cr)
+	if cr.n > maxBytes {
+		return nil, fmt.Errorf("%w: more than %v bytes", ErrInputTooLarge, maxBytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// matchesTableOptions
+// Returns whether tableNode matches TableParseOptions.MatchCaption, TableParseOptions.MatchHeaders, and
+// TableParseOptions.MinDataTableConfidence.
+func matchesTableOptions(tableNode *html.Node, opts TableParseOptions) bool {
+	if opts.MinDataTableConfidence > 0 {
+		if _, confidence := IsDataTable(tableNode); confidence < opts.MinDataTableConfidence {
+			return false
+		}
+	}
+	if opts.MatchCaption != "" && FindTableByCaption(tableNode, opts.MatchCaption) != tableNode {
+		return false
+	}
+	if len(opts.MatchHeaders) > 0 {
+		found, err := FindTableByHeaders(tableNode, opts.MatchHeaders, withMatchParseOptions(opts))
+		if err != nil || found != tableNode {
+			return false
+		}
+	}
+	return true
+}
+
+// findMatchingTables
+// Returns the <table> elements below and including root which match opts (see matchesTableOptions) in document order,
+// skipping inert <template> elements. Stops after the first table if first is set.
+func findMatchingTables(root *html.Node, opts TableParseOptions, first bool) []*html.Node {
+	var tableNodes []*html.Node
+	WalkHtmlTreeInclusive(root, func(node *html.Node) bool {
+		if first && len(tableNodes) > 0 {
+			return false
+		}
+		if MakeByTagNameCondition("table")(node) && matchesTableOptions(node, opts) {
+			tableNodes = append(tableNodes, node)
+		}
+		return !isInertTemplate(node)
+	})
+	return tableNodes
+}
+
+// ParseFirstHtmlTableFromReader
+// Parses the html document read from r and returns the first <table> parsed with opts (see ParseHtmlTableWithOptions).
+// If TableParseOptions.MatchCaption or TableParseOptions.MatchHeaders are set, the first table matching them is used.
+// Tables inside inert <template> elements are skipped, see ParseAllHtmlTables.
+// Returns an error wrapping ErrInputTooLarge if r provides more than maxBytes bytes. maxBytes <= 0 means no limit.
+func ParseFirstHtmlTableFromReader(r io.Reader, opts TableParseOptions, maxBytes int64) (*HtmlTable, error) {
+	doc, err := ParseDocumentFromReader(r, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	tableNodes := findMatchingTables(doc, opts, true)
+	if len(tableNodes) == 0 {
+		return nil, errors.New("no matching table found")
+	}
+	return ParseHtmlTableWithOptions(tableNodes[0], opts)
+}
+
+// ParseAllHtmlTablesFromReader
+// Same as ParseFirstHtmlTableFromReader but returns all matching tables in document order, see ParseAllHtmlTables.
+// Returns an empty slice if there are none.
+func ParseAllHtmlTablesFromReader(r io.Reader, opts TableParseOptions, maxBytes int64) ([]*HtmlTable, error) {
+	doc, err := ParseDocumentFromReader(r, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAllHtmlTables(doc, opts)
+}
+
+// ParseAllHtmlTables
+// Parses all <table> elements below and including root with opts (see ParseHtmlTableWithOptions) in document order.
+// Only tables matching TableParseOptions.MatchCaption, TableParseOptions.MatchHeaders, and
+// TableParseOptions.MinDataTableConfidence are parsed, e.g., to skip layout tables. Tables inside inert <template>
+// elements are skipped, see IncludeShadowTemplates.
+// Returns an empty slice if there are none.
+func ParseAllHtmlTables(root *html.Node, opts TableParseOptions) ([]*HtmlTable, error) {
+	tableNodes := findMatchingTables(root, opts, false)
+	tables := make([]*HtmlTable, 0, len(tableNodes))
+	for _, tableNode := range tableNodes {
+		table, err := ParseHtmlTableWithOptions(tableNode, opts)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
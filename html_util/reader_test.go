@@ -0,0 +1,85 @@
+package html_util
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const readerDocument = `<table><tr><td>layout</td></tr></table>` +
+	`<table><caption>Prices</caption><tr><th>Item</th><th>Price</th></tr><tr><td>a</td><td>1</td></tr></table>` +
+	`<table><tr><th>Item</th><th>Stock</th></tr><tr><td>b</td><td>2</td></tr></table>`
+
+func TestParseFirstHtmlTableFromReader(t *testing.T) {
+	tests := []struct {
+		opts TableParseOptions
+		want string
+	}{
+		{TableParseOptions{Suffix: "_"}, "Index\\Header|1\n1|layout"},
+		{TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_", MatchCaption: "prices"}, "Item|Price\na|1"},
+		{TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_", MatchHeaders: []string{"stock"}}, "Item|Stock\nb|2"},
+	}
+	for i, tt := range tests {
+		ht, err := ParseFirstHtmlTableFromReader(strings.NewReader(readerDocument), tt.opts, int64(len(readerDocument)))
+		if err != nil {
+			t.Errorf("test %v: got error %v", i, err)
+			continue
+		}
+		if got := tableString(ht); got != tt.want {
+			t.Errorf("test %v: got\n%v\nwant\n%v", i, got, tt.want)
+		}
+	}
+
+	if _, err := ParseFirstHtmlTableFromReader(strings.NewReader(readerDocument), TableParseOptions{MatchCaption: "Other"}, 0); err == nil ||
+		errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("got %v, want an error for no matching table", err)
+	}
+	_, err := ParseFirstHtmlTableFromReader(strings.NewReader(readerDocument), TableParseOptions{}, int64(len(readerDocument)-1))
+	if !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("got %v, want ErrInputTooLarge", err)
+	}
+}
+
+func TestParseAllHtmlTablesFromReader(t *testing.T) {
+	tables, err := ParseAllHtmlTablesFromReader(strings.NewReader(readerDocument),
+		TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_", MatchHeaders: []string{"Item"}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ht := range tables {
+		got = append(got, tableString(ht))
+	}
+	if want := "Item|Price\na|1\n---\nItem|Stock\nb|2"; strings.Join(got, "\n---\n") != want {
+		t.Errorf("got\n%v\nwant\n%v", strings.Join(got, "\n---\n"), want)
+	}
+
+	if _, err := ParseAllHtmlTablesFromReader(strings.NewReader(readerDocument), TableParseOptions{}, 10); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("got %v, want ErrInputTooLarge", err)
+	}
+	tables, err = ParseAllHtmlTablesFromReader(strings.NewReader(`<p>no tables</p>`), TableParseOptions{}, 0)
+	if err != nil || tables == nil || len(tables) != 0 {
+		t.Errorf("got %v, %v, want an empty slice", tables, err)
+	}
+}
+
+func TestParseTablesSkipTemplatesAndIncludeRoot(t *testing.T) {
+	document := `<template><table><tr><th>Item</th><th>Draft</th></tr><tr><td>x</td><td>0</td></tr></table></template>` +
+		`<table><tr><th>Item</th><th>Price</th></tr><tr><td>a</td><td>1</td></tr></table>`
+	opts := TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_"}
+
+	ht, err := ParseFirstHtmlTableFromReader(strings.NewReader(document), opts, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tableString(ht), "Item|Price\na|1"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	// a table passed as root is parsed itself
+	tableNode := GetNodesByCondition(parseDocument(t, document), MakeByTagNameCondition("table"))[1]
+	tables, err := ParseAllHtmlTables(tableNode, opts)
+	if err != nil || len(tables) != 1 || tableString(tables[0]) != "Item|Price\na|1" {
+		t.Errorf("got %v tables, %v, want the root table", len(tables), err)
+	}
+}
@@ -0,0 +1,120 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"sort"
+	"strings"
+)
+
+// FieldExtraction
+// Describes what is extracted from the node found for a FieldSpec.
+type FieldExtraction int
+
+const (
+	ExtractText      FieldExtraction = iota // composite of all content text nodes below the found node
+	ExtractAttribute                        // value of the attribute FieldSpec.AttributeKey of the found node
+	ExtractInnerHtml                        // rendered html of all children of the found node
+)
+
+// FieldSpec
+// Describes how a single named field of a record is extracted relative to the record node.
+type FieldSpec struct {
+	Condition    func(node *html.Node) bool // selects the field node, evaluated on the record node and its subtree
+	Extraction   FieldExtraction            // what to extract from the field node
+	AttributeKey string                     // attribute to extract if Extraction is ExtractAttribute
+	Required     bool                       // whether a missing field is reported as error
+}
+
+// MissingFieldsError
+// Returned by ExtractRecord if at least one required field could not be extracted.
+// Fields contains the names of all missing fields in sorted order.
+type MissingFieldsError struct {
+	Fields []string
+}
+
+func (e *MissingFieldsError) Error() string {
+	return fmt.Sprintf("missing required fields: '%v'", strings.Join(e.Fields, "', '"))
+}
+
+// ExtractRecord
+// Extracts a flat record from node, i.e., one value per field of spec.
+// Each field node is the first node of the tree of node (including node) for which the FieldSpec.Condition yields true.
+// Fields which could not be found or extracted are not set in the returned map.
+// If required fields are missing, returns the partial record together with a *MissingFieldsError.
+func ExtractRecord(node *html.Node, spec map[string]FieldSpec) (map[string]string, error) {
+	if node == nil {
+		return nil, errors.New("node is nil")
+	}
+
+	record := make(map[string]string)
+	var missing []string
+
+	for name, field := range spec {
+		value, ok := extractField(node, field)
+		if ok {
+			record[name] = value
+		} else if field.Required {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return record, &MissingFieldsError{Fields: missing}
+	}
+
+	return record, nil
+}
+
+// ExtractRecords
+// Calls ExtractRecord on each of the provided nodes, e.g., the items of a list or grid.
+// Returns the error of the first node which failed together with its index.
+func ExtractRecords(nodes []*html.Node, spec map[string]FieldSpec) ([]map[string]string, error) {
+	records := make([]map[string]string, 0, len(nodes))
+	for i, node := range nodes {
+		record, err := ExtractRecord(node, spec)
+		if err != nil {
+			return records, fmt.Errorf("record %v: %w", i, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func extractField(node *html.Node, field FieldSpec) (string, bool) {
+	if field.Condition == nil {
+		return "", false
+	}
+
+	fieldNode := GetNodeByCondition(node, field.Condition)
+	if fieldNode == nil {
+		return "", false
+	}
+
+	switch field.Extraction {
+	case ExtractText:
+		texts := GetTextNodesByCondition(fieldNode, isContentText)
+		if len(texts) == 0 {
+			return "", false
+		}
+		return MakeTextNodeCompositeWithNormalizerFunc(texts, " ", strings.TrimSpace), true
+	case ExtractAttribute:
+		attr, err := GetAttributeByKey(fieldNode, field.AttributeKey)
+		if err != nil {
+			return "", false
+		}
+		return attr.Val, true
+	case ExtractInnerHtml:
+		var sb strings.Builder
+		for _, c := range GetChildren(fieldNode) {
+			if err := html.Render(&sb, c); err != nil {
+				return "", false
+			}
+		}
+		return sb.String(), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,48 @@
+package html_util
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExtractRecord(t *testing.T) {
+	doc := parseDocument(t, `<ul>`+
+		`<li class="item"><a href="/a">  Alpha  </a><span class="price">5 <b>€</b></span></li>`+
+		`<li class="item"><a href="/b">Beta</a></li>`+
+		`</ul>`)
+	items := GetNodesByCondition(doc, MakeByClassNameCondition("item"))
+	spec := map[string]FieldSpec{
+		"name":  {Condition: MakeByTagNameCondition("a"), Extraction: ExtractText, Required: true},
+		"url":   {Condition: MakeByTagNameCondition("a"), Extraction: ExtractAttribute, AttributeKey: "href"},
+		"price": {Condition: MakeByClassNameCondition("price"), Extraction: ExtractText, Required: true},
+		"html":  {Condition: MakeByClassNameCondition("price"), Extraction: ExtractInnerHtml},
+		"title": {Condition: MakeByTagNameCondition("a"), Extraction: ExtractAttribute, AttributeKey: "title"},
+	}
+
+	record, err := ExtractRecord(items[0], spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"name": "Alpha", "url": "/a", "price": "5 €", "html": "5 <b>€</b>"}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("got %v, want %v", record, want)
+	}
+
+	record, err = ExtractRecord(items[1], spec)
+	var missing *MissingFieldsError
+	if !errors.As(err, &missing) || !reflect.DeepEqual(missing.Fields, []string{"price"}) {
+		t.Fatalf("got error %v, want missing field 'price'", err)
+	}
+	if record["name"] != "Beta" {
+		t.Errorf("partial record %v lacks the found fields", record)
+	}
+
+	records, err := ExtractRecords(items, spec)
+	if !errors.As(err, &missing) || len(records) != 1 {
+		t.Errorf("ExtractRecords returned %v records and %v, want 1 record and the error of the second", len(records), err)
+	}
+	if _, err := ExtractRecord(nil, spec); err == nil {
+		t.Error("no error for nil")
+	}
+}
@@ -0,0 +1,306 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// RegionKind
+// The kind of a top-level area of a page, see GetContentRegions.
+type RegionKind int
+
+const (
+	RegionHeader RegionKind = iota // site header, i.e., <header>, role="banner"
+	RegionNav                      // navigation, i.e., <nav>, role="navigation"
+	RegionMain                     // main content, i.e., <main>, role="main"
+	RegionAside                    // complementary content like sidebars, i.e., <aside>, role="complementary"
+	RegionFooter                   // site footer, i.e., <footer>, role="contentinfo"
+)
+
+// String
+// Returns the name of the kind, e.g., "nav".
+func (k RegionKind) String() string {
+	switch k {
+	case RegionHeader:
+		return "header"
+	case RegionNav:
+		return "nav"
+	case RegionMain:
+		return "main"
+	case RegionAside:
+		return "aside"
+	case RegionFooter:
+		return "footer"
+	}
+	return "unknown"
+}
+
+// Region
+// An area of a page classified by GetContentRegions.
+type Region struct {
+	Kind       RegionKind
+	Node       *html.Node // root of the area
+	Confidence float64    // 1 for ARIA landmarks, 0.9 for semantic elements, 0.5 for class and id heuristics, 0.3 for the text density fallback
+}
+
+// Regions
+// The classification of a page by GetContentRegions. Callers may correct it, e.g., by appending regions to All, before
+// passing it on via the Regions field of URLExtractOptions or VisibleTextOptions.
+type Regions struct {
+	All []Region // classified areas in document order
+}
+
+// ByKind
+// Returns the regions of the given kind in document order.
+func (r Regions) ByKind(kind RegionKind) []Region {
+	var regions []Region
+	for _, region := range r.All {
+		if region.Kind == kind {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// Nodes
+// Returns the root nodes of the regions of the given kinds in document order.
+func (r Regions) Nodes(kinds ...RegionKind) []*html.Node {
+	var nodes []*html.Node
+	for _, region := range r.All {
+		for _, kind := range kinds {
+			if region.Kind == kind {
+				nodes = append(nodes, region.Node)
+				break
+			}
+		}
+	}
+	return nodes
+}
+
+// landmarkRoles
+// Maps ARIA landmark roles to the kind of region they mark.
+var landmarkRoles = map[string]RegionKind{
+	"banner": RegionHeader, "navigation": RegionNav, "main": RegionMain, "complementary": RegionAside,
+	"contentinfo": RegionFooter,
+}
+
+// semanticRegionTags
+// Maps html5 sectioning elements to the kind of region they mark.
+var semanticRegionTags = map[string]RegionKind{
+	"header": RegionHeader, "nav": RegionNav, "main": RegionMain, "aside": RegionAside, "footer": RegionFooter,
+}
+
+// RegionClassTokens
+// Maps lower case tokens of class and id attributes, split at non-alphanumeric characters, to the kind of region they
+// indicate, used by GetContentRegions for elements without semantic markup. Callers may add tokens during
+// initialization, e.g., RegionClassTokens["kopf"] = RegionHeader.
+var RegionClassTokens = map[string]RegionKind{
+	"header": RegionHeader, "masthead": RegionHeader, "topbar": RegionHeader, "banner": RegionHeader,
+	"nav": RegionNav, "navbar": RegionNav, "navigation": RegionNav, "menu": RegionNav,
+	"breadcrumb": RegionNav, "breadcrumbs": RegionNav, "pagination": RegionNav,
+	"main": RegionMain, "content": RegionMain, "article": RegionMain, "post": RegionMain, "story": RegionMain,
+	"sidebar": RegionAside, "aside": RegionAside, "widgets": RegionAside, "related": RegionAside,
+	"footer": RegionFooter, "copyright": RegionFooter, "colophon": RegionFooter,
+}
+
+// regionHeuristicTags
+// Container elements GetContentRegions classifies by their class and id, other elements, e.g., <span class="menu">,
+// are too small to be a region.
+var regionHeuristicTags = map[string]bool{
+	"div": true, "section": true, "ul": true, "ol": true, "table": true, "td": true, "center": true,
+}
+
+// classifyRegion
+// Returns the kind of region node marks and the confidence of the classification, see GetContentRegions.
+func classifyRegion(node *html.Node) (RegionKind, float64, bool) {
+	if role, err := GetAttributeByKey(node, "role"); err == nil {
+		for _, r := range strings.Fields(strings.ToLower(role.Val)) {
+			if kind, ok := landmarkRoles[r]; ok {
+				return kind, 1, true
+			}
+		}
+	}
+	if kind, ok := semanticRegionTags[node.Data]; ok {
+		return kind, 0.9, true
+	}
+	if !regionHeuristicTags[node.Data] {
+		return 0, 0, false
+	}
+
+	// the first matching kind in this order wins, e.g., "entry-footer" is a footer, not main content
+	var found [RegionFooter + 1]bool
+	for _, key := range []string{"id", "class"} {
+		attr, err := GetAttributeByKey(node, key)
+		if err != nil {
+			continue
+		}
+		for _, token := range strings.FieldsFunc(strings.ToLower(attr.Val), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if kind, ok := RegionClassTokens[token]; ok {
+				found[kind] = true
+			}
+		}
+	}
+	for _, kind := range []RegionKind{RegionNav, RegionAside, RegionFooter, RegionHeader, RegionMain} {
+		if found[kind] {
+			return kind, 0.5, true
+		}
+	}
+	return 0, 0, false
+}
+
+// GetContentRegions
+// Classifies the top-level areas of the page of root into header, navigation, main content, complementary content, and
+// footer, see RegionKind. ARIA landmark roles take precedence over html5 sectioning elements, which take precedence over
+// class and id tokens (see RegionClassTokens) of container elements, Region.Confidence reflects which one was used.
+// Classified areas are only searched for nested navigation, e.g., a menu in the header, and main content is searched
+// for complementary content as well. <header> and <footer> elements within <article> or <section> elements or within
+// other regions belong to that content and are no regions, as in the ARIA mapping.
+// If there is no main content, the first <article> element or else the parent of the <p> elements with the most text
+// (see ScrapeArticle) which is not part of another region is used with confidence 0.3.
+func GetContentRegions(root *html.Node) Regions {
+	var regions Regions
+
+	var content []*html.Node // entered <article> and <section> elements and main content
+	var entered []Region     // entered regions
+	enter := func(n *html.Node) bool {
+		if n == root {
+			return true
+		}
+		if n.Type != html.ElementNode || isNonRenderedNode(n) {
+			return false
+		}
+		kind, confidence, ok := classifyRegion(n)
+		if ok && len(entered) > 0 && !isNestedRegion(entered[len(entered)-1].Kind, kind) {
+			ok = false
+		}
+		if ok && len(content) > 0 && (kind == RegionHeader || kind == RegionFooter) {
+			ok = false
+		}
+		if ok {
+			region := Region{Kind: kind, Node: n, Confidence: confidence}
+			regions.All = append(regions.All, region)
+			if kind == RegionNav {
+				return false
+			}
+			entered = append(entered, region)
+		}
+		if (ok && kind == RegionMain) || n.Data == "article" || n.Data == "section" {
+			content = append(content, n)
+		}
+		return true
+	}
+	leave := func(n *html.Node) {
+		if len(content) > 0 && content[len(content)-1] == n {
+			content = content[:len(content)-1]
+		}
+		if len(entered) > 0 && entered[len(entered)-1].Node == n {
+			entered = entered[:len(entered)-1]
+		}
+	}
+	if root != nil {
+		walkTree(root, enter, leave, nil)
+	}
+
+	if len(regions.ByKind(RegionMain)) == 0 {
+		if main := findUnclassifiedMainContent(root, regions); main != nil {
+			regions.All = append(regions.All, Region{Kind: RegionMain, Node: main, Confidence: 0.3})
+			sort.SliceStable(regions.All, func(i, j int) bool {
+				return CompareDocumentOrder(regions.All[i].Node, regions.All[j].Node) < 0
+			})
+		}
+	}
+	return regions
+}
+
+// findUnclassifiedMainContent
+// Returns the first <article> element or else the parent of the <p> elements with the most text outside of regions,
+// or nil, see GetContentRegions. Elements whose content is not rendered, e.g., inert templates, are skipped.
+func findUnclassifiedMainContent(root *html.Node, regions Regions) *html.Node {
+	if root == nil || isWithinRegion(root, regions) {
+		return nil
+	}
+	var article *html.Node
+	var paragraphs []*html.Node
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if isNonRenderedNode(n) {
+			return false
+		}
+		for _, region := range regions.All {
+			if region.Node == n {
+				return false
+			}
+		}
+		switch n.Data {
+		case "article":
+			if article == nil {
+				article = n
+			}
+		case "p":
+			paragraphs = append(paragraphs, n)
+		}
+		return true
+	})
+	if article != nil {
+		return article
+	}
+
+	textLengths := make(map[*html.Node]int)
+	var best *html.Node
+	for _, p := range paragraphs {
+		if p.Parent == nil {
+			continue
+		}
+		textLengths[p.Parent] += len(GetInnerText(p))
+		if best == nil || textLengths[p.Parent] > textLengths[best] {
+			best = p.Parent
+		}
+	}
+	return best
+}
+
+// isNestedRegion
+// Returns true if a region of kind inner may be nested in a region of kind outer, i.e., navigation in anything but
+// navigation and complementary content in main content.
+func isNestedRegion(outer, inner RegionKind) bool {
+	return (inner == RegionNav && outer != RegionNav) || (inner == RegionAside && outer == RegionMain)
+}
+
+// isWithinRegion
+// Returns true if node or one of its ancestors is the root of one of regions.
+func isWithinRegion(node *html.Node, regions Regions) bool {
+	for n := node; n != nil; n = n.Parent {
+		for _, region := range regions.All {
+			if region.Node == n {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// makeRegionExclusion
+// Returns a condition which is true for the root nodes of the regions of the given kinds in regions, or in
+// GetContentRegions(root) if regions is nil. Returns nil if kinds is empty.
+func makeRegionExclusion(root *html.Node, kinds []RegionKind, regions *Regions) func(n *html.Node) bool {
+	if len(kinds) == 0 {
+		return nil
+	}
+	if regions == nil {
+		classified := GetContentRegions(root)
+		regions = &classified
+	}
+	excluded := make(map[*html.Node]bool)
+	for _, node := range regions.Nodes(kinds...) {
+		excluded[node] = true
+	}
+	return func(n *html.Node) bool {
+		return excluded[n]
+	}
+}
@@ -0,0 +1,174 @@
+package html_util
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// semanticFixture
+// A page marked up with html5 sectioning elements and ARIA landmarks, including a header and footer within the article.
+const semanticFixture = `<html><body>
+<header id="header"><a href="/">Logo</a>
+<nav id="menu"><a href="/news">News</a> <a href="/sport">Sport</a></nav></header>
+<div role="navigation" id="crumbs"><a href="/news">News</a></div>
+<main id="main"><article id="article"><header id="article-header"><h1>Title</h1></header>
+<p>The <a href="/story">story</a> text.</p><aside id="note">A note.</aside>
+<footer id="article-footer"><a href="/tags/x">Tag</a></footer></article></main>
+<aside id="sidebar"><nav id="related"><a href="/related">Related</a></nav></aside>
+<footer id="footer"><nav id="legal"><a href="/imprint">Imprint</a></nav> (c) Example</footer>
+</body></html>`
+
+// divSoupFixture
+// A page without semantic markup, whose regions are only recognizable by class and id tokens.
+const divSoupFixture = `<html><body><div id="page">
+<div id="top" class="site-header"><div id="logo"><a href="/">Logo</a></div>
+<ul id="main-menu" class="menu"><li><a href="/news">News</a></li></ul></div>
+<div id="wrap"><div id="content" class="col-8 content"><div class="post"><h1>Title</h1>
+<p>The <a href="/story">story</a> text.</p><div id="entry-footer" class="entry-footer">Tags</div></div></div>
+<div id="sidebar" class="col-4 widgets"><div class="widget"><a href="/related">Related</a></div></div></div>
+<div id="bottom" class="footer"><div class="copyright">(c) Example</div></div>
+</div></body></html>`
+
+// regionsString
+// Formats the kind, the id of the root node, and the confidence of regions separated by spaces.
+func regionsString(regions Regions) string {
+	var parts []string
+	for _, region := range regions.All {
+		parts = append(parts, fmt.Sprintf("%v:%v:%v", region.Kind, nodeID(region.Node), region.Confidence))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestGetContentRegions(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"semantic", semanticFixture, "header:header:0.9 nav:menu:0.9 nav:crumbs:1 main:main:0.9 aside:note:0.9 " +
+			"aside:sidebar:0.9 nav:related:0.9 footer:footer:0.9 nav:legal:0.9"},
+		{"div soup", divSoupFixture,
+			"header:top:0.5 nav:main-menu:0.5 main:content:0.5 aside:sidebar:0.5 footer:bottom:0.5"},
+		{"landmark roles", `<div role="banner" class="footer" id="a"></div><nav role="main" id="b"><p>x</p></nav>` +
+			`<section role="contentinfo complementary" id="c"></section>`, "header:a:1 main:b:1 footer:c:1"},
+		{"table layout", `<table><tr><td id="nav" class="nav">n</td><td id="c" class="content"><p>x</p></td></tr></table>`,
+			"nav:nav:0.5 main:c:0.5"},
+		{"first kind wins", `<div id="main-menu"></div><div class="sidebar-content" id="s"></div>` +
+			`<div class="content"><div class="post-footer" id="pf"></div></div>`, "nav:main-menu:0.5 aside:s:0.5 main::0.5"},
+		{"small elements", `<span class="menu">m</span><a class="footer">f</a><p id="p">text</p>`, "main::0.3"},
+		{"article fallback", `<div class="footer" id="f">(c)</div><article id="a"><p>x</p></article>`,
+			"footer:f:0.5 main:a:0.3"},
+		{"paragraph fallback", `<div id="x"><p>Main text.</p><p>More.</p></div>` +
+			`<div class="footer" id="f"><p>A much longer footer paragraph with lots and lots of words.</p></div>`,
+			"main:x:0.3 footer:f:0.5"},
+		{"article in a region", `<aside id="s"><article><p>Teaser</p></article></aside><div id="x"><p>Text</p></div>`,
+			"aside:s:0.9 main:x:0.3"},
+		{"template", `<template><nav id="t"></nav></template><script>var nav;</script>`, ""},
+		{"fallback skips templates", `<template><article id="t"><p>Template text.</p></article></template>` +
+			`<div id="x"><p>Text</p></div><template><div><p>A much longer template paragraph.</p></div></template>`,
+			"main:x:0.3"},
+		{"empty", ``, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := regionsString(GetContentRegions(parseDocument(t, tt.html))); got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+
+	if regions := GetContentRegions(nil); len(regions.All) != 0 {
+		t.Errorf("got %v for nil", regionsString(regions))
+	}
+}
+
+func TestRegionsByKindAndNodes(t *testing.T) {
+	regions := GetContentRegions(parseDocument(t, semanticFixture))
+	got := regionsString(Regions{All: regions.ByKind(RegionNav)})
+	if want := "nav:menu:0.9 nav:crumbs:1 nav:related:0.9 nav:legal:0.9"; got != want {
+		t.Errorf("got navigation\n%v\nwant\n%v", got, want)
+	}
+	if got := nodeIDs(regions.Nodes(RegionFooter, RegionHeader)); got != "header footer" {
+		t.Errorf("got header and footer nodes %v", got)
+	}
+	if got := regions.Nodes(); len(got) != 0 {
+		t.Errorf("got %v nodes without kinds", len(got))
+	}
+	got = fmt.Sprint(RegionHeader, RegionNav, RegionMain, RegionAside, RegionFooter, RegionKind(9))
+	if want := "header nav main aside footer unknown"; got != want {
+		t.Errorf("got names %v, want %v", got, want)
+	}
+}
+
+func TestExcludeRegions(t *testing.T) {
+	chrome := []RegionKind{RegionHeader, RegionNav, RegionAside, RegionFooter}
+	for name, fixture := range map[string]string{"semantic": semanticFixture, "div soup": divSoupFixture} {
+		t.Run(name, func(t *testing.T) {
+			doc := parseDocument(t, fixture)
+
+			var urls []string
+			opts := URLExtractOptions{Attributes: []string{"href"}, AllowedSchemes: []string{""}, ExcludeRegions: chrome}
+			for _, extracted := range ExtractURLs(doc, nil, opts) {
+				urls = append(urls, extracted.URL.String())
+			}
+			// the footer of the article is part of the content in the semantic fixture
+			wantURLs := map[string]string{"semantic": "/story /tags/x", "div soup": "/story"}[name]
+			if got := strings.Join(urls, " "); got != wantURLs {
+				t.Errorf("got urls %v, want %v", got, wantURLs)
+			}
+
+			text, spans := ExtractTextWithOffsets(doc, VisibleTextOptions{ExcludeRegions: chrome})
+			if !strings.HasPrefix(text, "Title\nThe story text.") || strings.Contains(text, "News") ||
+				strings.Contains(text, "Related") || strings.Contains(text, "Example") {
+				t.Errorf("got text %q", text)
+			}
+			for _, span := range spans {
+				if isWithinRegion(span.Node, Regions{All: GetContentRegions(doc).ByKind(RegionNav)}) {
+					t.Errorf("got a span of navigation text %q", span.Node.Data)
+				}
+			}
+			got, all := CountWords(doc, VisibleTextOptions{ExcludeRegions: chrome}), CountWords(doc, VisibleTextOptions{})
+			if got >= all || got < 4 {
+				t.Errorf("counted %v words of %v", got, all)
+			}
+
+			// only the given kinds are excluded
+			text, _ = ExtractTextWithOffsets(doc, VisibleTextOptions{ExcludeRegions: []RegionKind{RegionNav}})
+			if !strings.Contains(text, "Logo") || strings.Contains(text, "Sport") || !strings.Contains(text, "Example") {
+				t.Errorf("got text %q without navigation", text)
+			}
+		})
+	}
+}
+
+func TestExcludeRegionsOverride(t *testing.T) {
+	doc := parseDocument(t, divSoupFixture)
+	regions := GetContentRegions(doc)
+	// the caller marks the logo as navigation and drops the sidebar
+	regions.All = append(regions.All, Region{Kind: RegionNav, Node: elementByID(t, doc, "logo"), Confidence: 1})
+	for k, region := range regions.All {
+		if region.Kind == RegionAside {
+			regions.All = append(regions.All[:k], regions.All[k+1:]...)
+			break
+		}
+	}
+
+	var urls []string
+	opts := URLExtractOptions{Attributes: []string{"href"}, AllowedSchemes: []string{""},
+		ExcludeRegions: []RegionKind{RegionNav, RegionAside}, Regions: &regions}
+	for _, extracted := range ExtractURLs(doc, nil, opts) {
+		urls = append(urls, extracted.URL.String())
+	}
+	if got := strings.Join(urls, " "); got != "/story /related" {
+		t.Errorf("got urls %v", got)
+	}
+	text, _ := ExtractTextWithOffsets(doc, VisibleTextOptions{ExcludeRegions: []RegionKind{RegionNav}, Regions: &regions})
+	if strings.Contains(text, "Logo") || !strings.Contains(text, "Related") {
+		t.Errorf("got text %q", text)
+	}
+	// without ExcludeRegions, Regions is ignored
+	if text, _ := ExtractTextWithOffsets(doc, VisibleTextOptions{Regions: &regions}); !strings.Contains(text, "Logo") {
+		t.Errorf("got text %q", text)
+	}
+}
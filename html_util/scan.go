@@ -0,0 +1,45 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+)
+
+// ScanAttributes
+// Calls f with every attribute of every element of the tree of root (including root) in document order until f returns
+// false, e.g., to collect attribute statistics over many documents.
+// key and val are the node's own html.Attribute fields, no copies or intermediate slices are made, so f must not
+// modify n.Attr. The content of <template> elements is scanned as well.
+func ScanAttributes(root *html.Node, f func(n *html.Node, key, val string) bool) {
+	stopped := false
+	walkTree(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		for k := range n.Attr {
+			if !f(n, n.Attr[k].Key, n.Attr[k].Val) {
+				stopped = true
+				return false
+			}
+		}
+		return true
+	}, nil, func() bool {
+		return stopped
+	})
+}
+
+// ScanTextNodes
+// Calls f with every text node of the tree of root (including root) and its data in document order until f returns
+// false, e.g., to build text length histograms over many documents.
+// data is the node's own Data field, no copies or intermediate slices are made. As with GetTextNodes, the text of
+// non-rendered elements like <script> is visited as well, check n.Parent in f to skip it.
+func ScanTextNodes(root *html.Node, f func(n *html.Node, data string) bool) {
+	stopped := false
+	walkTree(root, func(n *html.Node) bool {
+		if n.Type == html.TextNode && !f(n, n.Data) {
+			stopped = true
+		}
+		return !stopped
+	}, nil, func() bool {
+		return stopped
+	})
+}
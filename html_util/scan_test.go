@@ -0,0 +1,168 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+func TestScanAttributes(t *testing.T) {
+	doc := parseDocument(t, `<div id="d" class="c"><a href="/x" rel="next">x</a><template><i data-t="1"></i></template>`+
+		`<svg><use xlink:href="#i"></use></svg></div>`)
+	div := elementByID(t, doc, "d")
+
+	var got []string
+	ScanAttributes(div, func(n *html.Node, key, val string) bool {
+		got = append(got, n.Data+" "+key+"="+val)
+		return true
+	})
+	want := "div id=d, div class=c, a href=/x, a rel=next, i data-t=1, use href=#i"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("got\n%v\nwant\n%v", strings.Join(got, ", "), want)
+	}
+
+	// the strings are the fields of the node
+	ScanAttributes(div, func(n *html.Node, key, val string) bool {
+		for _, attr := range n.Attr {
+			if attr.Key == key && attr.Val != val {
+				t.Errorf("got %v=%v, the node has %v", key, val, attr.Val)
+			}
+		}
+		return true
+	})
+
+	// stops within the attributes of an element
+	got = nil
+	ScanAttributes(div, func(n *html.Node, key, val string) bool {
+		got = append(got, key)
+		return key != "href"
+	})
+	if strings.Join(got, " ") != "id class href" {
+		t.Errorf("got %v after stopping at href", got)
+	}
+
+	ScanAttributes(nil, func(n *html.Node, key, val string) bool {
+		t.Error("called for nil")
+		return true
+	})
+}
+
+func TestScanTextNodes(t *testing.T) {
+	doc := parseDocument(t, `<p>one <b>two</b></p><script>three()</script><!--no--><p>four</p>`)
+
+	var got []string
+	ScanTextNodes(doc, func(n *html.Node, data string) bool {
+		if n.Data != data || n.Type != html.TextNode {
+			t.Errorf("got %q for a %v node with data %q", data, n.Type, n.Data)
+		}
+		got = append(got, data)
+		return true
+	})
+	if strings.Join(got, "|") != "one |two|three()|four" {
+		t.Errorf("got %q", got)
+	}
+	texts := GetTextNodes(doc)
+	if len(texts) != len(got) {
+		t.Errorf("visited %v text nodes, GetTextNodes yields %v", len(got), len(texts))
+	}
+
+	got = nil
+	ScanTextNodes(doc, func(n *html.Node, data string) bool {
+		got = append(got, data)
+		return len(got) < 2
+	})
+	if strings.Join(got, "|") != "one |two" {
+		t.Errorf("got %q after stopping", got)
+	}
+
+	// root itself is visited
+	text := GetTextNodes(doc)[0]
+	visited := 0
+	ScanTextNodes(text, func(n *html.Node, data string) bool {
+		visited++
+		return n == text
+	})
+	if visited != 1 {
+		t.Errorf("visited %v nodes of a text node", visited)
+	}
+}
+
+func TestScanAllocations(t *testing.T) {
+	small := parseDocument(t, makeLargeDocument(1))
+	large := parseDocument(t, makeLargeDocument(1000))
+
+	count := 0
+	scanAttributes := func(root *html.Node) func() {
+		return func() {
+			ScanAttributes(root, func(n *html.Node, key, val string) bool {
+				count += len(val)
+				return true
+			})
+		}
+	}
+	scanTextNodes := func(root *html.Node) func() {
+		return func() {
+			ScanTextNodes(root, func(n *html.Node, data string) bool {
+				count += len(data)
+				return true
+			})
+		}
+	}
+	// the allocations of the traversal do not depend on the number of nodes
+	for name, scan := range map[string]func(root *html.Node) func(){"ScanAttributes": scanAttributes,
+		"ScanTextNodes": scanTextNodes} {
+		smallAllocs := testing.AllocsPerRun(10, scan(small))
+		largeAllocs := testing.AllocsPerRun(10, scan(large))
+		if largeAllocs != smallAllocs || largeAllocs > 2 {
+			t.Errorf("%v allocated %v times for a small and %v times for a large tree", name, smallAllocs, largeAllocs)
+		}
+	}
+}
+
+func BenchmarkScanAttributes(b *testing.B) {
+	doc := parseDocument(b, makeLargeDocument(10000))
+	b.Run("GetNodesByCondition", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			count := 0
+			for _, n := range GetNodesByCondition(doc, func(n *html.Node) bool { return n.Type == html.ElementNode }) {
+				for _, attr := range n.Attr {
+					count += len(attr.Val)
+				}
+			}
+		}
+	})
+	b.Run("ScanAttributes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			count := 0
+			ScanAttributes(doc, func(n *html.Node, key, val string) bool {
+				count += len(val)
+				return true
+			})
+		}
+	})
+}
+
+func BenchmarkScanTextNodes(b *testing.B) {
+	doc := parseDocument(b, makeLargeDocument(10000))
+	b.Run("GetTextNodes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			count := 0
+			for _, n := range GetTextNodes(doc) {
+				count += len(n.Data)
+			}
+		}
+	})
+	b.Run("ScanTextNodes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			count := 0
+			ScanTextNodes(doc, func(n *html.Node, data string) bool {
+				count += len(data)
+				return true
+			})
+		}
+	})
+}
@@ -0,0 +1,460 @@
+package html_util
+
+import (
+	"encoding/json"
+	"errors"
+	"golang.org/x/net/html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoArticle = errors.New("no article found")
+	ErrNoProduct = errors.New("no product found")
+)
+
+// Article
+// The main article of a page, see ScrapeArticle.
+type Article struct {
+	Title        string
+	Byline       string     // author names, "" if unknown
+	Published    time.Time  // publication time, zero if unknown
+	Text         string     // visible text of the content with blocks separated by newlines, see ExtractTextWithOffsets
+	HTML         string     // rendered html of the children of the content node
+	Images       []*url.URL // lead image first, then the images of the content in document order, without duplicates
+	CanonicalURL *url.URL   // nil if unknown
+	Node         *html.Node // the content node, nil if there is none
+	FromJSONLD   bool       // whether JSON-LD contributed to the article
+}
+
+// Product
+// The main product of a page, see ScrapeProduct.
+type Product struct {
+	Name         string
+	Description  string
+	SKU          string
+	Brand        string
+	Price        Money             // Valid is false if no price was found
+	Availability string            // schema.org availability without url prefix, e.g., "InStock", "" if unknown
+	Images       []*url.URL        // without duplicates
+	Specs        map[string]string // name value pairs of two-column tables and description lists
+	FromJSONLD   bool              // whether JSON-LD contributed to the product
+	FromMarkup   bool              // whether microdata contributed to the product
+}
+
+// ScrapeArticle
+// Extracts the main article of a page by combining the helpers of this package, e.g., as first step of a news crawler.
+// Each field is taken from the first source providing it: JSON-LD objects of type Article, NewsArticle, or BlogPosting,
+// then meta properties like og:title and article:published_time, then the document: the first <h1> or the <title>,
+// elements with rel="author" or a class "byline" or "author", and the first <time datetime>.
+// The content node is the first <article> element, else the <main> element, else the element with the most paragraph
+// text. URLs are resolved against base and the document's <base href>.
+// Returns ErrNoArticle if neither a title nor content was found.
+func ScrapeArticle(root *html.Node, base *url.URL) (Article, error) {
+	var article Article
+	documentBase := getDocumentBase(root, base)
+	meta := ExtractMetaProperties(root)
+	var images []string
+
+	for _, object := range findJSONLDObjectsInTree(root, "Article", "NewsArticle", "BlogPosting") {
+		article.FromJSONLD = true
+		article.Title = firstNonEmpty(article.Title, jsonLDString(object["headline"]), jsonLDString(object["name"]))
+		article.Byline = firstNonEmpty(article.Byline, strings.Join(jsonLDStrings(object["author"]), ", "))
+		if article.Published.IsZero() {
+			article.Published = parseScrapedTime(jsonLDString(object["datePublished"]))
+		}
+		images = append(images, jsonLDStrings(object["image"])...)
+		if article.CanonicalURL == nil {
+			article.CanonicalURL = resolveScrapedURL(firstNonEmpty(jsonLDString(object["mainEntityOfPage"]), jsonLDString(object["url"])), documentBase)
+		}
+	}
+
+	ogTitle, _ := meta.Get("og:title")
+	author, _ := meta.Get("author")
+	published, _ := meta.Get("article:published_time")
+	article.Title = firstNonEmpty(article.Title, ogTitle)
+	article.Byline = firstNonEmpty(article.Byline, author)
+	if article.Published.IsZero() {
+		article.Published = parseScrapedTime(published)
+	}
+	images = append(images, meta.Values("og:image")...)
+	if canonical := getCanonicalURL(root, documentBase); canonical != nil {
+		article.CanonicalURL = canonical // the document itself is authoritative
+	} else if ogURL, ok := meta.Get("og:url"); ok && article.CanonicalURL == nil {
+		article.CanonicalURL = resolveScrapedURL(ogURL, documentBase)
+	}
+
+	if article.Title == "" {
+		if h1 := GetNodeByCondition(root, MakeByTagNameCondition("h1")); h1 != nil {
+			article.Title = GetInnerText(h1)
+		} else if title := GetNodeByCondition(root, MakeByTagNameCondition("title")); title != nil {
+			article.Title = GetInnerText(title)
+		}
+	}
+	if article.Byline == "" {
+		if byline := GetNodeByCondition(root, isBylineNode); byline != nil {
+			article.Byline = GetInnerText(byline)
+		}
+	}
+
+	article.Node = findMainContent(root)
+	if article.Node != nil {
+		if article.Published.IsZero() {
+			if t := GetNodeByCondition(article.Node, MakeByTagNameCondition("time")); t != nil {
+				if datetime, err := GetAttributeByKey(t, "datetime"); err == nil {
+					article.Published = parseScrapedTime(datetime.Val)
+				}
+			}
+		}
+		article.Text, _ = ExtractTextWithOffsets(article.Node, VisibleTextOptions{})
+		var sb strings.Builder
+		for c := article.Node.FirstChild; c != nil; c = c.NextSibling {
+			_ = html.Render(&sb, c)
+		}
+		article.HTML = sb.String()
+		for _, img := range GetNodesByCondition(article.Node, MakeByTagNameCondition("img")) {
+			if src, err := GetAttributeByKey(img, "src"); err == nil {
+				images = append(images, src.Val)
+			}
+		}
+	}
+	article.Images = resolveScrapedURLs(images, documentBase)
+
+	if article.Title == "" && article.Node == nil {
+		return article, ErrNoArticle
+	}
+	return article, nil
+}
+
+// ScrapeProduct
+// Extracts the main product of a page by combining the helpers of this package, e.g., as first step of a price monitor.
+// Each field is taken from the first source providing it: JSON-LD objects of type Product and their offers, then
+// microdata of an itemscope with itemtype schema.org/Product, then meta properties like og:title and
+// product:price:amount, then the document: the first <h1> and the first element with a class containing "price" whose
+// text is a price, e.g., an inner <span class="price"> instead of its <div class="price-box">, struck-through old prices
+// in <del> or <s> elements are skipped.
+// Prices are parsed by ParseMoney. Specs are collected from all tables with two columns, see ParseHtmlTableWithOptions,
+// and from all <dl> elements. URLs are resolved against base and the document's <base href>.
+// Returns ErrNoProduct if neither a name nor a price was found.
+func ScrapeProduct(root *html.Node, base *url.URL) (Product, error) {
+	product := Product{Specs: make(map[string]string)}
+	documentBase := getDocumentBase(root, base)
+	var images []string
+
+	setPrice := func(amount, currency string, hints MoneyHints) {
+		if product.Price.Valid || strings.TrimSpace(amount) == "" {
+			return
+		}
+		hints.DefaultCurrency = strings.ToUpper(strings.TrimSpace(currency))
+		if price, err := ParseMoney(amount, hints); err == nil {
+			product.Price = price
+		}
+	}
+	setAvailability := func(availability string) {
+		if product.Availability == "" {
+			availability = strings.TrimSpace(availability)
+			product.Availability = availability[strings.LastIndex(availability, "/")+1:]
+		}
+	}
+	structuredPrice := MoneyHints{DecimalSeparator: '.'} // schema.org prices use '.' as decimal separator
+
+	for _, object := range findJSONLDObjectsInTree(root, "Product") {
+		product.FromJSONLD = true
+		product.Name = firstNonEmpty(product.Name, jsonLDString(object["name"]))
+		product.Description = firstNonEmpty(product.Description, jsonLDString(object["description"]))
+		product.SKU = firstNonEmpty(product.SKU, jsonLDString(object["sku"]))
+		product.Brand = firstNonEmpty(product.Brand, jsonLDString(object["brand"]))
+		images = append(images, jsonLDStrings(object["image"])...)
+		for _, offer := range jsonLDObjects(object["offers"]) {
+			setPrice(firstNonEmpty(jsonLDString(offer["price"]), jsonLDString(offer["lowPrice"])), jsonLDString(offer["priceCurrency"]), structuredPrice)
+			setAvailability(jsonLDString(offer["availability"]))
+		}
+	}
+
+	if item := GetNodeByCondition(root, isMicrodataProduct); item != nil {
+		product.FromMarkup = true
+		product.Name = firstNonEmpty(product.Name, getMicrodataValue(item, "name"))
+		product.Description = firstNonEmpty(product.Description, getMicrodataValue(item, "description"))
+		product.SKU = firstNonEmpty(product.SKU, getMicrodataValue(item, "sku"))
+		product.Brand = firstNonEmpty(product.Brand, getMicrodataValue(item, "brand"))
+		images = append(images, getMicrodataValue(item, "image"))
+		setPrice(getMicrodataValue(item, "price"), getMicrodataValue(item, "priceCurrency"), structuredPrice)
+		setAvailability(getMicrodataValue(item, "availability"))
+	}
+
+	meta := ExtractMetaProperties(root)
+	ogTitle, _ := meta.Get("og:title")
+	amount, _ := meta.Get("product:price:amount")
+	currency, _ := meta.Get("product:price:currency")
+	product.Name = firstNonEmpty(product.Name, ogTitle)
+	setPrice(amount, currency, structuredPrice)
+	images = append(images, meta.Values("og:image")...)
+
+	if product.Name == "" {
+		if h1 := GetNodeByCondition(root, MakeByTagNameCondition("h1")); h1 != nil {
+			product.Name = GetInnerText(h1)
+		}
+	}
+	for _, price := range GetNodesByCondition(root, isPriceNode) {
+		setPrice(GetInnerText(price), "", MoneyHints{})
+	}
+	product.Images = resolveScrapedURLs(images, documentBase)
+
+	for _, table := range GetNodesByCondition(root, MakeByTagNameCondition("table")) {
+		ht, err := ParseHtmlTableWithOptions(table, TableParseOptions{
+			HasIndexColumn:      true,
+			Suffix:              "_",
+			NormalizerFunc:      strings.TrimSpace,
+			AllowCompositeTexts: true,
+			CompositeDelimiter:  " ",
+		})
+		if err != nil || len(ht.Headers) != 2 {
+			continue
+		}
+		for i := 1; i < len(ht.Index); i++ {
+			if _, ok := product.Specs[ht.Index[i]]; !ok && ht.Index[i] != "" {
+				product.Specs[ht.Index[i]] = ht.TableData[i-1][0]
+			}
+		}
+	}
+	for _, dl := range GetNodesByCondition(root, MakeByTagNameCondition("dl")) {
+		term := ""
+		for _, c := range GetChildren(dl) {
+			switch {
+			case c.Type != html.ElementNode:
+			case c.Data == "dt":
+				term = GetInnerText(c)
+			case c.Data == "dd" && term != "":
+				if _, ok := product.Specs[term]; !ok {
+					product.Specs[term] = GetInnerText(c)
+				}
+			}
+		}
+	}
+
+	if product.Name == "" && !product.Price.Valid {
+		return product, ErrNoProduct
+	}
+	return product, nil
+}
+
+// findJSONLDObjectsInTree
+// Returns all objects of the given types of the JSON-LD scripts in the tree of root in document order, see
+// findJSONLDObjects. Scripts which are not valid JSON are skipped.
+func findJSONLDObjectsInTree(root *html.Node, typeNames ...string) []map[string]interface{} {
+	var objects []map[string]interface{}
+	for _, script := range GetNodesByCondition(root, isJSONLDScript) {
+		var data interface{}
+		if err := json.Unmarshal([]byte(getDataIslandContent(script)), &data); err != nil {
+			continue
+		}
+		for _, typeName := range typeNames {
+			objects = append(objects, findJSONLDObjects(data, typeName)...)
+		}
+	}
+	return objects
+}
+
+// jsonLDStrings
+// Returns the strings of a JSON-LD value: a string, a number, an object's name, url, or @id, or an array of those.
+func jsonLDStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if s := strings.TrimSpace(v); s != "" {
+			return []string{s}
+		}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case map[string]interface{}:
+		for _, key := range []string{"name", "url", "@id"} {
+			if s := jsonLDStrings(v[key]); len(s) > 0 {
+				return s[:1]
+			}
+		}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, jsonLDStrings(item)...)
+		}
+		return values
+	}
+	return nil
+}
+
+// jsonLDString
+// Returns the first string of a JSON-LD value, see jsonLDStrings, or "".
+func jsonLDString(value interface{}) string {
+	if values := jsonLDStrings(value); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// firstNonEmpty
+// Returns the first of values which is not blank, trimmed, or "".
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if !IsBlank(value) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// parseScrapedTime
+// Parses the common formats of publication times, e.g., RFC 3339, also with a zone offset without colon or without
+// seconds, or a date, returns the zero time if none matches.
+func parseScrapedTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05Z0700", "2006-01-02T15:04Z07:00", "2006-01-02T15:04:05",
+		"2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02", time.RFC1123Z, time.RFC1123} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// resolveScrapedURL
+// Returns s resolved against base, or nil if s is empty or unparseable.
+func resolveScrapedURL(s string, base *url.URL) *url.URL {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	return u
+}
+
+// resolveScrapedURLs
+// Returns the urls resolved against base in order without duplicates, empty and unparseable ones are skipped.
+func resolveScrapedURLs(urls []string, base *url.URL) []*url.URL {
+	var resolved []*url.URL
+	seen := make(map[string]bool, len(urls))
+	for _, s := range urls {
+		if u := resolveScrapedURL(s, base); u != nil && !seen[u.String()] {
+			seen[u.String()] = true
+			resolved = append(resolved, u)
+		}
+	}
+	return resolved
+}
+
+// getCanonicalURL
+// Returns the href of the first <link rel="canonical"> in the tree of root resolved against base, or nil.
+func getCanonicalURL(root *html.Node, base *url.URL) *url.URL {
+	link := GetNodeByCondition(root, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && node.Data == "link" &&
+			MakeByAttributeTokenCondition("rel", "canonical", false)(node) && hasAttribute(node, "href")
+	})
+	if link == nil {
+		return nil
+	}
+	href, _ := GetAttributeByKey(link, "href")
+	return resolveScrapedURL(href.Val, base)
+}
+
+// isBylineNode
+// Returns true for elements with rel="author" or a class "byline" or "author".
+func isBylineNode(node *html.Node) bool {
+	return node.Type == html.ElementNode && (MakeByAttributeTokenCondition("rel", "author", false)(node) ||
+		MakeByClassNameCondition("byline")(node) || MakeByClassNameCondition("author")(node))
+}
+
+// isPriceNode
+// Returns true for elements with a class containing "price" and a digit in their text, except for <del> and <s>.
+func isPriceNode(node *html.Node) bool {
+	if node.Type != html.ElementNode || node.Data == "del" || node.Data == "s" {
+		return false
+	}
+	class, err := GetAttributeByKey(node, "class")
+	return err == nil && strings.Contains(strings.ToLower(class.Val), "price") &&
+		strings.IndexFunc(GetInnerText(node), isASCIIDigit) != -1
+}
+
+// findMainContent
+// Returns the first <article> element in the tree of root, else the first <main> element, else the element whose
+// <p> children have the most text, or nil if there are no paragraphs.
+func findMainContent(root *html.Node) *html.Node {
+	if article := GetNodeByCondition(root, MakeByTagNameCondition("article")); article != nil {
+		return article
+	}
+	if main := GetNodeByCondition(root, MakeByTagNameCondition("main")); main != nil {
+		return main
+	}
+
+	textLengths := make(map[*html.Node]int)
+	var best *html.Node
+	for _, p := range GetNodesByCondition(root, MakeByTagNameCondition("p")) {
+		if p.Parent == nil {
+			continue
+		}
+		textLengths[p.Parent] += len(GetInnerText(p))
+		if best == nil || textLengths[p.Parent] > textLengths[best] {
+			best = p.Parent
+		}
+	}
+	return best
+}
+
+// isMicrodataProduct
+// Returns true for elements with an itemscope whose itemtype is schema.org/Product.
+func isMicrodataProduct(node *html.Node) bool {
+	if node.Type != html.ElementNode || !hasAttribute(node, "itemscope") {
+		return false
+	}
+	itemType, err := GetAttributeByKey(node, "itemtype")
+	return err == nil && strings.HasSuffix(strings.TrimRight(strings.TrimSpace(itemType.Val), "/"), "schema.org/Product")
+}
+
+// getMicrodataValue
+// Returns the value of the first element in the tree of item with the given itemprop: the name of a nested item, e.g.,
+// of a brand, its content attribute, the href, src, or datetime of <a>, <link>, <img>, and <time> elements, or its
+// visible text. The properties of nested items belong to them and are skipped, except for those of offers, e.g., the
+// name of the author of a review. Returns "" if there is none.
+func getMicrodataValue(item *html.Node, prop string) string {
+	isProp := MakeByAttributeTokenCondition("itemprop", prop, false)
+	isOffers := MakeByAttributeTokenCondition("itemprop", "offers", false)
+	var node *html.Node
+	WalkHtmlTree(item, func(n *html.Node) bool {
+		if node != nil || n.Type != html.ElementNode {
+			return false
+		}
+		if isProp(n) {
+			node = n
+			return false
+		}
+		return !hasAttribute(n, "itemscope") || isOffers(n)
+	})
+	if node == nil {
+		return ""
+	}
+	if hasAttribute(node, "itemscope") {
+		if name := getMicrodataValue(node, "name"); name != "" {
+			return name
+		}
+	}
+	keys := []string{"content"}
+	switch node.Data {
+	case "a", "link":
+		keys = append(keys, "href")
+	case "img", "source":
+		keys = append(keys, "src")
+	case "time":
+		keys = append(keys, "datetime")
+	}
+	for _, key := range keys {
+		if attr, err := GetAttributeByKey(node, key); err == nil {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return GetInnerText(node)
+}
@@ -0,0 +1,200 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// urlsString
+// Formats urls separated by spaces.
+func urlsString(urls []*url.URL) string {
+	var parts []string
+	for _, u := range urls {
+		parts = append(parts, u.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// newsFixture
+// A news page with JSON-LD, meta properties, a teaser sidebar before the article, and relative image urls.
+const newsFixture = `<!DOCTYPE html><html><head><title>City council | Example News</title>
+<link rel="canonical" href="/2024/03/city-council">
+<meta property="og:title" content="OG title">
+<meta property="og:image" content="https://cdn.example.com/og.jpg">
+<script type="application/ld+json">{"@context": "https://schema.org", "@graph": [
+	{"@type": "WebSite", "name": "Example News"},
+	{"@type": "NewsArticle", "headline": "City council approves budget",
+	 "author": [{"@type": "Person", "name": "Jane Roe"}, {"@type": "Person", "name": "John Doe"}],
+	 "datePublished": "2024-03-01T10:30:00+0100", "image": ["https://cdn.example.com/lead.jpg"],
+	 "mainEntityOfPage": {"@id": "https://example.com/ld-url"}}]}</script>
+</head><body>
+<aside><p>Teaser one with a lot of text to lure the paragraph heuristic.</p><p>Teaser two.</p></aside>
+<article><h1>City council approves budget</h1><p class="byline">By Jane Roe</p>
+<p>The council   approved the budget.</p><figure><img src="img/chart.png" alt="Chart"></figure>
+<p>It takes effect <b>in April</b>.</p><img src="https://cdn.example.com/lead.jpg"></article>
+</body></html>`
+
+func TestScrapeArticle(t *testing.T) {
+	base, _ := url.Parse("https://example.com/news/page")
+	article, err := ScrapeArticle(parseDocument(t, newsFixture), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Article{
+		Title:        "City council approves budget",
+		Byline:       "Jane Roe, John Doe",
+		Published:    time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		Text:         "City council approves budget\nBy Jane Roe\nThe council approved the budget.\nIt takes effect in April.",
+		CanonicalURL: &url.URL{Scheme: "https", Host: "example.com", Path: "/2024/03/city-council"},
+		FromJSONLD:   true,
+	}
+	if article.Title != want.Title || article.Byline != want.Byline || !article.Published.Equal(want.Published) ||
+		article.Text != want.Text || article.CanonicalURL.String() != want.CanonicalURL.String() || !article.FromJSONLD {
+		t.Errorf("got\n%+v\nwant\n%+v", article, want)
+	}
+	wantImages := "https://cdn.example.com/lead.jpg https://cdn.example.com/og.jpg https://example.com/news/img/chart.png"
+	if got := urlsString(article.Images); got != wantImages {
+		t.Errorf("got images\n%v\nwant\n%v", got, wantImages)
+	}
+	if article.Node == nil || article.Node.Data != "article" || !strings.HasPrefix(article.HTML, "<h1>City council") ||
+		!strings.Contains(article.HTML, `<img src="img/chart.png" alt="Chart"/>`) {
+		t.Errorf("got content node %v with html\n%v", nodeID(article.Node), article.HTML)
+	}
+}
+
+func TestScrapeArticleFallbacks(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/")
+	tests := []struct {
+		name                          string
+		html                          string
+		title, byline, published, url string
+		content                       string // tag of the content node
+	}{
+		{"meta properties", `<meta property="og:title" content="Meta title"><meta name="author" content="Ann">` +
+			`<meta property="article:published_time" content="2024-05-06T07:08+02:00">` +
+			`<meta property="og:url" content="/blog/meta"><main><h1>Heading</h1><p>Text</p></main>`,
+			"Meta title", "Ann", "2024-05-06T05:08:00Z", "https://example.com/blog/meta", "main"},
+		{"document", `<title>Page title</title><base href="/base/"><div><h1>Heading</h1>` +
+			`<a rel="author" href="/ann">Ann</a></div><div id="c"><p>Long paragraph text.</p><p>More text.</p>` +
+			`<time datetime="2024-01-02">Jan 2</time></div><div><p>Short.</p></div>`,
+			"Heading", "Ann", "2024-01-02T00:00:00Z", "", "div"},
+		{"title element", `<title> Page title </title><p>Text</p>`, "Page title", "", "", "", "body"},
+		{"invalid json-ld", `<script type="application/ld+json">{"@type": "Article", </script><h1>H</h1>`,
+			"H", "", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article, err := ScrapeArticle(parseDocument(t, tt.html), base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			published := ""
+			if !article.Published.IsZero() {
+				published = article.Published.UTC().Format(time.RFC3339)
+			}
+			canonical := ""
+			if article.CanonicalURL != nil {
+				canonical = article.CanonicalURL.String()
+			}
+			content := ""
+			if article.Node != nil {
+				content = article.Node.Data
+			}
+			if article.Title != tt.title || article.Byline != tt.byline || published != tt.published ||
+				canonical != tt.url || content != tt.content || article.FromJSONLD {
+				t.Errorf("got %q, %q, %q, %q, %q, want %q, %q, %q, %q, %q", article.Title, article.Byline, published,
+					canonical, content, tt.title, tt.byline, tt.published, tt.url, tt.content)
+			}
+		})
+	}
+
+	if _, err := ScrapeArticle(parseDocument(t, `<div>nothing</div>`), nil); !errors.Is(err, ErrNoArticle) {
+		t.Errorf("got error %v, want ErrNoArticle", err)
+	}
+}
+
+// productFixture
+// A product page with microdata whose review precedes the product name, nested offers and brand items, and specs.
+const productFixture = `<html><head><meta property="og:image" content="/og.jpg"></head><body>
+<div itemscope itemtype="https://schema.org/Product">
+<div itemprop="review" itemscope itemtype="https://schema.org/Review">
+	<span itemprop="author" itemscope itemtype="https://schema.org/Person"><span itemprop="name">Reviewer</span></span>
+	<p itemprop="description">Great kettle!</p></div>
+<h1 itemprop="name">Kettle 2000</h1><img itemprop="image" src="kettle.jpg">
+<div itemprop="brand" itemscope itemtype="https://schema.org/Brand"><meta itemprop="name" content="Acme"></div>
+<p itemprop="description">Boils water.</p><span itemprop="sku">K-2000</span>
+<div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+	<span class="price">19,90 €</span><meta itemprop="price" content="1299.50"><meta itemprop="priceCurrency" content="eur">
+	<link itemprop="availability" href="https://schema.org/OutOfStock"></div>
+</div>
+<table><tr><th>Volume</th><td>1.7 l</td></tr><tr><th>Power</th><td>2 <abbr>kW</abbr></td></tr></table>
+<table><tr><td>a</td><td>b</td><td>c</td></tr></table>
+<dl><dt>Color</dt><dd>Steel</dd><dt>Volume</dt><dd>ignored</dd></dl>
+</body></html>`
+
+func TestScrapeProduct(t *testing.T) {
+	base, _ := url.Parse("https://shop.example.com/p/")
+	product, err := ScrapeProduct(parseDocument(t, productFixture), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Name != "Kettle 2000" || product.Description != "Boils water." || product.SKU != "K-2000" ||
+		product.Brand != "Acme" || product.Availability != "OutOfStock" || !product.FromMarkup || product.FromJSONLD {
+		t.Errorf("got %+v", product)
+	}
+	if product.Price.Amount != 129950 || product.Price.Exponent != 2 || product.Price.Currency != "EUR" {
+		t.Errorf("got price %+v", product.Price)
+	}
+	wantImages := "https://shop.example.com/p/kettle.jpg https://shop.example.com/og.jpg"
+	if got, want := urlsString(product.Images), wantImages; got != want {
+		t.Errorf("got images\n%v\nwant\n%v", got, want)
+	}
+	if got, want := fmt.Sprint(product.Specs), "map[Color:Steel Power:2 kW Volume:1.7 l]"; got != want {
+		t.Errorf("got specs\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestScrapeProductSources(t *testing.T) {
+	tests := []struct {
+		name         string
+		html         string
+		wantName     string
+		wantPrice    string // amount, exponent, and currency
+		availability string
+	}{
+		{"json-ld", `<script type="application/ld+json">{"@type": "Product", "name": "Tea", "brand": {"name": "Leaf"},
+			"offers": [{"@type": "Offer", "price": 4.5, "priceCurrency": "USD",
+			"availability": "http://schema.org/InStock"}, {"price": "3.99"}]}</script>
+			<h1>Ignored</h1><span class="price">$9.99</span>`, "Tea", "45 1 USD", "InStock"},
+		{"aggregate offer", `<script type="application/ld+json">[{"@type": "Product", "name": "Cup",
+			"offers": {"@type": "AggregateOffer", "lowPrice": "1299", "priceCurrency": "JPY"}}]</script>`,
+			"Cup", "1299 0 JPY", ""},
+		{"meta properties", `<meta property="og:title" content="Mug"><meta property="product:price:amount" content="1,299.00">` +
+			`<meta property="product:price:currency" content="gbp">`, "Mug", "129900 2 GBP", ""},
+		{"document", `<h1> Plate </h1><div class="product-price-box"><span class="old-price">—</span>` +
+			`<span class="price">1.299,00 €</span></div>`, "Plate", "129900 2 EUR", ""},
+		{"old price", `<h1>Pot</h1><del class="price">$20.00</del> <span class="price">$15.00</span>`, "Pot", "1500 2 USD", ""},
+		{"name only", `<h1>Bowl</h1><span class="price">on request</span>`, "Bowl", "0 0 ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, err := ScrapeProduct(parseDocument(t, tt.html), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			price := fmt.Sprintf("%v %v %v", product.Price.Amount, product.Price.Exponent, product.Price.Currency)
+			if product.Name != tt.wantName || price != tt.wantPrice || product.Availability != tt.availability {
+				t.Errorf("got %q, %q, %q, want %q, %q, %q", product.Name, price, product.Availability, tt.wantName,
+					tt.wantPrice, tt.availability)
+			}
+		})
+	}
+
+	if _, err := ScrapeProduct(parseDocument(t, `<p>nothing</p>`), nil); !errors.Is(err, ErrNoProduct) {
+		t.Errorf("got error %v, want ErrNoProduct", err)
+	}
+}
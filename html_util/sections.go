@@ -0,0 +1,238 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"unicode/utf8"
+)
+
+// SectionOptions
+// Configures SplitIntoSections.
+type SectionOptions struct {
+	MaxRunes int  // maximum number of runes of a section's text, larger sections are split at paragraph boundaries, 0 for no limit
+	Flat     bool // if true, a section ends at the next heading of any level, i.e., sections do not contain their subsections
+}
+
+// Section
+// A heading and the visible text belonging to it, see SplitIntoSections.
+type Section struct {
+	Heading          string     // text of the heading, "" for the preamble before the first heading
+	Level            int        // level 1-6 of the heading, 0 for the preamble
+	HeadingNode      *html.Node // the <h1>-<h6> element, nil for the preamble
+	Text             string     // the visible text, paragraphs are separated by an empty line
+	RuneCount        int        // number of runes of Text
+	ApproxTokenCount int        // rough estimate of the number of tokens of Text, i.e., a quarter of RuneCount
+	FirstNode        *html.Node // first node contributing to Text, nil if Text is empty
+	LastNode         *html.Node // last node contributing to Text, nil if Text is empty
+	Part             int        // 0-based index of the part if the section was split because of SectionOptions.MaxRunes
+}
+
+// outlineItem
+// A heading (level > 0) or paragraph (level 0) of the linearized document.
+type outlineItem struct {
+	level    int
+	text     string
+	node     *html.Node // heading node or first node of the paragraph
+	lastNode *html.Node // last node of the paragraph
+}
+
+// linearizeOutline
+// Returns the headings and paragraphs of the tree of root in document order.
+func linearizeOutline(root *html.Node) []outlineItem {
+	var items []outlineItem
+	var paragraph strings.Builder
+	var first, last *html.Node
+
+	flush := func() {
+		if text := strings.Join(strings.Fields(paragraph.String()), " "); text != "" {
+			items = append(items, outlineItem{text: text, node: first, lastNode: last})
+		}
+		paragraph.Reset()
+		first, last = nil, nil
+	}
+	appendText := func(text string, node *html.Node) {
+		if strings.TrimSpace(text) != "" {
+			if first == nil {
+				first = node
+			}
+			last = node
+		}
+		paragraph.WriteString(text)
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			appendText(n.Data, n)
+			return
+		case html.ElementNode:
+			if isNonRenderedNode(n) {
+				return
+			}
+			if level := getHeadingLevel(n); level > 0 {
+				flush()
+				items = append(items, outlineItem{level: level, text: GetInnerText(n), node: n, lastNode: n})
+				return
+			}
+			if isPreformattedTag(n.Data) {
+				flush()
+				if text := GetInnerText(n); strings.TrimSpace(text) != "" {
+					items = append(items, outlineItem{text: text, node: n, lastNode: n})
+				}
+				return
+			}
+			if n.Data == "br" {
+				appendText(" ", n)
+				return
+			}
+			if isBlockTag(n.Data) {
+				flush()
+				defer flush()
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	if root != nil {
+		walk(root)
+	}
+	flush()
+
+	return items
+}
+
+// SplitIntoSections
+// Splits the visible text of the tree of root into sections along the document outline given by <h1>-<h6> elements,
+// e.g., as chunks for embedding pipelines.
+// Each heading starts a section containing everything until the next heading of equal or higher level, i.e., a section
+// includes its subsections (their headings become paragraphs) unless SectionOptions.Flat is set.
+// Text before the first heading forms a preamble section with level 0 if it is not empty.
+// Sections exceeding SectionOptions.MaxRunes are split at paragraph boundaries into multiple parts, a single paragraph
+// exceeding the limit is not split.
+func SplitIntoSections(root *html.Node, opts SectionOptions) []Section {
+	items := linearizeOutline(root)
+
+	var sections []Section
+	firstHeading := len(items)
+	for k, item := range items {
+		if item.level > 0 {
+			firstHeading = k
+			break
+		}
+	}
+	if firstHeading > 0 {
+		sections = append(sections, makeSections(Section{}, items[:firstHeading], opts.MaxRunes)...)
+	}
+
+	for k := firstHeading; k < len(items); k++ {
+		heading := items[k]
+		if heading.level == 0 {
+			continue
+		}
+		end := k + 1
+		for end < len(items) && (items[end].level == 0 || (!opts.Flat && items[end].level > heading.level)) {
+			end++
+		}
+		sections = append(sections, makeSections(Section{
+			Heading:     heading.text,
+			Level:       heading.level,
+			HeadingNode: heading.node,
+		}, items[k+1:end], opts.MaxRunes)...)
+	}
+
+	return sections
+}
+
+// makeSections
+// Fills the text of section with the paragraphs, splitting it into multiple parts if it exceeds maxRunes.
+func makeSections(section Section, paragraphs []outlineItem, maxRunes int) []Section {
+	var sections []Section
+	var texts []string
+	var first, last *html.Node
+	runeCount := 0
+
+	emit := func() {
+		part := section
+		part.Text = strings.Join(texts, "\n\n")
+		part.RuneCount = utf8.RuneCountInString(part.Text)
+		part.ApproxTokenCount = (part.RuneCount + 3) / 4
+		part.FirstNode, part.LastNode = first, last
+		part.Part = len(sections)
+		sections = append(sections, part)
+		texts, first, last, runeCount = nil, nil, nil, 0
+	}
+
+	for _, paragraph := range paragraphs {
+		length := utf8.RuneCountInString(paragraph.text)
+		if maxRunes > 0 && len(texts) > 0 && runeCount+2+length > maxRunes {
+			emit()
+		}
+		if len(texts) > 0 {
+			runeCount += 2 // paragraph separator
+		}
+		texts = append(texts, paragraph.text)
+		runeCount += length
+		if first == nil {
+			first = paragraph.node
+		}
+		last = paragraph.lastNode
+	}
+	if len(texts) > 0 || len(sections) == 0 {
+		emit()
+	}
+
+	// drop an empty preamble
+	if section.Level == 0 && len(sections) == 1 && sections[0].Text == "" {
+		return nil
+	}
+	return sections
+}
+
+// nextNodeInDocumentOrder
+// Returns the node following n in document order within the tree of root, skipping the subtree of n if skipChildren is
+// true, or nil if n is the last node.
+func nextNodeInDocumentOrder(n, root *html.Node, skipChildren bool) *html.Node {
+	if !skipChildren && n.FirstChild != nil {
+		return n.FirstChild
+	}
+	for ; n != nil && n != root; n = n.Parent {
+		if n.NextSibling != nil {
+			return n.NextSibling
+		}
+	}
+	return nil
+}
+
+// FindNodeAfterHeading
+// Locates the first <h1>-<h6> element in the tree of root whose text equals headingText, compared case-insensitively
+// after collapsing whitespace, and returns the first node following it in document order for which cond yields true,
+// e.g., the table under the heading 'Quarterly results', even if it is nested in other elements.
+// The search stops at the next heading of the same or a higher level, i.e., the end of the heading's section.
+// Returns nil if the heading or a matching node does not exist.
+func FindNodeAfterHeading(root *html.Node, headingText string, cond func(node *html.Node) bool) *html.Node {
+	heading := GetNodeByCondition(root, func(node *html.Node) bool {
+		return getHeadingLevel(node) > 0 && equalFoldCollapsed(GetInnerText(node), headingText)
+	})
+	if heading == nil {
+		return nil
+	}
+	level := getHeadingLevel(heading)
+
+	for n := nextNodeInDocumentOrder(heading, root, true); n != nil; n = nextNodeInDocumentOrder(n, root, false) {
+		if l := getHeadingLevel(n); l > 0 && l <= level {
+			return nil
+		}
+		if cond(n) {
+			return n
+		}
+	}
+	return nil
+}
+
+// FindTableAfterHeading
+// Returns the first <table> element following the heading with the given text, see FindNodeAfterHeading.
+func FindTableAfterHeading(root *html.Node, headingText string) *html.Node {
+	return FindNodeAfterHeading(root, headingText, MakeByTagNameCondition("table"))
+}
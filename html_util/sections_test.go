@@ -0,0 +1,112 @@
+package html_util
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+const sectionsDocument = `<body><p>Intro <b>text</b></p><script>ignored()</script>` +
+	`<h1>Title</h1><p>First paragraph.</p>` +
+	`<h2>Sub <i>one</i></h2><p>Alpha</p><div>Beta<br>Gamma</div>` +
+	`<h2>Sub two</h2><pre>a  b</pre>` +
+	`<h1>Next</h1></body>`
+
+// sectionSummary
+// Formats the heading, level, part, and text of each section, one per line.
+func sectionSummary(sections []Section) string {
+	var lines []string
+	for _, s := range sections {
+		lines = append(lines, fmt.Sprintf("%v|%v|%v|%q", s.Heading, s.Level, s.Part, s.Text))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestSplitIntoSections(t *testing.T) {
+	doc := parseDocument(t, sectionsDocument)
+
+	sections := SplitIntoSections(doc, SectionOptions{})
+	want := strings.Join([]string{
+		`|0|0|"Intro text"`,
+		`Title|1|0|"First paragraph.\n\nSub one\n\nAlpha\n\nBeta Gamma\n\nSub two\n\na  b"`,
+		`Sub one|2|0|"Alpha\n\nBeta Gamma"`,
+		`Sub two|2|0|"a  b"`,
+		`Next|1|0|""`,
+	}, "\n")
+	if got := sectionSummary(sections); got != want {
+		t.Errorf("got sections\n%v\nwant\n%v", got, want)
+	}
+
+	title := sections[1]
+	if title.RuneCount != len(title.Text) || title.ApproxTokenCount != (title.RuneCount+3)/4 {
+		t.Errorf("counts = %v, %v for %v runes", title.RuneCount, title.ApproxTokenCount, len(title.Text))
+	}
+	if title.HeadingNode == nil || title.HeadingNode.Data != "h1" || title.FirstNode == nil || title.FirstNode.Data != "First paragraph." ||
+		title.LastNode == nil || title.LastNode.Data != "pre" {
+		t.Errorf("node range of Title = %v, %v, %v", title.HeadingNode, title.FirstNode, title.LastNode)
+	}
+	if next := sections[4]; next.FirstNode != nil || next.LastNode != nil {
+		t.Errorf("empty section has nodes %v, %v", next.FirstNode, next.LastNode)
+	}
+
+	flat := SplitIntoSections(doc, SectionOptions{Flat: true})
+	if got := sectionSummary(flat[1:2]); got != `Title|1|0|"First paragraph."` {
+		t.Errorf("flat Title section = %v", got)
+	}
+}
+
+func TestSplitIntoSectionsMaxRunes(t *testing.T) {
+	doc := parseDocument(t, `<h1>H</h1><p>aaaa</p><p>bbbb</p><p>cccccccccccc</p><p>d</p>`)
+
+	want := strings.Join([]string{
+		`H|1|0|"aaaa\n\nbbbb"`,
+		`H|1|1|"cccccccccccc"`, // a single paragraph exceeding the limit is not split
+		`H|1|2|"d"`,
+	}, "\n")
+	if got := sectionSummary(SplitIntoSections(doc, SectionOptions{MaxRunes: 10})); got != want {
+		t.Errorf("got sections\n%v\nwant\n%v", got, want)
+	}
+	if sections := SplitIntoSections(parseDocument(t, `<p> </p>`), SectionOptions{}); len(sections) != 0 {
+		t.Errorf("got %v sections for an empty document, want none", len(sections))
+	}
+}
+
+func TestFindNodeAfterHeading(t *testing.T) {
+	doc := parseDocument(t, `<h1>Report</h1>`+
+		`<div class="header"><h2> Quarterly <em>results</em></h2></div>`+
+		`<p>intro</p><div class="wrapper"><div><table id="q"><tr><td>1</td></tr></table></div></div>`+
+		`<h3>Details</h3><ul id="details"><li>a</li></ul><table id="d"></table>`+
+		`<h2>Outlook</h2><ul id="outlook"></ul>`+
+		`<h2>Empty</h2><p>nothing</p><h1>Appendix</h1><table id="appendix"></table>`)
+
+	tests := []struct {
+		heading string
+		cond    func(node *html.Node) bool
+		want    string
+	}{
+		{"quarterly   RESULTS", MakeByTagNameCondition("table"), "q"},  // nested in a div, heading in a wrapper
+		{"Quarterly results", MakeByTagNameCondition("ul"), "details"}, // subsections belong to the section
+		{"Details", MakeByTagNameCondition("table"), "d"},
+		{"Outlook", MakeByTagNameCondition("table"), ""},          // the next h2 ends the section
+		{"Empty", MakeByTagNameCondition("table"), ""},            // so does a higher level
+		{"Appendix", MakeByTagNameCondition("table"), "appendix"}, // up to the end of the document
+		{"Missing", MakeByTagNameCondition("table"), ""},
+		{"Report", MakeByIdCondition("outlook"), "outlook"}, // h1 spans all h2 sections
+	}
+	for _, tt := range tests {
+		if got := nodeID(FindNodeAfterHeading(doc, tt.heading, tt.cond)); got != tt.want {
+			t.Errorf("FindNodeAfterHeading(%q) = %q, want %q", tt.heading, got, tt.want)
+		}
+	}
+
+	if got := nodeID(FindTableAfterHeading(doc, "quarterly results")); got != "q" {
+		t.Errorf("FindTableAfterHeading = %q, want q", got)
+	}
+
+	// the search is restricted to the tree of root
+	wrapper := GetElementNodeByTagNameAndClass("div", "header", doc)
+	if got := FindTableAfterHeading(wrapper, "Quarterly results"); got != nil {
+		t.Errorf("found %v outside of root", nodeID(got))
+	}
+}
@@ -0,0 +1,206 @@
+package html_util
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SelectorSuggestion
+// A candidate condition identifying a target node, see SuggestSelectors.
+type SelectorSuggestion struct {
+	Selector  string                     // CSS-like description of the condition, or the node path for path-based suggestions
+	Condition func(node *html.Node) bool // the ready-made condition, e.g., for GetNodesByCondition
+	Matches   int                        // number of nodes within the root matching Condition
+	Stability float64                    // estimated stability in [0, 1], low for generated class names and positional paths
+}
+
+// suggestionMaxClasses
+// Number of classes of the target which are combined pairwise by SuggestSelectors.
+const suggestionMaxClasses = 6
+
+// ignoredSuggestionAttributes
+// Attributes which are too volatile or too generic to identify elements.
+var ignoredSuggestionAttributes = map[string]bool{
+	"class": true, "id": true, "style": true, "href": true, "src": true, "srcset": true, "alt": true, "title": true,
+	"value": true, "width": true, "height": true,
+}
+
+// SuggestSelectors
+// Proposes conditions which identify target within root, e.g., to find a robust way to locate an element when writing
+// a scraper: its id, its tag with one or two of its classes, its tag with one of its other attributes, and its node
+// path. Suggestions matching only target come first, then suggestions are ordered by their number of matches, their
+// stability, i.e., semantic names are preferred over generated ones like 'css-1x2kq9', and the length of the selector.
+// Returns nil if target is not an element within root.
+func SuggestSelectors(target *html.Node, root *html.Node) []SelectorSuggestion {
+	if target == nil || target.Type != html.ElementNode || !isAncestorOrSelf(root, target) {
+		return nil
+	}
+	tag := target.Data
+	byTag := MakeByTagNameCondition(tag)
+
+	var suggestions []SelectorSuggestion
+	add := func(selector string, stability float64, cond func(node *html.Node) bool) {
+		if !cond(target) {
+			return
+		}
+		suggestions = append(suggestions, SelectorSuggestion{
+			Selector:  selector,
+			Condition: cond,
+			Matches:   len(GetNodesByCondition(root, cond)),
+			Stability: stability,
+		})
+	}
+
+	if id, err := GetAttributeByKey(target, "id"); err == nil && strings.TrimSpace(id.Val) != "" {
+		add("#"+id.Val, nameStability(id.Val), MakeByIdCondition(id.Val))
+	}
+
+	var classes []string
+	if attr, err := GetAttributeByKey(target, "class"); err == nil {
+		classes = strings.Fields(attr.Val)
+	}
+	if len(classes) > suggestionMaxClasses {
+		classes = classes[:suggestionMaxClasses]
+	}
+	for i, a := range classes {
+		byA := MakeByClassNameCondition(a)
+		add(tag+"."+a, nameStability(a), func(node *html.Node) bool {
+			return byTag(node) && byA(node)
+		})
+		for _, b := range classes[i+1:] {
+			byB := MakeByClassNameCondition(b)
+			add(tag+"."+a+"."+b, math.Min(nameStability(a), nameStability(b)), func(node *html.Node) bool {
+				return byTag(node) && byA(node) && byB(node)
+			})
+		}
+	}
+
+	for _, attr := range target.Attr {
+		if attr.Namespace != "" || ignoredSuggestionAttributes[attr.Key] || strings.HasPrefix(attr.Key, "on") {
+			continue
+		}
+		byAttr := MakeByAttributeNameAndValueCondition(attr.Key, attr.Val)
+		add(fmt.Sprintf("%v[%v=%q]", tag, attr.Key, attr.Val), nameStability(attr.Val), func(node *html.Node) bool {
+			return byTag(node) && byAttr(node)
+		})
+	}
+
+	path := GetNodePath(target)
+	add(path, 0.2, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && hasSameAncestry(node, target) && GetNodePath(node) == path
+	})
+
+	sort.SliceStable(suggestions, func(a, b int) bool {
+		sa, sb := suggestions[a], suggestions[b]
+		if (sa.Matches == 1) != (sb.Matches == 1) {
+			return sa.Matches == 1
+		}
+		if sa.Matches != sb.Matches {
+			return sa.Matches < sb.Matches
+		}
+		if sa.Stability != sb.Stability {
+			return sa.Stability > sb.Stability
+		}
+		return len(sa.Selector) < len(sb.Selector)
+	})
+	return suggestions
+}
+
+// nameStability
+// Estimates how stable a class name, id, or attribute value is in [0, 1]. Names which look generated by build tools,
+// e.g., 'css-1x2kq9' or 'sc-bdVaJa', or which are very long get a low score.
+func nameStability(name string) float64 {
+	if looksGenerated(name) {
+		return 0.1
+	}
+	if len(name) > 40 {
+		return 0.5
+	}
+	return 1
+}
+
+// generatedNamePrefixes
+// Prefixes of class names generated by CSS-in-JS libraries and component frameworks, e.g., 'sc-htpNat' of
+// styled-components.
+var generatedNamePrefixes = []string{"sc-", "jsx-", "svelte-", "emotion-"}
+
+// looksGenerated
+// Returns true for names with the prefix of a CSS-in-JS library, names containing a segment which mixes letters and
+// digits, consists of many digits, or alternates letter case in short runs like a hash (unlike camelCase), and names
+// whose characters have a high entropy.
+func looksGenerated(name string) bool {
+	for _, prefix := range generatedNamePrefixes {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			return true
+		}
+	}
+	segments := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	for _, segment := range segments {
+		if len(segment) < 5 {
+			continue
+		}
+		letters, digits, shortRuns := 0, 0, 0
+		runes := []rune(segment)
+		for k, r := range runes {
+			switch {
+			case unicode.IsDigit(r):
+				digits++
+			case unicode.IsLetter(r):
+				letters++
+				// an uppercase letter followed by exactly one lowercase letter, e.g., 'Va' in 'bdVaJa'
+				if unicode.IsUpper(r) && k+1 < len(runes) && unicode.IsLower(runes[k+1]) &&
+					(k+2 == len(runes) || !unicode.IsLower(runes[k+2])) {
+					shortRuns++
+				}
+			}
+		}
+		if letters > 0 && digits > 0 && digits*3 >= len(segment)/2 {
+			return true
+		}
+		if letters == 0 && digits >= 6 {
+			return true
+		}
+		if shortRuns >= 2 {
+			return true
+		}
+	}
+	return len(name) >= 8 && shannonEntropy(name) > 3.5 && !strings.ContainsAny(name, "-_")
+}
+
+// shannonEntropy
+// Returns the shannon entropy of the characters of s in bits.
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// hasSameAncestry
+// Returns true if a and b and their ancestors up to the document node have the same types and tags, a cheap
+// precondition of equal node paths, see GetNodePath.
+func hasSameAncestry(a, b *html.Node) bool {
+	isEnd := func(n *html.Node) bool {
+		return n == nil || n.Type == html.DocumentNode
+	}
+	for ; !isEnd(a) && !isEnd(b); a, b = a.Parent, b.Parent {
+		if a.Type != b.Type || a.Data != b.Data {
+			return false
+		}
+	}
+	return isEnd(a) && isEnd(b)
+}
@@ -0,0 +1,108 @@
+package html_util
+
+import (
+	"testing"
+)
+
+func TestSuggestSelectors(t *testing.T) {
+	doc := parseDocument(t, `<div class="flex p-4 css-1x2kq9">`+
+		`<div class="card flex p-4"><h2 class="flex text-sm sc-bdVaJa product-title">A</h2><span class="price">1</span></div>`+
+		`<div class="card flex p-4"><h2 class="flex text-sm sc-htpNat">B</h2><span class="price" data-testid="price-b">2</span></div>`+
+		`</div>`)
+	target := GetElementNodeByTagNameAndClass("h2", "product-title", doc)
+
+	suggestions := SuggestSelectors(target, doc)
+	if len(suggestions) == 0 {
+		t.Fatal("no suggestions")
+	}
+	top := suggestions[0]
+	if top.Selector != "h2.product-title" || top.Matches != 1 || top.Stability != 1 {
+		t.Errorf("top suggestion is %v with %v matches and stability %v, want h2.product-title", top.Selector,
+			top.Matches, top.Stability)
+	}
+	if matches := GetNodesByCondition(doc, top.Condition); len(matches) != 1 || matches[0] != target {
+		t.Errorf("the condition of the top suggestion matches %v nodes, want only the target", len(matches))
+	}
+	for k, s := range suggestions {
+		if got := len(GetNodesByCondition(doc, s.Condition)); got != s.Matches || !s.Condition(target) {
+			t.Errorf("suggestion %v reports %v matches, its condition matches %v nodes", s.Selector, s.Matches, got)
+		}
+		if k > 0 && suggestions[k-1].Matches > s.Matches {
+			t.Errorf("suggestion %v with %v matches is ranked after %v with %v", s.Selector, s.Matches,
+				suggestions[k-1].Selector, suggestions[k-1].Matches)
+		}
+	}
+
+	// without a semantic class, the attribute is preferred over the generated class and the path
+	price := GetNodeByCondition(doc, MakeByAttributeNameAndValueCondition("data-testid", "price-b"))
+	if top := SuggestSelectors(price, doc)[0]; top.Selector != `span[data-testid="price-b"]` {
+		t.Errorf("top suggestion for the second price is %v", top.Selector)
+	}
+	second := GetElementNodeByTagNameAndClass("h2", "sc-htpNat", doc)
+	if top := SuggestSelectors(second, doc)[0]; top.Selector != "/html/body/div/div[2]/h2" {
+		t.Errorf("top suggestion for the second title is %v, want its path", top.Selector)
+	}
+}
+
+func TestSuggestSelectorsInvalidTarget(t *testing.T) {
+	doc := parseDocument(t, `<p id="a">x</p>`)
+	other := parseDocument(t, `<p id="b">y</p>`)
+	p := elementByID(t, doc, "a")
+	if s := SuggestSelectors(nil, doc); s != nil {
+		t.Errorf("got %v for nil", s)
+	}
+	if s := SuggestSelectors(p.FirstChild, doc); s != nil {
+		t.Errorf("got %v for a text node", s)
+	}
+	if s := SuggestSelectors(p, other); s != nil {
+		t.Errorf("got %v for a target outside root", s)
+	}
+	if top := SuggestSelectors(p, doc)[0]; top.Selector != "#a" {
+		t.Errorf("top suggestion is %v, want #a", top.Selector)
+	}
+}
+
+func TestLooksGenerated(t *testing.T) {
+	tests := map[string]bool{
+		"product-title": false,
+		"navBarItem":    false,
+		"productTitle":  false,
+		"getURLParams":  false,
+		"sc-htpNat":     true,
+		"p-4":           false,
+		"col-md-6":      false,
+		"footer_2023":   false,
+		"css-1x2kq9":    true,
+		"sc-bdVaJa":     true,
+		"_3xk9Qm2":      true,
+		"item-12345678": true,
+		"aZ8kQ2pL0vX":   true,
+		"searchresults": false,
+	}
+	for name, want := range tests {
+		if got := looksGenerated(name); got != want {
+			t.Errorf("looksGenerated(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestSuggestSelectorsPathConditionOnOtherDocument(t *testing.T) {
+	doc := parseDocument(t, `<div><p>a</p><p>b</p></div>`)
+	target := GetElementNodesByTagName("p", doc)[1]
+	var path SelectorSuggestion
+	for _, s := range SuggestSelectors(target, doc) {
+		if s.Selector == GetNodePath(target) {
+			path = s
+		}
+	}
+	if path.Condition == nil {
+		t.Fatal("no path suggestion")
+	}
+
+	// the condition matches the same position in a fresh parse of a changed page
+	other := parseDocument(t, `<div><p>x</p><p id="match">y</p><p>z</p></div><p>w</p>`)
+	matches := GetNodesByCondition(other, path.Condition)
+	if len(matches) != 1 || nodeID(matches[0]) != "match" {
+		t.Errorf("path condition matches %v nodes in another document, want the second <p>", len(matches))
+	}
+}
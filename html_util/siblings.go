@@ -0,0 +1,49 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+)
+
+// GetFollowingSiblings
+// Returns the element siblings after node for which cond yields true in document order, like XPath's
+// following-sibling axis, e.g., the <dd> elements following a <dt>. Text, comment, and other non-element siblings are
+// skipped. Returns at most limit siblings, all of them if limit is not positive.
+func GetFollowingSiblings(node *html.Node, cond func(node *html.Node) bool, limit int) []*html.Node {
+	if node == nil {
+		return nil
+	}
+	return collectSiblings(node.NextSibling, func(n *html.Node) *html.Node { return n.NextSibling }, cond, limit)
+}
+
+// GetPrecedingSiblings
+// Same as GetFollowingSiblings but returns the element siblings before node, nearest first, like XPath's
+// preceding-sibling axis, i.e., limit 1 yields the closest matching sibling before node.
+func GetPrecedingSiblings(node *html.Node, cond func(node *html.Node) bool, limit int) []*html.Node {
+	if node == nil {
+		return nil
+	}
+	return collectSiblings(node.PrevSibling, func(n *html.Node) *html.Node { return n.PrevSibling }, cond, limit)
+}
+
+// collectSiblings
+// Returns up to limit element nodes for which cond yields true, starting at start and moving on via next.
+func collectSiblings(start *html.Node, next func(n *html.Node) *html.Node, cond func(node *html.Node) bool, limit int) []*html.Node {
+	var siblings []*html.Node
+	for s := start; s != nil && (limit <= 0 || len(siblings) < limit); s = next(s) {
+		if s.Type == html.ElementNode && cond(s) {
+			siblings = append(siblings, s)
+		}
+	}
+	return siblings
+}
+
+// NextMatchingSibling
+// Returns the first element sibling after node for which cond yields true, or nil, skipping non-element siblings,
+// e.g., the value of a label/value pair: NextMatchingSibling(dt, MakeByTagNameCondition("dd")) where dt was found by
+// MakeByTextContainsCondition("SKU").
+func NextMatchingSibling(node *html.Node, cond func(node *html.Node) bool) *html.Node {
+	if siblings := GetFollowingSiblings(node, cond, 1); len(siblings) > 0 {
+		return siblings[0]
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+// siblingsFixture
+// Label/value pairs as <dt>/<dd> and as sibling spans, with text nodes and comments between the siblings.
+const siblingsFixture = `<dl id="dl">
+	<dt id="brand">Brand</dt> <!-- brand --> <dd id="acme">Acme</dd>
+	<dt id="sku"><b>SKU</b>:</dt>
+	text <!-- no value yet -->
+	<dd id="k2000">K-2000</dd><dd id="k2001">K-2001</dd>
+	<dt id="color">Color</dt>
+</dl>
+<p id="p"><span id="l1">Weight</span> <span id="v1">1 kg</span>, <span id="l2">Width</span><!--x--><span id="v2">2 m</span></p>`
+
+// nodeIDs
+// Returns the ids of nodes separated by spaces.
+func nodeIDs(nodes []*html.Node) string {
+	var ids []string
+	for _, n := range nodes {
+		ids = append(ids, nodeID(n))
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestGetFollowingAndPrecedingSiblings(t *testing.T) {
+	doc := parseDocument(t, siblingsFixture)
+	all := func(n *html.Node) bool { return true }
+	dd := MakeByTagNameCondition("dd")
+	tests := []struct {
+		name string
+		got  []*html.Node
+		want string
+	}{
+		{"following", GetFollowingSiblings(elementByID(t, doc, "brand"), all, 0), "acme sku k2000 k2001 color"},
+		{"following dd", GetFollowingSiblings(elementByID(t, doc, "sku"), dd, 0), "k2000 k2001"},
+		{"following limit", GetFollowingSiblings(elementByID(t, doc, "sku"), dd, 1), "k2000"},
+		{"following negative limit", GetFollowingSiblings(elementByID(t, doc, "brand"), dd, -1), "acme k2000 k2001"},
+		{"following last", GetFollowingSiblings(elementByID(t, doc, "color"), all, 0), ""},
+		{"preceding", GetPrecedingSiblings(elementByID(t, doc, "color"), all, 0), "k2001 k2000 sku acme brand"},
+		{"preceding dd", GetPrecedingSiblings(elementByID(t, doc, "color"), dd, 2), "k2001 k2000"},
+		{"preceding first", GetPrecedingSiblings(elementByID(t, doc, "brand"), all, 0), ""},
+		{"spans", GetFollowingSiblings(elementByID(t, doc, "l1"), MakeByTagNameCondition("span"), 0), "v1 l2 v2"},
+		{"nil", GetFollowingSiblings(nil, all, 0), ""},
+		{"nil preceding", GetPrecedingSiblings(nil, all, 0), ""},
+	}
+	for _, tt := range tests {
+		if got := nodeIDs(tt.got); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// the condition only sees elements
+	GetFollowingSiblings(elementByID(t, doc, "brand"), func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			t.Errorf("the condition got a %v node", n.Type)
+		}
+		return true
+	}, 0)
+}
+
+func TestNextMatchingSibling(t *testing.T) {
+	doc := parseDocument(t, siblingsFixture)
+	dl := elementByID(t, doc, "dl")
+	tests := []struct {
+		label, tag string
+		want       string
+	}{
+		{"sku", "dd", "k2000"},
+		{"Brand", "dd", "acme"},
+		{"weight", "span", "v1"},
+		{"WIDTH", "span", "v2"},
+		{"color", "dd", ""},
+	}
+	for _, tt := range tests {
+		// the innermost label, as the ancestors of a match match as well
+		labels := GetNodesByCondition(doc.FirstChild, MakeByTextContainsCondition(tt.label))
+		label := labels[len(labels)-1]
+		if label.Data == "b" {
+			label = label.Parent
+		}
+		if got := nodeID(NextMatchingSibling(label, MakeByTagNameCondition(tt.tag))); got != tt.want {
+			t.Errorf("value of %q: got %q, want %q", tt.label, got, tt.want)
+		}
+	}
+
+	if n := NextMatchingSibling(nil, MakeByTagNameCondition("dd")); n != nil {
+		t.Errorf("got %v for nil", nodeID(n))
+	}
+	if !MakeByTextContainsCondition("k-2000")(dl) || MakeByTextContainsCondition("k-2000")(dl.FirstChild) {
+		t.Error("MakeByTextContainsCondition matched a text node or missed an ancestor")
+	}
+}
@@ -0,0 +1,295 @@
+package html_util
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"sort"
+	"strings"
+)
+
+// repeatedStructureSimilarity
+// Minimum similarity in [0, 1] two sibling elements need to be considered items of the same repeated structure.
+const repeatedStructureSimilarity = 0.5
+
+// RepeatedGroup
+// A set of sibling elements sharing a similar structure, e.g., product cards, search results, or comments.
+type RepeatedGroup struct {
+	Container  *html.Node   // common parent of all items
+	Items      []*html.Node // the similar sibling elements in document order
+	TextLength int          // total length of all content text within the items
+}
+
+// structureSignature
+// Describes the shape of an element for comparison with its siblings.
+type structureSignature struct {
+	tag       string
+	classes   map[string]int
+	childTags map[string]int
+}
+
+func makeStructureSignature(node *html.Node) structureSignature {
+	sig := structureSignature{
+		tag:       node.Data,
+		classes:   make(map[string]int),
+		childTags: make(map[string]int),
+	}
+	if attr, err := GetAttributeByKey(node, "class"); err == nil {
+		for _, className := range strings.Fields(attr.Val) {
+			sig.classes[strings.ToLower(className)] = 1
+		}
+	}
+	for _, c := range GetChildren(node) {
+		if c.Type == html.ElementNode {
+			sig.childTags[c.Data]++
+		}
+	}
+	return sig
+}
+
+// multisetSimilarity
+// Returns the weighted jaccard index of two multisets. Two empty sets are considered equal.
+func multisetSimilarity(a, b map[string]int) float64 {
+	intersection, union := 0, 0
+	for key, countA := range a {
+		countB := b[key]
+		if countA < countB {
+			intersection += countA
+			union += countB
+		} else {
+			intersection += countB
+			union += countA
+		}
+	}
+	for key, countB := range b {
+		if _, ok := a[key]; !ok {
+			union += countB
+		}
+	}
+	if union == 0 {
+		return 1
+	}
+	return float64(intersection) / float64(union)
+}
+
+// similarity
+// Returns 0 for different tags, else the mean of class and child tag similarity.
+func (sig structureSignature) similarity(other structureSignature) float64 {
+	if sig.tag != other.tag {
+		return 0
+	}
+	return (multisetSimilarity(sig.classes, other.classes) + multisetSimilarity(sig.childTags, other.childTags)) / 2
+}
+
+// FindRepeatedStructures
+// Returns all groups of at least minCount sibling elements within the tree of root (including root) which share a
+// similar structure, i.e., the same tag, overlapping class sets, and similar child tags.
+// Small differences between items, e.g., an additional badge element or class, are tolerated.
+// The groups are ranked by item count and then by text volume, both descending.
+func FindRepeatedStructures(root *html.Node, minCount int) []RepeatedGroup {
+	var groups []RepeatedGroup
+
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode && n.Type != html.DocumentNode {
+			return true
+		}
+
+		var representatives []structureSignature
+		var clusters [][]*html.Node
+		for _, c := range GetChildren(n) {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			sig := makeStructureSignature(c)
+
+			best, bestSimilarity := -1, 0.0
+			for k, rep := range representatives {
+				if s := rep.similarity(sig); s >= repeatedStructureSimilarity && s > bestSimilarity {
+					best, bestSimilarity = k, s
+				}
+			}
+
+			if best == -1 {
+				representatives = append(representatives, sig)
+				clusters = append(clusters, []*html.Node{c})
+			} else {
+				clusters[best] = append(clusters[best], c)
+			}
+		}
+
+		for _, items := range clusters {
+			if len(items) < minCount || len(items) < 2 {
+				continue
+			}
+			textLength := 0
+			for _, item := range items {
+				for _, t := range GetTextNodesByCondition(item, isContentText) {
+					textLength += len(strings.TrimSpace(t.Data))
+				}
+			}
+			groups = append(groups, RepeatedGroup{
+				Container:  n,
+				Items:      items,
+				TextLength: textLength,
+			})
+		}
+
+		return true
+	})
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].Items) != len(groups[j].Items) {
+			return len(groups[i].Items) > len(groups[j].Items)
+		}
+		return groups[i].TextLength > groups[j].TextLength
+	})
+
+	return groups
+}
+
+// structureSummaryTopClasses
+// Number of most frequent class names reported by SummarizeStructure.
+const structureSummaryTopClasses = 10
+
+// TagClassCount
+// Number of elements with the same tag and set of classes, see StructureSummary.
+type TagClassCount struct {
+	Tag     string // tag of the elements
+	Classes string // sorted class names of the elements joined by '.', empty for elements without classes
+	Count   int    // number of elements
+}
+
+// ClassCount
+// Number of elements with a class name, see StructureSummary.
+type ClassCount struct {
+	Class string
+	Count int
+}
+
+// StructureSummary
+// Structural overview of a document, see SummarizeStructure.
+type StructureSummary struct {
+	Levels     [][]TagClassCount // Levels[d] contains the tag/class combinations at depth d below the root, most frequent first
+	TopClasses []ClassCount      // most frequent class names of the whole document, most frequent first
+	Tables     []string          // paths of all <table> elements, see GetNodePath
+	Forms      []string          // paths of all <form> elements
+	Lists      []string          // paths of all <ul>, <ol>, and <dl> elements
+}
+
+// SummarizeStructure
+// Returns a structural overview of the document below and including root, e.g., to explore the layout of an unknown
+// page: the tag/class combinations per depth level up to maxDepth (root has depth 0, maxDepth < 0 means unlimited),
+// the most frequent class names, and the locations of tables, forms, and lists.
+func SummarizeStructure(root *html.Node, maxDepth int) StructureSummary {
+	var summary StructureSummary
+	if root == nil {
+		return summary
+	}
+
+	var levelCounts []map[[2]string]int
+	classCounts := make(map[string]int)
+	var walk func(n *html.Node, depth int)
+	walk = func(n *html.Node, depth int) {
+		if n.Type == html.ElementNode {
+			var classes []string
+			if attr, err := GetAttributeByKey(n, "class"); err == nil {
+				classes = strings.Fields(attr.Val)
+				sort.Strings(classes)
+				for _, class := range classes {
+					classCounts[class]++
+				}
+			}
+			if maxDepth < 0 || depth <= maxDepth {
+				for len(levelCounts) <= depth {
+					levelCounts = append(levelCounts, make(map[[2]string]int))
+				}
+				levelCounts[depth][[2]string{n.Data, strings.Join(classes, ".")}]++
+			}
+			switch n.Data {
+			case "table":
+				summary.Tables = append(summary.Tables, GetNodePath(n))
+			case "form":
+				summary.Forms = append(summary.Forms, GetNodePath(n))
+			case "ul", "ol", "dl":
+				summary.Lists = append(summary.Lists, GetNodePath(n))
+			}
+		}
+		if n.Type == html.ElementNode || n.Type == html.DocumentNode {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c, depth+1)
+			}
+		}
+	}
+	if root.Type == html.DocumentNode {
+		for c := root.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, 0)
+		}
+	} else {
+		walk(root, 0)
+	}
+
+	for _, counts := range levelCounts {
+		level := make([]TagClassCount, 0, len(counts))
+		for key, count := range counts {
+			level = append(level, TagClassCount{Tag: key[0], Classes: key[1], Count: count})
+		}
+		sort.Slice(level, func(a, b int) bool {
+			if level[a].Count != level[b].Count {
+				return level[a].Count > level[b].Count
+			}
+			if level[a].Tag != level[b].Tag {
+				return level[a].Tag < level[b].Tag
+			}
+			return level[a].Classes < level[b].Classes
+		})
+		summary.Levels = append(summary.Levels, level)
+	}
+
+	for class, count := range classCounts {
+		summary.TopClasses = append(summary.TopClasses, ClassCount{Class: class, Count: count})
+	}
+	sort.Slice(summary.TopClasses, func(a, b int) bool {
+		if summary.TopClasses[a].Count != summary.TopClasses[b].Count {
+			return summary.TopClasses[a].Count > summary.TopClasses[b].Count
+		}
+		return summary.TopClasses[a].Class < summary.TopClasses[b].Class
+	})
+	if len(summary.TopClasses) > structureSummaryTopClasses {
+		summary.TopClasses = summary.TopClasses[:structureSummaryTopClasses]
+	}
+	return summary
+}
+
+// String
+// Renders the summary as compact indented report.
+func (summary StructureSummary) String() string {
+	var sb strings.Builder
+	for depth, level := range summary.Levels {
+		fmt.Fprintf(&sb, "depth %v:\n", depth)
+		for _, tc := range level {
+			name := tc.Tag
+			if tc.Classes != "" {
+				name += "." + tc.Classes
+			}
+			fmt.Fprintf(&sb, "  %v x%v\n", name, tc.Count)
+		}
+	}
+	if len(summary.TopClasses) > 0 {
+		sb.WriteString("classes:\n")
+		for _, cc := range summary.TopClasses {
+			fmt.Fprintf(&sb, "  %v x%v\n", cc.Class, cc.Count)
+		}
+	}
+	for _, section := range []struct {
+		name  string
+		paths []string
+	}{{"tables", summary.Tables}, {"forms", summary.Forms}, {"lists", summary.Lists}} {
+		if len(section.paths) == 0 {
+			continue
+		}
+		sb.WriteString(section.name + ":\n")
+		for _, path := range section.paths {
+			sb.WriteString("  " + path + "\n")
+		}
+	}
+	return sb.String()
+}
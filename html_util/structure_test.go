@@ -0,0 +1,92 @@
+package html_util
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFindRepeatedStructures(t *testing.T) {
+	doc := parseDocument(t, `<div id="grid">`+
+		`<div class="card"><h3>A</h3><p>first product</p></div>`+
+		`<div class="card new"><h3>B</h3><p>second</p><span class="badge">new</span></div>`+
+		`<div class="card"><h3>C</h3><p>third</p></div>`+
+		`<aside class="ad"><img src="x.png"></aside>`+
+		`</div>`+
+		`<ul><li>1</li><li>2</li></ul>`)
+
+	groups := FindRepeatedStructures(doc, 2)
+	if len(groups) != 2 {
+		t.Fatalf("got %v groups, want 2", len(groups))
+	}
+	cards := groups[0]
+	if len(cards.Items) != 3 || cards.Container != GetNodeByCondition(doc, MakeByIdCondition("grid")) {
+		t.Errorf("first group has %v items in %v, want the 3 cards", len(cards.Items), cards.Container)
+	}
+	if want := len("Afirst product") + len("Bsecondnew") + len("Cthird"); cards.TextLength != want {
+		t.Errorf("got text length %v, want %v", cards.TextLength, want)
+	}
+	if len(groups[1].Items) != 2 || groups[1].Items[0].Data != "li" {
+		t.Errorf("second group has %v items, want the 2 list items", len(groups[1].Items))
+	}
+
+	if groups := FindRepeatedStructures(doc, 3); len(groups) != 1 {
+		t.Errorf("got %v groups of at least 3 items, want 1", len(groups))
+	}
+}
+
+func TestSummarizeStructure(t *testing.T) {
+	doc := parseDocument(t, `<body><div class="card b a"><ul><li>1</li></ul></div><div class="a b card"><p>x</p></div>`+
+		`<div><form><table><tr><td>1</td></tr></table></form><ol class="a"></ol></div></body>`)
+
+	want := `depth 0:
+  html x1
+depth 1:
+  body x1
+  head x1
+depth 2:
+  div.a.b.card x2
+  div x1
+classes:
+  a x3
+  b x2
+  card x2
+tables:
+  /html/body/div[3]/form/table
+forms:
+  /html/body/div[3]/form
+lists:
+  /html/body/div[1]/ul
+  /html/body/div[3]/ol
+`
+	if got := SummarizeStructure(doc, 2).String(); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	// a subtree root has depth 0, negative depths are unlimited
+	body := GetElementNodesByTagName("body", doc)[0]
+	summary := SummarizeStructure(body, -1)
+	if len(summary.Levels) != 7 || summary.Levels[0][0] != (TagClassCount{Tag: "body", Count: 1}) {
+		t.Errorf("got levels %v, want 7 levels starting at body", summary.Levels)
+	}
+	if got := SummarizeStructure(nil, 1).String(); got != "" {
+		t.Errorf("summary of nil is %q", got)
+	}
+}
+
+func TestSummarizeStructureTopClasses(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < structureSummaryTopClasses+5; i++ {
+		for k := 0; k <= i; k++ {
+			fmt.Fprintf(&sb, `<span class="c%02d"></span>`, i)
+		}
+	}
+	summary := SummarizeStructure(parseDocument(t, sb.String()), 0)
+	if len(summary.TopClasses) != structureSummaryTopClasses {
+		t.Fatalf("got %v classes, want %v", len(summary.TopClasses), structureSummaryTopClasses)
+	}
+	first, last := summary.TopClasses[0], summary.TopClasses[structureSummaryTopClasses-1]
+	if first != (ClassCount{Class: "c14", Count: 15}) || last != (ClassCount{Class: "c05", Count: 6}) {
+		t.Errorf("got top classes %v, want c14 to c05", summary.TopClasses)
+	}
+}
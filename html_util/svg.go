@@ -0,0 +1,202 @@
+package html_util
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// xmlNamespaces
+// Maps the namespaces of html.Node to their XML namespace URIs.
+var xmlNamespaces = map[string]string{
+	"":      "http://www.w3.org/1999/xhtml",
+	"svg":   "http://www.w3.org/2000/svg",
+	"math":  "http://www.w3.org/1998/Math/MathML",
+	"xlink": "http://www.w3.org/1999/xlink",
+}
+
+var (
+	xmlTextEscaper      = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xmlAttributeEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+)
+
+// MakeForeignContentCondition
+// Returns a condition matching elements in the SVG or MathML namespace.
+func MakeForeignContentCondition() func(node *html.Node) bool {
+	return func(node *html.Node) bool {
+		return node.Type == html.ElementNode && (node.Namespace == "svg" || node.Namespace == "math")
+	}
+}
+
+// isSVGRoot
+// Returns true for <svg> elements in the SVG namespace.
+func isSVGRoot(node *html.Node) bool {
+	return node.Type == html.ElementNode && node.Namespace == "svg" && node.Data == "svg"
+}
+
+// ExtractSVGs
+// Returns all outermost inline <svg> elements below and including root in document order, i.e., <svg> elements
+// nested in another <svg> element are not returned separately.
+func ExtractSVGs(root *html.Node) []*html.Node {
+	var svgs []*html.Node
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if isSVGRoot(n) {
+			svgs = append(svgs, n)
+			return false
+		}
+		return true
+	})
+	return svgs
+}
+
+// RenderSVG
+// Renders the <svg> element n as standalone, well-formed SVG document, e.g., to store inline icons as files.
+// Declarations of the SVG namespace and the xlink namespace, if used, are added if missing, as are namespace
+// declarations for embedded HTML and MathML content. Elements without children are self-closing and the casing of the
+// element and attribute names adjusted by html.Parse, e.g., viewBox, is kept. Attributes with an undeclared prefix
+// are omitted since they are not well-formed.
+func RenderSVG(n *html.Node) (string, error) {
+	if n == nil {
+		return "", errors.New("node is nil")
+	}
+	if !isSVGRoot(n) {
+		return "", errors.New("node is not an svg node")
+	}
+	var sb strings.Builder
+	sb.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	writeXML(&sb, n, "", true)
+	return sb.String(), nil
+}
+
+// writeXML
+// Writes node and its subtree as XML to sb. parentNamespace is the default namespace in scope, isRoot is true for the
+// document element.
+func writeXML(sb *strings.Builder, node *html.Node, parentNamespace string, isRoot bool) {
+	switch node.Type {
+	case html.TextNode:
+		sb.WriteString(xmlTextEscaper.Replace(toXMLChars(node.Data)))
+		return
+	case html.CommentNode:
+		comment := toXMLChars(node.Data)
+		for strings.Contains(comment, "--") {
+			comment = strings.ReplaceAll(comment, "--", "- -")
+		}
+		if strings.HasSuffix(comment, "-") {
+			comment += " "
+		}
+		sb.WriteString("<!--")
+		sb.WriteString(comment)
+		sb.WriteString("-->")
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if !isXMLName(node.Data) {
+		// not well-formed, keep the content
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			writeXML(sb, c, parentNamespace, false)
+		}
+		return
+	}
+
+	sb.WriteString("<")
+	sb.WriteString(node.Data)
+	if isRoot || node.Namespace != parentNamespace {
+		sb.WriteString(" xmlns=\"")
+		sb.WriteString(xmlNamespaces[node.Namespace])
+		sb.WriteString("\"")
+	}
+	if isRoot && usesXLink(node) {
+		sb.WriteString(" xmlns:xlink=\"")
+		sb.WriteString(xmlNamespaces["xlink"])
+		sb.WriteString("\"")
+	}
+	written := make(map[string]bool, len(node.Attr))
+	for _, attr := range node.Attr {
+		key := attr.Key
+		switch {
+		case attr.Namespace == "xmlns" || (attr.Namespace == "" && (key == "xmlns" || strings.HasPrefix(key, "xmlns:"))):
+			continue // declarations are written above
+		case !isXMLName(attr.Key):
+			continue
+		case attr.Namespace != "":
+			key = attr.Namespace + ":" + attr.Key
+		case strings.Contains(key, ":"):
+			continue
+		}
+		if written[key] {
+			continue // html.Parse keeps duplicates, browsers use the first one
+		}
+		written[key] = true
+		sb.WriteString(" ")
+		sb.WriteString(key)
+		sb.WriteString("=\"")
+		sb.WriteString(xmlAttributeEscaper.Replace(toXMLChars(attr.Val)))
+		sb.WriteString("\"")
+	}
+	if node.FirstChild == nil {
+		sb.WriteString("/>")
+		return
+	}
+	sb.WriteString(">")
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		writeXML(sb, c, node.Namespace, false)
+	}
+	sb.WriteString("</")
+	sb.WriteString(node.Data)
+	sb.WriteString(">")
+}
+
+// usesXLink
+// Returns true if any element of the tree of node has an attribute in the xlink namespace.
+func usesXLink(node *html.Node) bool {
+	return GetNodeByCondition(node, func(n *html.Node) bool {
+		for _, attr := range n.Attr {
+			if attr.Namespace == "xlink" {
+				return true
+			}
+		}
+		return false
+	}) != nil
+}
+
+// isXMLName
+// Returns true if s is a valid XML name without prefix. Only letters, digits, '_', '-', and '.' are accepted, which
+// is slightly stricter than the XML specification.
+func isXMLName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// toXMLChars
+// Removes the characters from s which must not occur in XML documents, e.g., control characters, and replaces invalid
+// UTF-8 by U+FFFD.
+func toXMLChars(s string) string {
+	isValid := func(r rune) bool {
+		return r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r <= 0xD7FF) || (r >= 0xE000 && r <= 0xFFFD) ||
+			(r >= 0x10000 && r <= 0x10FFFF)
+	}
+	if utf8.ValidString(s) && strings.IndexFunc(s, func(r rune) bool { return !isValid(r) }) < 0 {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if isValid(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
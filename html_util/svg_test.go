@@ -0,0 +1,146 @@
+package html_util
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// xmlElementNames
+// Decodes s with encoding/xml in strict mode and returns the start elements as "namespace:name" separated by spaces.
+// Returns an error if s is not well-formed.
+func xmlElementNames(s string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(s))
+	var names []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return strings.Join(names, " "), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			names = append(names, start.Name.Space+":"+start.Name.Local)
+		}
+	}
+}
+
+func TestExtractSVGsAndForeignContentCondition(t *testing.T) {
+	doc := parseDocument(t, `<p>a</p><svg id="a"><g><svg id="nested"></svg></g></svg>`+
+		`<div><svg id="b"></svg><math><mi>x</mi></math></div>`)
+
+	var ids []string
+	for _, svg := range ExtractSVGs(doc) {
+		ids = append(ids, nodeID(svg))
+	}
+	if got := strings.Join(ids, " "); got != "a b" {
+		t.Errorf("ExtractSVGs returned %v, want a b", got)
+	}
+	if svgs := ExtractSVGs(elementByID(t, doc, "nested")); len(svgs) != 1 {
+		t.Errorf("ExtractSVGs does not include the start node, got %v svgs", len(svgs))
+	}
+
+	var tags []string
+	for _, n := range GetNodesByCondition(doc, MakeForeignContentCondition()) {
+		tags = append(tags, n.Data)
+	}
+	if got := strings.Join(tags, " "); got != "svg g svg svg math mi" {
+		t.Errorf("foreign content is %v, want svg g svg svg math mi", got)
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	doc := parseDocument(t, `<svg viewbox="0 0 10 10" class="icon"><defs><lineargradient id="g"></lineargradient></defs>`+
+		`<use xlink:href="#g" data-x='a "b" &amp; <c>'/><text>1 &lt; 2</text><!-- a--b -->`+
+		`<foreignObject><div>html<br></div></foreignObject><path d="M0 0"/></svg>`)
+	svg := ExtractSVGs(doc)[0]
+
+	got, err := RenderSVG(svg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" +
+		`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 10 10" ` +
+		`class="icon"><defs><linearGradient id="g"/></defs>` +
+		`<use xlink:href="#g" data-x="a &quot;b&quot; &amp; &lt;c&gt;"/><text>1 &lt; 2</text><!-- a- -b -->` +
+		`<foreignObject><div xmlns="http://www.w3.org/1999/xhtml">html<br/></div></foreignObject><path d="M0 0"/></svg>`
+	if got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	names, err := xmlElementNames(got)
+	if err != nil {
+		t.Fatalf("rendered svg is not well-formed: %v", err)
+	}
+	wantNames := "http://www.w3.org/2000/svg:svg http://www.w3.org/2000/svg:defs " +
+		"http://www.w3.org/2000/svg:linearGradient http://www.w3.org/2000/svg:use http://www.w3.org/2000/svg:text " +
+		"http://www.w3.org/2000/svg:foreignObject http://www.w3.org/1999/xhtml:div http://www.w3.org/1999/xhtml:br " +
+		"http://www.w3.org/2000/svg:path"
+	if names != wantNames {
+		t.Errorf("xml parser found elements\n%v\nwant\n%v", names, wantNames)
+	}
+}
+
+func TestRenderSVGKeepsExistingDeclarationsOnce(t *testing.T) {
+	doc := parseDocument(t, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" `+
+		`foo:bar="x"><image xlink:href="a.png"/></svg>`)
+	got, err := RenderSVG(ExtractSVGs(doc)[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(got, "xmlns=") != 1 || strings.Count(got, "xmlns:xlink=") != 1 {
+		t.Errorf("namespace declarations are duplicated or missing: %v", got)
+	}
+	if strings.Contains(got, "foo:bar") {
+		t.Errorf("attribute with undeclared prefix was rendered: %v", got)
+	}
+	if _, err := xmlElementNames(got); err != nil {
+		t.Errorf("rendered svg is not well-formed: %v", err)
+	}
+}
+
+func TestRenderSVGDropsMalformedXML(t *testing.T) {
+	doc := parseDocument(t, "<svg 0=\"x\" a=\"1\" a=\"2\" viewbox=\"0\" viewBox=\"1\"><g\"x>a\x01b</g\"x>"+
+		"<!--c-->--><!--d---></svg>")
+	got, err := RenderSVG(ExtractSVGs(doc)[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" +
+		`<svg xmlns="http://www.w3.org/2000/svg" a="1" viewBox="0">ab<!--c-->--&gt;<!--d- --></svg>`
+	if got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	if _, err := xmlElementNames(got); err != nil {
+		t.Errorf("rendered svg is not well-formed: %v", err)
+	}
+}
+
+func TestRenderSVGErrors(t *testing.T) {
+	if _, err := RenderSVG(nil); err == nil {
+		t.Error("RenderSVG(nil) returned no error")
+	}
+	doc := parseDocument(t, `<div><svg></svg></div>`)
+	if _, err := RenderSVG(GetElementNodesByTagName("div", doc)[0]); err == nil {
+		t.Error("RenderSVG returned no error for a <div> element")
+	}
+}
+
+func FuzzRenderSVG(f *testing.F) {
+	f.Add(`<svg viewBox="0 0 1 1"><circle r="1"/></svg>`)
+	f.Add(`<svg><text>a<!--b--></text><foreignObject><p x:y="1">c</p></foreignObject></svg>`)
+	f.Add(`<svg><a xlink:href="#" xml:lang="en">-</a><math><mi>x</mi></math></svg>`)
+	f.Fuzz(func(t *testing.T, data string) {
+		for _, svg := range ExtractSVGs(parseFuzzDocument(t, data)) {
+			s, err := RenderSVG(svg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := xmlElementNames(s); err != nil {
+				t.Fatalf("RenderSVG produced malformed XML: %v\n%v", err, s)
+			}
+		}
+	})
+}
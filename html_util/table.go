@@ -0,0 +1,557 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rbnbr/go-utility/pkg/function"
+	"golang.org/x/net/html"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// isEmptyCell
+// Default definition of an empty cell, i.e., a blank cell, see IsBlank.
+func isEmptyCell(s string) bool {
+	return IsBlank(s)
+}
+
+// PruneEmptyRows
+// Removes all data rows in which at least a fraction of threshold of the cells (excluding the index) are empty after
+// trimming blank characters, see IsBlank. A threshold of 1.0 removes only rows which are entirely empty.
+// Returns the number of removed rows. Index is kept synchronized.
+func (ht *HtmlTable) PruneEmptyRows(threshold float64) int {
+	return ht.PruneEmptyRowsWithEmptyFunc(threshold, isEmptyCell)
+}
+
+// PruneEmptyRowsWithEmptyFunc
+// Same as PruneEmptyRows but isEmpty defines which cells are empty, e.g., to treat "-" or "n/a" as empty.
+func (ht *HtmlTable) PruneEmptyRowsWithEmptyFunc(threshold float64, isEmpty func(s string) bool) int {
+	if len(ht.Headers) < 2 {
+		return 0
+	}
+
+	keptRows := []int{0}
+	for i := 1; i < len(ht.Index); i++ {
+		emptyCount := 0
+		for _, cell := range ht.TableData[i-1] {
+			if isEmpty(cell) {
+				emptyCount++
+			}
+		}
+		if float64(emptyCount)/float64(len(ht.TableData[i-1])) < threshold {
+			keptRows = append(keptRows, i)
+		}
+	}
+
+	removed := len(ht.Index) - len(keptRows)
+	if removed > 0 {
+		ht.selectRowsAndColumns(keptRows, nil)
+	}
+	return removed
+}
+
+// PruneEmptyColumns
+// Removes all data columns in which at least a fraction of threshold of the cells (excluding the header) are empty
+// after trimming blank characters, see IsBlank. A threshold of 1.0 removes only columns which are entirely empty.
+// Returns the number of removed columns. Headers are kept synchronized.
+func (ht *HtmlTable) PruneEmptyColumns(threshold float64) int {
+	return ht.PruneEmptyColumnsWithEmptyFunc(threshold, isEmptyCell)
+}
+
+// PruneEmptyColumnsWithEmptyFunc
+// Same as PruneEmptyColumns but isEmpty defines which cells are empty, e.g., to treat "-" or "n/a" as empty.
+func (ht *HtmlTable) PruneEmptyColumnsWithEmptyFunc(threshold float64, isEmpty func(s string) bool) int {
+	if len(ht.Index) < 2 {
+		return 0
+	}
+
+	keptColumns := []int{0}
+	for j := 1; j < len(ht.Headers); j++ {
+		emptyCount := 0
+		for _, row := range ht.TableData {
+			if isEmpty(row[j-1]) {
+				emptyCount++
+			}
+		}
+		if float64(emptyCount)/float64(len(ht.TableData)) < threshold {
+			keptColumns = append(keptColumns, j)
+		}
+	}
+
+	removed := len(ht.Headers) - len(keptColumns)
+	if removed > 0 {
+		ht.selectRowsAndColumns(nil, keptColumns)
+	}
+	return removed
+}
+
+// selectRowsAndColumns
+// Rearranges the table such that the new row i is the old row rows[i] and the new column j is the old column
+// columns[j], using the indexing of GetElementByIndex. Rows and columns which are not selected are removed.
+// A nil slice keeps all rows or columns respectively.
+// rows[0] and columns[0] are expected to be 0, i.e., the header row and index column stay in place.
+// Position-keyed metadata like Spans is moved along, spans of removed cells are dropped.
+// Does nothing for an empty table.
+func (ht *HtmlTable) selectRowsAndColumns(rows, columns []int) {
+	if len(ht.Index) == 0 || len(ht.Headers) == 0 {
+		return // empty table, nothing to select
+	}
+	if rows == nil {
+		rows = make([]int, len(ht.Index))
+		for i := range rows {
+			rows[i] = i
+		}
+	}
+	if columns == nil {
+		columns = make([]int, len(ht.Headers))
+		for j := range columns {
+			columns[j] = j
+		}
+	}
+
+	index := make([]string, len(rows))
+	for newI, oldI := range rows {
+		index[newI] = ht.Index[oldI]
+	}
+	headers := make([]string, len(columns))
+	for newJ, oldJ := range columns {
+		headers[newJ] = ht.Headers[oldJ]
+	}
+	tableData := make([][]string, len(rows)-1)
+	for newI := 1; newI < len(rows); newI++ {
+		tableData[newI-1] = make([]string, len(columns)-1)
+		for newJ := 1; newJ < len(columns); newJ++ {
+			tableData[newI-1][newJ-1] = ht.TableData[rows[newI]-1][columns[newJ]-1]
+		}
+	}
+
+	// old position -> new position
+	rowMap := make(map[int]int, len(rows))
+	for newI, oldI := range rows {
+		rowMap[oldI] = newI
+	}
+	columnMap := make(map[int]int, len(columns))
+	for newJ, oldJ := range columns {
+		columnMap[oldJ] = newJ
+	}
+	movePosition := func(pos [2]int) ([2]int, bool) {
+		newI, okI := rowMap[pos[0]]
+		newJ, okJ := columnMap[pos[1]]
+		return [2]int{newI, newJ}, okI && okJ
+	}
+
+	if ht.Spans != nil {
+		spans := make(map[[2]int]CellSpan, len(ht.Spans))
+		for pos, span := range ht.Spans {
+			if newPos, ok := movePosition(pos); ok {
+				spans[newPos] = span
+			}
+		}
+		ht.Spans = spans
+	}
+	if ht.provenance != nil {
+		provenance := make(map[[2]int]CellProvenance, len(ht.provenance))
+		for pos, prov := range ht.provenance {
+			if newPos, ok := movePosition(pos); ok {
+				provenance[newPos] = prov
+			}
+		}
+		ht.provenance = provenance
+	}
+	if ht.rawText != nil {
+		rawText := make(map[[2]int]string, len(ht.rawText))
+		for pos, raw := range ht.rawText {
+			if newPos, ok := movePosition(pos); ok {
+				rawText[newPos] = raw
+			}
+		}
+		ht.rawText = rawText
+	}
+	if ht.columnMeta != nil {
+		columnMeta := make(map[int]ColumnMeta, len(ht.columnMeta))
+		for oldJ, meta := range ht.columnMeta {
+			if newJ, ok := columnMap[oldJ]; ok {
+				columnMeta[newJ] = meta
+			}
+		}
+		ht.columnMeta = columnMeta
+	}
+
+	ht.Index = index
+	ht.Headers = headers
+	ht.TableData = tableData
+}
+
+// resolveColumnKeys
+// Returns the column indices (see GetColumnByIndex) of the provided keys in the same order.
+// Returns an error for unknown or repeated keys, and for the key of the index column.
+func (ht HtmlTable) resolveColumnKeys(keys []string) ([]int, error) {
+	columns := make([]int, 0, len(keys))
+	seen := make(map[int]bool, len(keys))
+	for _, key := range keys {
+		_, j, ok := ht.GetColumnByKey(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown column key: '%v'", key)
+		}
+		if j == 0 {
+			return nil, fmt.Errorf("cannot rearrange the index column: '%v'", key)
+		}
+		if seen[j] {
+			return nil, fmt.Errorf("column key provided multiple times: '%v'", key)
+		}
+		seen[j] = true
+		columns = append(columns, j)
+	}
+	return columns, nil
+}
+
+// ReorderColumns
+// Rearranges Headers and every row of TableData such that the data columns appear in the order of keys.
+// Keys are matched case-insensitively as in GetColumnByKey, the index column always stays first.
+// Columns which are not contained in keys are removed if dropOmitted is true, else, they are appended in their
+// current order.
+// Returns an error and leaves the table unchanged if a key is unknown or provided multiple times.
+func (ht *HtmlTable) ReorderColumns(keys []string, dropOmitted bool) error {
+	selected, err := ht.resolveColumnKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	columns := append([]int{0}, selected...)
+	if !dropOmitted {
+		isSelected := make(map[int]bool, len(selected))
+		for _, j := range selected {
+			isSelected[j] = true
+		}
+		for j := 1; j < len(ht.Headers); j++ {
+			if !isSelected[j] {
+				columns = append(columns, j)
+			}
+		}
+	}
+
+	ht.selectRowsAndColumns(nil, columns)
+	return nil
+}
+
+// ProjectColumns
+// Returns a new table containing the index column and only the data columns with the provided keys in that order.
+// The original table is not modified.
+// Returns an error if a key is unknown or provided multiple times.
+func (ht HtmlTable) ProjectColumns(keys ...string) (*HtmlTable, error) {
+	selected, err := ht.resolveColumnKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	projection := ht
+	projection.selectRowsAndColumns(nil, append([]int{0}, selected...))
+	return &projection, nil
+}
+
+// ColumnCheck
+// Result of comparing the sum of a numeric column with its expected total, see ValidateFooterTotals.
+type ColumnCheck struct {
+	Key      string  // key of the column
+	Column   int     // index of the column, see GetColumnByIndex
+	Computed float64 // sum of all data cells of the column
+	Expected float64 // parsed footer value of the column
+	Passed   bool    // whether Computed and Expected differ by at most the tolerance
+}
+
+// splitNumberSign
+// Returns s without surrounding whitespace and leading sign, and whether the sign is a minus, see parseColumnNumber.
+// Returns an error if the remainder contains anything but digits and separators.
+func splitNumberSign(s string) (string, bool, error) {
+	text := TrimBlank(s)
+	r, size := utf8.DecodeRuneInString(text)
+	negative := r == '-' || r == '\u2212' || r == '\u2013'
+	if negative || r == '+' {
+		text = text[size:]
+	}
+	if text == "" || strings.IndexFunc(text, func(r rune) bool {
+		return !isASCIIDigit(r) && r != ',' && r != '.' && !isThousandsSpace(r)
+	}) != -1 {
+		return "", false, fmt.Errorf("no number in '%v'", s)
+	}
+	return text, negative, nil
+}
+
+// parseColumnNumber
+// Parses a signed number per convention, see ValidateFooterTotals.
+func parseColumnNumber(s string, convention DecimalConvention) (float64, error) {
+	text, negative, err := splitNumberSign(s)
+	if err != nil {
+		return 0, err
+	}
+	value, err := parseExtractedNumber(text, convention)
+	if negative {
+		value = -value
+	}
+	return value, err
+}
+
+// columnDecimalConvention
+// Returns the decimal convention of the majority of those values of column j and footer which can only be parsed with
+// one of both conventions, e.g., "12,5" but not "1,001" or "999", or DecimalUnknown if there is no majority.
+func (ht HtmlTable) columnDecimalConvention(j int, footer string) DecimalConvention {
+	values := []string{footer}
+	for _, row := range ht.TableData {
+		values = append(values, row[j-1])
+	}
+	votes := make(map[DecimalConvention]int)
+	for _, value := range values {
+		text, _, err := splitNumberSign(value)
+		if err != nil {
+			continue
+		}
+		_, pointErr := parseExtractedNumber(text, DecimalPoint)
+		_, commaErr := parseExtractedNumber(text, DecimalComma)
+		if pointErr == nil && commaErr != nil {
+			votes[DecimalPoint]++
+		} else if commaErr == nil && pointErr != nil {
+			votes[DecimalComma]++
+		}
+	}
+	switch {
+	case votes[DecimalPoint] > votes[DecimalComma]:
+		return DecimalPoint
+	case votes[DecimalComma] > votes[DecimalPoint]:
+		return DecimalComma
+	}
+	return DecimalUnknown
+}
+
+// ValidateFooterTotals
+// Sums each numeric data column and compares the sum with the corresponding footer value, e.g., from a <tfoot> row.
+// footer is aligned with Headers, i.e., footer[0] is the label of the index column and footer[j] is the expected
+// total of column j.
+// A column is numeric if its footer value and all of its non-empty data cells can be parsed by parse, other columns
+// are skipped. If parse is nil, the decimal separator is decided once per column from the values which are only valid
+// with either '.' or ',' as decimal separator, e.g., "12,5" or "1,234.5", and all values are parsed per that convention
+// with the grouping rules of ExtractFirstNumber. If no value decides it, each value is guessed as in ParseMoney, e.g.,
+// "1,001" is one thousand and one.
+// Returns the checks of all numeric columns in column order, or an error if footer does not match the Headers.
+func (ht HtmlTable) ValidateFooterTotals(footer []string, parse func(string) (float64, error), tolerance float64) ([]ColumnCheck, error) {
+	if len(footer) != len(ht.Headers) {
+		return nil, fmt.Errorf("footer has %v values but table has %v columns", len(footer), len(ht.Headers))
+	}
+
+	var checks []ColumnCheck
+	for j := 1; j < len(ht.Headers); j++ {
+		parseCell := parse
+		if parseCell == nil {
+			convention := ht.columnDecimalConvention(j, footer[j])
+			parseCell = func(s string) (float64, error) {
+				return parseColumnNumber(s, convention)
+			}
+		}
+
+		expected, err := parseCell(TrimBlank(footer[j]))
+		if err != nil {
+			continue
+		}
+
+		sum, numeric := 0.0, true
+		for _, row := range ht.TableData {
+			cell := TrimBlank(row[j-1])
+			if cell == "" {
+				continue
+			}
+			value, err := parseCell(cell)
+			if err != nil {
+				numeric = false
+				break
+			}
+			sum += value
+		}
+		if !numeric {
+			continue
+		}
+
+		checks = append(checks, ColumnCheck{
+			Key:      ht.Headers[j],
+			Column:   j,
+			Computed: sum,
+			Expected: expected,
+			Passed:   math.Abs(sum-expected) <= tolerance,
+		})
+	}
+	return checks, nil
+}
+
+// NormalizeKey
+// Normalizes a table key for comparison: collapses all whitespace (including non-breaking spaces) into single spaces,
+// trims it, strips trailing punctuation like ':' or '.' (but not closing brackets or quotes), and converts it to lower
+// case.
+// E.g., "Net Income: " yields "net income" while "Revenue (in $m)" yields "revenue (in $m)".
+func NormalizeKey(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.TrimRightFunc(s, func(r rune) bool {
+		if r == '"' || r == '\'' || unicode.In(r, unicode.Pe, unicode.Pf) {
+			return false
+		}
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+	return strings.ToLower(s)
+}
+
+// GetRowByKeyNormalized
+// Same as GetRowByKey but applies normalize to both key and every index key before comparing them for equality.
+// See NormalizeKey for a suitable normalizer. Aliases (see SetIndexAliases) are normalized as well.
+func (ht HtmlTable) GetRowByKeyNormalized(key string, normalize func(string) string) ([]string, int, bool) {
+	_, idx, ok := resolveKey(ht.Index, key, ht.indexAliases, func(a, b string) bool {
+		return normalize(a) == normalize(b)
+	})
+	if ok {
+		return function.GetFirstReturnElement(ht.GetRowByIndex(idx)).([]string), idx, true
+	}
+	return nil, -1, false
+}
+
+// FindClosestRowKey
+// Returns the index key with the smallest levenshtein distance to key, compared after NormalizeKey, together with its
+// row index (see GetRowByIndex). On ties, the first key wins. The key of the header row, Index[0], is not searched.
+// Returns false if no index key has a distance of at most maxDistance.
+func (ht HtmlTable) FindClosestRowKey(key string, maxDistance int) (string, int, bool) {
+	normalizedKey := NormalizeKey(key)
+	bestKey, bestIdx, bestDistance := "", -1, maxDistance+1
+	for idx := 1; idx < len(ht.Index); idx++ {
+		if distance := levenshteinDistance(normalizedKey, NormalizeKey(ht.Index[idx])); distance < bestDistance {
+			bestKey, bestIdx, bestDistance = ht.Index[idx], idx, distance
+		}
+	}
+	return bestKey, bestIdx, bestIdx != -1
+}
+
+// levenshteinDistance
+// Returns the minimal number of rune insertions, deletions, and substitutions to transform a into b.
+func levenshteinDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// refreshCell
+// Re-extracts the data cell at row i and column j (both >= 1) from its recorded source cell.
+func (ht *HtmlTable) refreshCell(tableNode *html.Node, i, j int, opts TableParseOptions) error {
+	prov, ok := ht.provenance[[2]int{i, j}]
+	if !ok {
+		return nil // cell without source cell, e.g., padding of a ragged row
+	}
+	if !isAncestorOrSelf(tableNode, prov.Node) {
+		return fmt.Errorf("source cell '%v' of row %v and column %v is no longer part of the table, parse it again", prov.Path, i, j)
+	}
+	ht.TableData[i-1][j-1] = opts.cellTextWithNormalizer(prov.Node, opts.columnNormalizer(ht.Headers[j], j))
+	if ht.rawText != nil {
+		ht.rawText[[2]int{i, j}] = opts.rawCellText(prov.Node)
+	}
+	return nil
+}
+
+// checkRefreshable
+// Returns an error if the table cannot be refreshed from tableNode.
+func (ht *HtmlTable) checkRefreshable(tableNode *html.Node) error {
+	if tableNode == nil {
+		return errors.New("node is nil")
+	}
+	if ht.provenance == nil {
+		return errors.New("table has no cell provenance, parse it with TableParseOptions.RecordProvenance enabled to refresh it")
+	}
+	return nil
+}
+
+// RefreshRow
+// Re-extracts the data cells of row rowIndex (see GetRowByIndex, >= 1) from their source cells within tableNode, e.g.,
+// after the document changed, using the normalizer and composite text settings of opts.
+// Requires that the table was parsed with TableParseOptions.RecordProvenance. Keys, i.e., Index and Headers, are not
+// refreshed. Returns an error if a source cell was removed from tableNode, in which case the table has to be parsed
+// again.
+func (ht *HtmlTable) RefreshRow(tableNode *html.Node, rowIndex int, opts TableParseOptions) error {
+	if err := ht.checkRefreshable(tableNode); err != nil {
+		return err
+	}
+	if rowIndex < 1 || rowIndex >= len(ht.Index) {
+		return fmt.Errorf("row index %v out of bounds [1, %v)", rowIndex, len(ht.Index))
+	}
+	for j := 1; j < len(ht.Headers); j++ {
+		if err := ht.refreshCell(tableNode, rowIndex, j, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefreshCellByKeys
+// Same as RefreshRow but only re-extracts the data cell with the provided row key and column key, see GetElementByKeys.
+func (ht *HtmlTable) RefreshCellByKeys(tableNode *html.Node, rowKey, colKey string, opts TableParseOptions) error {
+	if err := ht.checkRefreshable(tableNode); err != nil {
+		return err
+	}
+	_, i, j, ok := ht.GetElementByKeys(rowKey, colKey)
+	if !ok {
+		return fmt.Errorf("no cell with row key '%v' and column key '%v'", rowKey, colKey)
+	}
+	if i < 1 || j < 1 {
+		return fmt.Errorf("cell with row key '%v' and column key '%v' is a key, not a data cell", rowKey, colKey)
+	}
+	return ht.refreshCell(tableNode, i, j, opts)
+}
+
+// CellRef
+// A data cell of a table together with its keys and coordinates, see Cells.
+type CellRef struct {
+	Value     string // content of the cell
+	RowKey    string // key of the row, i.e., Index[I]
+	ColumnKey string // key of the column, i.e., Headers[J]
+	I         int    // row of the cell, see GetElementByIndex
+	J         int    // column of the cell, see GetElementByIndex
+}
+
+// Cells
+// Calls f on every data cell of the table in row-major order until it returns false. The header row and the index
+// column are not visited, so I and J of every cell are at least 1 and ht.GetElementByIndex(I, J) equals Value.
+func (ht HtmlTable) Cells(f func(cell CellRef) bool) {
+	for i := 1; i < len(ht.Index); i++ {
+		row, rowKey := ht.getRowByIndex(i)
+		for j := 1; j <= len(row) && j < len(ht.Headers); j++ {
+			if !f(CellRef{Value: row[j-1], RowKey: rowKey, ColumnKey: ht.Headers[j], I: i, J: j}) {
+				return
+			}
+		}
+	}
+}
+
+// MapCells
+// Replaces the value of every data cell of the table by the result of f in row-major order, see Cells, e.g., to redact
+// e-mail addresses in the whole table. Headers and Index are not modified.
+func (ht *HtmlTable) MapCells(f func(cell CellRef) string) {
+	ht.Cells(func(cell CellRef) bool {
+		ht.TableData[cell.I-1][cell.J-1] = f(cell)
+		return true
+	})
+}
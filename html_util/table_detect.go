@@ -0,0 +1,139 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+	"unicode/utf8"
+)
+
+// IsDataTable
+// Estimates whether tableNode is a data table rather than a table only used for layout.
+// Returns the verdict and a confidence score between 0 (certainly layout) and 1 (certainly data), the verdict is true
+// for scores of at least 0.5.
+// Indicators for data tables are <th>, <thead>, and <caption> elements, a summary attribute, and a consistent number
+// of cells per row. Indicators for layout tables are role="presentation" or role="none" (which yields a score of 0),
+// nested tables, many form controls, a single row or column, and long cell texts.
+func IsDataTable(tableNode *html.Node) (bool, float64) {
+	if tableNode == nil || !(tableNode.Type == html.ElementNode && tableNode.Data == "table") {
+		return false, 0
+	}
+	if role, err := GetAttributeByKey(tableNode, "role"); err == nil {
+		switch strings.ToLower(strings.TrimSpace(role.Val)) {
+		case "presentation", "none":
+			return false, 0
+		}
+	}
+
+	score := 0.5
+	rows := getOwnTableRows(tableNode)
+
+	var cellCounts []int
+	cells, headerCells, nestedTables, formControls, textRunes := 0, 0, 0, 0, 0
+	hasHead, hasCaption := false, false
+	WalkHtmlTree(tableNode, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		switch n.Data {
+		case "table":
+			nestedTables++
+			return false
+		case "thead":
+			hasHead = true
+		case "caption":
+			hasCaption = true
+		case "th":
+			headerCells++
+		case "input", "select", "textarea", "button":
+			formControls++
+		}
+		return true
+	})
+	for _, row := range rows {
+		count := 0
+		for c := row.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.Data == "td" || c.Data == "th") {
+				count += getSpanAttribute(c, "colspan", 1000)
+				if GetNextNodeByCondition(c, MakeByTagNameCondition("table")) == nil {
+					textRunes += utf8.RuneCountInString(GetInnerText(c))
+				}
+				cells++
+			}
+		}
+		cellCounts = append(cellCounts, count)
+	}
+
+	if headerCells > 0 || hasHead {
+		score += 0.25
+	}
+	if hasCaption {
+		score += 0.2
+	}
+	if _, err := GetAttributeByKey(tableNode, "summary"); err == nil {
+		score += 0.1
+	}
+	if nestedTables > 0 {
+		score -= 0.3
+	}
+	if len(rows) < 2 {
+		score -= 0.2
+	}
+	if cells > 0 {
+		if consistency := modeFraction(cellCounts); consistency >= 0.8 {
+			score += 0.1
+		} else if consistency < 0.5 {
+			score -= 0.15
+		}
+		if len(cellCounts) > 0 && modeValue(cellCounts) < 2 {
+			score -= 0.2
+		}
+		if float64(formControls)/float64(cells) > 0.5 {
+			score -= 0.15
+		}
+		if avgRunes := float64(textRunes) / float64(cells); avgRunes > 200 {
+			score -= 0.2
+		} else if avgRunes < 50 {
+			score += 0.05
+		}
+	} else {
+		score = 0
+	}
+
+	if score < 0 {
+		score = 0
+	} else if score > 1 {
+		score = 1
+	}
+	return score >= 0.5, score
+}
+
+// countValues
+// Returns the most frequent value of values and its number of occurrences, preferring the larger value on ties.
+func countValues(values []int) (int, int) {
+	counts := make(map[int]int)
+	mode, modeCount := 0, 0
+	for _, v := range values {
+		counts[v]++
+		if counts[v] > modeCount || (counts[v] == modeCount && v > mode) {
+			mode, modeCount = v, counts[v]
+		}
+	}
+	return mode, modeCount
+}
+
+// modeFraction
+// Returns the fraction of values equal to the most frequent value.
+func modeFraction(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	_, count := countValues(values)
+	return float64(count) / float64(len(values))
+}
+
+// modeValue
+// Returns the most frequent value of values.
+func modeValue(values []int) int {
+	mode, _ := countValues(values)
+	return mode
+}
@@ -0,0 +1,109 @@
+package html_util
+
+import (
+	"strings"
+	"testing"
+)
+
+// labeledTables
+// Tables modelled after real pages, labeled as data table (true) or layout table (false).
+var labeledTables = []struct {
+	name   string
+	html   string
+	isData bool
+}{
+	{"price list", `<table><thead><tr><th>Item</th><th>Price</th></tr></thead><tbody>` +
+		`<tr><td>Apple</td><td>1.20</td></tr><tr><td>Pear</td><td>0.90</td></tr><tr><td>Plum</td><td>2.10</td></tr>` +
+		`</tbody></table>`, true},
+	{"captioned without th", `<table><caption>Results 2023</caption><tr><td>Team</td><td>Points</td></tr>` +
+		`<tr><td>A</td><td>12</td></tr><tr><td>B</td><td>9</td></tr></table>`, true},
+	{"plain grid", `<table><tr><td>1</td><td>2</td><td>3</td></tr><tr><td>4</td><td>5</td><td>6</td></tr>` +
+		`<tr><td>7</td><td>8</td><td>9</td></tr></table>`, true},
+	{"row headers", `<table summary="Opening hours"><tr><th scope="row">Mon</th><td>9-17</td></tr>` +
+		`<tr><th scope="row">Sat</th><td>10-14</td></tr></table>`, true},
+	{"role presentation", `<table role="presentation"><tr><th>a</th><th>b</th></tr>` +
+		`<tr><td>1</td><td>2</td></tr></table>`, false},
+	{"page skeleton", `<table width="100%"><tr><td colspan="2"><img src="logo.gif"></td></tr>` +
+		`<tr><td><table><tr><td><a href="/">Home</a></td></tr><tr><td><a href="/news">News</a></td></tr></table></td>` +
+		`<td>` + strings.Repeat("Welcome to our homepage, we have news and articles for everyone. ", 6) + `</td></tr></table>`,
+		false},
+	{"single column spacer", `<table><tr><td><img src="spacer.gif"></td></tr><tr><td>menu</td></tr>` +
+		`<tr><td>content</td></tr></table>`, false},
+	{"login form", `<table><tr><td>User</td><td><input name="u"></td></tr>` +
+		`<tr><td><input type="password" name="p"></td><td><button>Login</button></td></tr></table>`, false},
+	{"single row banner", `<table><tr><td><img src="a.gif"></td><td>` +
+		strings.Repeat("Long introductory text of the page. ", 10) + `</td></tr></table>`, false},
+	{"empty", `<table></table>`, false},
+}
+
+func TestIsDataTableLabeledFixtures(t *testing.T) {
+	for _, tt := range labeledTables {
+		t.Run(tt.name, func(t *testing.T) {
+			table := GetElementNodesByTagName("table", parseDocument(t, tt.html))[0]
+			isData, score := IsDataTable(table)
+			if isData != tt.isData {
+				t.Errorf("IsDataTable = %v (score %.2f), want %v", isData, score, tt.isData)
+			}
+			if score < 0 || score > 1 || isData != (score >= 0.5) {
+				t.Errorf("score %.2f is inconsistent with verdict %v", score, isData)
+			}
+		})
+	}
+}
+
+func TestIsDataTableScores(t *testing.T) {
+	if isData, score := IsDataTable(nil); isData || score != 0 {
+		t.Errorf("IsDataTable(nil) = %v, %v", isData, score)
+	}
+	doc := parseDocument(t, `<div></div><table role=" None "><tr><td>a</td><td>b</td></tr><tr><td>c</td><td>d</td></tr></table>`)
+	if isData, _ := IsDataTable(GetElementNodesByTagName("div", doc)[0]); isData {
+		t.Error("a <div> is a data table")
+	}
+	if _, score := IsDataTable(GetElementNodesByTagName("table", doc)[0]); score != 0 {
+		t.Errorf("role none yields score %v, want 0", score)
+	}
+
+	// headers and a caption increase the confidence
+	plain := labeledTables[2].html
+	withHead := strings.Replace(plain, "<tr><td>1</td><td>2</td><td>3</td></tr>", "<tr><th>1</th><th>2</th><th>3</th></tr>", 1)
+	withCaption := strings.Replace(withHead, "<table>", "<table><caption>c</caption>", 1)
+	var scores []float64
+	for _, s := range []string{plain, withHead, withCaption} {
+		_, score := IsDataTable(GetElementNodesByTagName("table", parseDocument(t, s))[0])
+		scores = append(scores, score)
+	}
+	if !(scores[0] < scores[1] && scores[1] < scores[2]) {
+		t.Errorf("scores %v are not increasing with th and caption", scores)
+	}
+}
+
+func TestParseAllHtmlTablesMinDataTableConfidence(t *testing.T) {
+	var sb strings.Builder
+	wantData := 0
+	for _, tt := range labeledTables {
+		sb.WriteString(tt.html)
+		if tt.isData {
+			wantData++
+		}
+	}
+	doc := parseDocument(t, sb.String())
+
+	all, err := ParseAllHtmlTables(doc, TableParseOptions{Suffix: "_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ParseAllHtmlTables(doc, TableParseOptions{Suffix: "_", MinDataTableConfidence: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the nested navigation table of the page skeleton is parsed without confidence threshold as well
+	if len(all) != len(labeledTables)+1 {
+		t.Errorf("ParseAllHtmlTables returned %v tables, want %v", len(all), len(labeledTables)+1)
+	}
+	if len(data) != wantData {
+		t.Fatalf("ParseAllHtmlTables with MinDataTableConfidence returned %v tables, want %v", len(data), wantData)
+	}
+	if got := data[0].GetElementByIndex(1, 1); got != "Item" {
+		t.Errorf("first data table has value %v at (1, 1), want Item", got)
+	}
+}
@@ -0,0 +1,139 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// MatchOption
+// Configures how table texts are matched, see FindTableByHeaders.
+type MatchOption func(cfg *matchConfig)
+
+type matchConfig struct {
+	parseOptions TableParseOptions   // how header cell texts are extracted
+	normalize    func(string) string // applied to both required and found texts before comparison
+}
+
+// WithMatchNormalizer
+// Applies normalize to both the required and the found texts before comparing them.
+// The default trims whitespace and compares case-insensitively.
+func WithMatchNormalizer(normalize func(string) string) MatchOption {
+	return func(cfg *matchConfig) {
+		cfg.normalize = normalize
+	}
+}
+
+// WithMatchCompositeTexts
+// Uses the composite of all texts of a header cell joined by delimiter instead of only its first text.
+func WithMatchCompositeTexts(delimiter string) MatchOption {
+	return func(cfg *matchConfig) {
+		cfg.parseOptions.AllowCompositeTexts = true
+		cfg.parseOptions.CompositeDelimiter = delimiter
+	}
+}
+
+// withMatchParseOptions
+// Extracts the header cell texts like ParseHtmlTableWithOptions with opts would.
+func withMatchParseOptions(opts TableParseOptions) MatchOption {
+	return func(cfg *matchConfig) {
+		cfg.parseOptions = opts
+	}
+}
+
+func makeMatchConfig(opts []MatchOption) matchConfig {
+	cfg := matchConfig{
+		normalize: func(s string) string {
+			return strings.ToLower(strings.TrimSpace(s))
+		},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// getTableHeaderTexts
+// Returns the texts of the cells of the first row of tableNode without parsing the remaining rows.
+// Colspans are expanded if opts.ExpandSpans or opts.RecordSpans is set.
+// Returns nil if the table has no rows, and ErrTableTooLarge if the expanded row is too large, see expandTableSpans.
+func getTableHeaderTexts(tableNode *html.Node, opts TableParseOptions) ([]string, error) {
+	firstRow := GetNodeByCondition(tableNode, func(node *html.Node) bool {
+		return node.Type == html.ElementNode && node.Data == "tr" && GetNextNodeByCondition(node, MakeByTagNameCondition("tr")) == nil
+	})
+	if firstRow == nil {
+		return nil, nil
+	}
+
+	cells := getRowCells(firstRow)
+	if opts.ExpandSpans || opts.RecordSpans {
+		grid, _, err := expandTableSpans([][]*html.Node{cells}, opts.MaxCells)
+		if err != nil {
+			return nil, err
+		}
+		cells = grid[0]
+	}
+	texts := make([]string, len(cells))
+	for j, cell := range cells {
+		texts[j] = opts.cellText(cell)
+	}
+	return texts, nil
+}
+
+// FindTableByCaption
+// Returns the first <table> in the tree of root (including root) whose <caption> text equals caption after trimming
+// and collapsing whitespace, compared case-insensitively.
+// Returns nil if none found.
+func FindTableByCaption(root *html.Node, caption string) *html.Node {
+	wanted := strings.Join(strings.Fields(caption), " ")
+	return GetNodeByCondition(root, func(node *html.Node) bool {
+		if node.Type != html.ElementNode || node.Data != "table" {
+			return false
+		}
+		for _, c := range GetChildren(node) {
+			if c.Type == html.ElementNode && c.Data == "caption" {
+				return strings.EqualFold(GetInnerText(c), wanted)
+			}
+		}
+		return false
+	})
+}
+
+// FindTableByHeaders
+// Returns the first <table> in the tree of root (including root) whose first row contains all requiredHeaders.
+// Only the first row of each candidate table is parsed, see ParseHtmlTableHeaders.
+// Returns an error if no such table exists.
+func FindTableByHeaders(root *html.Node, requiredHeaders []string, opts ...MatchOption) (*html.Node, error) {
+	if root == nil {
+		return nil, errors.New("node is nil")
+	}
+	cfg := makeMatchConfig(opts)
+
+	required := make([]string, len(requiredHeaders))
+	for i, h := range requiredHeaders {
+		required[i] = cfg.normalize(h)
+	}
+
+	table := GetNodeByCondition(root, func(node *html.Node) bool {
+		if node.Type != html.ElementNode || node.Data != "table" {
+			return false
+		}
+		found := make(map[string]bool)
+		texts, _ := getTableHeaderTexts(node, cfg.parseOptions) // tables too large to expand have no headers to find
+		for _, text := range texts {
+			found[cfg.normalize(text)] = true
+		}
+		for _, h := range required {
+			if !found[h] {
+				return false
+			}
+		}
+		return true
+	})
+
+	if table == nil {
+		return nil, fmt.Errorf("no table with headers: '%v'", strings.Join(requiredHeaders, "', '"))
+	}
+	return table, nil
+}
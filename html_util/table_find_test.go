@@ -0,0 +1,54 @@
+package html_util
+
+import (
+	"strings"
+	"testing"
+)
+
+const findTablesDocument = `<table id="prices"><caption> Monthly
+	Prices </caption><tr><th>Product</th><th>Price <small>(EUR)</small></th></tr><tr><td>a</td><td>1</td></tr></table>` +
+	`<table id="stock"><tr><th> product </th><th>Stock</th><th>Price</th></tr></table>`
+
+func TestFindTableByCaption(t *testing.T) {
+	doc := parseDocument(t, findTablesDocument)
+
+	if table := FindTableByCaption(doc, "monthly  prices"); table == nil || nodeID(table) != "prices" {
+		t.Errorf("got %v, want the prices table", table)
+	}
+	if table := FindTableByCaption(doc, "Prices"); table != nil {
+		t.Errorf("got %v for a partial caption, want nil", nodeID(table))
+	}
+}
+
+func TestFindTableByHeaders(t *testing.T) {
+	doc := parseDocument(t, findTablesDocument)
+
+	tests := []struct {
+		headers []string
+		opts    []MatchOption
+		want    string
+	}{
+		{[]string{"PRODUCT", "price"}, nil, "prices"}, // only the first text "Price " counts by default
+		{[]string{"product", "stock"}, nil, "stock"},
+		{[]string{"Product", "Price (EUR)"}, []MatchOption{WithMatchCompositeTexts("")}, "prices"},
+		{[]string{"price"}, []MatchOption{WithMatchNormalizer(func(s string) string {
+			return strings.TrimSpace(s)
+		})}, ""},
+		{[]string{"Price"}, []MatchOption{WithMatchNormalizer(func(s string) string {
+			return strings.TrimSpace(s)
+		})}, "prices"},
+		{[]string{"Product", "Color"}, nil, ""},
+	}
+	for _, tt := range tests {
+		table, err := FindTableByHeaders(doc, tt.headers, tt.opts...)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("FindTableByHeaders(%v) = %v, want an error", tt.headers, nodeID(table))
+			}
+			continue
+		}
+		if err != nil || nodeID(table) != tt.want {
+			t.Errorf("FindTableByHeaders(%v) = %v, %v, want %v", tt.headers, table, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// ColumnMeta
+// Presentational hints of a table column taken from the table markup.
+type ColumnMeta struct {
+	Align   string // "left", "center", "right", "justify", or "" if not specified, from align attributes or text-align styles
+	Width   string // width hint as specified, e.g., "20%" or "120px", or "" if not specified
+	Numeric bool   // whether the column's data cells are predominantly numeric, i.e., candidates for right alignment
+}
+
+// getStyleProperty
+// Returns the trimmed, lower case value of the css property in the inline style attribute of node, or "".
+func getStyleProperty(node *html.Node, property string) string {
+	attr, err := GetAttributeByKey(node, "style")
+	if err != nil {
+		return ""
+	}
+	for _, declaration := range strings.Split(attr.Val, ";") {
+		name, value, found := strings.Cut(declaration, ":")
+		if found && strings.EqualFold(strings.TrimSpace(name), property) {
+			return strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important")))
+		}
+	}
+	return ""
+}
+
+// getAlignHint
+// Returns the alignment of node from its text-align style or its align attribute, or "".
+func getAlignHint(node *html.Node) string {
+	if align := getStyleProperty(node, "text-align"); align != "" {
+		return align
+	}
+	if attr, err := GetAttributeByKey(node, "align"); err == nil {
+		return strings.ToLower(strings.TrimSpace(attr.Val))
+	}
+	return ""
+}
+
+// getWidthHint
+// Returns the width of node from its width style or its width attribute, or "".
+func getWidthHint(node *html.Node) string {
+	if width := getStyleProperty(node, "width"); width != "" {
+		return width
+	}
+	if attr, err := GetAttributeByKey(node, "width"); err == nil {
+		return strings.TrimSpace(attr.Val)
+	}
+	return ""
+}
+
+// getColumnElementHints
+// Returns the <col> element covering each column of tableNode, taking their span attributes into account.
+func getColumnElementHints(tableNode *html.Node) []*html.Node {
+	var cols []*html.Node
+	for _, colgroup := range GetChildren(tableNode) {
+		if colgroup.Type != html.ElementNode || colgroup.Data != "colgroup" {
+			continue
+		}
+		for _, col := range GetChildren(colgroup) {
+			if col.Type != html.ElementNode || col.Data != "col" {
+				continue
+			}
+			span := getSpanAttribute(col, "span", 1000)
+			if span == 0 {
+				span = 1
+			}
+			for k := 0; k < span; k++ {
+				cols = append(cols, col)
+			}
+		}
+	}
+	return cols
+}
+
+// makeColumnMeta
+// Collects the ColumnMeta of each column of the (expanded) cell grid of tableNode.
+// Explicit hints of data cells take precedence over those of the header cell which take precedence over <col> elements.
+func makeColumnMeta(tableNode *html.Node, grid [][]*html.Node, hasHeader int) []ColumnMeta {
+	maxColumns := 0
+	for _, cells := range grid {
+		if len(cells) > maxColumns {
+			maxColumns = len(cells)
+		}
+	}
+	colElements := getColumnElementHints(tableNode)
+
+	metas := make([]ColumnMeta, maxColumns)
+	for c := range metas {
+		alignCounts := make(map[string]int)
+		numericCount, nonEmptyCount := 0, 0
+		width := ""
+		for r := hasHeader; r < len(grid); r++ {
+			if c >= len(grid[r]) || grid[r][c] == nil {
+				continue
+			}
+			cell := grid[r][c]
+			if align := getAlignHint(cell); align != "" {
+				alignCounts[align]++
+			}
+			if width == "" {
+				width = getWidthHint(cell)
+			}
+			text := strings.TrimSpace(GetInnerText(cell))
+			if text != "" {
+				nonEmptyCount++
+				if _, err := ParseValueWithUnit(text); err == nil {
+					numericCount++
+				}
+			}
+		}
+
+		align, bestCount := "", 0
+		for a, count := range alignCounts {
+			if count > bestCount || (count == bestCount && a < align) {
+				align, bestCount = a, count
+			}
+		}
+
+		var fallbacks []*html.Node
+		if hasHeader == 1 && c < len(grid[0]) && grid[0][c] != nil {
+			fallbacks = append(fallbacks, grid[0][c])
+		}
+		if c < len(colElements) {
+			fallbacks = append(fallbacks, colElements[c])
+		}
+		for _, node := range fallbacks {
+			if align == "" {
+				align = getAlignHint(node)
+			}
+			if width == "" {
+				width = getWidthHint(node)
+			}
+		}
+
+		metas[c] = ColumnMeta{
+			Align:   align,
+			Width:   width,
+			Numeric: nonEmptyCount > 0 && 2*numericCount > nonEmptyCount,
+		}
+	}
+	return metas
+}
+
+// ColumnMeta
+// Returns the presentational hints of the column with the given key (see GetColumnByKey).
+// Returns false if the key is unknown or column meta was not recorded during parsing.
+func (ht HtmlTable) ColumnMeta(key string) (ColumnMeta, bool) {
+	if _, j, ok := ht.ResolveColumnKey(key); ok {
+		meta, ok := ht.columnMeta[j]
+		return meta, ok
+	}
+	return ColumnMeta{}, false
+}
@@ -0,0 +1,44 @@
+package html_util
+
+import "testing"
+
+func TestColumnMeta(t *testing.T) {
+	ht := parseTableWithOptions(t, `<table>
+	<colgroup><col span="2" width="30%"><col style="width: 120px"></colgroup>
+	<tr><th>Name</th><th align="center">Qty</th><th style="text-align: Right !important">Price</th><th>Note</th></tr>
+	<tr><td>a</td><td>1</td><td align="left">1.50</td><td>x</td></tr>
+	<tr><td>b</td><td>2</td><td>2.50</td><td style="text-align:justify">y</td></tr>
+	<tr><td>c</td><td></td><td>3 kg</td><td style="text-align:justify">z</td></tr>
+	</table>`, TableParseOptions{HasHeaderRow: true, Suffix: "_", RecordColumnMeta: true})
+
+	tests := []struct {
+		key  string
+		want ColumnMeta
+	}{
+		{"name", ColumnMeta{Width: "30%"}}, // keys are compared case-insensitively
+		{"Qty", ColumnMeta{Align: "center", Width: "30%", Numeric: true}},
+		{"Price", ColumnMeta{Align: "left", Width: "120px", Numeric: true}}, // a data cell hint beats the header hint
+		{"Note", ColumnMeta{Align: "justify"}},
+	}
+	for _, tt := range tests {
+		meta, ok := ht.ColumnMeta(tt.key)
+		if !ok || meta != tt.want {
+			t.Errorf("ColumnMeta(%q) = %+v, %v, want %+v", tt.key, meta, ok, tt.want)
+		}
+	}
+
+	ht.SetColumnAliases(map[string][]string{"Cost": {"Price"}})
+	if meta, ok := ht.ColumnMeta("cost"); !ok || meta.Align != "left" {
+		t.Errorf("ColumnMeta(%q) = %+v, %v, want the meta of Price", "cost", meta, ok)
+	}
+
+	for _, key := range []string{"Missing", "Index"} { // the artificial index has no source column
+		if _, ok := ht.ColumnMeta(key); ok {
+			t.Errorf("ColumnMeta(%q) reported ok", key)
+		}
+	}
+	plain := parseTable(t, `<table><tr><th align="right">A</th></tr><tr><td>1</td></tr></table>`, true, false)
+	if _, ok := plain.ColumnMeta("A"); ok {
+		t.Error("ColumnMeta reported ok although it was not recorded")
+	}
+}
@@ -0,0 +1,150 @@
+package html_util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// renderAlign
+// Returns the alignment used by the renderers for column j: the recorded alignment hint (see ColumnMeta),
+// "right" for predominantly numeric columns without hint, or "" for the default (left) alignment.
+func (ht HtmlTable) renderAlign(j int) string {
+	meta := ht.columnMeta[j]
+	switch meta.Align {
+	case "left", "center", "right":
+		return meta.Align
+	case "":
+		if meta.Numeric {
+			return "right"
+		}
+	}
+	return ""
+}
+
+// renderRows
+// Returns the rows of the table as rendered, i.e., the header row followed by the data rows, each starting with the
+// index key, with cells mapped by escape.
+func (ht HtmlTable) renderRows(escape func(s string) string) [][]string {
+	rows := make([][]string, len(ht.Index))
+	for i := range rows {
+		row, key := ht.getRowByIndex(i)
+		if i > 0 {
+			row = append([]string{key}, row...)
+		}
+		rows[i] = make([]string, len(ht.Headers))
+		for j := range rows[i] {
+			if j < len(row) {
+				rows[i][j] = escape(row[j])
+			}
+		}
+	}
+	return rows
+}
+
+// padCell
+// Pads s with spaces to width runes according to align.
+func padCell(s string, width int, align string) string {
+	missing := width - utf8.RuneCountInString(s)
+	if missing <= 0 {
+		return s
+	}
+	switch align {
+	case "right":
+		return strings.Repeat(" ", missing) + s
+	case "center":
+		return strings.Repeat(" ", missing/2) + s + strings.Repeat(" ", missing-missing/2)
+	default:
+		return s + strings.Repeat(" ", missing)
+	}
+}
+
+// renderColumnWidths
+// Returns the width in runes of each column of rows, at least minWidth.
+func renderColumnWidths(rows [][]string, columns, minWidth int) []int {
+	widths := make([]int, columns)
+	for j := range widths {
+		widths[j] = minWidth
+		for _, row := range rows {
+			if n := utf8.RuneCountInString(row[j]); n > widths[j] {
+				widths[j] = n
+			}
+		}
+	}
+	return widths
+}
+
+// escapeMarkdownCell
+// Escapes pipes and folds whitespace of s, so it fits into a cell of a Markdown table.
+func escapeMarkdownCell(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`)
+}
+
+// Markdown
+// Renders the table, including headers and index, as a GitHub flavored Markdown table.
+// Columns are padded and aligned according to their ColumnMeta, which requires TableParseOptions.RecordColumnMeta,
+// i.e., the separator row uses ":---", ":---:", and "---:", and numeric columns without alignment hint are right aligned.
+func (ht HtmlTable) Markdown() string {
+	rows := ht.renderRows(escapeMarkdownCell)
+	widths := renderColumnWidths(rows, len(ht.Headers), 3)
+
+	separator := make([]string, len(ht.Headers))
+	for j, width := range widths {
+		dashes := []byte(strings.Repeat("-", width))
+		switch ht.renderAlign(j) {
+		case "left":
+			dashes[0] = ':'
+		case "center":
+			dashes[0], dashes[width-1] = ':', ':'
+		case "right":
+			dashes[width-1] = ':'
+		}
+		separator[j] = string(dashes)
+	}
+
+	var sb strings.Builder
+	writeLine := func(cells []string) {
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = padCell(cell, widths[j], ht.renderAlign(j))
+		}
+		writeLine(cells)
+		if i == 0 {
+			writeLine(separator)
+		}
+	}
+	return sb.String()
+}
+
+// PlainText
+// Renders the table, including headers and index, as plain text with columns separated by two spaces and the header row
+// underlined by dashes.
+// Columns are aligned according to their ColumnMeta, like in Markdown, e.g., numbers are padded to the right edge.
+func (ht HtmlTable) PlainText() string {
+	rows := ht.renderRows(func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	})
+	widths := renderColumnWidths(rows, len(ht.Headers), 1)
+
+	var sb strings.Builder
+	writeLine := func(cells []string) {
+		sb.WriteString(strings.TrimRight(strings.Join(cells, "  "), " ") + "\n")
+	}
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = padCell(cell, widths[j], ht.renderAlign(j))
+		}
+		writeLine(cells)
+		if i == 0 {
+			rule := make([]string, len(widths))
+			for j, width := range widths {
+				rule[j] = strings.Repeat("-", width)
+			}
+			writeLine(rule)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,34 @@
+package html_util
+
+import "testing"
+
+func TestRenderAlignedTables(t *testing.T) {
+	ht := parseTableWithOptions(t, `<table>
+	<tr><th>Item</th><th align="center">Size</th><th>Price</th><th>Note</th></tr>
+	<tr><td>Tea</td><td>S</td><td>3.50</td><td>a | b</td></tr>
+	<tr><td>Coffee</td><td>XL</td><td>12</td><td>hot</td></tr>
+	</table>`, TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_", RecordColumnMeta: true})
+
+	wantMarkdown := "| Item   | Size | Price | Note   |\n" +
+		"| ------ | :--: | ----: | ------ |\n" +
+		"| Tea    |  S   |  3.50 | a \\| b |\n" +
+		"| Coffee |  XL  |    12 | hot    |\n"
+	if got := ht.Markdown(); got != wantMarkdown {
+		t.Errorf("got\n%v\nwant\n%v", got, wantMarkdown)
+	}
+
+	wantPlain := "Item    Size  Price  Note\n" +
+		"------  ----  -----  -----\n" +
+		"Tea      S     3.50  a | b\n" +
+		"Coffee   XL      12  hot\n"
+	if got := ht.PlainText(); got != wantPlain {
+		t.Errorf("got\n%v\nwant\n%v", got, wantPlain)
+	}
+
+	// without recorded column meta all columns use the default alignment
+	plain := parseTable(t, `<table><tr><th align="right">A</th></tr><tr><td>1</td></tr></table>`, true, false)
+	want := "| Index\\Header | A   |\n| ------------ | --- |\n| 1            | 1   |\n"
+	if got := plain.Markdown(); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
@@ -0,0 +1,163 @@
+package html_util
+
+import (
+	"fmt"
+	"github.com/rbnbr/go-utility/pkg/slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	MeltVariableColumn = "Variable" // header of the column holding the former column keys, see HtmlTable.Melt
+	MeltValueColumn    = "Value"    // header of the column holding the values, see HtmlTable.Melt
+)
+
+// newReshapedTable
+// Returns a table with the given keys and data which inherits normalizer and suffix of ht. Headers and index with
+// duplicates are made unique with the suffix of ht, unique ones are kept as they are, e.g., keys made unique by an
+// earlier Melt. Parsing metadata like spans or provenance does not apply to reshaped tables.
+func (ht HtmlTable) newReshapedTable(headers, index []string, tableData [][]string, realIndex bool) (*HtmlTable, error) {
+	indexSource := KeySourceArtificial
+	if realIndex {
+		indexSource = KeySourceDerived
+	}
+	duplicateKeys := [2]int{countDuplicates(index), countDuplicates(headers)}
+	var err error
+	if duplicateKeys[1] > 0 {
+		if headers, err = slices.MakeUniqueStringSlice(headers, ht.suffix); err != nil {
+			return nil, err
+		}
+	}
+	if duplicateKeys[0] > 0 {
+		if index, err = slices.MakeUniqueStringSlice(index, ht.suffix); err != nil {
+			return nil, err
+		}
+	}
+	return &HtmlTable{
+		Headers:        headers,
+		Index:          index,
+		TableData:      tableData,
+		duplicateKeys:  duplicateKeys,
+		normalizerFunc: ht.normalizerFunc,
+		suffix:         ht.suffix,
+		headerSource:   KeySourceDerived,
+		indexSource:    indexSource,
+	}, nil
+}
+
+// Melt
+// Converts the table into long format: the result has one row per data row and data column of the table which is not
+// one of idColumns, in row-major order. Its columns are the index column of the table, which keeps its header, the
+// idColumns in the given order, MeltVariableColumn with the key of the melted column, and MeltValueColumn with the
+// value. The result has an artificial index and Headers made unique with the suffix of the table, keys of the table
+// are kept verbatim including their suffixes, so Pivot restores them. If the table has an artificial header row, the
+// header of the index column is TopLeftPlaceholder made unique, which Pivot keeps.
+// Returns an error if an id column is unknown or provided multiple times. The original table is not modified.
+func (ht HtmlTable) Melt(idColumns []string) (*HtmlTable, error) {
+	ids, err := ht.resolveColumnKeys(idColumns)
+	if err != nil {
+		return nil, err
+	}
+	isID := make(map[int]bool, len(ids))
+	for _, j := range ids {
+		isID[j] = true
+	}
+
+	headers := []string{TopLeftPlaceholder, ht.Headers[0]}
+	for _, j := range ids {
+		headers = append(headers, ht.Headers[j])
+	}
+	headers = append(headers, MeltVariableColumn, MeltValueColumn)
+
+	index := []string{TopLeftPlaceholder}
+	var tableData [][]string
+	for i := 1; i < len(ht.Index); i++ {
+		row, key := ht.getRowByIndex(i)
+		for j := 1; j < len(ht.Headers); j++ {
+			if isID[j] {
+				continue
+			}
+			melted := []string{key}
+			for _, id := range ids {
+				melted = append(melted, row[id-1])
+			}
+			melted = append(melted, ht.Headers[j], row[j-1])
+			tableData = append(tableData, melted)
+			index = append(index, strconv.Itoa(len(tableData)))
+		}
+	}
+	return ht.newReshapedTable(headers, index, tableData, false)
+}
+
+// Pivot
+// Converts a table in long format, e.g., the result of Melt, back into wide format. The data columns other than
+// variableCol and valueCol are the id columns, rows with equal values in all of them form one row of the result.
+// The first id column becomes the index of the result, the other id columns stay data columns, followed by one column
+// per distinct value of variableCol in order of first occurrence holding the respective values of valueCol.
+// Missing combinations yield "". Rows and keys are made unique with the suffix of the table.
+// Returns an error if a key is unknown, if there is no id column, or if an id and variable combination occurs
+// multiple times, since the value to keep would be ambiguous. The original table is not modified.
+func (ht HtmlTable) Pivot(variableCol, valueCol string) (*HtmlTable, error) {
+	columns, err := ht.resolveColumnKeys([]string{variableCol, valueCol})
+	if err != nil {
+		return nil, err
+	}
+	variable, value := columns[0], columns[1]
+
+	var ids []int
+	for j := 1; j < len(ht.Headers); j++ {
+		if j != variable && j != value {
+			ids = append(ids, j)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("cannot pivot without id columns besides '%v' and '%v'", variableCol, valueCol)
+	}
+
+	var variables []string
+	variablePositions := make(map[string]int) // variable -> position in variables
+	var groups [][]string                     // id values of each result row
+	groupPositions := make(map[string]int)    // joined id values -> position in groups
+	values := make(map[[2]int]string)         // (group, variable) -> value
+	for i := 1; i < len(ht.Index); i++ {
+		row, _ := ht.getRowByIndex(i)
+		idValues := make([]string, len(ids))
+		for k, j := range ids {
+			idValues[k] = row[j-1]
+		}
+		groupKey := strings.Join(idValues, "\x00")
+		g, ok := groupPositions[groupKey]
+		if !ok {
+			g = len(groups)
+			groupPositions[groupKey] = g
+			groups = append(groups, idValues)
+		}
+		v, ok := variablePositions[row[variable-1]]
+		if !ok {
+			v = len(variables)
+			variablePositions[row[variable-1]] = v
+			variables = append(variables, row[variable-1])
+		}
+		if _, ok := values[[2]int{g, v}]; ok {
+			return nil, fmt.Errorf("duplicate value for id '%v' and variable '%v'", strings.Join(idValues, ", "), row[variable-1])
+		}
+		values[[2]int{g, v}] = row[value-1]
+	}
+
+	headers := []string{ht.Headers[ids[0]]}
+	for _, j := range ids[1:] {
+		headers = append(headers, ht.Headers[j])
+	}
+	headers = append(headers, variables...)
+
+	index := []string{ht.Headers[ids[0]]}
+	tableData := make([][]string, len(groups))
+	for g, idValues := range groups {
+		index = append(index, idValues[0])
+		tableData[g] = append([]string{}, idValues[1:]...)
+		for v := range variables {
+			tableData[g] = append(tableData[g], values[[2]int{g, v}])
+		}
+	}
+	return ht.newReshapedTable(headers, index, tableData, true)
+}
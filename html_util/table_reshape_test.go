@@ -0,0 +1,111 @@
+package html_util
+
+import (
+	"strings"
+	"testing"
+)
+
+const reshapeFixture = `<table><tr><th>Product</th><th>Shop</th><th>Price</th><th>Price</th><th>Stock</th></tr>` +
+	`<tr><td>Tea</td><td>A</td><td>5</td><td>6</td><td>12</td></tr>` +
+	`<tr><td>Tea</td><td>B</td><td>4</td><td></td><td>3</td></tr>` +
+	`<tr><td>Cake</td><td>A</td><td>2</td><td>2.5</td><td>0</td></tr></table>`
+
+func TestHtmlTableMelt(t *testing.T) {
+	ht := parseTable(t, reshapeFixture, true, true)
+
+	long, err := ht.Melt([]string{"shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		TopLeftPlaceholder + "|Product|Shop|Variable|Value",
+		"1|Tea|A|Price|5", "2|Tea|A|Price_2|6", "3|Tea|A|Stock|12",
+		"4|Tea_2|B|Price|4", "5|Tea_2|B|Price_2|", "6|Tea_2|B|Stock|3",
+		"7|Cake|A|Price|2", "8|Cake|A|Price_2|2.5", "9|Cake|A|Stock|0",
+	}, "\n")
+	if got := tableString(long); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	// lookups work on the result as on any parsed table
+	if value, _, _, ok := long.GetElementByKeys("5", "value"); !ok || value != "" {
+		t.Errorf("got %q, %v", value, ok)
+	}
+	if got := tableString(ht); !strings.HasPrefix(got, "Product|Shop|Price|Price_2|Stock\nTea|A|5|6|12") {
+		t.Errorf("Melt modified the table:\n%v", got)
+	}
+
+	for _, ids := range [][]string{{"color"}, {"shop", "Shop"}, {"product"}} {
+		if _, err := ht.Melt(ids); err == nil {
+			t.Errorf("no error for id columns %v", ids)
+		}
+	}
+}
+
+func TestHtmlTablePivot(t *testing.T) {
+	long := parseTable(t, `<table><tr><th>Row</th><th>Product</th><th>Attribute</th><th>Amount</th></tr>`+
+		`<tr><td>1</td><td>Tea</td><td>Price</td><td>5</td></tr>`+
+		`<tr><td>2</td><td>Cake</td><td>Stock</td><td>0</td></tr>`+
+		`<tr><td>3</td><td>Tea</td><td>Stock</td><td>12</td></tr></table>`, true, true)
+
+	wide, err := long.Pivot("attribute", "amount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// missing combinations are empty
+	if got, want := tableString(wide), "Product|Price|Stock\nTea|5|12\nCake||0"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	if value, _, _, ok := wide.GetElementByKeys("cake", "stock"); !ok || value != "0" {
+		t.Errorf("got %q, %v", value, ok)
+	}
+
+	// duplicate id and variable combinations are an error, since last-wins would silently lose values
+	long.TableData[1][0] = "Tea"
+	long.TableData[1][1] = "Price"
+	if _, err := long.Pivot("Attribute", "Amount"); err == nil || err.Error() != "duplicate value for id 'Tea' and variable 'Price'" {
+		t.Errorf("got error %v for duplicates", err)
+	}
+	if _, err := long.Pivot("Attribute", "Color"); err == nil {
+		t.Error("no error for an unknown value column")
+	}
+	narrow := parseTable(t, `<table><tr><th>Row</th><th>A</th><th>V</th></tr><tr><td>1</td><td>x</td><td>1</td></tr></table>`,
+		true, true)
+	if _, err := narrow.Pivot("A", "V"); err == nil {
+		t.Error("no error without id columns")
+	}
+}
+
+func TestHtmlTableMeltPivotRoundTrip(t *testing.T) {
+	tests := []struct {
+		name                      string
+		html                      string
+		hasHeaderRow, hasIndexCol bool
+		ids                       []string
+	}{
+		{"fixture", reshapeFixture, true, true, nil},
+		{"fixture with id column", reshapeFixture, true, true, []string{"Shop"}},
+		{"artificial keys", `<table><tr><td>x</td><td>1</td><td>2</td></tr><tr><td>x</td><td>3</td><td>4</td></tr></table>`,
+			false, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ht := parseTable(t, tt.html, tt.hasHeaderRow, tt.hasIndexCol)
+			long, err := ht.Melt(tt.ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wide, err := long.Pivot(MeltVariableColumn, MeltValueColumn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tableString(ht)
+			if !tt.hasHeaderRow {
+				// the index column keeps its unique header, see Melt
+				want = strings.Replace(want, TopLeftPlaceholder, TopLeftPlaceholder+"_2", 1)
+			}
+			if got := tableString(wide); got != want {
+				t.Errorf("got\n%v\nwant\n%v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package html_util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ColumnSchema
+// Expectations for a single column of a table, see TableSchema.
+type ColumnSchema struct {
+	Key      string   `json:"key"`                // expected header of the column, compared case-insensitively
+	Aliases  []string `json:"aliases,omitempty"`  // alternative headers of the column
+	Required bool     `json:"required,omitempty"` // whether the table must contain the column
+	Type     string   `json:"type,omitempty"`     // expected type of non-empty cells, one of the CellType constants, "" accepts any
+	Unit     string   `json:"unit,omitempty"`     // expected unit of CellTypeQuantity cells, "" accepts any
+	Nullable bool     `json:"nullable,omitempty"` // whether cells may be empty
+}
+
+// TableSchema
+// Declares the expected shape of a parsed table, see ValidateTable.
+// Schemas can be marshaled to and unmarshaled from JSON, e.g., to supply them as configuration.
+type TableSchema struct {
+	Columns     []ColumnSchema `json:"columns"`
+	UniqueIndex bool           `json:"uniqueIndex,omitempty"` // whether the index keys must be unique
+	MinRows     int            `json:"minRows,omitempty"`     // minimum number of data rows
+	MaxRows     int            `json:"maxRows,omitempty"`     // maximum number of data rows, 0 means no limit
+}
+
+// SchemaViolation
+// A mismatch between a table and its TableSchema.
+type SchemaViolation struct {
+	RowKey string // key of the offending row, "" for violations of the whole table or column
+	Column string // key of the offending column as declared in the schema, "" for violations of the whole table
+	Reason string // description of the mismatch
+}
+
+func (v SchemaViolation) Error() string {
+	switch {
+	case v.Column == "":
+		return v.Reason
+	case v.RowKey == "":
+		return fmt.Sprintf("column '%v': %v", v.Column, v.Reason)
+	default:
+		return fmt.Sprintf("row '%v', column '%v': %v", v.RowKey, v.Column, v.Reason)
+	}
+}
+
+// ValidateTable
+// Checks ht against schema and returns all violations, or nil if ht matches the schema.
+// Columns are located by their key or one of their aliases. Cell types are checked with the cell parsers of this
+// package, e.g., CellTypeNumber accepts "1.234,5" and CellTypeBool accepts the CellBoolSynonyms. Columns of type
+// CellTypeEmpty accept empty cells regardless of Nullable, and an unknown type is reported once per column.
+// Duplicate index keys are detected as made unique during parsing, see TableParseOptions.Suffix.
+func ValidateTable(ht *HtmlTable, schema TableSchema) []SchemaViolation {
+	var violations []SchemaViolation
+	if ht == nil {
+		return []SchemaViolation{{Reason: "table is nil"}}
+	}
+
+	rows := len(ht.TableData)
+	if rows < schema.MinRows {
+		violations = append(violations, SchemaViolation{Reason: fmt.Sprintf("table has %v rows, expected at least %v", rows, schema.MinRows)})
+	}
+	if schema.MaxRows > 0 && rows > schema.MaxRows {
+		violations = append(violations, SchemaViolation{Reason: fmt.Sprintf("table has %v rows, expected at most %v", rows, schema.MaxRows)})
+	}
+	if schema.UniqueIndex && ht.duplicateKeys[0] > 0 {
+		violations = append(violations, SchemaViolation{Reason: fmt.Sprintf("%v index keys are not unique", ht.duplicateKeys[0])})
+	}
+
+	for _, column := range schema.Columns {
+		j := -1
+		for _, key := range append([]string{column.Key}, column.Aliases...) {
+			if _, jj, ok := ht.GetColumnByKey(key); ok {
+				j = jj
+				break
+			}
+		}
+		if j < 0 {
+			if column.Required {
+				violations = append(violations, SchemaViolation{Column: column.Key, Reason: "required column is missing"})
+			}
+			continue
+		}
+		if !isSchemaCellType(column.Type) {
+			violations = append(violations, SchemaViolation{Column: column.Key, Reason: fmt.Sprintf("unknown type '%v' in schema", column.Type)})
+			continue
+		}
+
+		for i := 1; i < len(ht.Index); i++ {
+			var cell string
+			if j == 0 {
+				cell = ht.Index[i]
+			} else {
+				cell = ht.TableData[i-1][j-1]
+			}
+			if reason := checkCellSchema(cell, column); reason != "" {
+				violations = append(violations, SchemaViolation{RowKey: ht.Index[i], Column: column.Key, Reason: reason})
+			}
+		}
+	}
+	return violations
+}
+
+// isSchemaCellType
+// Returns whether cellType is one of the CellType constants or "".
+func isSchemaCellType(cellType string) bool {
+	switch cellType {
+	case "", CellTypeEmpty, CellTypeNumber, CellTypePercent, CellTypeQuantity, CellTypeBool, CellTypeText:
+		return true
+	}
+	return false
+}
+
+// checkCellSchema
+// Returns why cell does not match column, or "" if it does. The type of column must be valid, see isSchemaCellType.
+func checkCellSchema(cell string, column ColumnSchema) string {
+	text := TrimBlank(cell)
+	if text == "" {
+		if column.Nullable || column.Type == CellTypeEmpty {
+			return ""
+		}
+		return "cell is empty"
+	}
+
+	var err error
+	switch column.Type {
+	case "", CellTypeText:
+		return ""
+	case CellTypeEmpty:
+		return fmt.Sprintf("expected an empty cell, got '%v'", cell)
+	case CellTypeNumber:
+		_, err = parseLocaleNumber(text)
+	case CellTypePercent:
+		_, err = ParseCellPercent(text)
+	case CellTypeBool:
+		_, err = ParseCellBool(text)
+	case CellTypeQuantity:
+		var v ValueWithUnit
+		v, err = ParseValueWithUnit(text)
+		if err == nil && column.Unit != "" && !strings.EqualFold(v.Unit, column.Unit) {
+			return fmt.Sprintf("expected unit '%v', got '%v'", column.Unit, v.Unit)
+		}
+	}
+	if err != nil {
+		return fmt.Sprintf("expected %v, got '%v'", column.Type, cell)
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package html_util
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// violationsErrorString
+// Formats violations one per line via their Error method.
+func violationsErrorString(violations []SchemaViolation) string {
+	var lines []string
+	for _, v := range violations {
+		lines = append(lines, v.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestValidateTable(t *testing.T) {
+	ht := parseTable(t, `<table><tr><th>Product</th><th>Price</th><th>Weight</th><th>In Stock</th><th>Note</th></tr>`+
+		`<tr><td>apple</td><td>1,50</td><td>1.2 kg</td><td>yes</td><td></td></tr>`+
+		`<tr><td>pear</td><td>n/a</td><td>300 g</td><td>maybe</td><td></td></tr>`+
+		`<tr><td>apple</td><td>2</td><td></td><td>no</td><td>x</td></tr></table>`, true, true)
+
+	tests := []struct {
+		name   string
+		schema TableSchema
+		want   string
+	}{
+		{"valid", TableSchema{Columns: []ColumnSchema{
+			{Key: "product", Required: true},
+			{Key: "cost", Aliases: []string{"PRICE"}, Type: CellTypeText},
+			{Key: "weight", Type: CellTypeQuantity, Nullable: true},
+		}}, ""},
+		{"cell types", TableSchema{Columns: []ColumnSchema{
+			{Key: "Price", Type: CellTypeNumber},
+			{Key: "Weight", Type: CellTypeQuantity, Unit: "kg"},
+			{Key: "In Stock", Type: CellTypeBool},
+		}}, strings.Join([]string{
+			"row 'pear', column 'Price': expected number, got 'n/a'",
+			"row 'pear', column 'Weight': expected unit 'kg', got 'g'",
+			"row 'apple_2', column 'Weight': cell is empty",
+			"row 'pear', column 'In Stock': expected bool, got 'maybe'",
+		}, "\n")},
+		{"empty type", TableSchema{Columns: []ColumnSchema{{Key: "Note", Type: CellTypeEmpty}}},
+			"row 'apple_2', column 'Note': expected an empty cell, got 'x'"},
+		{"missing and unknown", TableSchema{Columns: []ColumnSchema{
+			{Key: "Color", Required: true},
+			{Key: "Size"},
+			{Key: "Price", Type: "money"},
+		}}, "column 'Color': required column is missing\ncolumn 'Price': unknown type 'money' in schema"},
+		{"index column", TableSchema{Columns: []ColumnSchema{{Key: "Product", Type: CellTypeNumber}}}, strings.Join([]string{
+			"row 'apple', column 'Product': expected number, got 'apple'",
+			"row 'pear', column 'Product': expected number, got 'pear'",
+			"row 'apple_2', column 'Product': expected number, got 'apple_2'",
+		}, "\n")},
+		{"rows and index", TableSchema{UniqueIndex: true, MinRows: 4, MaxRows: 10}, strings.Join([]string{
+			"table has 3 rows, expected at least 4",
+			"1 index keys are not unique",
+		}, "\n")},
+		{"max rows", TableSchema{MaxRows: 2}, "table has 3 rows, expected at most 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := violationsErrorString(ValidateTable(ht, tt.schema)); got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+
+	if got := violationsErrorString(ValidateTable(nil, TableSchema{})); got != "table is nil" {
+		t.Errorf("got %v for a nil table", got)
+	}
+}
+
+func TestTableSchemaJSONRoundTrip(t *testing.T) {
+	schema := TableSchema{
+		Columns: []ColumnSchema{
+			{Key: "Product", Required: true},
+			{Key: "Price", Aliases: []string{"Cost", "Preis"}, Type: CellTypeNumber, Nullable: true},
+			{Key: "Weight", Type: CellTypeQuantity, Unit: "kg"},
+		},
+		UniqueIndex: true,
+		MinRows:     1,
+		MaxRows:     100,
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored TableSchema
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored, schema) {
+		t.Errorf("round trip changed the schema\n%+v\nwant\n%+v", restored, schema)
+	}
+
+	// schemas written by hand use the documented field names
+	config := `{"columns": [{"key": "Price", "type": "number", "nullable": true}], "uniqueIndex": true, "minRows": 2}`
+	var fromConfig TableSchema
+	if err := json.Unmarshal([]byte(config), &fromConfig); err != nil {
+		t.Fatal(err)
+	}
+	want := TableSchema{Columns: []ColumnSchema{{Key: "Price", Type: CellTypeNumber, Nullable: true}}, UniqueIndex: true, MinRows: 2}
+	if !reflect.DeepEqual(fromConfig, want) {
+		t.Errorf("got %+v, want %+v", fromConfig, want)
+	}
+}
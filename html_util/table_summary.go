@@ -0,0 +1,164 @@
+package html_util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxSummarySamples
+// Maximum number of sample values per column of a TableSummary.
+const maxSummarySamples = 3
+
+// Inferred cell types of a ColumnSummary.
+const (
+	CellTypeEmpty    = "empty"    // the cell is blank, see IsBlank
+	CellTypeNumber   = "number"   // e.g., "1,234.5"
+	CellTypePercent  = "percent"  // e.g., "12.5 %"
+	CellTypeQuantity = "quantity" // a number with unit, e.g., "1.2 kg"
+	CellTypeBool     = "bool"     // see ParseCellBool
+	CellTypeText     = "text"     // anything else
+)
+
+// ColumnSummary
+// Describes a data column of a table, see TableSummary.
+type ColumnSummary struct {
+	Key           string         // the column key
+	Type          string         // the most frequent type of the non-empty cells, or CellTypeEmpty, see CellType* constants
+	TypeCounts    map[string]int // number of cells per type
+	Samples       []string       // the first distinct non-empty values
+	EmptyFraction float64        // fraction of empty cells
+	MixedTypes    bool           // whether the non-empty cells have different types
+}
+
+// TableSummary
+// Describes the shape and content of a table, e.g., to detect layout drift of scraped pages.
+type TableSummary struct {
+	Rows                int             // number of data rows
+	Columns             int             // number of data columns
+	Headers             []string        // the data column keys
+	ColumnSummaries     []ColumnSummary // per data column
+	DuplicateIndexKeys  int             // number of index keys which had to be made unique during parsing
+	DuplicateHeaderKeys int             // number of header keys which had to be made unique during parsing
+	RaggedSourceRows    []int           // source rows with fewer cells than the widest row
+	Anomalies           []string        // human-readable descriptions of suspicious properties
+}
+
+// inferCellType
+// Returns the CellType* constant matching s.
+func inferCellType(s string) string {
+	text := TrimBlank(s)
+	if text == "" {
+		return CellTypeEmpty
+	}
+	if v, err := ParseValueWithUnit(text); err == nil {
+		switch v.Unit {
+		case "":
+			return CellTypeNumber
+		case "%":
+			return CellTypePercent
+		default:
+			return CellTypeQuantity
+		}
+	}
+	if _, err := ParseCellBool(text); err == nil {
+		return CellTypeBool
+	}
+	return CellTypeText
+}
+
+// Describe
+// Summarizes the table: counts, headers, per column inferred types with samples and empty fractions, and anomalies like
+// ragged source rows, duplicate keys, and columns with mixed types.
+func (ht HtmlTable) Describe() TableSummary {
+	summary := TableSummary{
+		DuplicateIndexKeys:  ht.duplicateKeys[0],
+		DuplicateHeaderKeys: ht.duplicateKeys[1],
+		RaggedSourceRows:    ht.raggedRows,
+	}
+	if len(ht.Index) > 0 {
+		summary.Rows = len(ht.Index) - 1
+	}
+	if len(ht.Headers) > 0 {
+		summary.Columns = len(ht.Headers) - 1
+		summary.Headers = append([]string(nil), ht.Headers[1:]...)
+	}
+
+	for j := 1; j < len(ht.Headers); j++ {
+		column := ColumnSummary{
+			Key:        ht.Headers[j],
+			Type:       CellTypeEmpty,
+			TypeCounts: make(map[string]int),
+		}
+		seen := make(map[string]bool)
+		for _, row := range ht.TableData {
+			cell := TrimBlank(row[j-1])
+			column.TypeCounts[inferCellType(cell)]++
+			if cell != "" && !seen[cell] && len(column.Samples) < maxSummarySamples {
+				seen[cell] = true
+				column.Samples = append(column.Samples, cell)
+			}
+		}
+
+		nonEmptyTypes, bestCount := 0, 0
+		for cellType, count := range column.TypeCounts {
+			if cellType == CellTypeEmpty {
+				continue
+			}
+			nonEmptyTypes++
+			if count > bestCount || (count == bestCount && cellType < column.Type) {
+				column.Type, bestCount = cellType, count
+			}
+		}
+		column.MixedTypes = nonEmptyTypes > 1
+		if len(ht.TableData) > 0 {
+			column.EmptyFraction = float64(column.TypeCounts[CellTypeEmpty]) / float64(len(ht.TableData))
+		}
+
+		if column.MixedTypes {
+			summary.Anomalies = append(summary.Anomalies, fmt.Sprintf("column '%v' has mixed types: %v", column.Key, formatTypeCounts(column.TypeCounts)))
+		}
+		summary.ColumnSummaries = append(summary.ColumnSummaries, column)
+	}
+
+	if len(ht.raggedRows) > 0 {
+		summary.Anomalies = append(summary.Anomalies, fmt.Sprintf("%v ragged source rows: %v", len(ht.raggedRows), ht.raggedRows))
+	}
+	if ht.duplicateKeys[0] > 0 {
+		summary.Anomalies = append(summary.Anomalies, fmt.Sprintf("%v duplicate index keys", ht.duplicateKeys[0]))
+	}
+	if ht.duplicateKeys[1] > 0 {
+		summary.Anomalies = append(summary.Anomalies, fmt.Sprintf("%v duplicate header keys", ht.duplicateKeys[1]))
+	}
+
+	return summary
+}
+
+// formatTypeCounts
+// Formats the non-empty type counts in a stable order, e.g., "number=3, text=1".
+func formatTypeCounts(counts map[string]int) string {
+	var parts []string
+	for _, cellType := range []string{CellTypeNumber, CellTypePercent, CellTypeQuantity, CellTypeBool, CellTypeText} {
+		if counts[cellType] > 0 {
+			parts = append(parts, fmt.Sprintf("%v=%v", cellType, counts[cellType]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// String
+// Formats the summary human-readable over multiple lines.
+func (ts TableSummary) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "table with %v rows and %v columns\n", ts.Rows, ts.Columns)
+	for _, column := range ts.ColumnSummaries {
+		fmt.Fprintf(&sb, "  '%v': %v, %.0f%% empty", column.Key, column.Type, 100*column.EmptyFraction)
+		if len(column.Samples) > 0 {
+			fmt.Fprintf(&sb, ", e.g., '%v'", strings.Join(column.Samples, "', '"))
+		}
+		sb.WriteString("\n")
+	}
+	for _, anomaly := range ts.Anomalies {
+		fmt.Fprintf(&sb, "  anomaly: %v\n", anomaly)
+	}
+	return sb.String()
+}
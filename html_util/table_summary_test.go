@@ -0,0 +1,60 @@
+package html_util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	ht := parseTable(t, `<table>`+
+		`<tr><th>Item</th><th>Qty</th><th>Qty</th><th>Share</th></tr>`+
+		`<tr><td>a</td><td>1</td><td>yes</td><td>10 %</td></tr>`+
+		`<tr><td>a</td><td>2</td><td>2 kg</td><td></td></tr>`+
+		`<tr><td>b</td><td>1</td></tr>`+
+		`</table>`, true, true)
+
+	summary := ht.Describe()
+	if summary.Rows != 3 || summary.Columns != 3 || strings.Join(summary.Headers, "|") != "Qty|Qty_2|Share" {
+		t.Errorf("got %v rows, %v columns, headers %v", summary.Rows, summary.Columns, summary.Headers)
+	}
+	if summary.DuplicateIndexKeys != 1 || summary.DuplicateHeaderKeys != 1 {
+		t.Errorf("got %v duplicate index and %v duplicate header keys, want 1 and 1", summary.DuplicateIndexKeys, summary.DuplicateHeaderKeys)
+	}
+	if len(summary.RaggedSourceRows) != 1 || summary.RaggedSourceRows[0] != 3 {
+		t.Errorf("RaggedSourceRows = %v, want [3]", summary.RaggedSourceRows)
+	}
+
+	want := `table with 3 rows and 3 columns
+  'Qty': number, 0% empty, e.g., '1', '2'
+  'Qty_2': bool, 33% empty, e.g., 'yes', '2 kg'
+  'Share': percent, 67% empty, e.g., '10 %'
+  anomaly: column 'Qty_2' has mixed types: quantity=1, bool=1
+  anomaly: 1 ragged source rows: [3]
+  anomaly: 1 duplicate index keys
+  anomaly: 1 duplicate header keys
+`
+	if got := summary.String(); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	qty := summary.ColumnSummaries[0]
+	if qty.MixedTypes || qty.TypeCounts[CellTypeNumber] != 3 || qty.EmptyFraction != 0 {
+		t.Errorf("Qty summary = %+v", qty)
+	}
+}
+
+func TestInferCellType(t *testing.T) {
+	tests := map[string]string{
+		" ":       CellTypeEmpty,
+		"1,234.5": CellTypeNumber,
+		"12.5 %":  CellTypePercent,
+		"3 kg":    CellTypeQuantity,
+		"No":      CellTypeBool,
+		"n/a":     CellTypeText,
+	}
+	for s, want := range tests {
+		if got := inferCellType(s); got != want {
+			t.Errorf("inferCellType(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package html_util
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeKey(t *testing.T) {
+	tests := map[string]string{
+		"Net Income: ":    "net income",
+		"Net\u00a0income": "net income",
+		" net   income.":  "net income",
+		"Revenue (in $m)": "revenue (in $m)", // closing brackets are no punctuation to strip
+		"\"Total\".":      "\"total\"",
+		"Q&A?!":           "q&a",
+		"":                "",
+		"\u00a0:\u00a0":   "",
+	}
+	for s, want := range tests {
+		if got := NormalizeKey(s); got != want {
+			t.Errorf("NormalizeKey(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestGetRowByKeyNormalized(t *testing.T) {
+	ht := parseTable(t, `<table>`+
+		`<tr><th>Item</th><th>2023</th></tr>`+
+		`<tr><td>Net&nbsp;income</td><td>10</td></tr>`+
+		`<tr><td>Revenue:</td><td>20</td></tr>`+
+		`</table>`, true, true)
+
+	for _, key := range []string{"Net Income ", "net income:", "Net\u00a0Income", "net  income"} {
+		row, idx, ok := ht.GetRowByKeyNormalized(key, NormalizeKey)
+		if !ok || idx != 1 || strings.Join(row, "|") != "10" || ht.Index[idx] != "Net\u00a0income" {
+			t.Errorf("GetRowByKeyNormalized(%q) = %q, %v, %v, want the net income row", key, row, idx, ok)
+		}
+	}
+	if _, idx, ok := ht.GetRowByKeyNormalized("REVENUE", NormalizeKey); !ok || idx != 2 {
+		t.Errorf("GetRowByKeyNormalized(REVENUE) = %v, %v, want 2, true", idx, ok)
+	}
+	if _, _, ok := ht.GetRowByKeyNormalized("Net Income", strings.TrimSpace); ok {
+		t.Error("a trimming normalizer should not match a differently cased key")
+	}
+	if row, idx, ok := ht.GetRowByKeyNormalized("Costs", NormalizeKey); ok || idx != -1 || row != nil {
+		t.Errorf("GetRowByKeyNormalized(Costs) = %q, %v, %v, want nil, -1, false", row, idx, ok)
+	}
+}
+
+func TestKeyAliases(t *testing.T) {
+	ht := parseTable(t, `<table>`+
+		`<tr><th>Product</th><th>Qty</th><th>Cost</th><th>Price</th></tr>`+
+		`<tr><td>Apple:</td><td>3</td><td>1</td><td>2</td></tr>`+
+		`<tr><td>Pear</td><td>5</td><td>4</td><td>6</td></tr>`+
+		`</table>`, true, true)
+	ht.SetColumnAliases(map[string][]string{
+		"Quantity": {"Amount", "qty"},
+		"quantity": {"Cost"}, // merged with the aliases above, after them
+		"Price":    {"Cost"}, // the real header takes precedence
+		"Weight":   {"Mass"},
+	})
+	ht.SetIndexAliases(map[string][]string{"Malus": {"apple:"}, "Pyrus": {"Pear"}})
+
+	tests := []struct {
+		key, wantHeader string
+		wantIdx         int
+		wantOk          bool
+	}{
+		{"quantity", "Qty", 1, true},
+		{"QTY", "Qty", 1, true},
+		{"price", "Price", 3, true},
+		{"Cost", "Cost", 2, true},
+		{"Weight", "", -1, false},
+		{"Amount", "", -1, false}, // aliases do not resolve in reverse
+	}
+	for _, tt := range tests {
+		header, idx, ok := ht.ResolveColumnKey(tt.key)
+		if header != tt.wantHeader || idx != tt.wantIdx || ok != tt.wantOk {
+			t.Errorf("ResolveColumnKey(%q) = %q, %v, %v, want %q, %v, %v", tt.key, header, idx, ok, tt.wantHeader, tt.wantIdx, tt.wantOk)
+		}
+	}
+
+	if column, _, ok := ht.GetColumnByKey("Quantity"); !ok || strings.Join(column, "|") != "3|5" {
+		t.Errorf("GetColumnByKey(Quantity) = %q, %v", column, ok)
+	}
+	if value, i, j, ok := ht.GetElementByKeys("malus", "quantity"); !ok || value != "3" || i != 1 || j != 1 {
+		t.Errorf("GetElementByKeys(malus, quantity) = %q, %v, %v, %v", value, i, j, ok)
+	}
+	if key, idx, ok := ht.ResolveRowKey("Pyrus"); !ok || key != "Pear" || idx != 2 {
+		t.Errorf("ResolveRowKey(Pyrus) = %q, %v, %v", key, idx, ok)
+	}
+
+	// normalized lookups normalize the aliases and find the aliases of any spelling of a key
+	for _, key := range []string{"Malus", "malus:", " MALUS "} {
+		if _, idx, ok := ht.GetRowByKeyNormalized(key, NormalizeKey); !ok || idx != 1 {
+			t.Errorf("GetRowByKeyNormalized(%q) = %v, %v, want 1, true", key, idx, ok)
+		}
+	}
+
+	ht.SetColumnAliases(nil)
+	if _, _, ok := ht.GetColumnByKey("Quantity"); ok {
+		t.Error("aliases are still used after removing them")
+	}
+}
+
+func TestFindClosestRowKey(t *testing.T) {
+	table := GetElementNodesByTagName("table", parseDocument(t, `<table>`+
+		`<tr><th>Country</th><th>Capital</th></tr>`+
+		`<tr><td>Germany</td><td>Berlin</td></tr>`+
+		`<tr><td>France</td><td>Paris</td></tr>`+
+		`<tr><td>Countr</td><td>-</td></tr>`+
+		`</table>`))[0]
+	ht, err := ParseHtmlTable(table, true, true, "_")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key         string
+		maxDistance int
+		wantKey     string
+		wantIdx     int
+		wantOk      bool
+	}{
+		{"germany", 0, "Germany", 1, true},
+		{"Frnace", 2, "France", 2, true},
+		{"France ", 0, "France", 2, true},
+		{"Spain", 2, "", -1, false},
+		{"Country", 1, "Countr", 3, true}, // the header row key is not a row key
+		{"Country:", 0, "", -1, false},
+	}
+	for _, tt := range tests {
+		key, idx, ok := ht.FindClosestRowKey(tt.key, tt.maxDistance)
+		if key != tt.wantKey || idx != tt.wantIdx || ok != tt.wantOk {
+			t.Errorf("FindClosestRowKey(%q, %v) = %q, %v, %v, want %q, %v, %v", tt.key, tt.maxDistance, key, idx, ok, tt.wantKey, tt.wantIdx, tt.wantOk)
+		}
+		if ok {
+			if row, _ := ht.GetRowByIndex(idx); len(row) == 0 {
+				t.Errorf("GetRowByIndex(%v) yields no row", idx)
+			}
+		}
+	}
+}
+
+// tableString
+// Formats the headers and rows of ht, one line per row with the index key first, cells separated by '|'.
+func tableString(ht *HtmlTable) string {
+	lines := []string{strings.Join(ht.Headers, "|")}
+	for i, row := range ht.TableData {
+		lines = append(lines, ht.Index[i+1]+"|"+strings.Join(row, "|"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestPruneEmptyRowsAndColumns(t *testing.T) {
+	const source = `<table>` +
+		`<tr><th>k</th><th>a</th><th>b</th><th>c</th></tr>` +
+		`<tr><td>1</td><td>x</td><td></td><td>-</td></tr>` +
+		`<tr><td>2</td><td> </td><td></td><td></td></tr>` +
+		`<tr><td>3</td><td>y</td><td>z</td><td>-</td></tr>` +
+		`</table>`
+
+	ht := parseTable(t, source, true, true)
+	if removed := ht.PruneEmptyRows(1); removed != 1 {
+		t.Errorf("removed %v rows, want 1", removed)
+	}
+	if removed := ht.PruneEmptyColumns(0.5); removed != 1 {
+		t.Errorf("removed %v columns, want 1", removed)
+	}
+	if got, want := tableString(ht), "k|a|c\n1|x|-\n3|y|-"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	ht = parseTable(t, source, true, true)
+	isEmpty := func(s string) bool {
+		return strings.TrimSpace(s) == "" || s == "-"
+	}
+	if removed := ht.PruneEmptyColumnsWithEmptyFunc(1, isEmpty); removed != 1 {
+		t.Errorf("removed %v columns treating '-' as empty, want 1", removed)
+	}
+	if removed := ht.PruneEmptyRowsWithEmptyFunc(0.5, isEmpty); removed != 2 {
+		t.Errorf("removed %v rows treating '-' as empty, want 2", removed)
+	}
+	if got, want := tableString(ht), "k|a|b\n3|y|z"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestReorderAndProjectColumns(t *testing.T) {
+	const source = `<table>` +
+		`<tr><th>k</th><th>a</th><th>B</th><th>c</th></tr>` +
+		`<tr><td>1</td><td>a1</td><td>b1</td><td>c1</td></tr>` +
+		`<tr><td>2</td><td>a2</td><td>b2</td><td>c2</td></tr>` +
+		`</table>`
+
+	ht := parseTable(t, source, true, true)
+	if err := ht.ReorderColumns([]string{"c", "b"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tableString(ht), "k|c|B|a\n1|c1|b1|a1\n2|c2|b2|a2"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+	if err := ht.ReorderColumns([]string{"a"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tableString(ht), "k|a\n1|a1\n2|a2"; got != want {
+		t.Errorf("got\n%v\nwant\n%v after dropping omitted columns", got, want)
+	}
+
+	ht = parseTable(t, source, true, true)
+	for _, keys := range [][]string{{"x"}, {"a", "A"}, {"k"}} {
+		if err := ht.ReorderColumns(keys, true); err == nil {
+			t.Errorf("no error for keys %v", keys)
+		}
+	}
+	if got := tableString(ht); got != tableString(parseTable(t, source, true, true)) {
+		t.Errorf("failed ReorderColumns changed the table to\n%v", got)
+	}
+
+	projection, err := ht.ProjectColumns("c", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tableString(projection), "k|c|a\n1|c1|a1\n2|c2|a2"; got != want {
+		t.Errorf("got projection\n%v\nwant\n%v", got, want)
+	}
+	if got, want := tableString(ht), tableString(parseTable(t, source, true, true)); got != want {
+		t.Errorf("ProjectColumns changed the original table to\n%v", got)
+	}
+}
+
+func TestValidateFooterTotals(t *testing.T) {
+	ht := parseTable(t, `<table>`+
+		`<tr><th>Item</th><th>Qty</th><th>Price</th><th>Note</th><th>Code</th></tr>`+
+		`<tr><td>a</td><td>1</td><td>1,50</td><td>x</td><td>7</td></tr>`+
+		`<tr><td>b</td><td>2</td><td></td><td>y</td><td>8</td></tr>`+
+		`<tr><td>c</td><td>3</td><td>2,25</td><td>z</td><td>n/a</td></tr>`+
+		`</table>`, true, true)
+
+	checks, err := ht.ValidateFooterTotals([]string{"Total", "6", "3,70", "", "15"}, nil, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ColumnCheck{
+		{Key: "Qty", Column: 1, Computed: 6, Expected: 6, Passed: true},
+		{Key: "Price", Column: 2, Computed: 3.75, Expected: 3.7, Passed: false},
+	}
+	if len(checks) != len(want) {
+		t.Fatalf("got %+v, want %+v", checks, want)
+	}
+	for i, check := range checks {
+		w := want[i]
+		if check.Key != w.Key || check.Column != w.Column || check.Passed != w.Passed ||
+			math.Abs(check.Computed-w.Computed) > 1e-9 || math.Abs(check.Expected-w.Expected) > 1e-9 {
+			t.Errorf("check %v = %+v, want %+v", i, check, w)
+		}
+	}
+
+	if _, err := ht.ValidateFooterTotals([]string{"Total", "6"}, nil, 0); err == nil {
+		t.Error("expected an error for a footer not matching the headers")
+	}
+
+	checks, err = ht.ValidateFooterTotals([]string{"Total", "6", "3,70", "", "15"}, func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	}, 0)
+	if err != nil || len(checks) != 1 || checks[0].Key != "Qty" {
+		t.Errorf("custom parser: got %+v, %v, want only the Qty check", checks, err)
+	}
+}
+
+func TestValidateFooterTotalsColumnConvention(t *testing.T) {
+	ht := parseTable(t, `<table>`+
+		`<tr><th>Item</th><th>Grouped</th><th>Mixed</th><th>Decimal</th><th>German</th></tr>`+
+		`<tr><td>a</td><td>999</td><td>1,250.5</td><td>1,5</td><td>1.250,5</td></tr>`+
+		`<tr><td>b</td><td>1,001</td><td>999</td><td>1,250</td><td>999</td></tr>`+
+		`<tr><td>c</td><td></td><td>-12,000</td><td>-0,25</td><td>1.000</td></tr>`+
+		`</table>`, true, true)
+
+	// "1,001" and "2,000" are grouped thousands, "1,250" follows the decimal comma of its column
+	checks, err := ht.ValidateFooterTotals([]string{"Total", "2,000", "-9,750.5", "2,5", "3.249,5"}, nil, 1e-9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"Grouped": 2000, "Mixed": -9750.5, "Decimal": 2.5, "German": 3249.5}
+	if len(checks) != len(want) {
+		t.Fatalf("got %+v, want checks of %v", checks, want)
+	}
+	for _, check := range checks {
+		if !check.Passed || math.Abs(check.Computed-want[check.Key]) > 1e-9 {
+			t.Errorf("check %+v, want computed %v", check, want[check.Key])
+		}
+	}
+
+	// a misplaced thousands separator makes the column non-numeric
+	checks, err = ht.ValidateFooterTotals([]string{"Total", "2,00,0", "", "", ""}, nil, 0)
+	if err != nil || len(checks) != 0 {
+		t.Errorf("got %+v, %v, want no checks", checks, err)
+	}
+}
+
+func TestRefreshRowAndCell(t *testing.T) {
+	doc := parseDocument(t, `<table><tr><th>K</th><th>V</th><th>W</th></tr>`+
+		`<tr><td>a</td><td id="a-v"> 1 </td><td>x</td></tr><tr><td>b</td><td id="b-v">2</td></tr></table>`)
+	table := GetElementNodesByTagName("table", doc)[0]
+	opts := TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_", RecordProvenance: true, NormalizerFunc: strings.TrimSpace}
+	ht, err := ParseHtmlTableWithOptions(table, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elementByID(t, doc, "a-v").FirstChild.Data = " 10 "
+	elementByID(t, doc, "b-v").FirstChild.Data = "20"
+	if err := ht.RefreshRow(table, 1, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tableString(ht), "K|V|W\na|10|x\nb|2|"; got != want {
+		t.Errorf("after RefreshRow got\n%v\nwant\n%v", got, want)
+	}
+	if err := ht.RefreshCellByKeys(table, "b", "V", opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.RefreshRow(table, 2, opts); err != nil { // the missing cell of the ragged row is skipped
+		t.Fatal(err)
+	}
+	if got, want := tableString(ht), "K|V|W\na|10|x\nb|20|"; got != want {
+		t.Errorf("after RefreshCellByKeys got\n%v\nwant\n%v", got, want)
+	}
+
+	for name, err := range map[string]error{
+		"row out of bounds": ht.RefreshRow(table, 3, opts),
+		"header row":        ht.RefreshRow(table, 0, opts),
+		"unknown keys":      ht.RefreshCellByKeys(table, "c", "V", opts),
+		"key cell":          ht.RefreshCellByKeys(table, "a", "K", opts),
+		"nil table":         ht.RefreshRow(nil, 1, opts),
+	} {
+		if err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+
+	if err := RemoveNode(elementByID(t, doc, "a-v")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.RefreshRow(table, 1, opts); err == nil || !strings.Contains(err.Error(), "no longer part of the table") {
+		t.Errorf("got %v, want an error for a removed source cell", err)
+	}
+
+	plain := parseTable(t, `<table><tr><th>K</th></tr><tr><td>a</td></tr></table>`, true, true)
+	if err := plain.RefreshRow(table, 1, opts); err == nil || !strings.Contains(err.Error(), "RecordProvenance") {
+		t.Errorf("got %v, want an error asking to record provenance", err)
+	}
+}
+
+func TestHtmlTableCells(t *testing.T) {
+	ht := parseTable(t, `<table><tr><th>Name</th><th>Mail</th><th>Mail</th></tr>`+
+		`<tr><td>Ann</td><td>ann@example.com</td><td>-</td></tr>`+
+		`<tr><td>Ann</td><td>none</td><td>a2@example.org</td></tr></table>`, true, true)
+
+	var cells []string
+	ht.Cells(func(cell CellRef) bool {
+		if got := ht.GetElementByIndex(cell.I, cell.J); got != cell.Value {
+			t.Errorf("cell (%v, %v) has value %v, GetElementByIndex yields %v", cell.I, cell.J, cell.Value, got)
+		}
+		if cell.RowKey != ht.Index[cell.I] || cell.ColumnKey != ht.Headers[cell.J] {
+			t.Errorf("cell (%v, %v) has keys %v, %v", cell.I, cell.J, cell.RowKey, cell.ColumnKey)
+		}
+		cells = append(cells, cell.RowKey+"/"+cell.ColumnKey+"="+cell.Value)
+		return true
+	})
+	// the header row and the index column are excluded, duplicate keys are unique
+	want := "Ann/Mail=ann@example.com Ann/Mail_2=- Ann_2/Mail=none Ann_2/Mail_2=a2@example.org"
+	if got := strings.Join(cells, " "); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	visited := 0
+	ht.Cells(func(cell CellRef) bool {
+		visited++
+		return cell.J < 2
+	})
+	if visited != 2 {
+		t.Errorf("visited %v cells, want 2 until f returns false", visited)
+	}
+
+	var empty HtmlTable
+	empty.Cells(func(cell CellRef) bool {
+		t.Error("visited a cell of an empty table")
+		return true
+	})
+}
+
+func TestHtmlTableMapCells(t *testing.T) {
+	ht := parseTable(t, `<table><tr><th>Name</th><th>Mail</th></tr>`+
+		`<tr><td>ann@example.com</td><td>ann@example.com</td></tr><tr><td>Bob</td><td>none</td></tr></table>`, true, true)
+	ht.MapCells(func(cell CellRef) string {
+		if strings.Contains(cell.Value, "@") {
+			return "[redacted]"
+		}
+		return cell.Value
+	})
+	if got, want := tableString(ht), "Name|Mail\nann@example.com|[redacted]\nBob|none"; got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
+
+func BenchmarkRefreshRow(b *testing.B) {
+	table := makeLargeTable(b, 5000, 10)
+	opts := TableParseOptions{HasHeaderRow: true, HasIndexColumn: true, Suffix: "_", RecordProvenance: true}
+	ht, err := ParseHtmlTableWithOptions(table, opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("refresh row", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := ht.RefreshRow(table, 1+i%5000, opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("full parse", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseHtmlTableWithOptions(table, opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,301 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var whitespaceRegex = regexp.MustCompile(`\s+`)
+
+// isPreformattedTag
+// Returns true for elements whose whitespace is semantically important.
+func isPreformattedTag(tag string) bool {
+	return tag == "pre" || tag == "textarea" || tag == "listing" || tag == "plaintext"
+}
+
+// IncludeShadowTemplates
+// If true, the contents of declarative shadow roots, i.e., <template shadowrootmode="open"> elements, are treated as
+// rendered content, so text extraction and table parsing include them. Other <template> elements are always inert.
+var IncludeShadowTemplates = false
+
+// isNonRenderedTag
+// Returns true for elements whose text content is not rendered.
+func isNonRenderedTag(tag string) bool {
+	return tag == "script" || tag == "style" || tag == "template" || tag == "noscript"
+}
+
+// isNonRenderedNode
+// Returns true for elements whose text content is not rendered, see isNonRenderedTag and IncludeShadowTemplates.
+func isNonRenderedNode(node *html.Node) bool {
+	if node.Data == "template" {
+		return isInertTemplate(node)
+	}
+	return isNonRenderedTag(node.Data)
+}
+
+// isDeclarativeShadowRoot
+// Returns true for <template> elements with a shadowrootmode attribute or its legacy name shadowroot.
+func isDeclarativeShadowRoot(node *html.Node) bool {
+	return node.Type == html.ElementNode && node.Data == "template" && (hasAttribute(node, "shadowrootmode") || hasAttribute(node, "shadowroot"))
+}
+
+// isInertTemplate
+// Returns true for <template> elements whose contents are not rendered, see IncludeShadowTemplates.
+func isInertTemplate(node *html.Node) bool {
+	return node.Type == html.ElementNode && node.Data == "template" && !(IncludeShadowTemplates && isDeclarativeShadowRoot(node))
+}
+
+// GetTemplateContents
+// Returns the content nodes of all <template> elements in the tree of root in document order, i.e., their children.
+// html.Parse attaches the contents of a template as its children, which most helpers of this package treat as inert.
+func GetTemplateContents(root *html.Node) []*html.Node {
+	var contents []*html.Node
+	for _, template := range GetNodesByCondition(root, MakeByTagNameCondition("template")) {
+		contents = append(contents, GetChildren(template)...)
+	}
+	return contents
+}
+
+// GetInnerText
+// Returns the text content of node and its subtree, roughly as a browser would render it.
+// Outside of preformatted elements (<pre>, <textarea>) whitespace runs are collapsed into a single space and the
+// result is trimmed, inside of them whitespace, newlines, and indentation are preserved verbatim.
+// <br> elements yield a newline, the content of <script> and <style> elements is skipped.
+func GetInnerText(node *html.Node) string {
+	return getInnerTextExcluding(node, func(n *html.Node) bool {
+		return false
+	})
+}
+
+// getInnerTextExcluding
+// Same as GetInnerText but skips the subtrees of all nodes for which exclude yields true.
+func getInnerTextExcluding(node *html.Node, exclude func(n *html.Node) bool) string {
+	if node == nil {
+		return ""
+	}
+	var sb strings.Builder
+	writeInnerText(&sb, node, false, exclude, nil)
+	if node.Type == html.ElementNode && isPreformattedTag(node.Data) {
+		return sb.String()
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// writeInnerText
+// Writes the inner text of node to sb, see GetInnerText. Stops as soon as counter is exceeded.
+// The traversal is iterative, so arbitrarily deep trees cannot exhaust the stack.
+func writeInnerText(sb *strings.Builder, node *html.Node, preserve bool, exclude func(n *html.Node) bool, counter *budgetCounter) {
+	preformatted := 0 // number of entered preformatted elements
+	enter := func(n *html.Node) bool {
+		if !counter.visit(n) || exclude(n) {
+			return false
+		}
+		switch n.Type {
+		case html.TextNode:
+			if preserve || preformatted > 0 {
+				sb.WriteString(n.Data)
+				return false
+			}
+			text := whitespaceRegex.ReplaceAllString(n.Data, " ")
+			if current := sb.String(); len(current) == 0 || strings.HasSuffix(current, " ") || strings.HasSuffix(current, "\n") {
+				text = strings.TrimLeft(text, " ")
+			}
+			sb.WriteString(text)
+			return false
+		case html.ElementNode:
+			if isNonRenderedNode(n) {
+				return false
+			}
+			if n.Data == "br" {
+				sb.WriteString("\n")
+				return false
+			}
+			if isPreformattedTag(n.Data) {
+				preformatted++
+			}
+		}
+		return true
+	}
+	leave := func(n *html.Node) {
+		if n.Type == html.ElementNode && isPreformattedTag(n.Data) {
+			preformatted--
+		}
+	}
+	walkTree(node, enter, leave, counter.done)
+}
+
+// PreBlock
+// A preformatted block of text, i.e., a <pre> element or a <code> element outside of a <pre> element.
+type PreBlock struct {
+	Node     *html.Node // the <pre> or <code> element
+	Text     string     // verbatim text content including newlines and indentation
+	Language string     // language hint from a class 'language-*' or 'lang-*' on the element or a nested <code>, or ""
+}
+
+// GetPreformattedBlocks
+// Returns all preformatted blocks in the tree of root (including root) in document order.
+// A <code> element nested inside a <pre> element is part of the <pre> block and not returned separately.
+func GetPreformattedBlocks(root *html.Node) []PreBlock {
+	var blocks []PreBlock
+
+	WalkHtmlTreeInclusive(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || (n.Data != "pre" && n.Data != "code") {
+			return true
+		}
+
+		var sb strings.Builder
+		for _, t := range GetTextNodes(n) {
+			sb.WriteString(t.Data)
+		}
+
+		language := getLanguageHint(n)
+		if language == "" {
+			if code := GetNextNodeByCondition(n, MakeByTagNameCondition("code")); code != nil {
+				language = getLanguageHint(code)
+			}
+		}
+
+		blocks = append(blocks, PreBlock{
+			Node:     n,
+			Text:     sb.String(),
+			Language: language,
+		})
+
+		return false // do not visit nested <code> separately
+	})
+
+	return blocks
+}
+
+func getLanguageHint(node *html.Node) string {
+	attr, err := GetAttributeByKey(node, "class")
+	if err != nil {
+		return ""
+	}
+	for _, className := range strings.Fields(attr.Val) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(className, prefix) && len(className) > len(prefix) {
+				return className[len(prefix):]
+			}
+		}
+	}
+	return ""
+}
+
+// blockTags
+// Elements which start a new block of text, e.g., a paragraph.
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true, "caption": true,
+	"dd": true, "details": true, "dialog": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "header": true, "hgroup": true, "hr": true, "li": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true, "summary": true, "table": true,
+	"td": true, "th": true, "tr": true, "ul": true,
+}
+
+// isBlockTag
+// Returns true for elements which start a new block of text.
+func isBlockTag(tag string) bool {
+	return blockTags[tag]
+}
+
+// getHeadingLevel
+// Returns the level 1-6 of a <h1>-<h6> element, or 0 for any other node.
+func getHeadingLevel(node *html.Node) int {
+	if node.Type != html.ElementNode || len(node.Data) != 2 || node.Data[0] != 'h' || node.Data[1] < '1' || node.Data[1] > '6' {
+		return 0
+	}
+	return int(node.Data[1] - '0')
+}
+
+// AppendTextContent
+// Appends the data of all text nodes of node and its subtree to dst, separated by sep, and returns the extended buffer.
+// Equivalent to MakeTextNodeComposite(GetTextNodes(node), sep) without intermediate allocations, except that the
+// content of <script>, <style>, <template>, and <noscript> elements is skipped.
+func AppendTextContent(dst []byte, node *html.Node, sep string) []byte {
+	first := true
+	_ = visitTextContent(node, func(data string) error {
+		if !first {
+			dst = append(dst, sep...)
+		}
+		first = false
+		dst = append(dst, data...)
+		return nil
+	})
+	return dst
+}
+
+// WriteTextContent
+// Same as AppendTextContent but streams the text to w. Returns the number of bytes written and the first write error.
+func WriteTextContent(w io.Writer, node *html.Node, sep string) (int, error) {
+	written := 0
+	first := true
+	err := visitTextContent(node, func(data string) error {
+		if !first && sep != "" {
+			n, err := io.WriteString(w, sep)
+			written += n
+			if err != nil {
+				return err
+			}
+		}
+		first = false
+		n, err := io.WriteString(w, data)
+		written += n
+		return err
+	})
+	return written, err
+}
+
+// visitTextContent
+// Calls f with the data of every rendered text node of node and its subtree in document order until f returns an
+// error.
+// The traversal is iterative, so arbitrarily deep trees cannot exhaust the stack.
+func visitTextContent(node *html.Node, f func(data string) error) error {
+	var err error
+	enter := func(n *html.Node) bool {
+		switch n.Type {
+		case html.TextNode:
+			err = f(n.Data)
+			return false
+		case html.ElementNode:
+			return !isNonRenderedNode(n)
+		}
+		return true
+	}
+	walkTree(node, enter, nil, func() bool {
+		return err != nil
+	})
+	return err
+}
+
+// isBlankRune
+// Returns true for unicode whitespace, zero-width characters, and the unicode replacement character.
+func isBlankRune(r rune) bool {
+	switch r {
+	case '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff', '\u180e', '\ufffd':
+		return true
+	}
+	return unicode.IsSpace(r)
+}
+
+// IsBlank
+// Returns true if s is effectively empty, i.e., consists only of unicode whitespace including non-breaking spaces,
+// zero-width spaces, joiners, and byte order marks, and the unicode replacement character.
+// This is the definition of emptiness used throughout the package, e.g., for table cells and text nodes.
+func IsBlank(s string) bool {
+	for _, r := range s {
+		if !isBlankRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// TrimBlank
+// Removes all leading and trailing characters of s which are blank, see IsBlank.
+func TrimBlank(s string) string {
+	return strings.TrimFunc(s, isBlankRune)
+}
@@ -0,0 +1,190 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// VisibleTextOptions
+// Configures the text extraction of ExtractTextWithOffsets.
+type VisibleTextOptions struct {
+	BlockSeparator string // written between blocks of text, i.e., around block elements, see isBlockTag, "\n" if empty
+	LineBreak      string // written for <br> elements, "\n" if empty
+	// receives the extraction as operation "ExtractTextWithOffsets", the visited nodes exclude skipped subtrees
+	Instrumentation Instrumentation
+	ExcludeRegions  []RegionKind // skip the subtrees of these regions, e.g., []RegionKind{RegionNav, RegionFooter}
+	Regions         *Regions     // classification used for ExcludeRegions, GetContentRegions(root) if nil
+}
+
+// TextSpan
+// A part of an extracted text which maps rune by rune to the data of a single text node.
+type TextSpan struct {
+	Start      int        // rune offset of the first rune of the span in the extracted text
+	End        int        // rune offset after the last rune of the span in the extracted text
+	Node       *html.Node // text node the span originates from
+	NodeOffset int        // rune offset in Node.Data corresponding to Start
+}
+
+// isCollapsibleSpace
+// Returns true for the whitespace characters which are collapsed outside of preformatted elements, see whitespaceRegex.
+func isCollapsibleSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r'
+}
+
+// offsetTextWriter
+// Builds the text of ExtractTextWithOffsets together with its spans.
+type offsetTextWriter struct {
+	sb           strings.Builder
+	runes        int // number of runes written
+	spans        []TextSpan
+	lineStart    bool       // whether the last write was a separator or a line break
+	pendingBlock bool       // whether a block separator is due before the next text
+	spaceNode    *html.Node // text node of a collapsed space due before the next text, or nil
+	spaceOffset  int        // rune offset of the pending space in spaceNode.Data
+}
+
+// writeRune
+// Writes r which originates from the rune at offset in the data of node, extending the last span if possible.
+func (w *offsetTextWriter) writeRune(r rune, node *html.Node, offset int) {
+	if k := len(w.spans) - 1; k >= 0 && w.spans[k].Node == node && w.spans[k].End == w.runes &&
+		w.spans[k].NodeOffset+w.spans[k].End-w.spans[k].Start == offset {
+		w.spans[k].End++
+	} else {
+		w.spans = append(w.spans, TextSpan{Start: w.runes, End: w.runes + 1, Node: node, NodeOffset: offset})
+	}
+	w.sb.WriteRune(r)
+	w.runes++
+	w.lineStart = r == '\n'
+}
+
+// writeSeparator
+// Writes s which does not originate from any text node.
+func (w *offsetTextWriter) writeSeparator(s string) {
+	w.sb.WriteString(s)
+	w.runes += utf8.RuneCountInString(s)
+	w.lineStart = true
+	w.spaceNode = nil
+}
+
+// flush
+// Writes a pending block separator or, else, a pending collapsed space. Nothing is written at the start of the text or
+// of a line.
+func (w *offsetTextWriter) flush(separator string) {
+	switch {
+	case w.runes == 0:
+	case w.pendingBlock && !w.lineStart:
+		w.writeSeparator(separator)
+	case w.spaceNode != nil && !w.pendingBlock:
+		w.writeRune(' ', w.spaceNode, w.spaceOffset)
+	}
+	w.pendingBlock = false
+	w.spaceNode = nil
+}
+
+// ExtractTextWithOffsets
+// Returns the visible text of the tree of root together with the spans which map it back to the text nodes, e.g., to
+// locate entities found in the text in the document.
+// Outside of preformatted elements, whitespace runs are collapsed into a single space which maps to the first character
+// of the run, leading and trailing whitespace of the text and of its lines is dropped, and block elements are
+// separated by VisibleTextOptions.BlockSeparator. Inside of them, text is kept verbatim. <br> elements yield
+// VisibleTextOptions.LineBreak, the content of non-rendered elements like <script> and of the regions in
+// VisibleTextOptions.ExcludeRegions is skipped.
+// All offsets count runes, not bytes. Spans are ordered by their offsets, separators and line breaks are not covered by
+// any span. See NodeAtOffset for the reverse mapping.
+func ExtractTextWithOffsets(root *html.Node, opts VisibleTextOptions) (string, []TextSpan) {
+	separator := opts.BlockSeparator
+	if separator == "" {
+		separator = "\n"
+	}
+	lineBreak := opts.LineBreak
+	if lineBreak == "" {
+		lineBreak = "\n"
+	}
+
+	timer := startOperation(opts.Instrumentation, "ExtractTextWithOffsets")
+	visited := 0
+	excluded := makeRegionExclusion(root, opts.ExcludeRegions, opts.Regions)
+
+	w := &offsetTextWriter{}
+	preformatted := 0 // number of entered preformatted elements
+	enter := func(n *html.Node) bool {
+		visited++
+		if excluded != nil && excluded(n) {
+			return false
+		}
+		switch n.Type {
+		case html.TextNode:
+			offset := 0
+			for _, r := range n.Data {
+				switch {
+				case preformatted > 0:
+					w.flush(separator)
+					w.writeRune(r, n, offset)
+				case !isCollapsibleSpace(r):
+					w.flush(separator)
+					w.writeRune(r, n, offset)
+				case w.spaceNode == nil && !w.pendingBlock && !w.lineStart && w.runes > 0:
+					w.spaceNode, w.spaceOffset = n, offset
+				}
+				offset++
+			}
+			return false
+		case html.ElementNode:
+			if isNonRenderedNode(n) {
+				return false
+			}
+			if n.Data == "br" {
+				w.spaceNode = nil // whitespace before a line break is trailing whitespace of its line
+				w.flush(separator)
+				w.writeSeparator(lineBreak)
+				return false
+			}
+			if isPreformattedTag(n.Data) {
+				preformatted++
+			}
+			if isBlockTag(n.Data) {
+				w.pendingBlock = true
+			}
+		}
+		return true
+	}
+	leave := func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		if isPreformattedTag(n.Data) {
+			preformatted--
+		}
+		if isBlockTag(n.Data) {
+			w.pendingBlock = true
+		}
+	}
+	walkTree(root, enter, leave, nil)
+	timer.end(visited)
+
+	return w.sb.String(), w.spans
+}
+
+// NodeAtOffset
+// Returns the text node and the rune offset in its data from which the rune at offset of a text extracted by
+// ExtractTextWithOffsets originates, or nil and -1 if the rune was inserted, e.g., a block separator, or if offset is
+// out of range.
+func NodeAtOffset(spans []TextSpan, offset int) (*html.Node, int) {
+	k := sort.Search(len(spans), func(k int) bool {
+		return spans[k].End > offset
+	})
+	if k == len(spans) || spans[k].Start > offset {
+		return nil, -1
+	}
+	return spans[k].Node, spans[k].NodeOffset + offset - spans[k].Start
+}
+
+// CountWords
+// Returns the number of whitespace separated words of the visible text of the tree of root as extracted by
+// ExtractTextWithOffsets with opts, e.g., to count only the main content with VisibleTextOptions.ExcludeRegions.
+func CountWords(root *html.Node, opts VisibleTextOptions) int {
+	text, _ := ExtractTextWithOffsets(root, opts)
+	return len(strings.Fields(text))
+}
@@ -0,0 +1,142 @@
+package html_util
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+)
+
+// checkTextSpans
+// Verifies the invariants of the result of ExtractTextWithOffsets: spans are ordered and disjoint, every rune of a span
+// equals the rune of its text node it maps to or is a space collapsing a whitespace rune, and NodeAtOffset agrees.
+func checkTextSpans(t *testing.T, text string, spans []TextSpan) {
+	t.Helper()
+	runes := []rune(text)
+	covered := make([]bool, len(runes))
+	end := 0
+	for _, span := range spans {
+		if span.Start < end || span.End <= span.Start || span.End > len(runes) || span.Node == nil || span.NodeOffset < 0 {
+			t.Fatalf("span %+v is out of order or range of %v runes", span, len(runes))
+		}
+		end = span.End
+		data := []rune(span.Node.Data)
+		for k := span.Start; k < span.End; k++ {
+			covered[k] = true
+			source := span.NodeOffset + k - span.Start
+			if source >= len(data) {
+				t.Fatalf("span %+v exceeds its node data %q", span, span.Node.Data)
+			}
+			if data[source] != runes[k] && !(runes[k] == ' ' && isCollapsibleSpace(data[source])) {
+				t.Fatalf("rune %v %q maps to %q of %q", k, runes[k], data[source], span.Node.Data)
+			}
+			if node, offset := NodeAtOffset(spans, k); node != span.Node || offset != source {
+				t.Fatalf("NodeAtOffset(%v) = %p, %v, want %p, %v", k, node, offset, span.Node, source)
+			}
+		}
+	}
+	for k, ok := range covered {
+		if node, offset := NodeAtOffset(spans, k); !ok && (node != nil || offset != -1) {
+			t.Fatalf("NodeAtOffset(%v) of an inserted rune = %p, %v", k, node, offset)
+		}
+	}
+}
+
+// spansString
+// Formats spans as the extracted text they cover together with the node data offset, e.g., `"b c"@2`.
+func spansString(text string, spans []TextSpan) string {
+	runes := []rune(text)
+	var parts []string
+	for _, span := range spans {
+		parts = append(parts, fmt.Sprintf("%q@%v", string(runes[span.Start:span.End]), span.NodeOffset))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestExtractTextWithOffsets(t *testing.T) {
+	tests := []struct {
+		name      string
+		html      string
+		opts      VisibleTextOptions
+		wantText  string
+		wantSpans string
+	}{
+		{"collapsed whitespace", "<p>  a \t\n b  </p>", VisibleTextOptions{}, "a b", `"a "@2 "b"@7`},
+		{"space across nodes", "<p>a <b> b</b> <i>c</i></p>", VisibleTextOptions{}, "a b c", `"a "@0 "b"@1 " "@0 "c"@0`},
+		{"blocks", "<div>a</div>  <div> <p>b</p>c </div>d", VisibleTextOptions{}, "a\nb\nc\nd",
+			`"a"@0 "b"@0 "c"@0 "d"@0`},
+		{"custom separators", "<p>a<br> b</p><p>c</p>", VisibleTextOptions{BlockSeparator: " | ", LineBreak: " / "},
+			"a / b | c", `"a"@0 "b"@1 "c"@0`},
+		{"line breaks", "a<br><br>b <br> c", VisibleTextOptions{}, "a\n\nb\nc", `"a"@0 "b"@0 "c"@1`},
+		{"preformatted", "<p>x</p><pre>  a\n  b </pre>y", VisibleTextOptions{}, "x\n  a\n  b \ny",
+			`"x"@0 "  a\n  b "@0 "y"@0`},
+		{"multibyte", "<p>größer  als €</p>", VisibleTextOptions{}, "größer als €", `"größer "@0 "als €"@8`},
+		{"non-breaking space", "<p>a&nbsp; \u00a0b</p>", VisibleTextOptions{}, "a\u00a0 \u00a0b", `"a\u00a0 \u00a0b"@0`},
+		{"non-rendered", "<p>a<script>x()</script><style>p{}</style> b<template>t</template></p>", VisibleTextOptions{},
+			"a b", `"a"@0 " b"@0`},
+		{"empty", "<p> </p><div>\n</div>", VisibleTextOptions{}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := GetElementNodesByTagName("body", parseDocument(t, tt.html))[0]
+			text, spans := ExtractTextWithOffsets(body, tt.opts)
+			if text != tt.wantText {
+				t.Errorf("got text %q, want %q", text, tt.wantText)
+			}
+			if got := spansString(text, spans); got != tt.wantSpans {
+				t.Errorf("got spans\n%v\nwant\n%v", got, tt.wantSpans)
+			}
+			checkTextSpans(t, text, spans)
+		})
+	}
+}
+
+func TestNodeAtOffset(t *testing.T) {
+	doc := parseDocument(t, `<p id="p">Hello  <b id="b">wörld</b></p><p>next</p>`)
+	text, spans := ExtractTextWithOffsets(doc, VisibleTextOptions{})
+	if text != "Hello wörld\nnext" {
+		t.Fatalf("got %q", text)
+	}
+	b := elementByID(t, doc, "b").FirstChild
+	tests := []struct {
+		offset     int
+		wantNode   *html.Node
+		wantOffset int
+	}{
+		{0, elementByID(t, doc, "p").FirstChild, 0},
+		{5, elementByID(t, doc, "p").FirstChild, 5},
+		{7, b, 1},
+		{10, b, 4},
+		{11, nil, -1}, // block separator
+		{12, GetElementNodesByTagName("p", doc)[1].FirstChild, 0},
+		{16, nil, -1},
+		{-1, nil, -1},
+	}
+	for _, tt := range tests {
+		if node, offset := NodeAtOffset(spans, tt.offset); node != tt.wantNode || offset != tt.wantOffset {
+			t.Errorf("NodeAtOffset(%v) = %p, %v, want %p, %v", tt.offset, node, offset, tt.wantNode, tt.wantOffset)
+		}
+	}
+	if node, offset := NodeAtOffset(nil, 0); node != nil || offset != -1 {
+		t.Errorf("NodeAtOffset without spans = %p, %v", node, offset)
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	doc := parseDocument(t, `<h1>Title</h1><p>one two<br>three</p><script>not counted</script><p>four</p>`)
+	if got := CountWords(doc, VisibleTextOptions{}); got != 5 {
+		t.Errorf("got %v words, want 5", got)
+	}
+}
+
+func FuzzExtractTextWithOffsets(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Add("<p> a \n<b> b </b>\t</p>c<br> d<pre> e\n</pre> f\xff ")
+	f.Fuzz(func(t *testing.T, data string) {
+		doc := parseFuzzDocument(t, data)
+		text, spans := ExtractTextWithOffsets(doc, VisibleTextOptions{})
+		checkTextSpans(t, text, spans)
+	})
+}
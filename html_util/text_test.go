@@ -0,0 +1,250 @@
+package html_util
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"io"
+	"math/rand"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestGetInnerTextPreservesPreformattedWhitespace(t *testing.T) {
+	doc := parseDocument(t, "<div>\n  Hello   <b>big</b>\n world<br>next<script>var x;</script><style>p{}</style>"+
+		"<pre>  line 1\n    indented</pre> end</div>")
+	div := GetElementNodesByTagName("div", doc)[0]
+
+	want := "Hello big world\nnext  line 1\n    indented end"
+	if got := GetInnerText(div); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	pre := GetElementNodesByTagName("pre", doc)[0]
+	if got, want := GetInnerText(pre), "  line 1\n    indented"; got != want {
+		t.Errorf("got %q for the <pre> itself, want %q", got, want)
+	}
+	if got := GetInnerText(nil); got != "" {
+		t.Errorf("got %q for nil", got)
+	}
+}
+
+func TestGetPreformattedBlocks(t *testing.T) {
+	doc := parseDocument(t, `<pre class="x"><code class="language-go">func main() {
+	return
+}</code></pre><p>inline <code class="lang-sh">ls -l</code> and <code>plain</code></p>`)
+
+	blocks := GetPreformattedBlocks(doc)
+	if len(blocks) != 3 {
+		t.Fatalf("got %v blocks, want 3", len(blocks))
+	}
+	want := []PreBlock{
+		{Text: "func main() {\n\treturn\n}", Language: "go"},
+		{Text: "ls -l", Language: "sh"},
+		{Text: "plain"},
+	}
+	for i, block := range blocks {
+		if block.Text != want[i].Text || block.Language != want[i].Language {
+			t.Errorf("block %v = %q (%q), want %q (%q)", i, block.Text, block.Language, want[i].Text, want[i].Language)
+		}
+	}
+	if blocks[0].Node.Data != "pre" || blocks[1].Node.Data != "code" {
+		t.Errorf("got blocks of %v and %v, want pre and code", blocks[0].Node.Data, blocks[1].Node.Data)
+	}
+}
+
+// failingWriter
+// Accepts limit bytes and fails afterwards.
+type failingWriter struct {
+	limit int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errors.New("write failed")
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestAppendAndWriteTextContent(t *testing.T) {
+	doc := parseDocument(t, `<div>a<b>b</b><!-- c --><p>d<i></i>e</p></div>`+
+		`<div>x<script>skip()</script><style>p{}</style><template>t</template><noscript>n</noscript>y</div>`)
+	divs := GetElementNodesByTagName("div", doc)
+
+	// without skipped elements, the result equals the composite of all text nodes
+	want := MakeTextNodeComposite(GetTextNodes(divs[0]), "|")
+	if got := string(AppendTextContent([]byte("prefix:"), divs[0], "|")); got != "prefix:"+want || want != "a|b|d|e" {
+		t.Errorf("AppendTextContent = %q, want prefix:%v", got, want)
+	}
+	if got := string(AppendTextContent(nil, divs[1], ",")); got != "x,y" {
+		t.Errorf("AppendTextContent = %q, want the content of non-rendered elements skipped", got)
+	}
+	if got := AppendTextContent(nil, nil, ","); len(got) != 0 {
+		t.Errorf("AppendTextContent(nil) = %q", got)
+	}
+
+	var sb strings.Builder
+	if n, err := WriteTextContent(&sb, divs[0], ", "); err != nil || sb.String() != "a, b, d, e" || n != sb.Len() {
+		t.Errorf("WriteTextContent = %v, %v, wrote %q", n, err, sb.String())
+	}
+	if n, err := WriteTextContent(&failingWriter{limit: 4}, divs[0], ", "); err == nil || n != 4 {
+		t.Errorf("WriteTextContent to a failing writer = %v, %v, want 4 and an error", n, err)
+	}
+}
+
+func TestTextContentDeepTree(t *testing.T) {
+	// deep enough to exhaust the limited stack with a recursive traversal
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+	root := &html.Node{Type: html.ElementNode, Data: "div"}
+	parent := root
+	for i := 0; i < 100000; i++ {
+		child := &html.Node{Type: html.ElementNode, Data: "span"}
+		parent.AppendChild(child)
+		parent = child
+	}
+	parent.AppendChild(&html.Node{Type: html.TextNode, Data: "deep"})
+
+	if got := string(AppendTextContent(nil, root, " ")); got != "deep" {
+		t.Errorf("AppendTextContent = %q, want deep", got)
+	}
+}
+
+func BenchmarkTextContent(b *testing.B) {
+	doc := parseDocument(b, makeLargeDocument(2000))
+	b.Run("GetTextNodes+MakeTextNodeComposite", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = MakeTextNodeComposite(GetTextNodes(doc), " ")
+		}
+	})
+	b.Run("AppendTextContent", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			buf = AppendTextContent(buf[:0], doc, " ")
+		}
+	})
+	b.Run("WriteTextContent", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := WriteTextContent(io.Discard, doc, " "); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestIsBlank(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"", true},
+		{" \t\n\r\v\f", true},
+		{"\u00a0\u202f\u2007", true},       // non-breaking spaces
+		{"\u200b\u200c\u200d\u2060", true}, // zero-width spaces and joiners
+		{"\ufeff", true},                   // byte order mark
+		{"\u3000\u2028\u2029\u0085", true}, // ideographic space, line and paragraph separators, next line
+		{"\ufffd", true},                   // replacement character
+		{"\xff\xfe", true},                 // invalid utf-8 decodes to the replacement character
+		{"a", false},
+		{"\u00a0€\u00a0", false},
+		{"—", false},
+		{"\u00ad", false}, // soft hyphen
+		{"\u200e", false}, // left-to-right mark
+		{"0", false},
+	}
+	for _, tt := range tests {
+		if got := IsBlank(tt.s); got != tt.want {
+			t.Errorf("IsBlank(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+		if got := TrimBlank(tt.s) == ""; got != tt.want {
+			t.Errorf("TrimBlank(%q) = %q", tt.s, TrimBlank(tt.s))
+		}
+	}
+	if got := TrimBlank("\u200b\u00a0 a\u00a0b \ufeff\n"); got != "a\u00a0b" {
+		t.Errorf("TrimBlank removed inner or kept outer blanks: %q", got)
+	}
+}
+
+func TestIsBlankProperties(t *testing.T) {
+	alphabet := []string{" ", "\t", "\n", "\u00a0", "\u200b", "\u200d", "\ufeff", "\ufffd", "\u3000", "\xff",
+		"a", "€", "—", "\u00ad", "\u200e", "e\u0301", "😀"}
+	rnd := rand.New(rand.NewSource(1))
+	random := func() string {
+		var sb strings.Builder
+		for k := rnd.Intn(8); k > 0; k-- {
+			sb.WriteString(alphabet[rnd.Intn(len(alphabet))])
+		}
+		return sb.String()
+	}
+
+	for i := 0; i < 10000; i++ {
+		s, u := random(), random()
+		trimmed := TrimBlank(s)
+		if IsBlank(s) != (trimmed == "") {
+			t.Fatalf("IsBlank(%q) = %v, but TrimBlank yields %q", s, IsBlank(s), trimmed)
+		}
+		if TrimBlank(trimmed) != trimmed {
+			t.Fatalf("TrimBlank is not idempotent for %q", s)
+		}
+		if !strings.Contains(s, trimmed) {
+			t.Fatalf("TrimBlank(%q) = %q is not a substring", s, trimmed)
+		}
+		if IsBlank(s+u) != (IsBlank(s) && IsBlank(u)) {
+			t.Fatalf("IsBlank of the concatenation of %q and %q is inconsistent", s, u)
+		}
+		// every emptiness check of the package agrees with IsBlank
+		if isEmptyCell(s) != IsBlank(s) || DefaultContentText(s) == IsBlank(s) || isContentText(s) == IsBlank(s) {
+			t.Fatalf("emptiness checks disagree with IsBlank for %q", s)
+		}
+	}
+}
+
+func TestIncludeShadowTemplates(t *testing.T) {
+	defer func(include bool) { IncludeShadowTemplates = include }(IncludeShadowTemplates)
+	doc := parseDocument(t, `<div id="host"><template shadowrootmode="open"><p>Shadow text</p>`+
+		`<a href="https://example.com/shadow">more</a><table><tr><td>cell</td></tr></table></template><p>light</p></div>`+
+		`<template id="row"><p>inert</p><a href="https://example.com/inert">x</a><table><tr><td>t</td></tr></table></template>`)
+
+	tests := []struct {
+		include bool
+		text    string
+		urls    string
+		tables  int
+	}{
+		{false, "light", "", 0},
+		{true, "Shadow text\nmore\ncell\nlight", "https://example.com/shadow", 1},
+	}
+	for _, tt := range tests {
+		IncludeShadowTemplates = tt.include
+		if got, _ := ExtractTextWithOffsets(doc, VisibleTextOptions{}); got != tt.text {
+			t.Errorf("IncludeShadowTemplates %v: text %q, want %q", tt.include, got, tt.text)
+		}
+		var urls []string
+		for _, u := range ExtractURLs(doc, nil, URLExtractOptions{}) {
+			urls = append(urls, u.URL.String())
+		}
+		if got := strings.Join(urls, " "); got != tt.urls {
+			t.Errorf("IncludeShadowTemplates %v: urls %q, want %q", tt.include, got, tt.urls)
+		}
+		tables, err := ParseAllHtmlTables(doc, TableParseOptions{Suffix: "_"})
+		if err != nil || len(tables) != tt.tables {
+			t.Errorf("IncludeShadowTemplates %v: %v tables with error %v, want %v", tt.include, len(tables), err, tt.tables)
+		} else if tt.tables > 0 && tableString(tables[0]) != "Index\\Header|1\n1|cell" {
+			t.Errorf("got table\n%v", tableString(tables[0]))
+		}
+	}
+
+	// explicit access to all template contents regardless of the option
+	IncludeShadowTemplates = false
+	var tags []string
+	for _, n := range GetTemplateContents(doc) {
+		tags = append(tags, n.Data)
+	}
+	if got := strings.Join(tags, " "); got != "p a table p a table" {
+		t.Errorf("GetTemplateContents = %v", got)
+	}
+}
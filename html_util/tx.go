@@ -0,0 +1,104 @@
+package html_util
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+)
+
+// ErrTxFinished
+// Returned by TreeTx.Commit and TreeTx.Rollback if the transaction was already committed or rolled back.
+var ErrTxFinished = errors.New("tree transaction already committed or rolled back")
+
+// TreeTx
+// A snapshot of a tree which allows to undo all modifications made since BeginTreeTransaction, see Rollback.
+type TreeTx struct {
+	root     *html.Node
+	snapshot *html.Node
+	parent   *html.Node   // original parent of root
+	siblings []*html.Node // original children of parent, including root
+	finished bool
+}
+
+// BeginTreeTransaction
+// Snapshots the tree of root, which may be a subtree of a larger document, via CloneTree.
+// The tree can be modified freely afterwards, e.g., by the mutation helpers of this package, until the transaction is
+// finished via Commit or Rollback.
+func BeginTreeTransaction(root *html.Node) *TreeTx {
+	tx := &TreeTx{
+		root:     root,
+		snapshot: CloneTree(root),
+	}
+	if root != nil && root.Parent != nil {
+		tx.parent = root.Parent
+		for c := root.Parent.FirstChild; c != nil; c = c.NextSibling {
+			tx.siblings = append(tx.siblings, c)
+		}
+	}
+	return tx
+}
+
+// Root
+// Returns the root of the transaction.
+func (tx *TreeTx) Root() *html.Node {
+	return tx.root
+}
+
+// Commit
+// Keeps all modifications and releases the snapshot.
+func (tx *TreeTx) Commit() error {
+	if tx.finished {
+		return ErrTxFinished
+	}
+	tx.finished = true
+	tx.snapshot = nil
+	return nil
+}
+
+// Rollback
+// Restores root in place to its state at the start of the transaction, so it renders identically to it afterwards.
+// root keeps its identity and the child list of its original parent is restored as well, i.e., root and its original
+// siblings are moved back to their original positions and nodes inserted next to them meanwhile are detached, e.g., the
+// replacement of root by ReplaceNode. The subtrees of the siblings are not restored.
+// The descendants of root are replaced by the snapshot, so references to them obtained before the rollback are stale.
+// Returns ErrFrozenNode if root was frozen meanwhile.
+func (tx *TreeTx) Rollback() error {
+	if tx.finished {
+		return ErrTxFinished
+	}
+	root, snapshot := tx.root, tx.snapshot
+	if root == nil {
+		tx.finished = true
+		return nil
+	}
+	if err := checkMutable(root); err != nil {
+		return err
+	}
+	tx.finished = true
+	tx.snapshot = nil
+
+	root.Type, root.DataAtom, root.Data, root.Namespace, root.Attr = snapshot.Type, snapshot.DataAtom, snapshot.Data, snapshot.Namespace, snapshot.Attr
+	for root.FirstChild != nil {
+		root.RemoveChild(root.FirstChild)
+	}
+	for snapshot.FirstChild != nil {
+		c := snapshot.FirstChild
+		snapshot.RemoveChild(c)
+		root.AppendChild(c)
+	}
+
+	if tx.parent == nil {
+		detachNode(root)
+		return nil
+	}
+	for tx.parent.FirstChild != nil {
+		tx.parent.RemoveChild(tx.parent.FirstChild)
+	}
+	for _, c := range tx.siblings {
+		if isAncestorOrSelf(c, tx.parent) {
+			continue // a sibling which became an ancestor of the parent cannot be moved back without a cycle
+		}
+		detachNode(c)
+		tx.parent.AppendChild(c)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package html_util
+
+import (
+	"errors"
+	"golang.org/x/net/html"
+	"testing"
+)
+
+// newElement
+// Returns a detached element with the given tag and text content.
+func newElement(tag, text string) *html.Node {
+	node := &html.Node{Type: html.ElementNode, Data: tag}
+	node.AppendChild(&html.Node{Type: html.TextNode, Data: text})
+	return node
+}
+
+func TestTreeTxRollbackAfterReplaceNode(t *testing.T) {
+	doc := parseDocument(t, `<p>before</p><div id="root"><span>a</span></div><p>after</p>`)
+	want := renderNode(t, doc)
+	root := GetElementNodesByTagName("div", doc)[0]
+
+	tx := BeginTreeTransaction(root)
+	replacement := newElement("section", "replacement")
+	if err := ReplaceNode(root, replacement); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := renderNode(t, doc); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if replacement.Parent != nil {
+		t.Error("replacement of root is still attached")
+	}
+	if GetElementNodesByTagName("div", doc)[0] != root {
+		t.Error("root lost its identity")
+	}
+}
+
+func TestTreeTxRollbackInterleavedMutations(t *testing.T) {
+	doc := parseDocument(t, `<p id="1">before</p><div id="root"><span>a</span><b>b</b></div><p id="2">after</p>`)
+	want := renderNode(t, doc)
+	root := GetElementNodesByTagName("div", doc)[0]
+	body := root.Parent
+	before, after := root.PrevSibling, root.NextSibling
+
+	tx := BeginTreeTransaction(root)
+	wrapper := newElement("section", "wrapper")
+	steps := []func() error{
+		func() error { return InsertNodeBefore(root, newElement("i", "inserted before")) },
+		func() error { return RemoveNode(root.FirstChild) },
+		func() error { return ReplaceNode(root, wrapper) },
+		func() error { return AppendChildNode(wrapper, root) }, // root moves into its replacement
+		func() error { return AppendChildNode(root, after) },   // a sibling moves into root
+		func() error { return InsertNodeAfter(wrapper, newElement("u", "inserted after")) },
+		func() error { return RemoveNode(before) },
+		func() error { return AppendChildNode(root, newElement("em", "appended")) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %v: %v", i, err)
+		}
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := renderNode(t, doc); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if root.Parent != body || root.PrevSibling != before || root.NextSibling != after {
+		t.Error("root and its siblings are not at their original positions")
+	}
+	if wrapper.Parent != nil {
+		t.Error("replacement of root is still attached")
+	}
+	if err := tx.Rollback(); !errors.Is(err, ErrTxFinished) {
+		t.Errorf("second Rollback returned %v, want ErrTxFinished", err)
+	}
+}
+
+func TestTreeTxCommitKeepsModifications(t *testing.T) {
+	doc := parseDocument(t, `<div><span>a</span></div>`)
+	root := GetElementNodesByTagName("div", doc)[0]
+
+	tx := BeginTreeTransaction(root)
+	if err := RemoveNode(root.FirstChild); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if root.FirstChild != nil {
+		t.Error("modification was undone by Commit")
+	}
+	if err := tx.Rollback(); !errors.Is(err, ErrTxFinished) {
+		t.Errorf("Rollback after Commit returned %v, want ErrTxFinished", err)
+	}
+}
+
+func TestTreeTxRollbackAttributeEdits(t *testing.T) {
+	doc := parseDocument(t, `<div id="root" class="a b" style="color: red"><p id="p" data-x="1">text <a href="/x">x</a></p></div>`)
+	want := renderNode(t, doc)
+	root, p := elementByID(t, doc, "root"), elementByID(t, doc, "p")
+
+	tx := BeginTreeTransaction(doc)
+	root.Attr[1].Val = "changed" // edited in place
+	p.Attr = append(p.Attr, html.Attribute{Key: "title", Val: "added"})
+	StripAttributes(doc, nil, []string{"id"})
+	if err := RemoveNode(p.FirstChild); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendChildNode(root, newElement("span", "inserted")); err != nil {
+		t.Fatal(err)
+	}
+	if renderNode(t, doc) == want {
+		t.Fatal("the mutations had no effect")
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if got := renderNode(t, doc); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestTreeTxRollbackFrozenAndNil(t *testing.T) {
+	frozen := FreezeTree(parseDocument(t, `<p>x</p>`))
+	defer frozen.Release()
+	if err := BeginTreeTransaction(frozen.Root()).Rollback(); !errors.Is(err, ErrFrozenNode) {
+		t.Errorf("Rollback of a frozen tree returned %v, want ErrFrozenNode", err)
+	}
+
+	tx := BeginTreeTransaction(nil)
+	if err := tx.Rollback(); err != nil || tx.Root() != nil {
+		t.Errorf("Rollback of nil returned %v", err)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrTxFinished) {
+		t.Errorf("Commit after Rollback returned %v, want ErrTxFinished", err)
+	}
+}
@@ -0,0 +1,210 @@
+package html_util
+
+import (
+	"golang.org/x/net/html"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// DefaultURLAttributes
+// Attributes ExtractURLs collects URLs from if URLExtractOptions.Attributes is nil.
+var DefaultURLAttributes = []string{"href", "src", "action", "formaction", "poster", "data", "cite", "srcset"}
+
+// URLExtractOptions
+// Configures ExtractURLs.
+type URLExtractOptions struct {
+	Attributes     []string // attributes to collect URLs from, DefaultURLAttributes if nil, 'srcset' is split into its candidates
+	AllowedSchemes []string // lower case schemes to keep, "http" and "https" if nil
+	SameHost       bool     // only keep URLs with the same host as the base url
+	KeepFragments  bool     // keep '#fragment' parts, else, they are removed before deduplication
+	// receives the extraction as operation "ExtractURLs", the visited nodes do not include the lookup of <base href>
+	Instrumentation Instrumentation
+	ExcludeRegions  []RegionKind // skip the subtrees of these regions, e.g., []RegionKind{RegionNav, RegionFooter}
+	Regions         *Regions     // classification used for ExcludeRegions, GetContentRegions(root) if nil
+}
+
+// URLOccurrence
+// An attribute of a node a URL was extracted from.
+type URLOccurrence struct {
+	Node      *html.Node
+	Attribute string
+}
+
+// ExtractedURL
+// A deduplicated, resolved URL together with all its occurrences in document order.
+type ExtractedURL struct {
+	URL         *url.URL
+	Occurrences []URLOccurrence
+}
+
+// srcsetCandidate
+// An image candidate of a srcset attribute, e.g., "image.png 2x".
+type srcsetCandidate struct {
+	URL        string
+	Descriptor string // e.g., "2x" or "480w", "" if missing
+}
+
+// parseSrcset
+// Splits the value of a srcset attribute into its image candidates.
+// The url of a candidate ends at whitespace, a trailing comma ends the candidate, else the descriptor follows up to the
+// next comma.
+func parseSrcset(s string) []srcsetCandidate {
+	var candidates []srcsetCandidate
+	for len(s) > 0 {
+		s = strings.TrimLeftFunc(s, func(r rune) bool {
+			return unicode.IsSpace(r) || r == ','
+		})
+		if s == "" {
+			break
+		}
+
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end == -1 {
+			end = len(s)
+		}
+		candidate := srcsetCandidate{URL: s[:end]}
+		s = s[end:]
+
+		if strings.HasSuffix(candidate.URL, ",") {
+			candidate.URL = strings.TrimRight(candidate.URL, ",")
+		} else {
+			descriptorEnd := strings.Index(s, ",")
+			if descriptorEnd == -1 {
+				descriptorEnd = len(s)
+			}
+			candidate.Descriptor = strings.TrimSpace(s[:descriptorEnd])
+			s = s[descriptorEnd:]
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// getDocumentBase
+// Returns base resolved against the href of the first <base> element in the tree of root, or base if there is none.
+func getDocumentBase(root *html.Node, base *url.URL) *url.URL {
+	return resolveBaseElement(GetNodeByCondition(root, isBaseElement), base)
+}
+
+// isBaseElement
+// Returns true for <base> elements with a href attribute.
+func isBaseElement(node *html.Node) bool {
+	if node.Type != html.ElementNode || node.Data != "base" {
+		return false
+	}
+	_, err := GetAttributeByKey(node, "href")
+	return err == nil
+}
+
+// resolveBaseElement
+// Returns base resolved against the href of baseNode, or base if baseNode is nil.
+func resolveBaseElement(baseNode *html.Node, base *url.URL) *url.URL {
+	if baseNode == nil {
+		return base
+	}
+	href, _ := GetAttributeByKey(baseNode, "href")
+	baseHref, err := url.Parse(strings.TrimSpace(href.Val))
+	if err != nil {
+		return base
+	}
+	if base == nil {
+		return baseHref
+	}
+	return base.ResolveReference(baseHref)
+}
+
+// ExtractURLs
+// Collects the URLs of the configured attributes of all elements in the tree of root (including root), resolves them
+// against base (and the document's <base href> if present), filters them by scheme and host, and deduplicates them.
+// If base is nil, relative URLs stay relative and are dropped unless "" is one of the allowed schemes.
+// The contents of inert <template> elements are skipped, see IncludeShadowTemplates.
+// Returns the URLs in document order of their first occurrence.
+func ExtractURLs(root *html.Node, base *url.URL, opts URLExtractOptions) []ExtractedURL {
+	timer := startOperation(opts.Instrumentation, "ExtractURLs")
+	counter := timer.counter()
+	extracted := extractURLs(root, getDocumentBase(root, base), base, opts, counter)
+	if counter != nil {
+		timer.end(counter.nodes)
+	}
+	return extracted
+}
+
+// extractURLs
+// Implements ExtractURLs with the already resolved documentBase, stops as soon as counter is exceeded.
+func extractURLs(root *html.Node, documentBase, base *url.URL, opts URLExtractOptions, counter *budgetCounter) []ExtractedURL {
+	attributes := opts.Attributes
+	if attributes == nil {
+		attributes = DefaultURLAttributes
+	}
+	allowedSchemes := opts.AllowedSchemes
+	if allowedSchemes == nil {
+		allowedSchemes = []string{"http", "https"}
+	}
+	isAllowedScheme := make(map[string]bool, len(allowedSchemes))
+	for _, scheme := range allowedSchemes {
+		isAllowedScheme[scheme] = true
+	}
+
+	excluded := makeRegionExclusion(root, opts.ExcludeRegions, opts.Regions)
+	var extracted []ExtractedURL
+	positions := make(map[string]int) // url -> position in extracted
+
+	add := func(rawURL string, occurrence URLOccurrence) {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" {
+			return
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return
+		}
+		if documentBase != nil {
+			u = documentBase.ResolveReference(u)
+		}
+		if !opts.KeepFragments {
+			u.Fragment = ""
+			u.RawFragment = ""
+		}
+		if !isAllowedScheme[strings.ToLower(u.Scheme)] {
+			return
+		}
+		if opts.SameHost && (base == nil || !strings.EqualFold(u.Host, base.Host)) {
+			return
+		}
+
+		key := u.String()
+		if k, ok := positions[key]; ok {
+			extracted[k].Occurrences = append(extracted[k].Occurrences, occurrence)
+			return
+		}
+		positions[key] = len(extracted)
+		extracted = append(extracted, ExtractedURL{URL: u, Occurrences: []URLOccurrence{occurrence}})
+	}
+
+	walkHtmlTreeWithCounter(root, counter, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if (excluded != nil && excluded(n)) || isInertTemplate(n) {
+			return false
+		}
+		for _, key := range attributes {
+			attr, err := GetAttributeByKey(n, key)
+			if err != nil {
+				continue
+			}
+			occurrence := URLOccurrence{Node: n, Attribute: key}
+			if key == "srcset" {
+				for _, candidate := range parseSrcset(attr.Val) {
+					add(candidate.URL, occurrence)
+				}
+			} else {
+				add(attr.Val, occurrence)
+			}
+		}
+		return true
+	})
+
+	return extracted
+}
@@ -0,0 +1,79 @@
+package html_util
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// extractedURLsString
+// Formats each extracted URL with its occurrences as "url <- tag.attribute ...", one per line.
+func extractedURLsString(extracted []ExtractedURL) string {
+	var lines []string
+	for _, e := range extracted {
+		var occurrences []string
+		for _, o := range e.Occurrences {
+			occurrences = append(occurrences, o.Node.Data+"."+o.Attribute)
+		}
+		lines = append(lines, fmt.Sprintf("%v <- %v", e.URL, strings.Join(occurrences, " ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestParseSrcset(t *testing.T) {
+	tests := map[string]string{
+		"a.png":                                "a.png:",
+		" a.png 1x,  b.png 2x ":                "a.png:1x b.png:2x",
+		"a.png, b.png 480w":                    "a.png: b.png:480w",
+		"a.png,b.png 480w":                     "a.png,b.png:480w", // commas within a url do not separate candidates
+		"data:image/png;base64,AAA= 1x, c.png": "data:image/png;base64,AAA=:1x c.png:",
+		" , ":                                  "",
+	}
+	for s, want := range tests {
+		var got []string
+		for _, c := range parseSrcset(s) {
+			got = append(got, c.URL+":"+c.Descriptor)
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("parseSrcset(%q) = %q, want %q", s, strings.Join(got, " "), want)
+		}
+	}
+}
+
+func TestExtractURLs(t *testing.T) {
+	doc := parseDocument(t, `<head><base href="/docs/"></head><body>`+
+		`<a href="page#top">p</a><a href=" page ">again</a>`+
+		`<img src="img.png" srcset="img.png 1x, big.png 2x">`+
+		`<form action="https://other.org/submit"><button formaction="mailto:x@y.z">m</button></form>`+
+		`<a href="javascript:void(0)">js</a><video poster="//cdn.example.com/p.jpg"></video>`+
+		`</body>`)
+	base, _ := url.Parse("https://example.com/index.html")
+
+	tests := []struct {
+		name string
+		base *url.URL
+		opts URLExtractOptions
+		want string
+	}{
+		{"defaults", base, URLExtractOptions{}, strings.Join([]string{
+			"https://example.com/docs/ <- base.href",
+			"https://example.com/docs/page <- a.href a.href",
+			"https://example.com/docs/img.png <- img.src img.srcset",
+			"https://example.com/docs/big.png <- img.srcset",
+			"https://other.org/submit <- form.action",
+			"https://cdn.example.com/p.jpg <- video.poster",
+		}, "\n")},
+		{"same host with fragments", base, URLExtractOptions{SameHost: true, KeepFragments: true, Attributes: []string{"href"}},
+			"https://example.com/docs/ <- base.href\nhttps://example.com/docs/page#top <- a.href\nhttps://example.com/docs/page <- a.href"},
+		{"schemes", base, URLExtractOptions{AllowedSchemes: []string{"mailto", "javascript"}},
+			"mailto:x@y.z <- button.formaction\njavascript:void(0) <- a.href"},
+		{"relative without base", nil, URLExtractOptions{AllowedSchemes: []string{""}, Attributes: []string{"src"}},
+			"/docs/img.png <- img.src"},
+	}
+	for _, tt := range tests {
+		if got := extractedURLsString(ExtractURLs(doc, tt.base, tt.opts)); got != tt.want {
+			t.Errorf("%v: got\n%v\nwant\n%v", tt.name, got, tt.want)
+		}
+	}
+}
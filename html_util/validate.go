@@ -0,0 +1,115 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+)
+
+// ErrCorruptTree
+// Returned by WalkHtmlTreeChecked if a node is reached twice, i.e., the pointers of the tree form a cycle.
+var ErrCorruptTree = errors.New("tree is corrupted, a node is reachable multiple times")
+
+// TreeViolation
+// An inconsistency of the pointers of a html tree, see ValidateTree.
+type TreeViolation struct {
+	Node    *html.Node // the node whose pointers are inconsistent
+	Path    string     // path of the node relative to the validated root, like GetNodePath
+	Problem string     // description of the inconsistency
+}
+
+func (v TreeViolation) String() string {
+	return fmt.Sprintf("%v: %v", v.Path, v.Problem)
+}
+
+// ValidateTree
+// Checks the pointer consistency of the tree below and including root, e.g., after manual pointer surgery: every child
+// points to its parent and its previous sibling, sibling chains terminate, LastChild is the end of the chain, and no
+// node is reachable twice. Returns all violations, or nil for a consistent tree.
+// Unlike GetNodePath, paths are relative to root, so they can be computed even if the tree is corrupted.
+func ValidateTree(root *html.Node) []TreeViolation {
+	if root == nil {
+		return nil
+	}
+	var violations []TreeViolation
+	visited := map[*html.Node]bool{root: true}
+
+	var validate func(parent *html.Node, path string)
+	validate = func(parent *html.Node, path string) {
+		report := func(n *html.Node, nodePath, problem string) {
+			violations = append(violations, TreeViolation{Node: n, Path: nodePath, Problem: problem})
+		}
+
+		var children []*html.Node
+		inChain := make(map[*html.Node]bool)
+		var prev *html.Node
+		for c := parent.FirstChild; c != nil; c = c.NextSibling {
+			if inChain[c] {
+				report(parent, path, "sibling chain of the children does not terminate")
+				break
+			}
+			inChain[c] = true
+			children = append(children, c)
+			prev = c
+		}
+		if parent.LastChild != prev {
+			report(parent, path, "LastChild is not the last node of the sibling chain of the children")
+		}
+
+		prev = nil
+		steps := getSiblingPathSteps(children)
+		for k, c := range children {
+			childPath := path + "/" + steps[k]
+			if path == "/" {
+				childPath = path + steps[k]
+			}
+			if c.Parent != parent {
+				report(c, childPath, "Parent does not point to the parent whose children contain the node")
+			}
+			if c.PrevSibling != prev {
+				report(c, childPath, "PrevSibling does not point to the previous node of the sibling chain")
+			}
+			prev = c
+			if visited[c] {
+				report(c, childPath, "node is reachable multiple times")
+				continue
+			}
+			visited[c] = true
+			validate(c, childPath)
+		}
+	}
+
+	rootPath := "/"
+	if root.Type != html.DocumentNode {
+		rootPath = "/" + getNodePathStep(root, []*html.Node{root})
+	}
+	validate(root, rootPath)
+	return violations
+}
+
+// WalkHtmlTreeChecked
+// Same as WalkHtmlTreeInclusive but aborts with ErrCorruptTree instead of looping forever if a node is reached twice,
+// e.g., because of a cyclic sibling chain. Use ValidateTree to locate the inconsistency.
+func WalkHtmlTreeChecked(node *html.Node, f func(n *html.Node) bool) error {
+	if node == nil {
+		return nil
+	}
+	visited := make(map[*html.Node]bool)
+	var walk func(n *html.Node) error
+	walk = func(n *html.Node) error {
+		if visited[n] {
+			return ErrCorruptTree
+		}
+		visited[n] = true
+		if !f(n) {
+			return nil
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if err := walk(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(node)
+}
@@ -0,0 +1,151 @@
+package html_util
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+	"testing"
+	"time"
+)
+
+// violationsString
+// Formats violations one per line.
+func violationsString(violations []TreeViolation) string {
+	var lines []string
+	for _, v := range violations {
+		lines = append(lines, v.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestValidateTree(t *testing.T) {
+	const doc = `<div id="a"><p id="b">1</p><p id="c">2</p><span id="d"></span></div>`
+	tests := []struct {
+		name    string
+		corrupt func(doc *html.Node, a, b, c, d *html.Node)
+		want    string
+	}{
+		{"consistent", func(doc, a, b, c, d *html.Node) {}, ""},
+		{"wrong parent", func(doc, a, b, c, d *html.Node) { c.Parent = d },
+			"/html/body/div/p[2]: Parent does not point to the parent whose children contain the node"},
+		{"wrong previous sibling", func(doc, a, b, c, d *html.Node) { d.PrevSibling = b },
+			"/html/body/div/span: PrevSibling does not point to the previous node of the sibling chain"},
+		{"wrong last child", func(doc, a, b, c, d *html.Node) { a.LastChild = c },
+			"/html/body/div: LastChild is not the last node of the sibling chain of the children"},
+		{"cyclic sibling chain", func(doc, a, b, c, d *html.Node) { d.NextSibling = b },
+			"/html/body/div: sibling chain of the children does not terminate"},
+		{"shared child", func(doc, a, b, c, d *html.Node) { d.FirstChild, d.LastChild = b.FirstChild, b.FirstChild },
+			strings.Join([]string{
+				"/html/body/div/span/text(): Parent does not point to the parent whose children contain the node",
+				"/html/body/div/span/text(): node is reachable multiple times",
+			}, "\n")},
+		{"ancestor as child", func(doc, a, b, c, d *html.Node) { d.FirstChild, d.LastChild = a, a }, strings.Join([]string{
+			"/html/body/div/span/div: Parent does not point to the parent whose children contain the node",
+			"/html/body/div/span/div: node is reachable multiple times",
+		}, "\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := parseDocument(t, doc)
+			tt.corrupt(root, elementByID(t, root, "a"), elementByID(t, root, "b"), elementByID(t, root, "c"),
+				elementByID(t, root, "d"))
+			if got := violationsString(ValidateTree(root)); got != tt.want {
+				t.Errorf("got\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTreeOfSubtreeAndNil(t *testing.T) {
+	if violations := ValidateTree(nil); violations != nil {
+		t.Errorf("ValidateTree(nil) = %v", violations)
+	}
+	doc := parseDocument(t, `<div id="a"><p>1</p><p>2</p></div>`)
+	a := elementByID(t, doc, "a")
+	a.LastChild.PrevSibling = nil
+	want := "/div/p[2]: PrevSibling does not point to the previous node of the sibling chain"
+	if got := violationsString(ValidateTree(a)); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestValidateTreeAfterMutationHelpers(t *testing.T) {
+	doc := parseDocument(t, `<div id="a"><p id="b">1</p><p id="c">2</p></div><span id="d">3</span>`)
+	a, b, c, d := elementByID(t, doc, "a"), elementByID(t, doc, "b"), elementByID(t, doc, "c"), elementByID(t, doc, "d")
+	steps := []struct {
+		name   string
+		mutate func() error
+	}{
+		{"InsertNodeBefore", func() error { return InsertNodeBefore(b, c) }},
+		{"InsertNodeAfter", func() error { return InsertNodeAfter(b, d) }},
+		{"AppendChildNode", func() error { return AppendChildNode(c, b) }},
+		{"ReplaceNode", func() error { return ReplaceNode(a, CloneTree(c)) }},
+		{"RemoveNode", func() error { return RemoveNode(d) }},
+	}
+	for _, step := range steps {
+		if err := step.mutate(); err != nil {
+			t.Fatalf("%v: %v", step.name, err)
+		}
+		if violations := ValidateTree(doc); violations != nil {
+			t.Errorf("%v corrupted the tree:\n%v", step.name, violationsString(violations))
+		}
+	}
+}
+
+func TestValidateTreeWideNode(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 20000; i++ {
+		sb.WriteString("<p>x</p>")
+	}
+	doc := parseDocument(t, sb.String())
+	start := time.Now()
+	if violations := ValidateTree(doc); violations != nil {
+		t.Fatal(violationsString(violations))
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("validating 20000 siblings took %v", elapsed)
+	}
+}
+
+func TestWalkHtmlTreeChecked(t *testing.T) {
+	doc := parseDocument(t, `<div id="a"><p id="b"><i id="x"></i></p><p id="c"></p></div>`)
+	a := elementByID(t, doc, "a")
+
+	var visited []string
+	err := WalkHtmlTreeChecked(a, func(n *html.Node) bool {
+		visited = append(visited, nodeID(n))
+		return nodeID(n) != "b"
+	})
+	if err != nil || strings.Join(visited, " ") != "a b c" {
+		t.Errorf("visited %v with error %v, want a b c without error", visited, err)
+	}
+	if err := WalkHtmlTreeChecked(nil, func(n *html.Node) bool { return true }); err != nil {
+		t.Errorf("walking nil returned %v", err)
+	}
+
+	c := elementByID(t, doc, "c")
+	c.NextSibling = elementByID(t, doc, "b") // cyclic sibling chain
+	done := make(chan error, 1)
+	go func() {
+		done <- WalkHtmlTreeChecked(a, func(n *html.Node) bool { return true })
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrCorruptTree) {
+			t.Errorf("walking a cyclic tree returned %v, want ErrCorruptTree", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("walking a cyclic tree does not terminate")
+	}
+}
+
+func BenchmarkValidateTree(b *testing.B) {
+	doc := parseDocument(b, makeLargeDocument(2000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if violations := ValidateTree(doc); violations != nil {
+			b.Fatal(fmt.Sprint(violations))
+		}
+	}
+}